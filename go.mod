@@ -8,7 +8,9 @@ require (
 	github.com/cespare/cp v1.1.1
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/google/go-github v17.0.0+incompatible
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.0.1
 	github.com/joyt/godate v0.0.0-20150226210126-7151572574a7
+	github.com/lib/pq v1.10.9
 	github.com/marcmak/calc v0.0.0-20150509200512-5bbbfc3b3149
 	github.com/mattn/go-colorable v0.1.8
 	github.com/mattn/go-sqlite3 v1.14.7
@@ -21,7 +23,7 @@ require (
 	github.com/x-cray/logrus-prefixed-formatter v0.5.2
 	golang.org/x/oauth2 v0.0.0-20210514164344-f6687ab2804c
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
-	golang.org/x/text v0.7.0
+	google.golang.org/genproto v0.0.0-20210611144927-798beca9d670
 	google.golang.org/grpc v1.38.0
 	google.golang.org/protobuf v1.26.0
 )
@@ -43,7 +45,7 @@ require (
 	golang.org/x/net v0.7.0 // indirect
 	golang.org/x/sys v0.5.0 // indirect
 	golang.org/x/term v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
 	google.golang.org/appengine v1.6.6 // indirect
-	google.golang.org/genproto v0.0.0-20210611144927-798beca9d670 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+var backupCommand = &cli.Command{
+	Action:    backupDatabase,
+	Name:      "backup",
+	Usage:     "godbledger backup <file>",
+	ArgsUsage: "<file>",
+	Category:  "MAINTENANCE COMMANDS",
+	Description: `The backup command writes a backup of the database to a file while the node
+keeps running. SQLite3 ledgers are copied with the SQLite online backup API and
+MySQL ledgers are dumped from a consistent snapshot. The file carries a manifest
+recording the schema version of the database and a checksum of its contents.
+Only the default ledger is backed up, a node with entity ledgers is refused.`,
+}
+
+var restoreCommand = &cli.Command{
+	Action:    restoreDatabase,
+	Name:      "restore",
+	Usage:     "godbledger restore <file>",
+	ArgsUsage: "<file>",
+	Category:  "MAINTENANCE COMMANDS",
+	Description: `The restore command replaces the database with a backup written by the backup
+command. The backup is checked against its manifest before any data is replaced
+and is migrated to the current schema once restored.`,
+}
+
+// backupDatabase is the backup command.
+func backupDatabase(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("Usage: godbledger backup <file>")
+	}
+	file := ctx.Args().First()
+
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := ledger.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer ledger.Stop()
+
+	// Written alongside the destination and renamed into place once complete
+	// so a failed backup never leaves a partial file behind
+	out, err := os.CreateTemp(filepath.Dir(file), ".godbledger-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	manifest, err := ledger.Backup(ctx.Context, out)
+	if err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(out.Name(), file); err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %s database at schema version %d to %s\n", manifest.DatabaseType, manifest.SchemaVersion, file)
+
+	return nil
+}
+
+// restoreDatabase is the restore command.
+func restoreDatabase(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("Usage: godbledger restore <file>")
+	}
+
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	ledger, err := ledger.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer ledger.Stop()
+
+	manifest, err := ledger.RestoreBackup(ctx.Context, in)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s database at schema version %d backed up %s\n", manifest.DatabaseType, manifest.SchemaVersion, manifest.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	return nil
+}
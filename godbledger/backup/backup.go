@@ -0,0 +1,138 @@
+// Package backup reads and writes godbledger backup archives. An archive is a
+// gzipped tar holding a manifest followed by the backup of the database, in
+// whatever form the database produced it.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	manifestName = "manifest.json"
+	dataName     = "data"
+
+	// Format is the layout of the archives written by this version
+	Format = 1
+)
+
+var (
+	// ErrNotSupported is returned when the database cannot be backed up
+	ErrNotSupported = errors.New("Backups are not supported by this database")
+	// ErrInvalidArchive is returned when a file is not a backup archive
+	ErrInvalidArchive = errors.New("Not a godbledger backup")
+	// ErrChecksumMismatch is returned when the backed up database does not
+	// match the checksum recorded in its manifest
+	ErrChecksumMismatch = errors.New("Backup checksum does not match its manifest")
+)
+
+// Manifest describes the database held in a backup archive.
+type Manifest struct {
+	Format        int       `json:"format"`
+	DatabaseType  string    `json:"database_type"`
+	SchemaVersion int       `json:"schema_version"`
+	Version       string    `json:"godbledger_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Size and SHA256 are those of the database backup and are filled in
+	// when the archive is written
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Write archives the database backup at path along with its manifest.
+func Write(w io.Writer, manifest *Manifest, path string) error {
+	data, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, data)
+	if err != nil {
+		return err
+	}
+	manifest.Format = Format
+	manifest.Size = size
+	manifest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	header, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	entry := &tar.Header{Name: manifestName, Mode: 0600, Size: int64(len(header)), ModTime: manifest.CreatedAt}
+	if err := archive.WriteHeader(entry); err != nil {
+		return err
+	}
+	if _, err := archive.Write(header); err != nil {
+		return err
+	}
+	entry = &tar.Header{Name: dataName, Mode: 0600, Size: size, ModTime: manifest.CreatedAt}
+	if err := archive.WriteHeader(entry); err != nil {
+		return err
+	}
+	if _, err := io.Copy(archive, data); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Read unpacks an archive, writing the database backup to path, and returns
+// its manifest once the backup has been checked against it.
+func Read(r io.Reader, path string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+
+	entry, err := archive.Next()
+	if err != nil || entry.Name != manifestName {
+		return nil, fmt.Errorf("%w: missing manifest", ErrInvalidArchive)
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(archive).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	if manifest.Format != Format {
+		return nil, fmt.Errorf("%w: unknown format %d", ErrInvalidArchive, manifest.Format)
+	}
+
+	entry, err = archive.Next()
+	if err != nil || entry.Name != dataName {
+		return nil, fmt.Errorf("%w: missing database", ErrInvalidArchive)
+	}
+	data, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(data, hash), archive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	if size != manifest.Size || hex.EncodeToString(hash.Sum(nil)) != manifest.SHA256 {
+		return nil, ErrChecksumMismatch
+	}
+
+	return &manifest, data.Close()
+}
@@ -0,0 +1,66 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	assert.NoError(t, os.WriteFile(data, []byte("ledger contents"), 0600))
+
+	var archive bytes.Buffer
+	manifest := &Manifest{DatabaseType: "sqlite3", SchemaVersion: 5, CreatedAt: time.Now().UTC()}
+	assert.NoError(t, Write(&archive, manifest, data))
+	assert.Equal(t, int64(15), manifest.Size)
+
+	restored := filepath.Join(dir, "restored")
+	read, err := Read(&archive, restored)
+	assert.NoError(t, err)
+	assert.Equal(t, manifest.SHA256, read.SHA256)
+	assert.Equal(t, 5, read.SchemaVersion)
+	contents, err := os.ReadFile(restored)
+	assert.NoError(t, err)
+	assert.Equal(t, "ledger contents", string(contents))
+}
+
+func TestReadRejectsTamperedBackup(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	assert.NoError(t, os.WriteFile(data, []byte("ledger contents"), 0600))
+	manifest := &Manifest{DatabaseType: "sqlite3", SchemaVersion: 5}
+	var archive bytes.Buffer
+	assert.NoError(t, Write(&archive, manifest, data))
+
+	// Rewrite the archive with the same manifest but different contents
+	var tampered bytes.Buffer
+	gz := gzip.NewWriter(&tampered)
+	tw := tar.NewWriter(gz)
+	header, _ := json.Marshal(manifest)
+	for _, entry := range []struct {
+		name string
+		body []byte
+	}{{manifestName, header}, {dataName, []byte("ledger content!")}} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0600, Size: int64(len(entry.body))}))
+		_, err := tw.Write(entry.body)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	_, err := Read(&tampered, filepath.Join(dir, "restored"))
+	assert.True(t, errors.Is(err, ErrChecksumMismatch))
+
+	_, err = Read(bytes.NewReader([]byte("not an archive")), filepath.Join(dir, "restored"))
+	assert.True(t, errors.Is(err, ErrInvalidArchive))
+}
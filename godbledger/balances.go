@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+var balancesCommand = &cli.Command{
+	Action:    checkBalances,
+	Name:      "balances",
+	Usage:     "godbledger balances [--rebuild]",
+	ArgsUsage: "",
+	Category:  "MAINTENANCE COMMANDS",
+	Description: `The balances command verifies the monthly account balances the trial balance
+is read from against the splits they summarise and lists any that differ. The
+balances are kept up to date as transactions are posted, voided and deleted,
+use --rebuild to recalculate them from the splits. The ledger of each entity is
+checked after the default ledger.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "rebuild",
+			Usage: "recalculate every account balance from the splits",
+		},
+	},
+}
+
+// checkBalances is the balances command.
+func checkBalances(ctx *cli.Context) error {
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := ledger.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	ledger.Start()
+	defer ledger.Stop()
+
+	// The default ledger is checked first and then that of each entity
+	ids := []string{""}
+	entities, err := ledger.ListEntities(ctx.Context)
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		ids = append(ids, entity.Id)
+	}
+
+	mismatched := 0
+	for _, id := range ids {
+		ld, err := ledger.Entity(ctx.Context, id)
+		if err != nil {
+			return err
+		}
+		printLedger(id)
+
+		if ctx.Bool("rebuild") {
+			if err := ld.RebuildBalances(ctx.Context); err != nil {
+				return err
+			}
+			fmt.Println("Account balances rebuilt")
+			continue
+		}
+
+		discrepancies, err := ld.VerifyBalances(ctx.Context)
+		if err != nil {
+			return err
+		}
+		if len(discrepancies) == 0 {
+			fmt.Println("Account balances match the splits")
+			continue
+		}
+		for _, d := range discrepancies {
+			fmt.Printf("%-30s %-5s %s  stored %d  actual %d\n", d.Account, d.Currency, d.Period.Format("2006-01"), d.Stored, d.Actual)
+		}
+		mismatched += len(discrepancies)
+	}
+	if mismatched > 0 {
+		return fmt.Errorf("%d account balances do not match the splits, run godbledger balances --rebuild", mismatched)
+	}
+	return nil
+}
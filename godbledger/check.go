@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+var checkCommand = &cli.Command{
+	Action:    checkIntegrity,
+	Name:      "check",
+	Usage:     "godbledger check [--repair]",
+	ArgsUsage: "",
+	Category:  "MAINTENANCE COMMANDS",
+	Description: `The check command scans the ledger for splits without an account, splits in
+currencies that have been deleted, transactions without splits, transactions
+whose splits do not sum to zero and account balances that do not match their
+splits, listing each one found. With --repair the violations that can be fixed
+without changing any balance are repaired: orphan splits of zero and empty
+transactions are deleted and the account balances rebuilt. The others are left
+to be corrected by hand. The --ledger flag checks one of the other ledgers of
+the node in place of the default one.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "repair",
+			Usage: "repair the violations that can be fixed safely",
+		},
+		cmd.LedgerFlag,
+	},
+}
+
+// checkIntegrity is the check command.
+func checkIntegrity(ctx *cli.Context) error {
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := ledger.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	ledger.Start()
+	defer ledger.Stop()
+
+	entity, err := ledger.Entity(ctx.Context, ctx.String(cmd.LedgerFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	violations, repaired, err := entity.CheckIntegrity(ctx.Context, ctx.Bool("repair"))
+	if err != nil {
+		return err
+	}
+	if repaired > 0 {
+		fmt.Printf("Repaired %d violations\n", repaired)
+	}
+	if len(violations) == 0 {
+		fmt.Println("No integrity violations found")
+		return nil
+	}
+	repairable := 0
+	for _, violation := range violations {
+		if violation.Repairable {
+			repairable++
+			fmt.Printf("%-22s %s (repairable)\n", violation.Kind, violation.Detail)
+			continue
+		}
+		fmt.Printf("%-22s %s\n", violation.Kind, violation.Detail)
+	}
+	if repairable > 0 {
+		return fmt.Errorf("%d integrity violations found, %d can be repaired with godbledger check --repair", len(violations), repairable)
+	}
+	return fmt.Errorf("%d integrity violations found", len(violations))
+}
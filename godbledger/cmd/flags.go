@@ -68,6 +68,11 @@ var (
 		Name:  "rpc-port",
 		Usage: "RPC port exposed by GoDBLedger",
 	}
+	// RESTPort defines the port of the REST gateway, it is not served when unset.
+	RESTPort = &cli.StringFlag{
+		Name:  "rest-port",
+		Usage: "Port of the REST/JSON gateway to the RPC (blank for none)",
+	}
 	// CertFlag defines a flag for the node's TLS CA certificate.
 	CACertFlag = &cli.StringFlag{
 		Name:  "ca-cert",
@@ -88,12 +93,17 @@ var (
 		Name:  "log-file",
 		Usage: "Specify log file name, relative or absolute",
 	}
+	// LedgerFlag selects the ledger of the node that client requests are for.
+	LedgerFlag = &cli.StringFlag{
+		Name:  "ledger",
+		Usage: "Identifier of the ledger on the node to use, blank for the default ledger",
+	}
 	// DatabaseType specifies the backend for GoDBLedger
 	DatabaseTypeFlag = &cli.StringFlag{
 		Name:  "database",
-		Usage: "Specify database type, sqlite3 or mysql",
+		Usage: "Specify database type, sqlite3, memorydb, mysql or postgres",
 	}
-	// DatabaseLocation specifies file location for Sqlite or connection string for MySQL
+	// DatabaseLocation specifies file location for Sqlite or connection string for MySQL and PostgreSQL
 	DatabaseLocationFlag = &cli.StringFlag{
 		Name:  "database-location",
 		Usage: "location of database file or connection string",
@@ -102,6 +112,11 @@ var (
 		Name:  "pidfile",
 		Usage: "location of PID File (blank will mean none is created)",
 	}
+	// QueryTimeoutFlag bounds how long the server spends on the database for a single request
+	QueryTimeoutFlag = &cli.StringFlag{
+		Name:  "query-timeout",
+		Usage: "maximum time a single RPC request may spend querying the database, eg 30s (blank or 0 for no limit)",
+	}
 )
 
 func setConfig(ctx *cli.Context, cfg *LedgerConfig) {
@@ -120,6 +135,9 @@ func setConfig(ctx *cli.Context, cfg *LedgerConfig) {
 	if ctx.IsSet(RPCPort.Name) {
 		cfg.RPCPort = ctx.String(RPCPort.Name)
 	}
+	if ctx.IsSet(RESTPort.Name) {
+		cfg.RESTPort = ctx.String(RESTPort.Name)
+	}
 	if ctx.IsSet(CACertFlag.Name) {
 		cfg.CACert = ctx.String(CACertFlag.Name)
 	}
@@ -138,4 +156,7 @@ func setConfig(ctx *cli.Context, cfg *LedgerConfig) {
 	if ctx.IsSet(PidFileFlag.Name) {
 		cfg.PidFile = ctx.String(PidFileFlag.Name)
 	}
+	if ctx.IsSet(QueryTimeoutFlag.Name) {
+		cfg.QueryTimeout = ctx.String(QueryTimeoutFlag.Name)
+	}
 }
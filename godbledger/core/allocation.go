@@ -0,0 +1,148 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// AllocationTarget is a single destination account of an allocation rule and
+// the weight of the amount that it receives.
+type AllocationTarget struct {
+	Account *Account
+	Weight  int64
+}
+
+// AllocationRule spreads the amount of a single split across several accounts
+// in proportion to the weights of its targets, for example splitting rent
+// 60/30/10 across three cost centres.
+type AllocationRule struct {
+	Name    string
+	Targets []*AllocationTarget
+}
+
+func NewAllocationRule(name string) (*AllocationRule, error) {
+	if len(name) == 0 {
+		return nil, errors.New("Allocation rule requires a name")
+	}
+	rule := &AllocationRule{name, []*AllocationTarget{}}
+	return rule, nil
+}
+
+func (rule *AllocationRule) AppendTarget(acc *Account, weight int64) error {
+	if weight <= 0 {
+		return errors.New("Allocation weight must be greater than zero")
+	}
+	rule.Targets = append(rule.Targets, &AllocationTarget{acc, weight})
+	return nil
+}
+
+// Validate checks that the rule is well formed.
+func (rule *AllocationRule) Validate() error {
+	if len(rule.Name) == 0 {
+		return errors.New("Allocation rule requires a name")
+	}
+	if len(rule.Targets) == 0 {
+		return errors.New("Allocation rule has no targets")
+	}
+	for _, target := range rule.Targets {
+		if target.Account == nil {
+			return errors.New("Allocation target requires an account")
+		}
+		if target.Weight <= 0 {
+			return errors.New("Allocation weight must be greater than zero")
+		}
+	}
+	return nil
+}
+
+// EqualAllocationRule builds a rule giving every account the same weight. It
+// defines the meaning of a split posted against more than one account.
+func EqualAllocationRule(accs []*Account) (*AllocationRule, error) {
+	rule := &AllocationRule{"equal", []*AllocationTarget{}}
+	for _, acc := range accs {
+		if err := rule.AppendTarget(acc, 1); err != nil {
+			return nil, err
+		}
+	}
+	return rule, nil
+}
+
+// Allocate expands a split into one split per target of the rule. Each target
+// receives the truncated proportional share of the amount, and the remaining
+// units are handed out one at a time to the targets with the largest
+// truncated remainder, ties going to the target listed first. The resulting
+// splits always sum to the original amount so the journal still balances.
+func (rule *AllocationRule) Allocate(spl *Split) ([]*Split, error) {
+	if len(rule.Targets) == 0 {
+		return nil, errors.New("Allocation rule has no targets")
+	}
+
+	totalWeight := big.NewInt(0)
+	for _, target := range rule.Targets {
+		if target.Weight <= 0 {
+			return nil, errors.New("Allocation weight must be greater than zero")
+		}
+		totalWeight.Add(totalWeight, big.NewInt(target.Weight))
+	}
+
+	amount := new(big.Int).Abs(spl.Amount)
+	shares := make([]*big.Int, len(rule.Targets))
+	remainders := make([]*big.Int, len(rule.Targets))
+	allocated := big.NewInt(0)
+	for i, target := range rule.Targets {
+		product := new(big.Int).Mul(amount, big.NewInt(target.Weight))
+		shares[i], remainders[i] = new(big.Int).QuoRem(product, totalWeight, new(big.Int))
+		allocated.Add(allocated, shares[i])
+	}
+
+	order := make([]int, len(rule.Targets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+
+	leftover := new(big.Int).Sub(amount, allocated)
+	for i := 0; leftover.Sign() > 0; i++ {
+		shares[order[i%len(order)]].Add(shares[order[i%len(order)]], big.NewInt(1))
+		leftover.Sub(leftover, big.NewInt(1))
+	}
+
+	splits := []*Split{}
+	for i, target := range rule.Targets {
+		if spl.Amount.Sign() < 0 {
+			shares[i].Neg(shares[i])
+		}
+		newSplt, err := NewSplit(spl.Date, spl.Description, []*Account{target.Account}, spl.Currency, shares[i])
+		if err != nil {
+			return nil, err
+		}
+		splits = append(splits, newSplt)
+	}
+	return splits, nil
+}
+
+// ExpandSplits replaces every split posted against more than one account with
+// an equal allocation across those accounts.
+func (txn *Transaction) ExpandSplits() error {
+	splits := []*Split{}
+	for _, split := range txn.Splits {
+		if len(split.Accounts) <= 1 {
+			splits = append(splits, split)
+			continue
+		}
+		rule, err := EqualAllocationRule(split.Accounts)
+		if err != nil {
+			return err
+		}
+		allocated, err := rule.Allocate(split)
+		if err != nil {
+			return err
+		}
+		splits = append(splits, allocated...)
+	}
+	txn.Splits = splits
+	return nil
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocationRule(t *testing.T) {
+	rule, err := NewAllocationRule("rent")
+	assert.NoError(t, err)
+
+	admin, _ := NewAccount("6100", "Rent:Admin")
+	sales, _ := NewAccount("6200", "Rent:Sales")
+	warehouse, _ := NewAccount("6300", "Rent:Warehouse")
+	assert.Error(t, (&AllocationRule{Name: "empty"}).Validate())
+	assert.NoError(t, rule.AppendTarget(admin, 60))
+	assert.NoError(t, rule.AppendTarget(sales, 30))
+	assert.NoError(t, rule.AppendTarget(warehouse, 10))
+	assert.Error(t, rule.AppendTarget(warehouse, 0))
+	assert.Error(t, rule.AppendTarget(warehouse, -10))
+	assert.NoError(t, rule.Validate())
+	assert.Error(t, (&AllocationRule{"negative", []*AllocationTarget{{admin, -1}}}).Validate())
+
+	rent, _ := NewAccount("6000", "Rent")
+	aud, _ := NewCurrency("AUD", 2)
+	spl, err := NewSplit(time.Now(), []byte("Rent"), []*Account{rent}, aud, big.NewInt(100001))
+	assert.NoError(t, err)
+
+	splits, err := rule.Allocate(spl)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(splits))
+	assert.Equal(t, big.NewInt(60001), splits[0].Amount)
+	assert.Equal(t, big.NewInt(30000), splits[1].Amount)
+	assert.Equal(t, big.NewInt(10000), splits[2].Amount)
+	assert.Equal(t, "Rent:Sales", splits[1].Accounts[0].Name)
+
+	spl.Amount = big.NewInt(-100001)
+	splits, err = rule.Allocate(spl)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(-60001), splits[0].Amount)
+	assert.Equal(t, big.NewInt(-30000), splits[1].Amount)
+	assert.Equal(t, big.NewInt(-10000), splits[2].Amount)
+}
+
+func TestExpandSplits(t *testing.T) {
+	user, _ := NewUser("Tester")
+	txn, _ := NewTransaction(user)
+
+	cash, _ := NewAccount("1", "cash")
+	a, _ := NewAccount("2", "a")
+	b, _ := NewAccount("3", "b")
+	c, _ := NewAccount("4", "c")
+	aud, _ := NewCurrency("AUD", 2)
+
+	spl1, _ := NewSplit(time.Now(), []byte("Shared"), []*Account{a, b, c}, aud, big.NewInt(100))
+	spl2, _ := NewSplit(time.Now(), []byte("Shared"), []*Account{cash}, aud, big.NewInt(-100))
+	txn.AppendSplit(spl1)
+	txn.AppendSplit(spl2)
+
+	assert.NoError(t, txn.ExpandSplits())
+	assert.Equal(t, 4, len(txn.Splits))
+	assert.Equal(t, big.NewInt(34), txn.Splits[0].Amount)
+	assert.Equal(t, big.NewInt(33), txn.Splits[1].Amount)
+	assert.Equal(t, big.NewInt(33), txn.Splits[2].Amount)
+
+	total, balanced := txn.Balance()
+	assert.True(t, balanced)
+	assert.Equal(t, 0, total.Cmp(big.NewInt(0)))
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ParseAmount reads an amount in the smallest unit of its currency written
+// as a base 10 integer, such as "-1050" for -10.50 in a currency with two
+// decimals. Amounts are unbounded so tokens with many decimals do not
+// overflow.
+func ParseAmount(amount string) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(strings.TrimSpace(amount), 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid amount %q", amount)
+	}
+	return value, nil
+}
+
+// FormatAmount writes an amount in the smallest unit of its currency as a
+// decimal number with the decimals of the currency, so 1050 with two
+// decimals is "10.50".
+func FormatAmount(amount *big.Int, decimals int) string {
+	if amount == nil {
+		amount = new(big.Int)
+	}
+	digits := new(big.Int).Abs(amount).String()
+	if decimals > 0 {
+		if len(digits) <= decimals {
+			digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-decimals] + "." + digits[len(digits)-decimals:]
+	}
+	if amount.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	amount, err := ParseAmount("-123456789012345678901234567890")
+	assert.NoError(t, err)
+	expected, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	assert.Equal(t, 0, expected.Cmp(amount))
+
+	_, err = ParseAmount("12.50")
+	assert.Error(t, err)
+	_, err = ParseAmount("")
+	assert.Error(t, err)
+}
+
+func TestFormatAmount(t *testing.T) {
+	wei, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	tests := []struct {
+		amount   *big.Int
+		decimals int
+		expected string
+	}{
+		{big.NewInt(1050), 2, "10.50"},
+		{big.NewInt(-1050), 2, "-10.50"},
+		{big.NewInt(5), 2, "0.05"},
+		{big.NewInt(-5), 2, "-0.05"},
+		{big.NewInt(0), 2, "0.00"},
+		{big.NewInt(42), 0, "42"},
+		{wei, 18, "123456789012.345678901234567890"},
+		{new(big.Int).Neg(wei), 18, "-123456789012.345678901234567890"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, FormatAmount(test.amount, test.decimals))
+	}
+}
@@ -0,0 +1,18 @@
+package core
+
+import (
+	"math/big"
+	"time"
+)
+
+// BalanceDiscrepancy is an account balance summary that does not match the
+// splits it is built from. Period is the first day of the month summarised.
+type BalanceDiscrepancy struct {
+	Account  string
+	Currency string
+	Period   time.Time
+	// Stored is the total held in the summary and Actual the total of the
+	// splits, either is zero when the other has no matching row
+	Stored *big.Int
+	Actual *big.Int
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Accounts added to a consolidation to hold the differences that keep it
+// balanced. Intercompany balances that do not net to zero across the group
+// are left in IntercompanyDifferenceAccount, and the rounding of amounts
+// converted into the group currency in TranslationDifferenceAccount.
+const (
+	IntercompanyDifferenceAccount = "Intercompany Difference"
+	TranslationDifferenceAccount  = "Translation Difference"
+)
+
+// EntityTB is the trial balance of one entity of a group.
+type EntityTB struct {
+	Entity   string
+	Accounts []TBAccount
+}
+
+// ConsolidatedAccount is an account of a consolidated trial balance. Entities
+// holds the balance of each entity converted into the group currency,
+// Eliminated the amount removed because the account is intercompany and
+// Amount what is left for the group, the sum of the other two.
+type ConsolidatedAccount struct {
+	Account    string
+	Entities   map[string]*big.Int
+	Eliminated *big.Int
+	Amount     *big.Int
+}
+
+// Consolidation is the trial balance of a group of entities in the group
+// currency.
+type Consolidation struct {
+	Currency Currency
+	Entities []string
+	Accounts []*ConsolidatedAccount
+}
+
+// Consolidate combines the trial balances of the entities of a group. Each
+// line is converted into the group currency at the rate for its currency,
+// the value of one unit of it in the group currency, and the balances of
+// accounts tagged with intercompanyTag are eliminated.
+func Consolidate(entities []EntityTB, currency Currency, rates map[string]*big.Rat, intercompanyTag string) (*Consolidation, error) {
+	consolidation := &Consolidation{Currency: currency}
+	accounts := make(map[string]*ConsolidatedAccount)
+	account := func(name string) *ConsolidatedAccount {
+		acc, ok := accounts[name]
+		if !ok {
+			acc = &ConsolidatedAccount{
+				Account:    name,
+				Entities:   make(map[string]*big.Int),
+				Eliminated: new(big.Int),
+				Amount:     new(big.Int),
+			}
+			accounts[name] = acc
+		}
+		return acc
+	}
+
+	eliminated := new(big.Int)
+	for _, entity := range entities {
+		consolidation.Entities = append(consolidation.Entities, entity.Entity)
+		total := new(big.Int)
+		for _, line := range entity.Accounts {
+			amount, err := convertAmount(line, currency, rates)
+			if err != nil {
+				return nil, fmt.Errorf("Converting %s of %s: %w", line.Account, entity.Entity, err)
+			}
+			acc := account(line.Account)
+			acc.add(entity.Entity, amount)
+			total.Add(total, amount)
+			if containsString(line.Tags, intercompanyTag) {
+				acc.Eliminated.Sub(acc.Eliminated, amount)
+				eliminated.Add(eliminated, amount)
+			} else {
+				acc.Amount.Add(acc.Amount, amount)
+			}
+		}
+		if total.Sign() != 0 {
+			difference := account(TranslationDifferenceAccount)
+			difference.add(entity.Entity, new(big.Int).Neg(total))
+			difference.Amount.Sub(difference.Amount, total)
+		}
+	}
+	if eliminated.Sign() != 0 {
+		difference := account(IntercompanyDifferenceAccount)
+		difference.Eliminated.Add(difference.Eliminated, eliminated)
+		difference.Amount.Add(difference.Amount, eliminated)
+	}
+
+	for _, acc := range accounts {
+		consolidation.Accounts = append(consolidation.Accounts, acc)
+	}
+	sort.Slice(consolidation.Accounts, func(i, j int) bool {
+		return consolidation.Accounts[i].Account < consolidation.Accounts[j].Account
+	})
+	return consolidation, nil
+}
+
+// Statement totals the consolidated balances by the top level of the account
+// names, such as Assets or Revenue in Assets:Cash and Revenue:Sales.
+func (c *Consolidation) Statement() map[string]*big.Int {
+	totals := make(map[string]*big.Int)
+	for _, acc := range c.Accounts {
+		class := strings.SplitN(acc.Account, ":", 2)[0]
+		if _, ok := totals[class]; !ok {
+			totals[class] = new(big.Int)
+		}
+		totals[class].Add(totals[class], acc.Amount)
+	}
+	return totals
+}
+
+func (a *ConsolidatedAccount) add(entity string, amount *big.Int) {
+	if _, ok := a.Entities[entity]; !ok {
+		a.Entities[entity] = new(big.Int)
+	}
+	a.Entities[entity].Add(a.Entities[entity], amount)
+}
+
+// convertAmount converts the balance of a trial balance line into the group
+// currency, rounding half away from zero to its smallest unit.
+func convertAmount(line TBAccount, currency Currency, rates map[string]*big.Rat) (*big.Int, error) {
+	rate := big.NewRat(1, 1)
+	if line.Currency != currency.Name {
+		r, ok := rates[line.Currency]
+		if !ok {
+			return nil, fmt.Errorf("No exchange rate from %s to %s", line.Currency, currency.Name)
+		}
+		rate = r
+	}
+	if line.Amount == nil {
+		return new(big.Int), nil
+	}
+
+	value := new(big.Rat).SetInt(line.Amount)
+	value.Mul(value, rate)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(currency.Decimals-line.Decimals))), nil)
+	if currency.Decimals >= line.Decimals {
+		value.Mul(value, new(big.Rat).SetInt(scale))
+	} else {
+		value.Quo(value, new(big.Rat).SetInt(scale))
+	}
+
+	quo, rem := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(value.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(value.Sign())))
+	}
+	return quo, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsolidate(t *testing.T) {
+	entities := []EntityTB{
+		{Entity: "parent", Accounts: []TBAccount{
+			{Account: "Assets:Cash", Amount: big.NewInt(50000), Tags: []string{"Assets"}, Currency: "USD", Decimals: 2},
+			{Account: "Assets:Loan to Sub", Amount: big.NewInt(10000), Tags: []string{"Assets", "intercompany"}, Currency: "USD", Decimals: 2},
+			{Account: "Equity:Capital", Amount: big.NewInt(-60000), Tags: []string{"Equity"}, Currency: "USD", Decimals: 2},
+		}},
+		{Entity: "sub", Accounts: []TBAccount{
+			{Account: "Assets:Cash", Amount: big.NewInt(20000), Tags: []string{"Assets"}, Currency: "NZD", Decimals: 2},
+			{Account: "Liabilities:Loan from Parent", Amount: big.NewInt(-20000), Tags: []string{"Liabilities", "intercompany"}, Currency: "NZD", Decimals: 2},
+		}},
+	}
+	rates := map[string]*big.Rat{"NZD": big.NewRat(1, 2)}
+
+	consolidation, err := Consolidate(entities, Currency{Name: "USD", Decimals: 2}, rates, "intercompany")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"parent", "sub"}, consolidation.Entities)
+
+	amounts := make(map[string]string)
+	for _, acc := range consolidation.Accounts {
+		amounts[acc.Account] = acc.Amount.String()
+		total := new(big.Int).Set(acc.Eliminated)
+		for _, amount := range acc.Entities {
+			total.Add(total, amount)
+		}
+		assert.Equal(t, acc.Amount.String(), total.String(), acc.Account)
+	}
+	assert.Equal(t, map[string]string{
+		"Assets:Cash":                  "60000",
+		"Assets:Loan to Sub":           "0",
+		"Equity:Capital":               "-60000",
+		"Liabilities:Loan from Parent": "0",
+	}, amounts)
+
+	statement := consolidation.Statement()
+	assert.Equal(t, "60000", statement["Assets"].String())
+	assert.Equal(t, "0", statement["Liabilities"].String())
+	assert.Equal(t, "-60000", statement["Equity"].String())
+
+	_, err = Consolidate(entities, Currency{Name: "USD", Decimals: 2}, nil, "intercompany")
+	assert.Error(t, err)
+}
+
+func TestConsolidateDifferences(t *testing.T) {
+	entities := []EntityTB{
+		{Entity: "parent", Accounts: []TBAccount{
+			{Account: "Assets:Receivable from Sub", Amount: big.NewInt(1000), Tags: []string{"intercompany"}, Currency: "USD", Decimals: 2},
+			{Account: "Revenue:Fees", Amount: big.NewInt(-1000), Currency: "USD", Decimals: 2},
+		}},
+		{Entity: "sub", Accounts: []TBAccount{
+			// A third of each converts to 0.33, -0.17 and -0.17
+			{Account: "Expenses:Fees", Amount: big.NewInt(100), Currency: "AUD", Decimals: 2},
+			{Account: "Liabilities:Payable to Parent", Amount: big.NewInt(-50), Tags: []string{"intercompany"}, Currency: "AUD", Decimals: 2},
+			{Account: "Assets:Cash", Amount: big.NewInt(-50), Currency: "AUD", Decimals: 2},
+		}},
+	}
+	rates := map[string]*big.Rat{"AUD": big.NewRat(1, 3)}
+
+	consolidation, err := Consolidate(entities, Currency{Name: "USD", Decimals: 2}, rates, "intercompany")
+	assert.NoError(t, err)
+
+	amounts := make(map[string]string)
+	total := new(big.Int)
+	for _, acc := range consolidation.Accounts {
+		amounts[acc.Account] = acc.Amount.String()
+		total.Add(total, acc.Amount)
+	}
+	assert.Equal(t, "0", total.String())
+	assert.Equal(t, "1", amounts[TranslationDifferenceAccount])
+	// The parent is owed 10.00 but the sub only records 0.17 owing
+	assert.Equal(t, "983", amounts[IntercompanyDifferenceAccount])
+}
+
+func TestConvertAmount(t *testing.T) {
+	tests := []struct {
+		line     TBAccount
+		rate     *big.Rat
+		expected string
+	}{
+		{TBAccount{Amount: big.NewInt(1005), Currency: "AUD", Decimals: 2}, big.NewRat(1, 2), "503"},
+		{TBAccount{Amount: big.NewInt(-1005), Currency: "AUD", Decimals: 2}, big.NewRat(1, 2), "-503"},
+		{TBAccount{Amount: big.NewInt(150000000), Currency: "BTC", Decimals: 8}, big.NewRat(30000, 1), "4500000"},
+		{TBAccount{Amount: big.NewInt(100), Currency: "JPY", Decimals: 0}, big.NewRat(1, 100), "100"},
+	}
+	for _, test := range tests {
+		rates := map[string]*big.Rat{test.line.Currency: test.rate}
+		amount, err := convertAmount(test.line, Currency{Name: "USD", Decimals: 2}, rates)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, amount.String())
+	}
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// entityIDPattern limits entity identifiers to names that are safe to use in
+// file paths and database names on every backend.
+var entityIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,31}$`)
+
+// Entity is a company, or other body, whose books are kept in a ledger of
+// their own on the node. Each entity has its own accounts, currencies and
+// transactions that are isolated from those of every other entity.
+type Entity struct {
+	Id          string
+	Name        string
+	Description string
+}
+
+func NewEntity(id, name, description string) (*Entity, error) {
+	if !entityIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("Invalid ledger identifier %q, it must be up to 32 lower case letters, digits and underscores", id)
+	}
+	if len(name) == 0 {
+		name = id
+	}
+	return &Entity{id, name, description}, nil
+}
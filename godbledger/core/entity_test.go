@@ -0,0 +1,18 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEntity(t *testing.T) {
+	entity, err := NewEntity("acme_pty_ltd", "", "Holding company")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme_pty_ltd", entity.Name)
+
+	for _, id := range []string{"", "Acme", "_acme", "../acme", "acme-ltd", "a23456789012345678901234567890123"} {
+		_, err := NewEntity(id, "Acme", "")
+		assert.Error(t, err, id)
+	}
+}
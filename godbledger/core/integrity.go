@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// The kinds of integrity violation a check of the ledger reports.
+const (
+	// OrphanSplitViolation is a split without an account to post to
+	OrphanSplitViolation = "orphan_split"
+	// UnbalancedTransactionViolation is a transaction whose splits in one of
+	// its currencies do not sum to zero
+	UnbalancedTransactionViolation = "unbalanced_transaction"
+	// MissingCurrencyViolation is a split in a currency that has been deleted
+	MissingCurrencyViolation = "missing_currency"
+	// EmptyTransactionViolation is a transaction without any splits
+	EmptyTransactionViolation = "empty_transaction"
+	// BalanceMismatchViolation is an account balance summary that does not
+	// match its splits
+	BalanceMismatchViolation = "balance_mismatch"
+)
+
+// IntegrityViolation is a break of the structural or double entry rules of
+// the ledger. Repairable is set when it can be fixed without changing the
+// balance of any account, those are deleting orphan splits of zero and empty
+// transactions, and rebuilding the balance summaries.
+type IntegrityViolation struct {
+	Kind        string
+	Transaction string
+	Split       string
+	Currency    string
+	Detail      string
+	Repairable  bool
+}
+
+func (v IntegrityViolation) String() string {
+	return v.Detail
+}
+
+// OrphanSplit is a split without an account, repairable when it is zero.
+func OrphanSplit(txnID, splitID string, amount *big.Int) IntegrityViolation {
+	return IntegrityViolation{
+		Kind:        OrphanSplitViolation,
+		Transaction: txnID,
+		Split:       splitID,
+		Detail:      fmt.Sprintf("Split %s of transaction %s for %s has no account", splitID, txnID, amount),
+		Repairable:  amount.Sign() == 0,
+	}
+}
+
+// UnbalancedTransaction is a transaction whose splits in currency sum to
+// total rather than zero.
+func UnbalancedTransaction(txnID, currency string, total *big.Int) IntegrityViolation {
+	return IntegrityViolation{
+		Kind:        UnbalancedTransactionViolation,
+		Transaction: txnID,
+		Currency:    currency,
+		Detail:      fmt.Sprintf("Splits of transaction %s in %s sum to %s", txnID, currency, total),
+	}
+}
+
+// MissingCurrency is a split in a currency that does not exist.
+func MissingCurrency(txnID, splitID, currency string) IntegrityViolation {
+	return IntegrityViolation{
+		Kind:        MissingCurrencyViolation,
+		Transaction: txnID,
+		Split:       splitID,
+		Currency:    currency,
+		Detail:      fmt.Sprintf("Split %s of transaction %s is in the missing currency %s", splitID, txnID, currency),
+	}
+}
+
+// EmptyTransaction is a transaction without splits.
+func EmptyTransaction(txnID string) IntegrityViolation {
+	return IntegrityViolation{
+		Kind:        EmptyTransactionViolation,
+		Transaction: txnID,
+		Detail:      fmt.Sprintf("Transaction %s has no splits", txnID),
+		Repairable:  true,
+	}
+}
+
+// BalanceMismatch is an account balance summary that differs from its
+// splits, repaired by rebuilding the summaries.
+func BalanceMismatch(d BalanceDiscrepancy) IntegrityViolation {
+	return IntegrityViolation{
+		Kind:       BalanceMismatchViolation,
+		Currency:   d.Currency,
+		Detail:     fmt.Sprintf("Balance of %s in %s for %s is stored as %s but its splits sum to %s", d.Account, d.Currency, d.Period.Format("2006-01"), d.Stored, d.Actual),
+		Repairable: true,
+	}
+}
@@ -0,0 +1,21 @@
+package core
+
+import "time"
+
+// ListingFilter selects the transactions returned by a listing. Only splits
+// dated within the range are listed and transactions without any are skipped.
+// Transactions are ordered by identifier, which follows the order they were
+// posted, so a page carries on from the last identifier of the one before.
+type ListingFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	// Accounts limits the listing to transactions posting to one of these
+	// accounts within the range
+	Accounts []string
+	// Tags limits the listing to transactions carrying one of these tags
+	Tags []string
+	// After skips every transaction up to and including this identifier
+	After string
+	// Limit is the most transactions returned, zero for no limit
+	Limit int
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"math/big"
+	"sort"
+	"time"
+)
+
+// AccountSplit is a split posted to an account, as it is read for the
+// register of the account.
+type AccountSplit struct {
+	Transaction string
+	Split       string
+	Date        time.Time
+	Description string
+	Currency    string
+	Decimals    int
+	Amount      *big.Int
+}
+
+// RegisterLine is a split in a register with the balance of the account
+// after it.
+type RegisterLine struct {
+	AccountSplit
+	Balance *big.Int
+}
+
+// Register is the splits of an account in one currency over a date range,
+// between its balance before the first day and after the last.
+type Register struct {
+	Account  string
+	Currency string
+	Decimals int
+	Opening  *big.Int
+	Closing  *big.Int
+	Lines    []RegisterLine
+}
+
+// BuildRegisters groups the splits of an account by currency, in the order
+// they are given, and runs a balance through each group from the opening
+// balances. A currency with an opening balance but no splits in the range
+// still has a register, and the registers are sorted by currency.
+func BuildRegisters(account string, opening []TBAccount, splits []AccountSplit) []Register {
+	registers := make(map[string]*Register)
+	register := func(currency string, decimals int) *Register {
+		r, ok := registers[currency]
+		if !ok {
+			r = &Register{
+				Account:  account,
+				Currency: currency,
+				Decimals: decimals,
+				Opening:  new(big.Int),
+				Closing:  new(big.Int),
+				Lines:    []RegisterLine{},
+			}
+			registers[currency] = r
+		}
+		return r
+	}
+
+	for _, balance := range opening {
+		if balance.Amount.Sign() == 0 {
+			continue
+		}
+		r := register(balance.Currency, balance.Decimals)
+		r.Opening.Add(r.Opening, balance.Amount)
+		r.Closing.Add(r.Closing, balance.Amount)
+	}
+	for _, split := range splits {
+		r := register(split.Currency, split.Decimals)
+		r.Closing = new(big.Int).Add(r.Closing, split.Amount)
+		r.Lines = append(r.Lines, RegisterLine{AccountSplit: split, Balance: r.Closing})
+	}
+
+	result := make([]Register, 0, len(registers))
+	for _, r := range registers {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result
+}
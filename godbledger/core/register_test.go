@@ -0,0 +1,41 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildRegisters(t *testing.T) {
+	date := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	opening := []TBAccount{
+		{Account: "Assets:Cash", Amount: big.NewInt(10000), Currency: "USD", Decimals: 2},
+		{Account: "Assets:Cash", Amount: big.NewInt(500), Currency: "AUD", Decimals: 2},
+		{Account: "Assets:Cash", Amount: big.NewInt(0), Currency: "GBP", Decimals: 2},
+	}
+	splits := []AccountSplit{
+		{Transaction: "a", Split: "1", Date: date, Currency: "USD", Decimals: 2, Amount: big.NewInt(-2500)},
+		{Transaction: "b", Split: "2", Date: date.AddDate(0, 0, 1), Currency: "BTC", Decimals: 8, Amount: big.NewInt(100000000)},
+		{Transaction: "c", Split: "3", Date: date.AddDate(0, 0, 2), Currency: "USD", Decimals: 2, Amount: big.NewInt(700)},
+	}
+
+	registers := BuildRegisters("Assets:Cash", opening, splits)
+	if !assert.Len(t, registers, 3) {
+		return
+	}
+	summary := func(r Register) []string {
+		balances := []string{r.Currency, r.Opening.String()}
+		for _, line := range r.Lines {
+			balances = append(balances, line.Split+"="+line.Balance.String())
+		}
+		return append(balances, r.Closing.String())
+	}
+	assert.Equal(t, []string{"AUD", "500", "500"}, summary(registers[0]))
+	assert.Equal(t, []string{"BTC", "0", "2=100000000", "100000000"}, summary(registers[1]))
+	assert.Equal(t, []string{"USD", "10000", "1=7500", "3=8200", "8200"}, summary(registers[2]))
+	assert.Equal(t, 8, registers[1].Decimals)
+
+	assert.Empty(t, BuildRegisters("Assets:Cash", nil, nil))
+}
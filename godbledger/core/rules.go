@@ -0,0 +1,157 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Types of posting rule understood by the rules engine.
+const (
+	RuleRequireDescription = "description"  // the transaction must have a description
+	RuleRequireAccountTag  = "account_tag"  // every posted account must carry Tag
+	RuleMaxAmount          = "max_amount"   // no single line may exceed Amount in Currency
+	RuleRequireTag         = "required_tag" // postings to Account must carry the transaction tag Tag
+)
+
+// PostingRule is a guardrail evaluated against every transaction before it
+// is written to the database. Account limits the rule to accounts whose name
+// starts with it, and Currency limits it to lines in that currency. Either
+// may be left blank to apply the rule everywhere.
+type PostingRule struct {
+	Name     string
+	Type     string
+	Account  string
+	Tag      string
+	Currency string
+	Amount   *big.Int
+}
+
+// RuleViolation describes a single way a transaction breaks a posting rule.
+type RuleViolation struct {
+	Rule        string
+	Field       string
+	Description string
+}
+
+// RuleViolationError is returned when a transaction breaks one or more
+// posting rules, it carries every violation found.
+type RuleViolationError struct {
+	Violations []RuleViolation
+}
+
+func (e *RuleViolationError) Error() string {
+	descriptions := []string{}
+	for _, violation := range e.Violations {
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", violation.Rule, violation.Description))
+	}
+	return "Transaction violates posting rules: " + strings.Join(descriptions, "; ")
+}
+
+// Validate checks that the rule is well formed.
+func (rule *PostingRule) Validate() error {
+	if len(rule.Name) == 0 {
+		return fmt.Errorf("Posting rule requires a name")
+	}
+	switch rule.Type {
+	case RuleRequireDescription:
+	case RuleRequireAccountTag, RuleRequireTag:
+		if len(rule.Tag) == 0 {
+			return fmt.Errorf("Posting rule %s requires a tag", rule.Name)
+		}
+	case RuleMaxAmount:
+		if rule.Amount == nil || rule.Amount.Sign() <= 0 {
+			return fmt.Errorf("Posting rule %s requires an amount greater than zero", rule.Name)
+		}
+	default:
+		return fmt.Errorf("Posting rule %s has unknown type %s", rule.Name, rule.Type)
+	}
+	return nil
+}
+
+func (rule *PostingRule) appliesTo(split *Split) bool {
+	if len(rule.Currency) > 0 && (split.Currency == nil || split.Currency.Name != rule.Currency) {
+		return false
+	}
+	if len(rule.Account) == 0 {
+		return true
+	}
+	for _, acc := range split.Accounts {
+		if strings.HasPrefix(acc.Name, rule.Account) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns every violation of the rule by the transaction. The
+// accountTags function looks up the tags currently held by an account.
+func (rule *PostingRule) Evaluate(txn *Transaction, accountTags func(account string) ([]string, error)) ([]RuleViolation, error) {
+	violations := []RuleViolation{}
+	switch rule.Type {
+	case RuleRequireDescription:
+		if len(strings.TrimSpace(string(txn.Description))) == 0 {
+			violations = append(violations, RuleViolation{rule.Name, "description", "transaction requires a description"})
+		}
+	case RuleRequireAccountTag:
+		for i, split := range txn.Splits {
+			if !rule.appliesTo(split) {
+				continue
+			}
+			for _, acc := range split.Accounts {
+				tags, err := accountTags(acc.Name)
+				if err != nil {
+					return nil, err
+				}
+				if !containsString(tags, rule.Tag) {
+					violations = append(violations, RuleViolation{rule.Name, fmt.Sprintf("lines[%d].accountname", i), fmt.Sprintf("account %s is missing the %s tag", acc.Name, rule.Tag)})
+				}
+			}
+		}
+	case RuleMaxAmount:
+		limit := rule.Amount
+		for i, split := range txn.Splits {
+			if !rule.appliesTo(split) {
+				continue
+			}
+			if new(big.Int).Abs(split.Amount).Cmp(limit) > 0 {
+				violations = append(violations, RuleViolation{rule.Name, fmt.Sprintf("lines[%d].amount", i), fmt.Sprintf("amount %s exceeds the maximum of %s", split.Amount.String(), rule.Amount.String())})
+			}
+		}
+	case RuleRequireTag:
+		for i, split := range txn.Splits {
+			if rule.appliesTo(split) && !containsString(txn.Tags, rule.Tag) {
+				violations = append(violations, RuleViolation{rule.Name, fmt.Sprintf("lines[%d].accountname", i), fmt.Sprintf("postings to %s require the %s tag", split.Accounts[0].Name, rule.Tag)})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Posting rule %s has unknown type %s", rule.Name, rule.Type)
+	}
+	return violations, nil
+}
+
+// EvaluateRules runs every rule against the transaction and returns a
+// RuleViolationError listing all violations found, or nil if it passes.
+func EvaluateRules(rules []*PostingRule, txn *Transaction, accountTags func(account string) ([]string, error)) error {
+	violations := []RuleViolation{}
+	for _, rule := range rules {
+		found, err := rule.Evaluate(txn, accountTags)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, found...)
+	}
+	if len(violations) > 0 {
+		return &RuleViolationError{violations}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, elem := range list {
+		if strings.EqualFold(elem, s) {
+			return true
+		}
+	}
+	return false
+}
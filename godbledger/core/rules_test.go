@@ -0,0 +1,62 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostingRules(t *testing.T) {
+	user, _ := NewUser("Tester")
+	txn, _ := NewTransaction(user)
+
+	cash, _ := NewAccount("Assets:Cash", "Assets:Cash")
+	project, _ := NewAccount("Expenses:Projects:Apollo", "Expenses:Projects:Apollo")
+	aud, _ := NewCurrency("AUD", 2)
+
+	spl1, _ := NewSplit(time.Now(), []byte{}, []*Account{project}, aud, big.NewInt(5000))
+	spl2, _ := NewSplit(time.Now(), []byte{}, []*Account{cash}, aud, big.NewInt(-5000))
+	txn.AppendSplit(spl1)
+	txn.AppendSplit(spl2)
+
+	rules := []*PostingRule{
+		{Name: "memo", Type: RuleRequireDescription},
+		{Name: "main", Type: RuleRequireAccountTag, Tag: "main"},
+		{Name: "limit", Type: RuleMaxAmount, Amount: big.NewInt(1000), Currency: "AUD"},
+		{Name: "project", Type: RuleRequireTag, Account: "Expenses:Projects", Tag: "project-code"},
+	}
+	for _, rule := range rules {
+		assert.NoError(t, rule.Validate())
+	}
+	assert.Error(t, (&PostingRule{Name: "broken", Type: RuleMaxAmount}).Validate())
+
+	accountTags := func(account string) ([]string, error) {
+		if account == "Assets:Cash" {
+			return []string{"main"}, nil
+		}
+		return []string{}, nil
+	}
+
+	err := EvaluateRules(rules, txn, accountTags)
+	var verr *RuleViolationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, []RuleViolation{
+		{"memo", "description", "transaction requires a description"},
+		{"main", "lines[0].accountname", "account Expenses:Projects:Apollo is missing the main tag"},
+		{"limit", "lines[0].amount", "amount 5000 exceeds the maximum of 1000"},
+		{"limit", "lines[1].amount", "amount -5000 exceeds the maximum of 1000"},
+		{"project", "lines[0].accountname", "postings to Expenses:Projects:Apollo require the project-code tag"},
+	}, verr.Violations)
+
+	txn.Description = []byte("Apollo supplies")
+	txn.Tags = append(txn.Tags, "project-code")
+	spl1.Amount = big.NewInt(900)
+	spl2.Amount = big.NewInt(-900)
+	err = EvaluateRules(rules[:1], txn, accountTags)
+	assert.NoError(t, err)
+	err = EvaluateRules(rules[2:], txn, accountTags)
+	assert.NoError(t, err)
+}
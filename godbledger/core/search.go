@@ -0,0 +1,58 @@
+package core
+
+import (
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchFilter selects the transactions returned by a search. Every term of
+// the query must start a word of the transaction description, its long body
+// or the description of one of its splits. Like listings the results are
+// ordered by identifier so a page carries on from the last identifier of the
+// one before.
+type SearchFilter struct {
+	Query string
+	// StartDate and EndDate limit the search to transactions with a split
+	// dated within the range, inclusive of both days and unbounded when zero
+	StartDate time.Time
+	EndDate   time.Time
+	// MinAmount and MaxAmount limit the search to transactions with a split
+	// whose amount, ignoring its sign, is within the range, unbounded when nil
+	MinAmount *big.Int
+	MaxAmount *big.Int
+	// After skips every transaction up to and including this identifier
+	After string
+	// Limit is the most transactions returned, zero for no limit
+	Limit int
+}
+
+// SearchTerms splits text into lower case words of letters and digits, the
+// way both the queries and the searched descriptions are broken up.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// MatchesSearch reports whether every term starts one of the words of texts.
+func MatchesSearch(terms []string, texts ...string) bool {
+	words := []string{}
+	for _, text := range texts {
+		words = append(words, SearchTerms(text)...)
+	}
+	for _, term := range terms {
+		found := false
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,21 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"office", "rent", "june", "2021"}, SearchTerms("Office rent: June-2021"))
+	assert.Equal(t, []string{"café", "ünïcode"}, SearchTerms("  Café, ÜNÏCODE!"))
+	assert.Empty(t, SearchTerms(" -- "))
+}
+
+func TestMatchesSearch(t *testing.T) {
+	assert.True(t, MatchesSearch(SearchTerms("rent jun"), "Office rent", "Paid June"))
+	assert.True(t, MatchesSearch(SearchTerms("RENT"), "office rental"))
+	assert.False(t, MatchesSearch(SearchTerms("rent june"), "Office rent"))
+	// Terms must start a word
+	assert.False(t, MatchesSearch(SearchTerms("ent"), "Office rent"))
+}
@@ -45,17 +45,18 @@ type Transaction struct {
 	Poster      *User
 	Description []byte
 	Splits      []*Split
+	Tags        []string
 }
 
 func NewTransaction(usr *User) (*Transaction, error) {
 	guid := xid.New()
-	txn := &Transaction{guid.String(), time.Now(), usr, []byte{}, []*Split{}}
+	txn := &Transaction{guid.String(), time.Now(), usr, []byte{}, []*Split{}, []string{}}
 	return txn, nil
 }
 
 func ReverseTransaction(originalTxn *Transaction, usr *User) (*Transaction, error) {
 	guid := xid.New()
-	txn := &Transaction{guid.String(), time.Now(), usr, []byte{}, []*Split{}}
+	txn := &Transaction{guid.String(), time.Now(), usr, []byte{}, []*Split{}, []string{}}
 
 	for _, split := range originalTxn.Splits {
 		newSplt, err := NewSplit(split.Date, split.Description, split.Accounts, split.Currency, big.NewInt(0).Mul(big.NewInt(-1), split.Amount))
@@ -67,6 +68,19 @@ func ReverseTransaction(originalTxn *Transaction, usr *User) (*Transaction, erro
 	return txn, nil
 }
 
+// TrashedTransaction is a deleted transaction that remains in the trash until
+// it is restored or purged.
+type TrashedTransaction struct {
+	Transaction
+	TrashedAt time.Time
+}
+
+// Reconciliation is a set of splits matched against each other.
+type Reconciliation struct {
+	Id     string
+	Splits []string
+}
+
 func (txn *Transaction) AppendSplit(spl *Split) error {
 	txn.Splits = append(txn.Splits, spl)
 	return nil
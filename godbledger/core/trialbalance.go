@@ -1,8 +1,10 @@
 package core
 
+import "math/big"
+
 type TBAccount struct {
 	Account  string   `json:"Account"`
-	Amount   int      `json:"Amount"`
+	Amount   *big.Int `json:"Amount"`
 	Tags     []string `json:"Tags"`
 	Currency string   `json:"Currency"`
 	Decimals int      `json:"Decimals"`
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultWebhookAttempts is the number of times a delivery is tried before it
+// is moved to the dead letters, when the webhook does not set its own.
+const DefaultWebhookAttempts = 8
+
+// Webhook is an HTTP endpoint that is sent the events of the node as signed
+// JSON. Events limits it to those kinds of event, left empty it is sent every
+// kind.
+type Webhook struct {
+	Name        string
+	URL         string
+	Secret      string   // Key the payloads are signed with, shared with the receiver
+	Events      []string // Kinds of event sent to the endpoint, eg "transaction_added"
+	MaxAttempts int      // Deliveries failing this many times become dead letters, 0 for DefaultWebhookAttempts
+}
+
+// WebhookDelivery is an event waiting to be sent to a webhook. Deliveries
+// that fail are tried again at NextAttempt until they run out of attempts,
+// when they are kept as dead letters.
+type WebhookDelivery struct {
+	ID          string
+	Webhook     string
+	Kind        string
+	Payload     []byte
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+	Dead        bool
+	Created     time.Time
+}
+
+// WebhookPayload is the JSON body sent to a webhook for an event. Delivery
+// identifies it and stays the same when it is retried, so receivers can
+// ignore one they have already handled.
+type WebhookPayload struct {
+	Delivery    string `json:"delivery"`
+	Kind        string `json:"kind"`
+	Ledger      string `json:"ledger,omitempty"`
+	Transaction string `json:"transaction,omitempty"`
+	Account     string `json:"account,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	Time        string `json:"time"`
+}
+
+// Validate checks that the webhook is well formed.
+func (w *Webhook) Validate() error {
+	if len(w.Name) == 0 {
+		return fmt.Errorf("Webhook requires a name")
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("Webhook %s requires an http or https URL", w.Name)
+	}
+	if len(w.Secret) == 0 {
+		return fmt.Errorf("Webhook %s requires a secret to sign its payloads", w.Name)
+	}
+	if w.MaxAttempts < 0 {
+		return fmt.Errorf("Webhook %s cannot have negative attempts", w.Name)
+	}
+	return nil
+}
+
+// Attempts is the number of times a delivery to the webhook is tried.
+func (w *Webhook) Attempts() int {
+	if w.MaxAttempts == 0 {
+		return DefaultWebhookAttempts
+	}
+	return w.MaxAttempts
+}
+
+// Wants reports whether the webhook is sent events of the kind.
+func (w *Webhook) Wants(kind string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, event := range w.Events {
+		if event == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// SignWebhook returns the hex encoded HMAC-SHA256, keyed with the secret, of
+// the timestamp in unix seconds and the payload joined by a full stop.
+// Receivers recompute it to check the payload came from the node, and
+// reject old timestamps to stop it being replayed.
+func SignWebhook(secret string, timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
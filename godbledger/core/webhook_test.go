@@ -0,0 +1,41 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	hook := Webhook{Name: "books", URL: "https://example.com/hook", Secret: "shh"}
+	assert.NoError(t, hook.Validate())
+	assert.Equal(t, DefaultWebhookAttempts, hook.Attempts())
+	assert.True(t, hook.Wants("transaction_added"))
+
+	hook.Events = []string{"transaction_voided"}
+	assert.False(t, hook.Wants("transaction_added"))
+	assert.True(t, hook.Wants("transaction_voided"))
+
+	for _, broken := range []Webhook{
+		{URL: "https://example.com/hook", Secret: "shh"},
+		{Name: "books", URL: "ftp://example.com/hook", Secret: "shh"},
+		{Name: "books", URL: "example.com/hook", Secret: "shh"},
+		{Name: "books", URL: "https://example.com/hook"},
+		{Name: "books", URL: "https://example.com/hook", Secret: "shh", MaxAttempts: -1},
+	} {
+		assert.Error(t, broken.Validate(), broken.Name+" "+broken.URL)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	timestamp := time.Unix(1614556800, 0)
+	payload := []byte(`{"kind":"transaction_added"}`)
+
+	signature := SignWebhook("shh", timestamp, payload)
+	assert.Len(t, signature, 64)
+	assert.Equal(t, signature, SignWebhook("shh", timestamp, payload))
+	assert.NotEqual(t, signature, SignWebhook("other", timestamp, payload))
+	assert.NotEqual(t, signature, SignWebhook("shh", timestamp.Add(time.Second), payload))
+	assert.NotEqual(t, signature, SignWebhook("shh", timestamp, []byte(`{}`)))
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+// AmountValue is the value an amount is written to the database as. Amounts
+// are passed as base 10 strings, which every backend converts to its
+// arbitrary precision column type without loss, and a nil amount as zero.
+func AmountValue(amount *big.Int) string {
+	if amount == nil {
+		return "0"
+	}
+	return amount.String()
+}
+
+// ScanAmount returns a scanner that reads an amount column, or a sum of one,
+// into dest. Drivers return arbitrary precision numbers as text and small
+// ones as integers, NULL reads as zero.
+func ScanAmount(dest **big.Int) sql.Scanner {
+	return amountScanner{dest}
+}
+
+type amountScanner struct {
+	dest **big.Int
+}
+
+func (s amountScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s.dest = new(big.Int)
+	case int64:
+		*s.dest = big.NewInt(v)
+	case []byte:
+		amount, err := core.ParseAmount(string(v))
+		if err != nil {
+			return err
+		}
+		*s.dest = amount
+	case string:
+		amount, err := core.ParseAmount(v)
+		if err != nil {
+			return err
+		}
+		*s.dest = amount
+	default:
+		return fmt.Errorf("Cannot read an amount from %T", src)
+	}
+	return nil
+}
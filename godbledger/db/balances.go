@@ -0,0 +1,82 @@
+package db
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+// BalanceKey identifies a monthly account balance summary.
+type BalanceKey struct {
+	Account  string
+	Currency string
+	Period   time.Time
+}
+
+// BalanceTotal is the amount and number of splits summarised by a balance.
+type BalanceTotal struct {
+	Amount *big.Int
+	Splits int64
+}
+
+// Equal reports whether both summarise the same amount and number of splits.
+func (total BalanceTotal) Equal(other BalanceTotal) bool {
+	return total.Splits == other.Splits && amountOrZero(total.Amount).Cmp(amountOrZero(other.Amount)) == 0
+}
+
+func amountOrZero(amount *big.Int) *big.Int {
+	if amount == nil {
+		return new(big.Int)
+	}
+	return amount
+}
+
+// ParsePeriod reads the month of a balance summary, which drivers return
+// either as a date or a timestamp.
+func ParsePeriod(period string) (time.Time, error) {
+	if len(period) > 10 {
+		period = period[:10]
+	}
+	return time.Parse("2006-01-02", period)
+}
+
+// CompareBalances returns the summaries in stored that do not match the ones
+// calculated in actual, ordered by account, currency and period.
+func CompareBalances(stored, actual map[BalanceKey]BalanceTotal) []core.BalanceDiscrepancy {
+	discrepancies := []core.BalanceDiscrepancy{}
+	for key, total := range stored {
+		if !total.Equal(actual[key]) {
+			discrepancies = append(discrepancies, core.BalanceDiscrepancy{
+				Account:  key.Account,
+				Currency: key.Currency,
+				Period:   key.Period,
+				Stored:   amountOrZero(total.Amount),
+				Actual:   amountOrZero(actual[key].Amount),
+			})
+		}
+	}
+	for key, total := range actual {
+		if _, ok := stored[key]; !ok {
+			discrepancies = append(discrepancies, core.BalanceDiscrepancy{
+				Account:  key.Account,
+				Currency: key.Currency,
+				Period:   key.Period,
+				Stored:   new(big.Int),
+				Actual:   amountOrZero(total.Amount),
+			})
+		}
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		a, b := discrepancies[i], discrepancies[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Period.Before(b.Period)
+	})
+	return discrepancies
+}
@@ -1,41 +1,132 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
 	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db/migrate"
 )
 
-// Database wraps all database operations.
+// Database wraps all database operations. Every operation other than Close
+// takes the context of the request it is serving so that a cancelled or timed
+// out request stops its queries.
 type Database interface {
-	InitDB() error
+	InitDB(ctx context.Context) error
+	// UnitOfWork runs fn in a single database transaction, committing it when
+	// fn returns nil and rolling every change back otherwise. Operations must
+	// be made through the Database passed to fn to take part in it.
+	UnitOfWork(ctx context.Context, fn func(tx Database) error) error
+	Migrate(ctx context.Context, dryRun bool) ([]migrate.Migration, error)
+	MigrationStatus(ctx context.Context) ([]migrate.Status, error)
 	Close() error
-	AddTransaction(txn *core.Transaction) (string, error)
-	FindTransaction(txnID string) (*core.Transaction, error)
-	DeleteTransaction(txnID string) error
-	FindTag(tag string) (int, error)
-	AddTag(tag string) error
-	SafeAddTag(tag string) error
-	SafeAddTagToAccount(account, tag string) error
-	AddTagToAccount(accountID string, tag int) error
-	DeleteTagFromAccount(account, tag string) error
-	SafeAddTagToTransaction(txnID, tag string) error
-	AddTagToTransaction(txnID string, tag int) error
-	DeleteTagFromTransaction(txnID, tag string) error
-	FindCurrency(cur string) (*core.Currency, error)
-	AddCurrency(cur *core.Currency) error
-	SafeAddCurrency(cur *core.Currency) error
-	DeleteCurrency(currency string) error
-	FindAccount(code string) (*core.Account, error)
-	AddAccount(*core.Account) error
-	SafeAddAccount(*core.Account) (bool, error)
-	DeleteAccount(accountName string) error
-	FindUser(pubKey string) (*core.User, error)
-	AddUser(usr *core.User) error
-	ReconcileTransactions(reconciliationID string, splitIDs []string) (string, error)
-	SafeAddUser(usr *core.User) error
-	GetTB(date time.Time) (*[]core.TBAccount, error)
-	GetListing(startdate, enddate time.Time) (*[]core.Transaction, error)
-	Query(query string, args ...interface{}) (*sql.Rows, error)
+	AddTransaction(ctx context.Context, txn *core.Transaction) (string, error)
+	AddTransactions(ctx context.Context, txns []*core.Transaction) ([]string, error)
+	FindTransaction(ctx context.Context, txnID string) (*core.Transaction, error)
+	DeleteTransaction(ctx context.Context, txnID string) error
+	// TrashTransaction is DeleteTransaction recording the time it was trashed,
+	// such as when the trash is copied from another database
+	TrashTransaction(ctx context.Context, txnID string, trashedAt time.Time) error
+	ListTrash(ctx context.Context) ([]core.TrashedTransaction, error)
+	IsTrashed(ctx context.Context, txnID string) (bool, error)
+	RestoreTransaction(ctx context.Context, txnID string) error
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	FindTag(ctx context.Context, tag string) (int, error)
+	AddTag(ctx context.Context, tag string) error
+	SafeAddTag(ctx context.Context, tag string) error
+	SafeAddTagToAccount(ctx context.Context, account, tag string) error
+	AddTagToAccount(ctx context.Context, accountID string, tag int) error
+	DeleteTagFromAccount(ctx context.Context, account, tag string) error
+	SafeAddTagToTransaction(ctx context.Context, txnID, tag string) error
+	AddTagToTransaction(ctx context.Context, txnID string, tag int) error
+	DeleteTagFromTransaction(ctx context.Context, txnID, tag string) error
+	FindCurrency(ctx context.Context, cur string) (*core.Currency, error)
+	AddCurrency(ctx context.Context, cur *core.Currency) error
+	SafeAddCurrency(ctx context.Context, cur *core.Currency) error
+	DeleteCurrency(ctx context.Context, currency string) error
+	FindAccount(ctx context.Context, code string) (*core.Account, error)
+	AddAccount(ctx context.Context, acc *core.Account) error
+	SafeAddAccount(ctx context.Context, acc *core.Account) (bool, error)
+	DeleteAccount(ctx context.Context, accountName string) error
+	FindUser(ctx context.Context, pubKey string) (*core.User, error)
+	AddUser(ctx context.Context, usr *core.User) error
+	ReconcileTransactions(ctx context.Context, reconciliationID string, splitIDs []string) (string, error)
+	SafeAddUser(ctx context.Context, usr *core.User) error
+	AddAllocationRule(ctx context.Context, rule *core.AllocationRule) error
+	FindAllocationRule(ctx context.Context, name string) (*core.AllocationRule, error)
+	DeleteAllocationRule(ctx context.Context, name string) error
+	AddPostingRule(ctx context.Context, rule *core.PostingRule) error
+	GetPostingRules(ctx context.Context) ([]*core.PostingRule, error)
+	DeletePostingRule(ctx context.Context, name string) error
+	// The webhook deliveries are the events waiting to be sent to webhooks,
+	// kept in the database so that they survive a restart. Deliveries that
+	// ran out of attempts stay as dead letters until they are retried or
+	// deleted
+	AddWebhookDelivery(ctx context.Context, delivery *core.WebhookDelivery) error
+	FindWebhookDelivery(ctx context.Context, id string) (*core.WebhookDelivery, error)
+	// DueWebhookDeliveries returns up to limit of the live deliveries whose
+	// next attempt is at or before now, the longest waiting first
+	DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*core.WebhookDelivery, error)
+	// UpdateWebhookDelivery saves the attempts, next attempt, last error and
+	// whether the delivery is dead
+	UpdateWebhookDelivery(ctx context.Context, delivery *core.WebhookDelivery) error
+	DeleteWebhookDelivery(ctx context.Context, id string) error
+	ListDeadWebhookDeliveries(ctx context.Context) ([]*core.WebhookDelivery, error)
+	FindAccountTags(ctx context.Context, account string) ([]string, error)
+	GetTB(ctx context.Context, date time.Time) (*[]core.TBAccount, error)
+	// GetAccountBalance returns the lines GetTB would for the account, one
+	// for each currency, without totalling the other accounts
+	GetAccountBalance(ctx context.Context, account string, date time.Time) ([]core.TBAccount, error)
+	// RebuildBalances recalculates the monthly account balance summaries read
+	// by GetTB from the splits, VerifyBalances lists those that disagree
+	RebuildBalances(ctx context.Context) error
+	VerifyBalances(ctx context.Context) ([]core.BalanceDiscrepancy, error)
+	// CheckIntegrity lists the orphan splits, unbalanced and empty
+	// transactions and splits in missing currencies, RepairIntegrity deletes
+	// the orphan splits and empty transactions of those that are repairable
+	CheckIntegrity(ctx context.Context) ([]core.IntegrityViolation, error)
+	RepairIntegrity(ctx context.Context, violations []core.IntegrityViolation) error
+	GetListing(ctx context.Context, filter core.ListingFilter) (*[]core.Transaction, error)
+	// GetAccountSplits returns the splits posted to an account dated within
+	// the days from start to end, either left zero to be unbounded. Like the
+	// trial balance it leaves out void and trashed transactions. They are
+	// ordered by date, transaction and split
+	GetAccountSplits(ctx context.Context, account string, start, end time.Time) ([]core.AccountSplit, error)
+	// SearchTransactions returns the transactions outside the trash whose
+	// descriptions match the query of the filter, with all of their splits
+	SearchTransactions(ctx context.Context, filter core.SearchFilter) (*[]core.Transaction, error)
+	ListUsers(ctx context.Context) ([]*core.User, error)
+	ListAccounts(ctx context.Context) ([]*core.Account, error)
+	ListCurrencies(ctx context.Context) ([]*core.Currency, error)
+	ListTags(ctx context.Context) ([]string, error)
+	// ListTransactions returns every transaction, including those in the
+	// trash, with all of their splits and tags. They are ordered by identifier
+	// starting after the one given, limit caps how many are returned
+	ListTransactions(ctx context.Context, after string, limit int) ([]*core.Transaction, error)
+	ListReconciliations(ctx context.Context) ([]core.Reconciliation, error)
+	// The entities registered in a node's own database are the other ledgers
+	// it serves, their books are held in databases of their own
+	AddEntity(ctx context.Context, entity *core.Entity) error
+	FindEntity(ctx context.Context, id string) (*core.Entity, error)
+	ListEntities(ctx context.Context) ([]*core.Entity, error)
+	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Backuper is implemented by the databases that can be backed up and restored
+// while the node is running.
+type Backuper interface {
+	// BackupTo writes a consistent copy of the database to the file at path
+	BackupTo(ctx context.Context, path string) error
+	// RestoreFrom replaces the contents of the database with a copy written
+	// by BackupTo
+	RestoreFrom(ctx context.Context, path string) error
+}
+
+// EntityOpener is implemented by the databases that can hold the books of
+// other entities alongside their own.
+type EntityOpener interface {
+	// OpenEntity opens, creating it if needed, the separate database that
+	// holds the ledger of an entity. It is initialised by the caller.
+	OpenEntity(ctx context.Context, id string) (Database, error)
 }
@@ -0,0 +1,72 @@
+// Package dbtest holds the fixtures shared by the tests of the database
+// backends and of the packages built on them.
+package dbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db"
+)
+
+// Ledger posts balanced AUD transactions to a database as a single user.
+type Ledger struct {
+	t        *testing.T
+	db       db.Database
+	User     *core.User
+	Currency *core.Currency
+}
+
+// NewLedger adds the user posting the transactions to an initialised
+// database.
+func NewLedger(t *testing.T, database db.Database) *Ledger {
+	t.Helper()
+	ctx := context.Background()
+	usr, _ := core.NewUser("Tester")
+	if err := database.SafeAddUser(ctx, usr); err != nil {
+		t.Fatal(err)
+	}
+	aud, err := database.FindCurrency(ctx, "AUD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Ledger{t: t, db: database, User: usr, Currency: aud}
+}
+
+// Journal builds a transaction on the date debiting one account and
+// crediting the other with the amount, without adding it. The splits are
+// described by the name of their account.
+func (l *Ledger) Journal(debit, credit string, amount int64, date time.Time) *core.Transaction {
+	txn, _ := core.NewTransaction(l.User)
+	txn.Postdate = date
+	txn.Description = []byte("Line")
+	for _, line := range []struct {
+		account string
+		amount  int64
+	}{{debit, amount}, {credit, -amount}} {
+		acc, _ := core.NewAccount(line.account, line.account)
+		spl, _ := core.NewSplit(date, []byte(line.account), []*core.Account{acc}, l.Currency, big.NewInt(line.amount))
+		txn.AppendSplit(spl)
+	}
+	return txn
+}
+
+// Post adds the Journal to the database, adding the accounts when they are
+// new.
+func (l *Ledger) Post(debit, credit string, amount int64, date time.Time) *core.Transaction {
+	l.t.Helper()
+	ctx := context.Background()
+	txn := l.Journal(debit, credit, amount, date)
+	for _, spl := range txn.Splits {
+		if _, err := l.db.SafeAddAccount(ctx, spl.Accounts[0]); err != nil {
+			l.t.Fatal(err)
+		}
+	}
+	if _, err := l.db.AddTransaction(ctx, txn); err != nil {
+		l.t.Fatal(err)
+	}
+	return txn
+}
@@ -0,0 +1,19 @@
+package db
+
+import "errors"
+
+// Errors returned by the Database implementations. Backends wrap the driver
+// error where it carries more detail so callers should test for these with
+// errors.Is rather than comparing directly.
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("Record not found")
+	// ErrAlreadyExists is returned when inserting a record whose key is taken
+	ErrAlreadyExists = errors.New("Record already exists")
+	// ErrConstraintViolation is returned when a change would break a
+	// constraint of the schema, such as removing an account that has postings
+	ErrConstraintViolation = errors.New("Constraint violation")
+	// ErrUnbalanced is returned when the splits of a transaction do not sum
+	// to zero
+	ErrUnbalanced = errors.New("Transaction does not balance")
+)
@@ -0,0 +1,97 @@
+package memorydb
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+// balancePeriod is the first day of the month, in UTC, of a split date.
+func balancePeriod(date time.Time) time.Time {
+	date = date.UTC()
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// counted reports whether the transaction exists and is included in the
+// balance summaries, which leave out void and trashed transactions.
+func (db *Database) counted(txnID string) bool {
+	if _, ok := db.transactions[txnID]; !ok {
+		return false
+	}
+	if _, trashed := db.trash[txnID]; trashed {
+		return false
+	}
+	return !db.isVoid(txnID)
+}
+
+// updateBalances adds the transaction to, or takes it out of, the balance
+// summaries when a change has voided, trashed or restored it. before is
+// whether it was counted ahead of the change.
+func (db *Database) updateBalances(txnID string, before bool) {
+	switch after := db.counted(txnID); {
+	case after && !before:
+		addBalances(db.balances, db.transactions[txnID], 1)
+	case before && !after:
+		addBalances(db.balances, db.transactions[txnID], -1)
+	}
+}
+
+// addBalances adds the splits of a transaction to the balance summaries, or
+// takes them away when sign is -1.
+func addBalances(balances map[dberr.BalanceKey]dberr.BalanceTotal, record *transaction, sign int64) {
+	for _, s := range record.splits {
+		for _, code := range s.accounts {
+			key := dberr.BalanceKey{Account: code, Currency: s.currency, Period: balancePeriod(s.date)}
+			total := balances[key]
+			// Totals are replaced rather than added to in place as they are
+			// shared with the snapshots of units of work
+			amount := new(big.Int).Mul(s.amount, big.NewInt(sign))
+			if total.Amount != nil {
+				amount.Add(amount, total.Amount)
+			}
+			total.Amount = amount
+			total.Splits += sign
+			if total.Splits == 0 {
+				delete(balances, key)
+				continue
+			}
+			balances[key] = total
+		}
+	}
+}
+
+// summariseSplits calculates the balance summaries from the splits.
+func (db *Database) summariseSplits() map[dberr.BalanceKey]dberr.BalanceTotal {
+	balances := make(map[dberr.BalanceKey]dberr.BalanceTotal)
+	for txnID, record := range db.transactions {
+		if db.counted(txnID) {
+			addBalances(balances, record, 1)
+		}
+	}
+	return balances
+}
+
+// RebuildBalances replaces the balance summaries with totals calculated from
+// the splits.
+func (db *Database) RebuildBalances(ctx context.Context) error {
+	log.Debug("Rebuilding Account Balances")
+	db.lock()
+	defer db.unlock()
+
+	db.balances = db.summariseSplits()
+
+	return nil
+}
+
+// VerifyBalances compares the balance summaries with totals calculated from
+// the splits and returns those that differ.
+func (db *Database) VerifyBalances(ctx context.Context) ([]core.BalanceDiscrepancy, error) {
+	log.Debug("Verifying Account Balances")
+	db.rlock()
+	defer db.runlock()
+
+	return dberr.CompareBalances(db.balances, db.summariseSplits()), nil
+}
@@ -0,0 +1,51 @@
+package memorydb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func (db *Database) AddEntity(ctx context.Context, entity *core.Entity) error {
+	log.WithField("entity", entity.Id).Debug("Adding Entity to DB")
+	db.lock()
+	defer db.unlock()
+
+	if _, exists := db.entities[entity.Id]; exists {
+		return fmt.Errorf("%w: entity %s", dberr.ErrAlreadyExists, entity.Id)
+	}
+	db.entities[entity.Id] = &core.Entity{Id: entity.Id, Name: entity.Name, Description: entity.Description}
+	return nil
+}
+
+func (db *Database) FindEntity(ctx context.Context, id string) (*core.Entity, error) {
+	db.rlock()
+	defer db.runlock()
+
+	e, ok := db.entities[id]
+	if !ok {
+		return nil, dberr.ErrNotFound
+	}
+	return &core.Entity{Id: e.Id, Name: e.Name, Description: e.Description}, nil
+}
+
+func (db *Database) ListEntities(ctx context.Context) ([]*core.Entity, error) {
+	db.rlock()
+	defer db.runlock()
+
+	entities := make([]*core.Entity, 0, len(db.entities))
+	for _, e := range db.entities {
+		entities = append(entities, &core.Entity{Id: e.Id, Name: e.Name, Description: e.Description})
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].Id < entities[j].Id })
+	return entities, nil
+}
+
+// OpenEntity returns a new, empty, in-memory ledger for the entity. It only
+// lives as long as the caller keeps hold of it.
+func (db *Database) OpenEntity(ctx context.Context, id string) (dberr.Database, error) {
+	return NewDB(), nil
+}
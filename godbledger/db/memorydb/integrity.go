@@ -0,0 +1,93 @@
+package memorydb
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+// CheckIntegrity goes through the transactions in the order of their
+// identifiers like the SQL backends.
+func (db *Database) CheckIntegrity(ctx context.Context) ([]core.IntegrityViolation, error) {
+	log.Debug("Checking Ledger Integrity")
+	db.rlock()
+	defer db.runlock()
+
+	ids := append([]string{}, db.txnOrder...)
+	sort.Strings(ids)
+
+	orphans, unbalanced, missing, empty := []core.IntegrityViolation{}, []core.IntegrityViolation{}, []core.IntegrityViolation{}, []core.IntegrityViolation{}
+	for _, txnID := range ids {
+		record := db.transactions[txnID]
+		if len(record.splits) == 0 {
+			empty = append(empty, core.EmptyTransaction(txnID))
+			continue
+		}
+		splits := append([]*split{}, record.splits...)
+		sort.Slice(splits, func(i, j int) bool { return splits[i].id < splits[j].id })
+		totals := make(map[string]*big.Int)
+		for _, s := range splits {
+			if len(s.accounts) == 0 {
+				orphans = append(orphans, core.OrphanSplit(txnID, s.id, s.amount))
+			}
+			if _, ok := db.currencies[s.currency]; !ok {
+				missing = append(missing, core.MissingCurrency(txnID, s.id, s.currency))
+			}
+			if _, ok := totals[s.currency]; !ok {
+				totals[s.currency] = new(big.Int)
+			}
+			totals[s.currency].Add(totals[s.currency], s.amount)
+		}
+		currencies := []string{}
+		for currency := range totals {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+		for _, currency := range currencies {
+			if totals[currency].Sign() != 0 {
+				unbalanced = append(unbalanced, core.UnbalancedTransaction(txnID, currency, totals[currency]))
+			}
+		}
+	}
+
+	violations := append(orphans, unbalanced...)
+	violations = append(violations, missing...)
+	return append(violations, empty...), nil
+}
+
+func (db *Database) RepairIntegrity(ctx context.Context, violations []core.IntegrityViolation) error {
+	log.Debug("Repairing Ledger Integrity")
+	db.lock()
+	defer db.unlock()
+
+	for _, violation := range violations {
+		if !violation.Repairable {
+			continue
+		}
+		record, ok := db.transactions[violation.Transaction]
+		if !ok {
+			continue
+		}
+		switch violation.Kind {
+		case core.OrphanSplitViolation:
+			for i, s := range record.splits {
+				if s.id == violation.Split && len(s.accounts) == 0 && s.amount.Sign() == 0 {
+					record.splits = append(record.splits[:i:i], record.splits[i+1:]...)
+					for _, splitIDs := range db.reconciliations {
+						delete(splitIDs, s.id)
+					}
+					delete(db.splits, s.id)
+					break
+				}
+			}
+		case core.EmptyTransactionViolation:
+			if len(record.splits) == 0 {
+				db.removeTransaction(record.id)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package memorydb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+func (db *Database) ListUsers(ctx context.Context) ([]*core.User, error) {
+	db.rlock()
+	defer db.runlock()
+
+	users := []*core.User{}
+	for _, usr := range db.users {
+		users = append(users, &core.User{Id: usr.Id, Name: usr.Name})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users, nil
+}
+
+func (db *Database) ListAccounts(ctx context.Context) ([]*core.Account, error) {
+	db.rlock()
+	defer db.runlock()
+
+	accounts := []*core.Account{}
+	for _, acc := range db.accounts {
+		accounts = append(accounts, &core.Account{Code: acc.Code, Name: acc.Name})
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Code < accounts[j].Code })
+	return accounts, nil
+}
+
+func (db *Database) ListCurrencies(ctx context.Context) ([]*core.Currency, error) {
+	db.rlock()
+	defer db.runlock()
+
+	currencies := []*core.Currency{}
+	for _, cur := range db.currencies {
+		currencies = append(currencies, &core.Currency{Name: cur.Name, Decimals: cur.Decimals})
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Name < currencies[j].Name })
+	return currencies, nil
+}
+
+func (db *Database) ListTags(ctx context.Context) ([]string, error) {
+	db.rlock()
+	defer db.runlock()
+
+	tags := []string{}
+	for tag := range db.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (db *Database) ListTransactions(ctx context.Context, after string, limit int) ([]*core.Transaction, error) {
+	db.rlock()
+	defer db.runlock()
+
+	ids := []string{}
+	for id := range db.transactions {
+		if id > after {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	txns := make([]*core.Transaction, 0, len(ids))
+	for _, id := range ids {
+		txn := db.toTransaction(db.transactions[id], func(*split) bool { return true })
+		txns = append(txns, &txn)
+	}
+	return txns, nil
+}
+
+func (db *Database) ListReconciliations(ctx context.Context) ([]core.Reconciliation, error) {
+	db.rlock()
+	defer db.runlock()
+
+	reconciliations := []core.Reconciliation{}
+	for id, splitIDs := range db.reconciliations {
+		if len(splitIDs) == 0 {
+			continue
+		}
+		reconciliation := core.Reconciliation{Id: id}
+		for splitID := range splitIDs {
+			reconciliation.Splits = append(reconciliation.Splits, splitID)
+		}
+		sort.Strings(reconciliation.Splits)
+		reconciliations = append(reconciliations, reconciliation)
+	}
+	sort.Slice(reconciliations, func(i, j int) bool { return reconciliations[i].Id < reconciliations[j].Id })
+	return reconciliations, nil
+}
@@ -0,0 +1,144 @@
+// Package memorydb is a pure Go implementation of db.Database that keeps the
+// ledger in maps and indexes in memory. Nothing is persisted, it is intended
+// for embedding and for tests that should not need cgo.
+package memorydb
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+	"github.com/darcys22/godbledger/godbledger/db/migrate"
+)
+
+var log = logrus.WithField("prefix", "MemoryDB")
+
+// ErrQueryNotSupported is returned by Query as there is no SQL engine behind
+// the in-memory database.
+var ErrQueryNotSupported = errors.New("Raw SQL queries are not supported by the in-memory database")
+
+type transaction struct {
+	id          string
+	postdate    time.Time
+	description []byte
+	poster      core.User
+	splits      []*split
+}
+
+type split struct {
+	id          string
+	txnID       string
+	date        time.Time
+	description []byte
+	currency    string
+	amount      *big.Int
+	accounts    []string
+}
+
+type Database struct {
+	*tables
+	mu *sync.RWMutex
+
+	// inUnit is set on the copy of the database handed to a unit of work,
+	// which already holds the write lock for the duration
+	inUnit bool
+}
+
+type tables struct {
+	users map[string]*core.User // by username
+
+	accounts      map[string]*core.Account // by account code
+	accountNames  map[string]string        // account name to code
+	accountTags   map[string]map[int]bool  // account code to tag ids
+	accountSplits map[string][]string      // account code to the ids of splits posted to it
+
+	tags     map[string]int // tag name to id
+	tagNames map[int]string
+	nextTag  int
+
+	currencies map[string]*core.Currency
+
+	transactions    map[string]*transaction
+	txnOrder        []string // transaction ids in the order they were added
+	transactionTags map[string]map[int]bool
+	splits          map[string]*split
+
+	reconciliations map[string]map[string]bool // reconciliation id to split ids
+	trash           map[string]time.Time       // trashed transaction id to when it was deleted
+
+	allocationRules map[string]*core.AllocationRule
+	postingRules    map[string]*core.PostingRule
+
+	balances map[dberr.BalanceKey]dberr.BalanceTotal // monthly totals of the counted splits
+
+	entities map[string]*core.Entity
+
+	webhookDeliveries map[string]*core.WebhookDelivery
+}
+
+// NewDB initializes a new, empty, DB.
+func NewDB() *Database {
+	log.Debug("Creating DB")
+	return &Database{mu: new(sync.RWMutex), tables: &tables{
+		users:           make(map[string]*core.User),
+		accounts:        make(map[string]*core.Account),
+		accountNames:    make(map[string]string),
+		accountTags:     make(map[string]map[int]bool),
+		accountSplits:   make(map[string][]string),
+		tags:            make(map[string]int),
+		tagNames:        make(map[int]string),
+		currencies:      make(map[string]*core.Currency),
+		transactions:    make(map[string]*transaction),
+		transactionTags: make(map[string]map[int]bool),
+		splits:          make(map[string]*split),
+		reconciliations: make(map[string]map[string]bool),
+		trash:           make(map[string]time.Time),
+		allocationRules: make(map[string]*core.AllocationRule),
+		postingRules:    make(map[string]*core.PostingRule),
+		balances:        make(map[dberr.BalanceKey]dberr.BalanceTotal),
+		entities:        make(map[string]*core.Entity),
+
+		webhookDeliveries: make(map[string]*core.WebhookDelivery),
+	}}
+}
+
+// Close is a no-op, the ledger is dropped along with the Database.
+func (db *Database) Close() error {
+	return nil
+}
+
+// InitDB adds the default currencies.
+func (db *Database) InitDB(ctx context.Context) error {
+	log.Debug("Initialising DB")
+	defaults := []core.Currency{
+		{Name: "USD", Decimals: 2},
+		{Name: "AUD", Decimals: 2},
+		{Name: "GBP", Decimals: 2},
+		{Name: "BTC", Decimals: 8},
+		{Name: "ETH", Decimals: 18},
+		{Name: "OXEN", Decimals: 9},
+	}
+	for i := range defaults {
+		if err := db.SafeAddCurrency(ctx, &defaults[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrate does nothing as the in-memory database has no stored schema.
+func (db *Database) Migrate(ctx context.Context, dryRun bool) ([]migrate.Migration, error) {
+	return []migrate.Migration{}, nil
+}
+
+// MigrationStatus returns no migrations as the in-memory database has no
+// stored schema.
+func (db *Database) MigrationStatus(ctx context.Context) ([]migrate.Status, error) {
+	return []migrate.Status{}, nil
+}
@@ -0,0 +1,555 @@
+package memorydb
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func addTestTransaction(t *testing.T, db *Database, usr *core.User, date time.Time, debit, credit string, amount int64) *core.Transaction {
+	ctx := context.Background()
+	aud, err := db.FindCurrency(ctx, "AUD")
+	assert.NoError(t, err)
+
+	txn, _ := core.NewTransaction(usr)
+	txn.Description = []byte("Test transaction")
+	for _, line := range []struct {
+		account string
+		amount  int64
+	}{{debit, amount}, {credit, -amount}} {
+		acc, _ := core.NewAccount(line.account, line.account)
+		_, err := db.SafeAddAccount(ctx, acc)
+		assert.NoError(t, err)
+		assert.NoError(t, db.SafeAddTagToAccount(ctx, line.account, "main"))
+		spl, _ := core.NewSplit(date, []byte{}, []*core.Account{acc}, aud, big.NewInt(line.amount))
+		txn.AppendSplit(spl)
+	}
+
+	_, err = db.AddTransaction(ctx, txn)
+	assert.NoError(t, err)
+	return txn
+}
+
+func newTestDB(t *testing.T) (*Database, *core.User) {
+	ctx := context.Background()
+	db := NewDB()
+	assert.NoError(t, db.InitDB(ctx))
+	usr, _ := core.NewUser("Tester")
+	assert.NoError(t, db.SafeAddUser(ctx, usr))
+	return db, usr
+}
+
+func TestTrialBalance(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	addTestTransaction(t, db, usr, date, "Expenses:Groceries", "Assets:Checking", 1000)
+	voided := addTestTransaction(t, db, usr, date, "Expenses:Groceries", "Assets:Checking", 250)
+	trashed := addTestTransaction(t, db, usr, date, "Expenses:Rent", "Assets:Checking", 500)
+	addTestTransaction(t, db, usr, date.AddDate(0, 1, 0), "Expenses:Groceries", "Assets:Checking", 75)
+
+	assert.NoError(t, db.SafeAddTagToTransaction(ctx, voided.Id, "Void"))
+	assert.NoError(t, db.DeleteTransaction(ctx, trashed.Id))
+
+	tb, err := db.GetTB(ctx, date)
+	assert.NoError(t, err)
+	assert.Equal(t, []core.TBAccount{
+		{Account: "Assets:Checking", Amount: big.NewInt(-1000), Tags: []string{"main"}, Currency: "AUD", Decimals: 2},
+		{Account: "Expenses:Groceries", Amount: big.NewInt(1000), Tags: []string{"main"}, Currency: "AUD", Decimals: 2},
+	}, *tb)
+
+	assert.NoError(t, db.RestoreTransaction(ctx, trashed.Id))
+	tb, err = db.GetTB(ctx, date.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	assert.Len(t, *tb, 3)
+	assert.Equal(t, "-1575", (*tb)[0].Amount.String())
+}
+
+func TestListingAndTrash(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	first := addTestTransaction(t, db, usr, date, "Expenses:Groceries", "Assets:Checking", 1000)
+	second := addTestTransaction(t, db, usr, date.AddDate(0, 0, 1), "Expenses:Groceries", "Assets:Checking", 250)
+	addTestTransaction(t, db, usr, date.AddDate(0, 1, 0), "Expenses:Groceries", "Assets:Checking", 75)
+
+	listing, err := db.GetListing(ctx, core.ListingFilter{StartDate: date, EndDate: date.AddDate(0, 0, 1)})
+	assert.NoError(t, err)
+	if assert.Len(t, *listing, 2) {
+		assert.Equal(t, first.Id, (*listing)[0].Id)
+		assert.Equal(t, second.Id, (*listing)[1].Id)
+		assert.Len(t, (*listing)[0].Splits, 2)
+	}
+
+	assert.NoError(t, db.DeleteTransaction(ctx, first.Id))
+	assert.True(t, errors.Is(db.DeleteTransaction(ctx, first.Id), dberr.ErrNotFound))
+	listing, err = db.GetListing(ctx, core.ListingFilter{StartDate: date, EndDate: date.AddDate(0, 0, 1)})
+	assert.NoError(t, err)
+	assert.Len(t, *listing, 1)
+
+	trash, err := db.ListTrash(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, first.Id, trash[0].Id)
+	}
+	trashed, err := db.IsTrashed(ctx, first.Id)
+	assert.NoError(t, err)
+	assert.True(t, trashed)
+	trashed, err = db.IsTrashed(ctx, second.Id)
+	assert.NoError(t, err)
+	assert.False(t, trashed)
+
+	purged, err := db.PurgeTrash(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	_, err = db.FindTransaction(ctx, first.Id)
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+	assert.True(t, errors.Is(db.RestoreTransaction(ctx, first.Id), dberr.ErrNotFound))
+}
+
+func TestTagsAccountsAndReconciliation(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+	txn := addTestTransaction(t, db, usr, date, "Expenses:Groceries", "Assets:Checking", 1000)
+
+	assert.NoError(t, db.SafeAddTagToAccount(ctx, "Assets:Checking", "bank"))
+	tags, err := db.FindAccountTags(ctx, "Assets:Checking")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bank", "main"}, tags)
+	assert.NoError(t, db.DeleteTagFromAccount(ctx, "Assets:Checking", "bank"))
+	tags, _ = db.FindAccountTags(ctx, "Assets:Checking")
+	assert.Equal(t, []string{"main"}, tags)
+	assert.True(t, errors.Is(db.AddTag(ctx, "bank"), dberr.ErrAlreadyExists))
+	assert.True(t, errors.Is(db.SafeAddTagToAccount(ctx, "Assets:Missing", "bank"), dberr.ErrNotFound))
+
+	// Accounts with postings cannot be removed
+	assert.True(t, errors.Is(db.DeleteAccount(ctx, "Assets:Checking"), dberr.ErrConstraintViolation))
+
+	_, err = db.ReconcileTransactions(ctx, "rec1", []string{txn.Splits[0].Id})
+	assert.NoError(t, err)
+	_, err = db.ReconcileTransactions(ctx, "rec1", []string{txn.Splits[0].Id})
+	assert.Error(t, err)
+	_, err = db.ReconcileTransactions(ctx, "rec2", []string{"missing"})
+	assert.Error(t, err)
+
+	_, err = db.Query(ctx, "SELECT * FROM splits")
+	assert.Equal(t, ErrQueryNotSupported, err)
+}
+
+func TestUnbalancedTransaction(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	aud, _ := db.FindCurrency(ctx, "AUD")
+
+	txn, _ := core.NewTransaction(usr)
+	acc, _ := core.NewAccount("Assets:Checking", "Assets:Checking")
+	assert.NoError(t, db.AddAccount(ctx, acc))
+	spl, _ := core.NewSplit(time.Now(), []byte{}, []*core.Account{acc}, aud, big.NewInt(1000))
+	txn.AppendSplit(spl)
+
+	_, err := db.AddTransaction(ctx, txn)
+	assert.True(t, errors.Is(err, dberr.ErrUnbalanced))
+	_, err = db.FindTransaction(ctx, txn.Id)
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+}
+
+func TestUnitOfWorkRollback(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	aud, _ := db.FindCurrency(ctx, "AUD")
+
+	txn, _ := core.NewTransaction(usr)
+	acc, _ := core.NewAccount("Assets:Orphan", "Assets:Orphan")
+	spl, _ := core.NewSplit(time.Now(), []byte{}, []*core.Account{acc}, aud, big.NewInt(1000))
+	txn.AppendSplit(spl)
+
+	err := db.UnitOfWork(ctx, func(tx dberr.Database) error {
+		if _, err := tx.SafeAddAccount(ctx, acc); err != nil {
+			return err
+		}
+		if err := tx.SafeAddTagToAccount(ctx, "Assets:Orphan", "main"); err != nil {
+			return err
+		}
+		_, err := tx.AddTransaction(ctx, txn)
+		return err
+	})
+	assert.True(t, errors.Is(err, dberr.ErrUnbalanced))
+
+	_, err = db.FindAccount(ctx, "Assets:Orphan")
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+	_, err = db.FindTag(ctx, "main")
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+
+	err = db.UnitOfWork(ctx, func(tx dberr.Database) error {
+		_, err := tx.SafeAddAccount(ctx, acc)
+		return err
+	})
+	assert.NoError(t, err)
+	_, err = db.FindAccount(ctx, "Assets:Orphan")
+	assert.NoError(t, err)
+}
+
+func TestAddTransactions(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	aud, _ := db.FindCurrency(ctx, "AUD")
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	checking, _ := core.NewAccount("Assets:Checking", "Assets:Checking")
+	groceries, _ := core.NewAccount("Expenses:Groceries", "Expenses:Groceries")
+	assert.NoError(t, db.AddAccount(ctx, checking))
+	assert.NoError(t, db.AddAccount(ctx, groceries))
+
+	journal := func(amount int64) *core.Transaction {
+		txn, _ := core.NewTransaction(usr)
+		debit, _ := core.NewSplit(date, []byte{}, []*core.Account{groceries}, aud, big.NewInt(amount))
+		credit, _ := core.NewSplit(date, []byte{}, []*core.Account{checking}, aud, big.NewInt(-amount))
+		txn.AppendSplit(debit)
+		txn.AppendSplit(credit)
+		return txn
+	}
+
+	first, second := journal(100), journal(200)
+	ids, err := db.AddTransactions(ctx, []*core.Transaction{first, second})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.Id, second.Id}, ids)
+
+	// A duplicate rolls back the journals before it
+	third := journal(300)
+	_, err = db.AddTransactions(ctx, []*core.Transaction{third, first})
+	assert.True(t, errors.Is(err, dberr.ErrAlreadyExists))
+	_, err = db.FindTransaction(ctx, third.Id)
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+}
+
+func TestUnitOfWorkNested(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newTestDB(t)
+
+	kept, _ := core.NewAccount("Assets:Kept", "Assets:Kept")
+	dropped, _ := core.NewAccount("Assets:Dropped", "Assets:Dropped")
+	err := db.UnitOfWork(ctx, func(tx dberr.Database) error {
+		if err := tx.AddAccount(ctx, kept); err != nil {
+			return err
+		}
+		err := tx.UnitOfWork(ctx, func(item dberr.Database) error {
+			if err := item.AddAccount(ctx, dropped); err != nil {
+				return err
+			}
+			return item.AddAccount(ctx, kept)
+		})
+		assert.True(t, errors.Is(err, dberr.ErrAlreadyExists))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	_, err = db.FindAccount(ctx, "Assets:Kept")
+	assert.NoError(t, err)
+	_, err = db.FindAccount(ctx, "Assets:Dropped")
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+}
+
+func TestListingFilters(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	groceries := addTestTransaction(t, db, usr, date, "Expenses:Groceries", "Assets:Checking", 1000)
+	rent := addTestTransaction(t, db, usr, date, "Expenses:Rent", "Assets:Checking", 250)
+	fuel := addTestTransaction(t, db, usr, date, "Expenses:Fuel", "Assets:Savings", 75)
+	assert.NoError(t, db.SafeAddTagToTransaction(ctx, rent.Id, "Home"))
+
+	filter := core.ListingFilter{StartDate: date, EndDate: date, Accounts: []string{"Assets:Checking"}}
+	listing, err := db.GetListing(ctx, filter)
+	assert.NoError(t, err)
+	assert.Len(t, *listing, 2)
+
+	filter = core.ListingFilter{StartDate: date, EndDate: date, Tags: []string{"Home"}}
+	listing, err = db.GetListing(ctx, filter)
+	assert.NoError(t, err)
+	if assert.Len(t, *listing, 1) {
+		assert.Equal(t, rent.Id, (*listing)[0].Id)
+	}
+
+	// Paging through one transaction at a time returns each once
+	seen := []string{}
+	filter = core.ListingFilter{StartDate: date, EndDate: date, Limit: 1}
+	for {
+		listing, err = db.GetListing(ctx, filter)
+		assert.NoError(t, err)
+		if len(*listing) == 0 {
+			break
+		}
+		assert.Len(t, *listing, 1)
+		filter.After = (*listing)[0].Id
+		seen = append(seen, filter.After)
+	}
+	assert.ElementsMatch(t, []string{groceries.Id, rent.Id, fuel.Id}, seen)
+}
+
+func TestSearchTransactions(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	rent := addTestTransaction(t, db, usr, date, "Expenses:Rent", "Assets:Checking", 250)
+	groceries := addTestTransaction(t, db, usr, date.AddDate(0, 1, 0), "Expenses:Groceries", "Assets:Checking", 1000)
+	db.transactions[rent.Id].description = []byte("Test transaction for the office rent")
+	db.transactions[groceries.Id].splits[0].description = []byte("Weekly groceries at the market")
+
+	search := func(filter core.SearchFilter) []string {
+		listing, err := db.SearchTransactions(ctx, filter)
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, txn := range *listing {
+			ids = append(ids, txn.Id)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{rent.Id}, search(core.SearchFilter{Query: "RENT off"}))
+	assert.Equal(t, []string{groceries.Id}, search(core.SearchFilter{Query: "grocer"}))
+	assert.Empty(t, search(core.SearchFilter{Query: "rent groceries"}))
+	assert.ElementsMatch(t, []string{rent.Id, groceries.Id}, search(core.SearchFilter{Query: "transaction"}))
+
+	assert.Equal(t, []string{groceries.Id}, search(core.SearchFilter{Query: "transaction", StartDate: date.AddDate(0, 0, 1)}))
+	assert.Equal(t, []string{rent.Id}, search(core.SearchFilter{Query: "transaction", EndDate: date}))
+	assert.Equal(t, []string{groceries.Id}, search(core.SearchFilter{Query: "transaction", MinAmount: big.NewInt(500)}))
+	assert.Equal(t, []string{rent.Id}, search(core.SearchFilter{Query: "transaction", MaxAmount: big.NewInt(500)}))
+
+	listing, err := db.SearchTransactions(ctx, core.SearchFilter{Query: "market"})
+	assert.NoError(t, err)
+	if assert.Len(t, *listing, 1) {
+		assert.Len(t, (*listing)[0].Splits, 2)
+	}
+
+	assert.NoError(t, db.DeleteTransaction(ctx, rent.Id))
+	assert.Empty(t, search(core.SearchFilter{Query: "rent"}))
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	healthy := addTestTransaction(t, db, usr, date, "Expenses:Rent", "Assets:Checking", 250)
+	broken := addTestTransaction(t, db, usr, date, "Expenses:Groceries", "Assets:Checking", 100)
+	violations, err := db.CheckIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+
+	// Currencies in use cannot be deleted
+	assert.True(t, errors.Is(db.DeleteCurrency(ctx, "AUD"), dberr.ErrConstraintViolation))
+	assert.NoError(t, db.DeleteCurrency(ctx, "GBP"))
+
+	zero := &split{id: "zero", txnID: broken.Id, date: date, currency: "AUD", amount: big.NewInt(0)}
+	stray := &split{id: "stray", txnID: broken.Id, date: date, currency: "AUD", amount: big.NewInt(50)}
+	db.transactions[broken.Id].splits = append(db.transactions[broken.Id].splits, zero, stray)
+	db.splits[zero.id], db.splits[stray.id] = zero, stray
+	db.transactions["empty"] = &transaction{id: "empty", postdate: date, poster: *usr}
+	db.txnOrder = append(db.txnOrder, "empty")
+	db.transactions[healthy.Id].splits[0].currency = "NZD"
+	db.transactions[healthy.Id].splits[1].currency = "NZD"
+	delete(db.currencies, "NZD")
+
+	violations, err = db.CheckIntegrity(ctx)
+	assert.NoError(t, err)
+	kinds := []string{}
+	for _, violation := range violations {
+		kinds = append(kinds, violation.Kind+" "+violation.Transaction+" "+violation.Split)
+	}
+	assert.ElementsMatch(t, []string{
+		core.OrphanSplitViolation + " " + broken.Id + " stray",
+		core.OrphanSplitViolation + " " + broken.Id + " zero",
+		core.UnbalancedTransactionViolation + " " + broken.Id + " ",
+		core.MissingCurrencyViolation + " " + healthy.Id + " " + healthy.Splits[0].Id,
+		core.MissingCurrencyViolation + " " + healthy.Id + " " + healthy.Splits[1].Id,
+		core.EmptyTransactionViolation + " empty ",
+	}, kinds)
+
+	assert.NoError(t, db.RepairIntegrity(ctx, violations))
+	violations, err = db.CheckIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, violations, 4)
+	for _, violation := range violations {
+		assert.False(t, violation.Repairable)
+	}
+	_, ok := db.transactions["empty"]
+	assert.False(t, ok)
+	assert.Len(t, db.transactions[broken.Id].splits, 3)
+}
+
+func TestGetAccountSplits(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	date := time.Date(2011, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	addTestTransaction(t, db, usr, date.AddDate(0, 0, -1), "Assets:Checking", "Equity:Capital", 10000)
+	second := addTestTransaction(t, db, usr, date.AddDate(0, 0, 5), "Expenses:Rent", "Assets:Checking", 700)
+	first := addTestTransaction(t, db, usr, date, "Expenses:Rent", "Assets:Checking", 2500)
+	voided := addTestTransaction(t, db, usr, date, "Expenses:Rent", "Assets:Checking", 250)
+	trashed := addTestTransaction(t, db, usr, date, "Expenses:Rent", "Assets:Checking", 500)
+	addTestTransaction(t, db, usr, date.AddDate(0, 1, 0), "Expenses:Rent", "Assets:Checking", 100)
+	assert.NoError(t, db.SafeAddTagToTransaction(ctx, voided.Id, "Void"))
+	assert.NoError(t, db.DeleteTransaction(ctx, trashed.Id))
+
+	splits, err := db.GetAccountSplits(ctx, "Assets:Checking", date, date.AddDate(0, 0, 30))
+	assert.NoError(t, err)
+	lines := []string{}
+	for _, split := range splits {
+		lines = append(lines, split.Transaction+" "+split.Date.Format("2006-01-02")+" "+split.Amount.String())
+	}
+	assert.Equal(t, []string{first.Id + " 2011-03-01 -2500", second.Id + " 2011-03-06 -700"}, lines)
+
+	splits, err = db.GetAccountSplits(ctx, "Assets:Checking", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, splits, 4)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb, _ := newTestDB(t)
+
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"first", "second", "later"} {
+		assert.NoError(t, ledgerdb.AddWebhookDelivery(ctx, &core.WebhookDelivery{
+			ID:          id,
+			Webhook:     "books",
+			Kind:        "transaction_added",
+			Payload:     []byte(`{"transaction":"` + id + `"}`),
+			NextAttempt: now.Add(time.Duration(i-1) * time.Minute),
+			Created:     now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	due, err := ledgerdb.DueWebhookDeliveries(ctx, now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 2) {
+		assert.Equal(t, "first", due[0].ID)
+		assert.Equal(t, "second", due[1].ID)
+		assert.Equal(t, `{"transaction":"first"}`, string(due[0].Payload))
+		assert.Equal(t, "transaction_added", due[0].Kind)
+	}
+	due, err = ledgerdb.DueWebhookDeliveries(ctx, now, 1)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	// Running out of attempts moves the delivery to the dead letters
+	dead := due[0]
+	dead.Attempts = 3
+	dead.LastError = "503 Service Unavailable"
+	dead.Dead = true
+	assert.NoError(t, ledgerdb.UpdateWebhookDelivery(ctx, dead))
+	letters, err := ledgerdb.ListDeadWebhookDeliveries(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, letters, 1) {
+		assert.Equal(t, "first", letters[0].ID)
+		assert.Equal(t, 3, letters[0].Attempts)
+		assert.Equal(t, "503 Service Unavailable", letters[0].LastError)
+	}
+	due, err = ledgerdb.DueWebhookDeliveries(ctx, now.Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+
+	assert.NoError(t, ledgerdb.DeleteWebhookDelivery(ctx, "second"))
+	_, err = ledgerdb.FindWebhookDelivery(ctx, "second")
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+	assert.True(t, errors.Is(ledgerdb.DeleteWebhookDelivery(ctx, "second"), dberr.ErrNotFound))
+	assert.True(t, errors.Is(ledgerdb.UpdateWebhookDelivery(ctx, &core.WebhookDelivery{ID: "second"}), dberr.ErrNotFound))
+
+	found, err := ledgerdb.FindWebhookDelivery(ctx, "first")
+	assert.NoError(t, err)
+	assert.True(t, found.Dead)
+	assert.True(t, found.Created.Equal(now), found.Created.String())
+}
+
+func TestAccountBalances(t *testing.T) {
+	ctx := context.Background()
+	db, usr := newTestDB(t)
+	date := time.Date(2011, 1, 20, 0, 0, 0, 0, time.UTC)
+
+	addTestTransaction(t, db, usr, date, "Expenses:Groceries", "Assets:Checking", 1000)
+	voided := addTestTransaction(t, db, usr, date.AddDate(0, 1, 0), "Expenses:Groceries", "Assets:Checking", 250)
+	trashed := addTestTransaction(t, db, usr, date.AddDate(0, 1, 0), "Expenses:Rent", "Assets:Checking", 500)
+	addTestTransaction(t, db, usr, date.AddDate(0, 1, 5), "Expenses:Groceries", "Assets:Checking", 75)
+
+	assert.NoError(t, db.SafeAddTagToTransaction(ctx, voided.Id, "Void"))
+	assert.NoError(t, db.DeleteTransaction(ctx, trashed.Id))
+	assert.NoError(t, db.RestoreTransaction(ctx, trashed.Id))
+	assert.NoError(t, db.DeleteTransaction(ctx, trashed.Id))
+
+	discrepancies, err := db.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+
+	// The summary of January and the splits of February up to the date
+	tb, err := db.GetTB(ctx, date.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []core.TBAccount{
+		{Account: "Assets:Checking", Amount: big.NewInt(-1000), Tags: []string{"main"}, Currency: "AUD", Decimals: 2},
+		{Account: "Expenses:Groceries", Amount: big.NewInt(1000), Tags: []string{"main"}, Currency: "AUD", Decimals: 2},
+	}, *tb)
+
+	assert.NoError(t, db.DeleteTagFromTransaction(ctx, voided.Id, "Void"))
+	tb, err = db.GetTB(ctx, date.AddDate(0, 2, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "-1325", (*tb)[0].Amount.String())
+	balance, err := db.GetAccountBalance(ctx, "Assets:Checking", date.AddDate(0, 2, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []core.TBAccount{(*tb)[0]}, balance)
+
+	key := dberr.BalanceKey{Account: "Assets:Checking", Currency: "AUD", Period: time.Date(2011, 1, 1, 0, 0, 0, 0, time.UTC)}
+	db.balances[key] = dberr.BalanceTotal{Amount: big.NewInt(-1), Splits: 1}
+	discrepancies, err = db.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []core.BalanceDiscrepancy{
+		{Account: "Assets:Checking", Currency: "AUD", Period: key.Period, Stored: big.NewInt(-1), Actual: big.NewInt(-1000)},
+	}, discrepancies)
+
+	assert.NoError(t, db.RebuildBalances(ctx))
+	discrepancies, err = db.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
+
+func TestEntities(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newTestDB(t)
+
+	for _, id := range []string{"globex", "acme"} {
+		entity, err := core.NewEntity(id, "", "")
+		assert.NoError(t, err)
+		assert.NoError(t, db.AddEntity(ctx, entity))
+	}
+	duplicate, _ := core.NewEntity("acme", "Acme", "")
+	assert.True(t, errors.Is(db.AddEntity(ctx, duplicate), dberr.ErrAlreadyExists))
+
+	entity, err := db.FindEntity(ctx, "acme")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", entity.Name)
+	_, err = db.FindEntity(ctx, "initech")
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+
+	entities, err := db.ListEntities(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, entities, 2) {
+		assert.Equal(t, "acme", entities[0].Id)
+		assert.Equal(t, "globex", entities[1].Id)
+	}
+
+	// The ledger of an entity shares nothing with the node's own
+	other, err := db.OpenEntity(ctx, "acme")
+	assert.NoError(t, err)
+	assert.NoError(t, other.InitDB(ctx))
+	_, err = other.FindEntity(ctx, "acme")
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+}
@@ -0,0 +1,865 @@
+package memorydb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func (db *Database) AddTransaction(ctx context.Context, txn *core.Transaction) (string, error) {
+	log.Debug("Adding Transaction to DB")
+	if _, balanced := txn.Balance(); !balanced {
+		return "", dberr.ErrUnbalanced
+	}
+	db.lock()
+	defer db.unlock()
+
+	poster, ok := db.users[txn.Poster.Name]
+	if !ok {
+		return "", dberr.ErrNotFound
+	}
+	if _, exists := db.transactions[txn.Id]; exists {
+		return "", fmt.Errorf("%w: transaction %s", dberr.ErrAlreadyExists, txn.Id)
+	}
+
+	record := &transaction{
+		id:          txn.Id,
+		postdate:    txn.Postdate,
+		description: append([]byte{}, txn.Description...),
+		poster:      *poster,
+	}
+	for _, spl := range txn.Splits {
+		if _, exists := db.splits[spl.Id]; exists {
+			return "", fmt.Errorf("%w: split %s", dberr.ErrAlreadyExists, spl.Id)
+		}
+		s := &split{
+			id:          spl.Id,
+			txnID:       txn.Id,
+			date:        spl.Date,
+			description: append([]byte{}, spl.Description...),
+			currency:    spl.Currency.Name,
+			amount:      new(big.Int).Set(spl.Amount),
+		}
+		for _, acc := range spl.Accounts {
+			code := strings.TrimSpace(acc.Code)
+			if _, ok := db.accounts[code]; !ok {
+				return "", fmt.Errorf("%w: account %s", dberr.ErrNotFound, code)
+			}
+			s.accounts = append(s.accounts, code)
+		}
+		record.splits = append(record.splits, s)
+	}
+
+	// Only index the transaction once every split has been checked so a
+	// failed insert leaves nothing behind
+	db.transactions[txn.Id] = record
+	db.txnOrder = append(db.txnOrder, txn.Id)
+	for _, s := range record.splits {
+		db.splits[s.id] = s
+		for _, code := range s.accounts {
+			db.accountSplits[code] = append(db.accountSplits[code], s.id)
+		}
+	}
+	addBalances(db.balances, record, 1)
+
+	return txn.Id, nil
+}
+
+// AddTransactions inserts the transactions in a single database transaction
+// and returns their ids. If any of them fails none are saved.
+func (db *Database) AddTransactions(ctx context.Context, txns []*core.Transaction) ([]string, error) {
+	log.Debugf("Adding %d Transactions to DB", len(txns))
+	ids := make([]string, 0, len(txns))
+	err := db.UnitOfWork(ctx, func(tx dberr.Database) error {
+		for _, txn := range txns {
+			id, err := tx.AddTransaction(ctx, txn)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// toTransaction copies a stored transaction, and those of its splits
+// accepted by include, into a core.Transaction.
+func (db *Database) toTransaction(record *transaction, include func(*split) bool) core.Transaction {
+	poster := record.poster
+	txn := core.Transaction{
+		Id:          record.id,
+		Postdate:    record.postdate,
+		Poster:      &poster,
+		Description: append([]byte{}, record.description...),
+		Splits:      []*core.Split{},
+		Tags:        db.transactionTagNames(record.id),
+	}
+	for _, s := range record.splits {
+		if !include(s) {
+			continue
+		}
+		cur, ok := db.currencies[s.currency]
+		if !ok {
+			continue
+		}
+		spl := &core.Split{
+			Id:          s.id,
+			Date:        s.date,
+			Description: append([]byte{}, s.description...),
+			Currency:    &core.Currency{Name: cur.Name, Decimals: cur.Decimals},
+			Amount:      new(big.Int).Set(s.amount),
+		}
+		for _, code := range s.accounts {
+			acc := db.accounts[code]
+			spl.Accounts = append(spl.Accounts, &core.Account{Code: acc.Code, Name: acc.Name})
+		}
+		txn.Splits = append(txn.Splits, spl)
+	}
+	return txn
+}
+
+func (db *Database) transactionTagNames(txnID string) []string {
+	names := []string{}
+	for id := range db.transactionTags[txnID] {
+		names = append(names, db.tagNames[id])
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (db *Database) FindTransaction(ctx context.Context, txnID string) (*core.Transaction, error) {
+	log.Debug("Searching Transaction in DB: ", txnID)
+	db.rlock()
+	defer db.runlock()
+
+	record, ok := db.transactions[txnID]
+	if !ok {
+		return nil, dberr.ErrNotFound
+	}
+	txn := db.toTransaction(record, func(*split) bool { return true })
+
+	return &txn, nil
+}
+
+// DeleteTransaction moves a transaction into the trash, where it is excluded
+// from reports until it is restored or purged.
+func (db *Database) DeleteTransaction(ctx context.Context, txnID string) error {
+	return db.TrashTransaction(ctx, txnID, time.Now().UTC())
+}
+
+// TrashTransaction moves a transaction into the trash recording that it was
+// trashed at the given time.
+func (db *Database) TrashTransaction(ctx context.Context, txnID string, trashedAt time.Time) error {
+	db.lock()
+	defer db.unlock()
+
+	if _, ok := db.transactions[txnID]; !ok {
+		return dberr.ErrNotFound
+	}
+	if _, trashed := db.trash[txnID]; trashed {
+		return dberr.ErrNotFound
+	}
+	before := db.counted(txnID)
+	db.trash[txnID] = trashedAt.UTC()
+	db.updateBalances(txnID, before)
+
+	return nil
+}
+
+// ListTrash returns every transaction currently in the trash.
+func (db *Database) ListTrash(ctx context.Context) ([]core.TrashedTransaction, error) {
+	log.Debug("Searching Trashed Transactions in DB")
+	db.rlock()
+	defer db.runlock()
+
+	trash := []core.TrashedTransaction{}
+	for txnID, trashedAt := range db.trash {
+		txn := db.toTransaction(db.transactions[txnID], func(*split) bool { return false })
+		txn.Splits = nil
+		trash = append(trash, core.TrashedTransaction{Transaction: txn, TrashedAt: trashedAt})
+	}
+	sort.Slice(trash, func(i, j int) bool { return trash[i].TrashedAt.Before(trash[j].TrashedAt) })
+
+	return trash, nil
+}
+
+// IsTrashed reports whether a transaction is in the trash.
+func (db *Database) IsTrashed(ctx context.Context, txnID string) (bool, error) {
+	db.rlock()
+	defer db.runlock()
+
+	_, trashed := db.trash[txnID]
+	return trashed, nil
+}
+
+// RestoreTransaction takes a transaction back out of the trash.
+func (db *Database) RestoreTransaction(ctx context.Context, txnID string) error {
+	db.lock()
+	defer db.unlock()
+
+	if _, trashed := db.trash[txnID]; !trashed {
+		return dberr.ErrNotFound
+	}
+	delete(db.trash, txnID)
+	db.updateBalances(txnID, false)
+
+	return nil
+}
+
+// PurgeTrash permanently deletes the transactions that were moved into the
+// trash before the given time and returns how many were removed.
+func (db *Database) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	db.lock()
+	defer db.unlock()
+
+	purged := 0
+	for txnID, trashedAt := range db.trash {
+		if !trashedAt.Before(before.UTC()) {
+			continue
+		}
+		log.Debugf("Purging Transaction: %s", txnID)
+		db.removeTransaction(txnID)
+		purged++
+	}
+
+	return purged, nil
+}
+
+// removeTransaction deletes a transaction and everything that refers to it.
+func (db *Database) removeTransaction(txnID string) {
+	record := db.transactions[txnID]
+	for _, s := range record.splits {
+		for _, code := range s.accounts {
+			db.accountSplits[code] = removeString(db.accountSplits[code], s.id)
+		}
+		for _, splitIDs := range db.reconciliations {
+			delete(splitIDs, s.id)
+		}
+		delete(db.splits, s.id)
+	}
+	delete(db.transactionTags, txnID)
+	delete(db.trash, txnID)
+	delete(db.transactions, txnID)
+	db.txnOrder = removeString(db.txnOrder, txnID)
+}
+
+func removeString(list []string, s string) []string {
+	for i, elem := range list {
+		if elem == s {
+			return append(list[:i:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+func (db *Database) FindTag(ctx context.Context, tag string) (int, error) {
+	log.Debug("Searching Tag in DB")
+	db.rlock()
+	defer db.runlock()
+
+	id, ok := db.tags[tag]
+	if !ok {
+		log.Debug("Find Tag Failed: ", dberr.ErrNotFound)
+		return 0, dberr.ErrNotFound
+	}
+	return id, nil
+}
+
+func (db *Database) AddTag(ctx context.Context, tag string) error {
+	log.Debug("Adding Tag to DB")
+	db.lock()
+	defer db.unlock()
+
+	return db.addTag(tag)
+}
+
+func (db *Database) addTag(tag string) error {
+	if _, exists := db.tags[tag]; exists {
+		return fmt.Errorf("%w: tag %s", dberr.ErrAlreadyExists, tag)
+	}
+	db.nextTag++
+	db.tags[tag] = db.nextTag
+	db.tagNames[db.nextTag] = tag
+	return nil
+}
+
+func (db *Database) SafeAddTag(ctx context.Context, tag string) error {
+	db.lock()
+	defer db.unlock()
+
+	return db.safeAddTag(tag)
+}
+
+func (db *Database) safeAddTag(tag string) error {
+	if _, exists := db.tags[strings.TrimSpace(tag)]; exists {
+		return nil
+	}
+	return db.addTag(strings.TrimSpace(tag))
+}
+
+func (db *Database) SafeAddTagToAccount(ctx context.Context, account, tag string) error {
+	db.lock()
+	defer db.unlock()
+
+	if err := db.safeAddTag(tag); err != nil {
+		log.Debug(err)
+		return err
+	}
+	code, ok := db.accountNames[account]
+	if !ok {
+		log.Debug(dberr.ErrNotFound)
+		return dberr.ErrNotFound
+	}
+
+	return db.addTagToAccount(code, db.tags[strings.TrimSpace(tag)])
+}
+
+func (db *Database) AddTagToAccount(ctx context.Context, accountID string, tag int) error {
+	db.lock()
+	defer db.unlock()
+
+	return db.addTagToAccount(accountID, tag)
+}
+
+func (db *Database) addTagToAccount(accountID string, tag int) error {
+	if _, ok := db.accounts[accountID]; !ok {
+		return fmt.Errorf("%w: account %s", dberr.ErrNotFound, accountID)
+	}
+	if _, ok := db.tagNames[tag]; !ok {
+		return fmt.Errorf("%w: tag %d", dberr.ErrNotFound, tag)
+	}
+	if db.accountTags[accountID] == nil {
+		db.accountTags[accountID] = make(map[int]bool)
+	}
+	db.accountTags[accountID][tag] = true
+	return nil
+}
+
+func (db *Database) DeleteTagFromAccount(ctx context.Context, account, tag string) error {
+	db.lock()
+	defer db.unlock()
+
+	tagID, ok := db.tags[tag]
+	if !ok {
+		return dberr.ErrNotFound
+	}
+	delete(db.accountTags[account], tagID)
+
+	return nil
+}
+
+func (db *Database) SafeAddTagToTransaction(ctx context.Context, txnID, tag string) error {
+	db.lock()
+	defer db.unlock()
+
+	if err := db.safeAddTag(tag); err != nil {
+		log.Debug(err)
+		return err
+	}
+
+	return db.addTagToTransaction(txnID, db.tags[strings.TrimSpace(tag)])
+}
+
+func (db *Database) AddTagToTransaction(ctx context.Context, txnID string, tag int) error {
+	db.lock()
+	defer db.unlock()
+
+	return db.addTagToTransaction(txnID, tag)
+}
+
+func (db *Database) addTagToTransaction(txnID string, tag int) error {
+	if _, ok := db.transactions[txnID]; !ok {
+		return fmt.Errorf("%w: transaction %s", dberr.ErrNotFound, txnID)
+	}
+	if _, ok := db.tagNames[tag]; !ok {
+		return fmt.Errorf("%w: tag %d", dberr.ErrNotFound, tag)
+	}
+	if db.transactionTags[txnID] == nil {
+		db.transactionTags[txnID] = make(map[int]bool)
+	}
+	before := db.counted(txnID)
+	db.transactionTags[txnID][tag] = true
+	db.updateBalances(txnID, before)
+	return nil
+}
+
+func (db *Database) DeleteTagFromTransaction(ctx context.Context, txnID, tag string) error {
+	db.lock()
+	defer db.unlock()
+
+	tagID, ok := db.tags[tag]
+	if !ok {
+		return dberr.ErrNotFound
+	}
+	before := db.counted(txnID)
+	delete(db.transactionTags[txnID], tagID)
+	db.updateBalances(txnID, before)
+
+	return nil
+}
+
+func (db *Database) FindCurrency(ctx context.Context, cur string) (*core.Currency, error) {
+	log.Debug("Searching Currency in DB: ", cur)
+	db.rlock()
+	defer db.runlock()
+
+	c, ok := db.currencies[strings.TrimSpace(cur)]
+	if !ok {
+		return nil, dberr.ErrNotFound
+	}
+	return &core.Currency{Name: c.Name, Decimals: c.Decimals}, nil
+}
+
+func (db *Database) AddCurrency(ctx context.Context, cur *core.Currency) error {
+	log.Debug("Adding Currency to DB")
+	db.lock()
+	defer db.unlock()
+
+	name := strings.TrimSpace(cur.Name)
+	if _, exists := db.currencies[name]; exists {
+		return fmt.Errorf("%w: currency %s", dberr.ErrAlreadyExists, name)
+	}
+	db.currencies[name] = &core.Currency{Name: name, Decimals: cur.Decimals}
+
+	return nil
+}
+
+func (db *Database) SafeAddCurrency(ctx context.Context, cur *core.Currency) error {
+	u, _ := db.FindCurrency(ctx, cur.Name)
+	if u != nil {
+		return nil
+	}
+	return db.AddCurrency(ctx, cur)
+}
+
+// DeleteCurrency removes a currency that no split is in.
+func (db *Database) DeleteCurrency(ctx context.Context, currency string) error {
+	db.lock()
+	defer db.unlock()
+
+	splits := 0
+	for _, s := range db.splits {
+		if s.currency == currency {
+			splits++
+		}
+	}
+	if splits > 0 {
+		return fmt.Errorf("%w: currency %s is used by %d splits", dberr.ErrConstraintViolation, currency, splits)
+	}
+	delete(db.currencies, currency)
+
+	return nil
+}
+
+func (db *Database) FindAccount(ctx context.Context, code string) (*core.Account, error) {
+	log.Debug("Searching Account in DB")
+	db.rlock()
+	defer db.runlock()
+
+	acc, ok := db.accounts[strings.TrimSpace(code)]
+	if !ok {
+		return nil, dberr.ErrNotFound
+	}
+	return &core.Account{Code: acc.Code, Name: acc.Name}, nil
+}
+
+func (db *Database) AddAccount(ctx context.Context, acc *core.Account) error {
+	log.Debug("Adding Account to DB")
+	db.lock()
+	defer db.unlock()
+
+	return db.addAccount(acc)
+}
+
+func (db *Database) addAccount(acc *core.Account) error {
+	code := strings.TrimSpace(acc.Code)
+	if _, exists := db.accounts[code]; exists {
+		return fmt.Errorf("%w: account %s", dberr.ErrAlreadyExists, code)
+	}
+	name := strings.TrimSpace(acc.Name)
+	db.accounts[code] = &core.Account{Code: code, Name: name}
+	if _, exists := db.accountNames[name]; !exists {
+		db.accountNames[name] = code
+	}
+	return nil
+}
+
+func (db *Database) SafeAddAccount(ctx context.Context, acc *core.Account) (bool, error) {
+	db.lock()
+	defer db.unlock()
+
+	if _, exists := db.accounts[strings.TrimSpace(acc.Code)]; exists {
+		return false, nil
+	}
+	return true, db.addAccount(acc)
+}
+
+func (db *Database) DeleteAccount(ctx context.Context, account string) error {
+	db.lock()
+	defer db.unlock()
+
+	code, ok := db.accountNames[account]
+	if !ok {
+		return nil
+	}
+	if len(db.accountSplits[code]) > 0 {
+		return fmt.Errorf("%w: account %s has transactions posted to it", dberr.ErrConstraintViolation, account)
+	}
+	delete(db.accounts, code)
+	delete(db.accountNames, account)
+	delete(db.accountTags, code)
+	delete(db.accountSplits, code)
+
+	return nil
+}
+
+func (db *Database) FindUser(ctx context.Context, pubKey string) (*core.User, error) {
+	log.Debug("Searching User in DB")
+	db.rlock()
+	defer db.runlock()
+
+	usr, ok := db.users[pubKey]
+	if !ok {
+		return nil, dberr.ErrNotFound
+	}
+	return &core.User{Id: usr.Id, Name: usr.Name}, nil
+}
+
+func (db *Database) AddUser(ctx context.Context, usr *core.User) error {
+	log.Debug("Adding User to DB")
+	db.lock()
+	defer db.unlock()
+
+	if _, exists := db.users[usr.Name]; exists {
+		return fmt.Errorf("%w: user %s", dberr.ErrAlreadyExists, usr.Name)
+	}
+	db.users[usr.Name] = &core.User{Id: usr.Id, Name: usr.Name}
+
+	return nil
+}
+
+func (db *Database) SafeAddUser(ctx context.Context, usr *core.User) error {
+	u, _ := db.FindUser(ctx, usr.Name)
+	if u != nil {
+		return nil
+	}
+	return db.AddUser(ctx, usr)
+}
+
+// isVoid reports whether the transaction carries the void tag, in any case.
+func (db *Database) isVoid(txnID string) bool {
+	for id := range db.transactionTags[txnID] {
+		if strings.EqualFold(db.tagNames[id], "void") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTB totals the balance summaries of the months before the date and adds
+// the splits of its own month up to the date.
+func (db *Database) GetTB(ctx context.Context, queryDate time.Time) (*[]core.TBAccount, error) {
+	log.Debug("Querying Database for Trial Balance")
+	db.rlock()
+	defer db.runlock()
+
+	accounts := db.trialBalance(queryDate, "")
+	return &accounts, nil
+}
+
+// GetAccountBalance is the trial balance of a single account, reading only
+// its own summaries and splits.
+func (db *Database) GetAccountBalance(ctx context.Context, account string, queryDate time.Time) ([]core.TBAccount, error) {
+	log.Debug("Querying Database for Account Balance")
+	db.rlock()
+	defer db.runlock()
+
+	return db.trialBalance(queryDate, account), nil
+}
+
+// trialBalance returns the lines of the trial balance at the date, only those
+// of the account unless it is blank.
+func (db *Database) trialBalance(queryDate time.Time, account string) []core.TBAccount {
+	type key struct {
+		account  string
+		currency string
+	}
+	period := balancePeriod(queryDate)
+	totals := make(map[key]*big.Int)
+	add := func(k key, amount *big.Int) {
+		if totals[k] == nil {
+			totals[k] = big.NewInt(0)
+		}
+		totals[k].Add(totals[k], amount)
+	}
+	for bk, total := range db.balances {
+		if _, ok := db.currencies[bk.Currency]; !ok || (account != "" && bk.Account != account) {
+			continue
+		}
+		k := key{bk.Account, bk.Currency}
+		if bk.Period.Before(period) {
+			add(k, total.Amount)
+			continue
+		}
+		if !bk.Period.Equal(period) {
+			continue
+		}
+		for _, splitID := range db.accountSplits[bk.Account] {
+			s := db.splits[splitID]
+			if s.currency != bk.Currency || !balancePeriod(s.date).Equal(period) || s.date.After(queryDate) || !db.counted(s.txnID) {
+				continue
+			}
+			add(k, s.amount)
+		}
+	}
+
+	accounts := []core.TBAccount{}
+	for k, total := range totals {
+		accounts = append(accounts, core.TBAccount{
+			Account:  k.account,
+			Amount:   total,
+			Tags:     db.accountTagNames(k.account),
+			Currency: k.currency,
+			Decimals: db.currencies[k.currency].Decimals,
+		})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Account != accounts[j].Account {
+			return accounts[i].Account < accounts[j].Account
+		}
+		return accounts[i].Currency < accounts[j].Currency
+	})
+
+	return accounts
+}
+
+// accountTagNames returns the tags on the account with the given name.
+func (db *Database) accountTagNames(account string) []string {
+	names := []string{}
+	code, ok := db.accountNames[account]
+	if !ok {
+		return names
+	}
+	for id := range db.accountTags[code] {
+		names = append(names, db.tagNames[id])
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Query is not supported by the in-memory database and always returns
+// ErrQueryNotSupported.
+func (db *Database) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, ErrQueryNotSupported
+}
+
+func (db *Database) ReconcileTransactions(ctx context.Context, reconciliationID string, splitIDs []string) (string, error) {
+	log.Debug("Adding Reconciliation to DB")
+	db.lock()
+	defer db.unlock()
+
+	existing := db.reconciliations[reconciliationID]
+	seen := make(map[string]bool)
+	for _, splitID := range splitIDs {
+		if _, ok := db.splits[splitID]; !ok {
+			return "", fmt.Errorf("%w: split %s", dberr.ErrNotFound, splitID)
+		}
+		if existing[splitID] || seen[splitID] {
+			return "", fmt.Errorf("%w: split %s is already in reconciliation %s", dberr.ErrAlreadyExists, splitID, reconciliationID)
+		}
+		seen[splitID] = true
+	}
+
+	if existing == nil {
+		existing = make(map[string]bool)
+		db.reconciliations[reconciliationID] = existing
+	}
+	for splitID := range seen {
+		existing[splitID] = true
+	}
+
+	return reconciliationID, nil
+}
+
+func (db *Database) GetListing(ctx context.Context, filter core.ListingFilter) (*[]core.Transaction, error) {
+	startDate, endDate := filter.StartDate, filter.EndDate
+	log.Debugf("Searching Transactions in DB between %s & %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	db.rlock()
+	defer db.runlock()
+
+	// Listings compare whole days, as the SQL backends do with their date strings
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, time.UTC)
+	inRange := func(s *split) bool {
+		return !s.date.Before(start) && !s.date.After(end)
+	}
+	accounts := make(map[string]bool, len(filter.Accounts))
+	for _, account := range filter.Accounts {
+		accounts[strings.TrimSpace(account)] = true
+	}
+	tags := make(map[string]bool, len(filter.Tags))
+	for _, tag := range filter.Tags {
+		tags[tag] = true
+	}
+
+	// Listings are ordered by identifier like the SQL backends
+	ids := append([]string{}, db.txnOrder...)
+	sort.Strings(ids)
+
+	txns := []core.Transaction{}
+	for _, txnID := range ids {
+		if filter.Limit > 0 && len(txns) == filter.Limit {
+			break
+		}
+		if _, trashed := db.trash[txnID]; trashed || txnID <= filter.After {
+			continue
+		}
+		if len(tags) > 0 && !db.hasTag(txnID, tags) {
+			continue
+		}
+		t := db.toTransaction(db.transactions[txnID], inRange)
+		if len(t.Splits) == 0 || (len(accounts) > 0 && !postsTo(t, accounts)) {
+			continue
+		}
+		txns = append(txns, t)
+	}
+
+	return &txns, nil
+}
+
+func (db *Database) hasTag(txnID string, tags map[string]bool) bool {
+	for id := range db.transactionTags[txnID] {
+		if tags[db.tagNames[id]] {
+			return true
+		}
+	}
+	return false
+}
+
+func postsTo(txn core.Transaction, accounts map[string]bool) bool {
+	for _, spl := range txn.Splits {
+		for _, acc := range spl.Accounts {
+			if accounts[acc.Code] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (db *Database) AddAllocationRule(ctx context.Context, rule *core.AllocationRule) error {
+	log.Debug("Adding Allocation Rule to DB")
+	db.lock()
+	defer db.unlock()
+
+	name := strings.TrimSpace(rule.Name)
+	if _, exists := db.allocationRules[name]; exists {
+		return fmt.Errorf("%w: allocation rule %s", dberr.ErrAlreadyExists, name)
+	}
+	stored, err := core.NewAllocationRule(name)
+	if err != nil {
+		return err
+	}
+	for _, target := range rule.Targets {
+		code := strings.TrimSpace(target.Account.Code)
+		if err := stored.AppendTarget(&core.Account{Code: code, Name: code}, target.Weight); err != nil {
+			return err
+		}
+	}
+	db.allocationRules[name] = stored
+
+	return nil
+}
+
+func (db *Database) FindAllocationRule(ctx context.Context, name string) (*core.AllocationRule, error) {
+	log.Debugf("Searching Allocation Rule in DB: %s", name)
+	db.rlock()
+	defer db.runlock()
+
+	stored, ok := db.allocationRules[strings.TrimSpace(name)]
+	if !ok {
+		return nil, dberr.ErrNotFound
+	}
+	rule, err := core.NewAllocationRule(stored.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range stored.Targets {
+		acc := &core.Account{Code: target.Account.Code, Name: target.Account.Code}
+		if existing, ok := db.accounts[acc.Code]; ok {
+			acc.Name = existing.Name
+		}
+		if err := rule.AppendTarget(acc, target.Weight); err != nil {
+			return nil, err
+		}
+	}
+
+	return rule, nil
+}
+
+func (db *Database) DeleteAllocationRule(ctx context.Context, name string) error {
+	db.lock()
+	defer db.unlock()
+
+	delete(db.allocationRules, strings.TrimSpace(name))
+
+	return nil
+}
+
+func (db *Database) AddPostingRule(ctx context.Context, rule *core.PostingRule) error {
+	log.Debug("Adding Posting Rule to DB")
+	db.lock()
+	defer db.unlock()
+
+	stored := *rule
+	stored.Name = strings.TrimSpace(rule.Name)
+	if _, exists := db.postingRules[stored.Name]; exists {
+		return fmt.Errorf("%w: posting rule %s", dberr.ErrAlreadyExists, stored.Name)
+	}
+	db.postingRules[stored.Name] = &stored
+
+	return nil
+}
+
+func (db *Database) GetPostingRules(ctx context.Context) ([]*core.PostingRule, error) {
+	log.Debug("Querying Database for Posting Rules")
+	db.rlock()
+	defer db.runlock()
+
+	rules := []*core.PostingRule{}
+	for _, stored := range db.postingRules {
+		rule := *stored
+		rules = append(rules, &rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+
+	return rules, nil
+}
+
+func (db *Database) DeletePostingRule(ctx context.Context, name string) error {
+	db.lock()
+	defer db.unlock()
+
+	delete(db.postingRules, strings.TrimSpace(name))
+
+	return nil
+}
+
+func (db *Database) FindAccountTags(ctx context.Context, account string) ([]string, error) {
+	log.Debugf("Querying Database for Tags on Account: %s", account)
+	db.rlock()
+	defer db.runlock()
+
+	return db.accountTagNames(strings.TrimSpace(account)), nil
+}
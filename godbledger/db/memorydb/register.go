@@ -0,0 +1,53 @@
+package memorydb
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+func (db *Database) GetAccountSplits(ctx context.Context, account string, start, end time.Time) ([]core.AccountSplit, error) {
+	log.Debugf("Querying Database for Splits of Account: %s", account)
+	db.rlock()
+	defer db.runlock()
+
+	splits := []core.AccountSplit{}
+	for _, splitID := range db.accountSplits[strings.TrimSpace(account)] {
+		s := db.splits[splitID]
+		if !db.counted(s.txnID) {
+			continue
+		}
+		if !start.IsZero() && s.date.Before(day(start)) {
+			continue
+		}
+		if !end.IsZero() && !s.date.Before(day(end).AddDate(0, 0, 1)) {
+			continue
+		}
+		cur, ok := db.currencies[s.currency]
+		if !ok {
+			continue
+		}
+		splits = append(splits, core.AccountSplit{
+			Transaction: s.txnID,
+			Split:       s.id,
+			Date:        s.date,
+			Description: string(s.description),
+			Currency:    s.currency,
+			Decimals:    cur.Decimals,
+			Amount:      s.amount,
+		})
+	}
+	sort.Slice(splits, func(i, j int) bool {
+		if !splits[i].Date.Equal(splits[j].Date) {
+			return splits[i].Date.Before(splits[j].Date)
+		}
+		if splits[i].Transaction != splits[j].Transaction {
+			return splits[i].Transaction < splits[j].Transaction
+		}
+		return splits[i].Split < splits[j].Split
+	})
+	return splits, nil
+}
@@ -0,0 +1,68 @@
+package memorydb
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+// SearchTransactions matches the terms of the query against the words of the
+// descriptions as they are held, there is no index to keep up to date.
+func (db *Database) SearchTransactions(ctx context.Context, filter core.SearchFilter) (*[]core.Transaction, error) {
+	log.Debugf("Searching Transactions in DB for %q", filter.Query)
+	db.rlock()
+	defer db.runlock()
+
+	terms := core.SearchTerms(filter.Query)
+	matches := func(s *split) bool {
+		if !filter.StartDate.IsZero() && s.date.Before(day(filter.StartDate)) {
+			return false
+		}
+		if !filter.EndDate.IsZero() && !s.date.Before(day(filter.EndDate).AddDate(0, 0, 1)) {
+			return false
+		}
+		amount := new(big.Int).Abs(s.amount)
+		if filter.MinAmount != nil && amount.Cmp(filter.MinAmount) < 0 {
+			return false
+		}
+		if filter.MaxAmount != nil && amount.Cmp(filter.MaxAmount) > 0 {
+			return false
+		}
+		return true
+	}
+
+	ids := append([]string{}, db.txnOrder...)
+	sort.Strings(ids)
+
+	txns := []core.Transaction{}
+	for _, txnID := range ids {
+		if filter.Limit > 0 && len(txns) == filter.Limit {
+			break
+		}
+		if _, trashed := db.trash[txnID]; trashed || txnID <= filter.After {
+			continue
+		}
+		record := db.transactions[txnID]
+		texts := []string{string(record.description)}
+		found := false
+		for _, s := range record.splits {
+			texts = append(texts, string(s.description))
+			found = found || matches(s)
+		}
+		if !found || !core.MatchesSearch(terms, texts...) {
+			continue
+		}
+		txns = append(txns, db.toTransaction(record, func(*split) bool { return true }))
+	}
+
+	return &txns, nil
+}
+
+// day is the start of the day of t, the way the SQL backends compare their
+// date strings.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
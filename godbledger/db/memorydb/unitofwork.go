@@ -0,0 +1,152 @@
+package memorydb
+
+import (
+	"context"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db"
+)
+
+func (db *Database) lock() {
+	if !db.inUnit {
+		db.mu.Lock()
+	}
+}
+
+func (db *Database) unlock() {
+	if !db.inUnit {
+		db.mu.Unlock()
+	}
+}
+
+func (db *Database) rlock() {
+	if !db.inUnit {
+		db.mu.RLock()
+	}
+}
+
+func (db *Database) runlock() {
+	if !db.inUnit {
+		db.mu.RUnlock()
+	}
+}
+
+// UnitOfWork runs fn while holding the write lock. The indexes are copied
+// beforehand and put back if fn returns an error, so a failed unit of work
+// leaves the ledger as it found it. Nested units of work take their own copy
+// and only undo their own changes.
+func (db *Database) UnitOfWork(ctx context.Context, fn func(tx db.Database) error) error {
+	if db.inUnit {
+		saved := db.tables.snapshot()
+		if err := fn(db); err != nil {
+			*db.tables = *saved
+			return err
+		}
+		return nil
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	saved := db.tables.snapshot()
+	if err := fn(&Database{tables: db.tables, mu: db.mu, inUnit: true}); err != nil {
+		*db.tables = *saved
+		return err
+	}
+	return nil
+}
+
+// snapshot copies the maps and slices of the ledger. Stored records are never
+// modified in place so they are shared with the copy.
+func (t *tables) snapshot() *tables {
+	s := &tables{
+		users:           make(map[string]*core.User, len(t.users)),
+		accounts:        make(map[string]*core.Account, len(t.accounts)),
+		accountNames:    make(map[string]string, len(t.accountNames)),
+		accountTags:     make(map[string]map[int]bool, len(t.accountTags)),
+		accountSplits:   make(map[string][]string, len(t.accountSplits)),
+		tags:            make(map[string]int, len(t.tags)),
+		tagNames:        make(map[int]string, len(t.tagNames)),
+		nextTag:         t.nextTag,
+		currencies:      make(map[string]*core.Currency, len(t.currencies)),
+		transactions:    make(map[string]*transaction, len(t.transactions)),
+		txnOrder:        append([]string{}, t.txnOrder...),
+		transactionTags: make(map[string]map[int]bool, len(t.transactionTags)),
+		splits:          make(map[string]*split, len(t.splits)),
+		reconciliations: make(map[string]map[string]bool, len(t.reconciliations)),
+		trash:           make(map[string]time.Time, len(t.trash)),
+		allocationRules: make(map[string]*core.AllocationRule, len(t.allocationRules)),
+		postingRules:    make(map[string]*core.PostingRule, len(t.postingRules)),
+		balances:        make(map[db.BalanceKey]db.BalanceTotal, len(t.balances)),
+		entities:        make(map[string]*core.Entity, len(t.entities)),
+
+		webhookDeliveries: make(map[string]*core.WebhookDelivery, len(t.webhookDeliveries)),
+	}
+	for k, v := range t.users {
+		s.users[k] = v
+	}
+	for k, v := range t.accounts {
+		s.accounts[k] = v
+	}
+	for k, v := range t.accountNames {
+		s.accountNames[k] = v
+	}
+	for k, v := range t.accountTags {
+		s.accountTags[k] = copySet(v)
+	}
+	for k, v := range t.accountSplits {
+		s.accountSplits[k] = append([]string{}, v...)
+	}
+	for k, v := range t.tags {
+		s.tags[k] = v
+	}
+	for k, v := range t.tagNames {
+		s.tagNames[k] = v
+	}
+	for k, v := range t.currencies {
+		s.currencies[k] = v
+	}
+	for k, v := range t.transactions {
+		s.transactions[k] = v
+	}
+	for k, v := range t.transactionTags {
+		s.transactionTags[k] = copySet(v)
+	}
+	for k, v := range t.splits {
+		s.splits[k] = v
+	}
+	for k, v := range t.reconciliations {
+		ids := make(map[string]bool, len(v))
+		for id, ok := range v {
+			ids[id] = ok
+		}
+		s.reconciliations[k] = ids
+	}
+	for k, v := range t.trash {
+		s.trash[k] = v
+	}
+	for k, v := range t.allocationRules {
+		s.allocationRules[k] = v
+	}
+	for k, v := range t.postingRules {
+		s.postingRules[k] = v
+	}
+	for k, v := range t.balances {
+		s.balances[k] = v
+	}
+	for k, v := range t.entities {
+		s.entities[k] = v
+	}
+	for k, v := range t.webhookDeliveries {
+		s.webhookDeliveries[k] = v
+	}
+	return s
+}
+
+func copySet(set map[int]bool) map[int]bool {
+	c := make(map[int]bool, len(set))
+	for k, v := range set {
+		c[k] = v
+	}
+	return c
+}
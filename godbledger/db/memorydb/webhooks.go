@@ -0,0 +1,111 @@
+package memorydb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func (db *Database) AddWebhookDelivery(ctx context.Context, delivery *core.WebhookDelivery) error {
+	log.WithField("delivery", delivery.ID).Debug("Adding Webhook Delivery to DB")
+	db.lock()
+	defer db.unlock()
+
+	if _, exists := db.webhookDeliveries[delivery.ID]; exists {
+		return fmt.Errorf("%w: webhook delivery %s", dberr.ErrAlreadyExists, delivery.ID)
+	}
+	db.webhookDeliveries[delivery.ID] = copyDelivery(delivery)
+	return nil
+}
+
+func (db *Database) FindWebhookDelivery(ctx context.Context, id string) (*core.WebhookDelivery, error) {
+	db.rlock()
+	defer db.runlock()
+
+	d, ok := db.webhookDeliveries[id]
+	if !ok {
+		return nil, dberr.ErrNotFound
+	}
+	return copyDelivery(d), nil
+}
+
+func (db *Database) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*core.WebhookDelivery, error) {
+	db.rlock()
+	defer db.runlock()
+
+	due := []*core.WebhookDelivery{}
+	for _, d := range db.webhookDeliveries {
+		if !d.Dead && !d.NextAttempt.After(now) {
+			due = append(due, copyDelivery(d))
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttempt.Equal(due[j].NextAttempt) {
+			return due[i].NextAttempt.Before(due[j].NextAttempt)
+		}
+		return due[i].Created.Before(due[j].Created)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (db *Database) ListDeadWebhookDeliveries(ctx context.Context) ([]*core.WebhookDelivery, error) {
+	db.rlock()
+	defer db.runlock()
+
+	dead := []*core.WebhookDelivery{}
+	for _, d := range db.webhookDeliveries {
+		if d.Dead {
+			dead = append(dead, copyDelivery(d))
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		if !dead[i].Created.Equal(dead[j].Created) {
+			return dead[i].Created.Before(dead[j].Created)
+		}
+		return dead[i].ID < dead[j].ID
+	})
+	return dead, nil
+}
+
+func (db *Database) UpdateWebhookDelivery(ctx context.Context, delivery *core.WebhookDelivery) error {
+	db.lock()
+	defer db.unlock()
+
+	stored, ok := db.webhookDeliveries[delivery.ID]
+	if !ok {
+		return dberr.ErrNotFound
+	}
+	updated := copyDelivery(stored)
+	updated.Attempts = delivery.Attempts
+	updated.NextAttempt = delivery.NextAttempt
+	updated.LastError = delivery.LastError
+	updated.Dead = delivery.Dead
+	db.webhookDeliveries[delivery.ID] = updated
+	return nil
+}
+
+func (db *Database) DeleteWebhookDelivery(ctx context.Context, id string) error {
+	db.lock()
+	defer db.unlock()
+
+	if _, ok := db.webhookDeliveries[id]; !ok {
+		return dberr.ErrNotFound
+	}
+	delete(db.webhookDeliveries, id)
+	return nil
+}
+
+// copyDelivery copies a delivery so that stored records are never shared
+// with callers.
+func copyDelivery(d *core.WebhookDelivery) *core.WebhookDelivery {
+	c := *d
+	c.Payload = append([]byte{}, d.Payload...)
+	return &c
+}
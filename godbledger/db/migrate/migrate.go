@@ -0,0 +1,185 @@
+// Package migrate applies ordered schema migrations to a ledger database and
+// records each applied version in the schema_version table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var log = logrus.WithField("prefix", "Migrate")
+
+// Migration is a single ordered change to a database schema. Its statements
+// are run in order inside one transaction and the version is recorded once
+// they have all succeeded. Databases that commit DDL as it runs, such as
+// MySQL, keep the statements that ran before a failure without recording the
+// version, so the migrations of those backends must be safe to run again.
+type Migration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// Status reports whether a migration has been applied to the database.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies a backend's migrations. The SQL used to keep track of the
+// schema version is given by the backend so it can be written in its dialect.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+
+	// CreateTable creates the schema_version table if it does not exist
+	CreateTable string
+	// TableExists counts the schema_version tables, so the status can be read
+	// without creating one
+	TableExists string
+	// Insert records an applied migration from its version, description and
+	// the time it was applied
+	Insert string
+}
+
+// Validate checks the migrations are numbered in strictly increasing order.
+func (m *Migrator) Validate() error {
+	previous := 0
+	for _, migration := range m.Migrations {
+		if migration.Version <= previous {
+			return fmt.Errorf("Migration %d (%s) is out of order, it must come after version %d", migration.Version, migration.Description, previous)
+		}
+		previous = migration.Version
+	}
+	return nil
+}
+
+// applied returns the time each recorded migration was applied by version.
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	versions := make(map[int]time.Time)
+
+	var tables int
+	if err := m.DB.QueryRowContext(ctx, m.TableExists).Scan(&tables); err != nil {
+		return nil, err
+	}
+	if tables == 0 {
+		return versions, nil
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT version, applied_at FROM schema_version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	versions, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range m.Migrations {
+		appliedAt, ok := versions[migration.Version]
+		statuses = append(statuses, Status{migration, ok, appliedAt})
+	}
+
+	return statuses, nil
+}
+
+// Version returns the highest applied migration version, or 0 for a database
+// that has never been migrated.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	versions, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	current := 0
+	for version := range versions {
+		if version > current {
+			current = version
+		}
+	}
+
+	return current, nil
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+// With dryRun set the pending migrations are returned without being run.
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Migration)
+		}
+	}
+	if dryRun || len(pending) == 0 {
+		return pending, nil
+	}
+
+	log.Debug("Query: " + m.CreateTable)
+	if _, err := m.DB.ExecContext(ctx, m.CreateTable); err != nil {
+		return nil, fmt.Errorf("Creating schema_version table failed: %w", err)
+	}
+
+	for i, migration := range pending {
+		if err := m.apply(ctx, migration); err != nil {
+			return pending[:i], fmt.Errorf("Migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+		log.Infof("Applied schema migration %d: %s", migration.Version, migration.Description)
+	}
+
+	return pending, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range migration.Statements {
+		log.Debug("Query: " + statement)
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, m.Insert, migration.Version, migration.Description, time.Now().UTC()); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
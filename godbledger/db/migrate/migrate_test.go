@@ -0,0 +1,101 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestMigrator(t *testing.T, migrations []Migration) *Migrator {
+	conn, err := sql.Open("sqlite3", path.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &Migrator{
+		DB:         conn,
+		Migrations: migrations,
+		CreateTable: `
+			CREATE TABLE IF NOT EXISTS schema_version (
+				version INT NOT NULL,
+				description VARCHAR(255) NOT NULL,
+				applied_at DATETIME NOT NULL,
+				PRIMARY KEY(version)
+			);`,
+		TableExists: `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'`,
+		Insert:      `INSERT INTO schema_version(version, description, applied_at) VALUES(?,?,?)`,
+	}
+}
+
+func TestMigratorUp(t *testing.T) {
+	migrations := []Migration{
+		{1, "Accounts", []string{`CREATE TABLE accounts (account_id VARCHAR(255) NOT NULL PRIMARY KEY);`}},
+		{2, "Account names", []string{`ALTER TABLE accounts ADD COLUMN name VARCHAR(255);`}},
+	}
+	ctx := context.Background()
+	m := newTestMigrator(t, migrations[:1])
+
+	// A dry run reports the pending migrations without creating anything
+	pending, err := m.Up(ctx, true)
+	assert.NoError(t, err)
+	assert.Equal(t, migrations[:1], pending)
+	version, err := m.Version(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	applied, err := m.Up(ctx, false)
+	assert.NoError(t, err)
+	assert.Equal(t, migrations[:1], applied)
+
+	// Only the newly appended migration is applied on the next run
+	m.Migrations = migrations
+	statuses, err := m.Status(ctx)
+	assert.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	applied, err = m.Up(ctx, false)
+	assert.NoError(t, err)
+	assert.Equal(t, migrations[1:], applied)
+	_, err = m.DB.Exec(`INSERT INTO accounts(account_id, name) VALUES("1000", "Cash")`)
+	assert.NoError(t, err)
+
+	applied, err = m.Up(ctx, false)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+	version, err = m.Version(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+}
+
+func TestMigratorFailedMigrationIsNotRecorded(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator(t, []Migration{
+		{1, "Accounts", []string{`CREATE TABLE accounts (account_id VARCHAR(255) NOT NULL PRIMARY KEY);`}},
+		{2, "Broken", []string{`ALTER TABLE missing ADD COLUMN name VARCHAR(255);`}},
+	})
+
+	applied, err := m.Up(ctx, false)
+	assert.Error(t, err)
+	assert.Len(t, applied, 1)
+	version, err := m.Version(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+}
+
+func TestMigratorValidate(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator(t, []Migration{
+		{2, "Second", nil},
+		{1, "First", nil},
+	})
+	assert.Error(t, m.Validate())
+	_, err := m.Up(ctx, false)
+	assert.Error(t, err)
+}
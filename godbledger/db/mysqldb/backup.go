@@ -0,0 +1,189 @@
+package mysqldb
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupTo writes a logical dump of the ledger to the file at path. The rows
+// are read in a single consistent snapshot so the node can keep writing while
+// it runs. Every statement of the dump is written on a line of its own.
+func (db *Database) BackupTo(ctx context.Context, path string) error {
+	log.WithField("path", path).Debug("Dumping MySQL database")
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ"); err != nil {
+		return translateError(err)
+	}
+	if _, err := conn.ExecContext(ctx, "START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY"); err != nil {
+		return translateError(err)
+	}
+	defer conn.ExecContext(context.Background(), "COMMIT")
+
+	tables, err := listTables(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	out := bufio.NewWriter(file)
+
+	fmt.Fprintln(out, "-- godbledger MySQL dump")
+	fmt.Fprintln(out, "SET FOREIGN_KEY_CHECKS=0;")
+	for _, table := range tables {
+		if err := dumpTable(ctx, conn, out, table); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(out, "SET FOREIGN_KEY_CHECKS=1;")
+
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// RestoreFrom drops every table of the ledger and replays a dump written by
+// BackupTo in its place.
+func (db *Database) RestoreFrom(ctx context.Context, path string) error {
+	log.WithField("path", path).Debug("Restoring MySQL database")
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS=0"); err != nil {
+		return translateError(err)
+	}
+	defer conn.ExecContext(context.Background(), "SET FOREIGN_KEY_CHECKS=1")
+
+	tables, err := listTables(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if _, err := conn.ExecContext(ctx, "DROP TABLE "+quoteIdentifier(table)); err != nil {
+			return translateError(err)
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		statement := scanner.Text()
+		if statement == "" || strings.HasPrefix(statement, "--") {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return translateError(err)
+		}
+	}
+	return scanner.Err()
+}
+
+func listTables(ctx context.Context, conn *sql.Conn) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, "SHOW FULL TABLES WHERE Table_type = 'BASE TABLE'")
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	tables := []string{}
+	for rows.Next() {
+		var table, tableType string
+		if err := rows.Scan(&table, &tableType); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// dumpTable writes the statements recreating a table and its rows.
+func dumpTable(ctx context.Context, conn *sql.Conn, out *bufio.Writer, table string) error {
+	var name, create string
+	if err := conn.QueryRowContext(ctx, "SHOW CREATE TABLE "+quoteIdentifier(table)).Scan(&name, &create); err != nil {
+		return translateError(err)
+	}
+	fmt.Fprintf(out, "DROP TABLE IF EXISTS %s;\n", quoteIdentifier(table))
+	fmt.Fprintf(out, "%s;\n", strings.Join(strings.Fields(create), " "))
+
+	rows, err := conn.QueryContext(ctx, "SELECT * FROM "+quoteIdentifier(table))
+	if err != nil {
+		return translateError(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	literals := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		for i, value := range values {
+			literals[i] = quoteValue(value)
+		}
+		fmt.Fprintf(out, "INSERT INTO %s VALUES (%s);\n", quoteIdentifier(table), strings.Join(literals, ","))
+	}
+	return rows.Err()
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+var literalEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
+
+// quoteValue writes a column value as an SQL literal that fits on one line.
+func quoteValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+	case []byte:
+		return "'" + literalEscaper.Replace(string(v)) + "'"
+	default:
+		return "'" + literalEscaper.Replace(fmt.Sprint(v)) + "'"
+	}
+}
@@ -0,0 +1,66 @@
+package mysqldb
+
+import (
+	"context"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func (db *Database) AddEntity(ctx context.Context, entity *core.Entity) error {
+	log.WithField("entity", entity.Id).Debug("Adding Entity to DB")
+	_, err := db.conn().ExecContext(ctx, `INSERT INTO entities(entity_id, name, description) VALUES(?, ?, ?)`, entity.Id, entity.Name, entity.Description)
+	return translateError(err)
+}
+
+func (db *Database) FindEntity(ctx context.Context, id string) (*core.Entity, error) {
+	var entity core.Entity
+	err := db.conn().QueryRowContext(ctx, `SELECT entity_id, name, COALESCE(description, '') FROM entities WHERE entity_id = ?`, id).Scan(&entity.Id, &entity.Name, &entity.Description)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &entity, nil
+}
+
+func (db *Database) ListEntities(ctx context.Context) ([]*core.Entity, error) {
+	rows, err := db.conn().QueryContext(ctx, `SELECT entity_id, name, COALESCE(description, '') FROM entities ORDER BY entity_id`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	entities := []*core.Entity{}
+	for rows.Next() {
+		var entity core.Entity
+		if err := rows.Scan(&entity.Id, &entity.Name, &entity.Description); err != nil {
+			return nil, err
+		}
+		entities = append(entities, &entity)
+	}
+	return entities, rows.Err()
+}
+
+// OpenEntity opens the ledger of an entity, kept in a database of its own
+// named after this one with the entity identifier appended.
+func (db *Database) OpenEntity(ctx context.Context, id string) (dberr.Database, error) {
+	validated, err := ValidateConnectionString(db.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := mysql.ParseDSN(validated)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.DBName) == 0 {
+		cfg.DBName = "ledger"
+	}
+	cfg.DBName = cfg.DBName + "_" + id
+
+	log.WithField("database", cfg.DBName).Debug("Creating Entity Database")
+	if _, err := db.DB.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+quoteIdentifier(cfg.DBName)); err != nil {
+		return nil, translateError(err)
+	}
+	return NewDB(cfg.FormatDSN())
+}
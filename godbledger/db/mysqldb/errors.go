@@ -0,0 +1,77 @@
+package mysqldb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/darcys22/godbledger/godbledger/db"
+)
+
+// MySQL server error numbers for the constraint failures we translate
+const (
+	errDuplicateEntry      = 1062
+	errRowIsReferenced     = 1451
+	errNoReferencedRow     = 1452
+	errRowIsReferencedOld  = 1217
+	errNoReferencedRowOld  = 1216
+	errColumnCannotBeNull  = 1048
+	errCheckConstraintFail = 3819
+	errOutOfRange          = 1264
+	errDataOutOfRange      = 1690
+)
+
+// maxAmountDigits is the precision of the DECIMAL(65,0) amount columns, the
+// widest integer MySQL stores exactly.
+const maxAmountDigits = 65
+
+// checkAmount rejects amounts with more digits than the amount columns hold,
+// which MySQL would otherwise refuse with an out of range error.
+func checkAmount(amount *big.Int) error {
+	if amount == nil {
+		return nil
+	}
+	if digits := len(new(big.Int).Abs(amount).String()); digits > maxAmountDigits {
+		return fmt.Errorf("%w: amount of %d digits exceeds the %d MySQL stores", db.ErrConstraintViolation, digits, maxAmountDigits)
+	}
+	return nil
+}
+
+// maxTotal is the largest value of a DECIMAL(65,0). MySQL sums amounts to
+// the same precision, and without strict mode clips a total that overflows
+// it to this value rather than failing.
+var maxTotal = new(big.Int).Sub(new(big.Int).Exp(big.NewInt(10), big.NewInt(maxAmountDigits), nil), big.NewInt(1))
+
+// checkTotal rejects a sum of amounts that reached the limit of what MySQL
+// can add up, as it may have been clipped.
+func checkTotal(total *big.Int) error {
+	if total != nil && new(big.Int).Abs(total).Cmp(maxTotal) >= 0 {
+		return fmt.Errorf("%w: total exceeds the %d digits MySQL can sum", db.ErrConstraintViolation, maxAmountDigits)
+	}
+	return nil
+}
+
+// translateError converts MySQL errors into the errors defined by the db
+// package, anything it does not recognise is returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.ErrNotFound
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case errDuplicateEntry:
+			return fmt.Errorf("%w: %v", db.ErrAlreadyExists, err)
+		case errRowIsReferenced, errNoReferencedRow, errRowIsReferencedOld, errNoReferencedRowOld,
+			errColumnCannotBeNull, errCheckConstraintFail, errOutOfRange, errDataOutOfRange:
+			return fmt.Errorf("%w: %v", db.ErrConstraintViolation, err)
+		}
+	}
+	return err
+}
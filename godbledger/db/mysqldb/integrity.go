@@ -0,0 +1,155 @@
+package mysqldb
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+// CheckIntegrity scans the splits and transactions for the structural and
+// double entry rules the ledger relies on.
+func (db *Database) CheckIntegrity(ctx context.Context) ([]core.IntegrityViolation, error) {
+	log.Debug("Checking Ledger Integrity")
+	violations := []core.IntegrityViolation{}
+
+	rows, err := db.conn().QueryContext(ctx, `
+		SELECT s.transaction_id,
+					 s.split_id,
+					 s.amount
+		FROM   splits AS s
+		WHERE  NOT EXISTS (SELECT 1 FROM split_accounts AS sa WHERE sa.split_id = s.split_id)
+		ORDER  BY s.transaction_id, s.split_id;`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	err = scanViolations(rows, &violations, func(rows *sql.Rows) (core.IntegrityViolation, error) {
+		var txnID, splitID string
+		var amount *big.Int
+		err := rows.Scan(&txnID, &splitID, dberr.ScanAmount(&amount))
+		return core.OrphanSplit(txnID, splitID, amount), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.conn().QueryContext(ctx, `
+		SELECT transaction_id,
+					 currency,
+					 SUM(amount)
+		FROM   splits
+		GROUP  BY transaction_id, currency
+		HAVING SUM(amount) <> 0
+		ORDER  BY transaction_id, currency;`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	err = scanViolations(rows, &violations, func(rows *sql.Rows) (core.IntegrityViolation, error) {
+		var txnID string
+		var currency sql.NullString
+		var total *big.Int
+		if err := rows.Scan(&txnID, &currency, dberr.ScanAmount(&total)); err != nil {
+			return core.IntegrityViolation{}, err
+		}
+		return core.UnbalancedTransaction(txnID, currency.String, total), checkTotal(total)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.conn().QueryContext(ctx, `
+		SELECT s.transaction_id,
+					 s.split_id,
+					 s.currency
+		FROM   splits AS s
+		WHERE  NOT EXISTS (SELECT 1 FROM currencies AS c WHERE c.name = s.currency)
+		ORDER  BY s.transaction_id, s.split_id;`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	err = scanViolations(rows, &violations, func(rows *sql.Rows) (core.IntegrityViolation, error) {
+		var txnID, splitID string
+		var currency sql.NullString
+		err := rows.Scan(&txnID, &splitID, &currency)
+		return core.MissingCurrency(txnID, splitID, currency.String), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.conn().QueryContext(ctx, `
+		SELECT t.transaction_id
+		FROM   transactions AS t
+		WHERE  NOT EXISTS (SELECT 1 FROM splits AS s WHERE s.transaction_id = t.transaction_id)
+		ORDER  BY t.transaction_id;`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	err = scanViolations(rows, &violations, func(rows *sql.Rows) (core.IntegrityViolation, error) {
+		var txnID string
+		err := rows.Scan(&txnID)
+		return core.EmptyTransaction(txnID), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return violations, nil
+}
+
+// scanViolations reads a violation from each row and closes the rows.
+func scanViolations(rows *sql.Rows, violations *[]core.IntegrityViolation, scan func(*sql.Rows) (core.IntegrityViolation, error)) error {
+	defer rows.Close()
+	for rows.Next() {
+		violation, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		*violations = append(*violations, violation)
+	}
+	return rows.Err()
+}
+
+// RepairIntegrity deletes the orphan splits and empty transactions among the
+// repairable violations. Each is checked again as it is deleted so that a
+// split given an account or a transaction given splits since is kept.
+func (db *Database) RepairIntegrity(ctx context.Context, violations []core.IntegrityViolation) error {
+	log.Debug("Repairing Ledger Integrity")
+	tx, err := db.begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, violation := range violations {
+		if !violation.Repairable {
+			continue
+		}
+		switch violation.Kind {
+		case core.OrphanSplitViolation:
+			_, err = tx.ExecContext(ctx, `
+				DELETE FROM splits
+				WHERE  split_id = ?
+							 AND amount = 0
+							 AND NOT EXISTS (SELECT 1 FROM split_accounts WHERE split_id = ?);`, violation.Split, violation.Split)
+		case core.EmptyTransactionViolation:
+			_, err = tx.ExecContext(ctx, `
+				DELETE FROM transaction_tag
+				WHERE  transaction_id = ?
+							 AND NOT EXISTS (SELECT 1 FROM splits WHERE transaction_id = ?);`, violation.Transaction, violation.Transaction)
+			if err == nil {
+				_, err = tx.ExecContext(ctx, `
+					DELETE FROM transactions
+					WHERE  transaction_id = ?
+								 AND NOT EXISTS (SELECT 1 FROM splits WHERE transaction_id = ?);`, violation.Transaction, violation.Transaction)
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return translateError(err)
+		}
+	}
+
+	return tx.Commit()
+}
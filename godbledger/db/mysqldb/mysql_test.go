@@ -10,10 +10,15 @@ package mysqldb
 import (
 	//"database/sql"
 	//"log"
+	"errors"
+	"math/big"
+	"strings"
 	"testing"
 
 	//"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/db"
 )
 
 func TestValidateConnectionString(t *testing.T) {
@@ -38,3 +43,42 @@ func TestValidateConnectionString(t *testing.T) {
 	}
 	assert.Equal(t, "", nilString)
 }
+
+func TestCheckAmount(t *testing.T) {
+	widest, _ := new(big.Int).SetString(strings.Repeat("9", maxAmountDigits), 10)
+	assert.NoError(t, checkAmount(widest))
+	assert.NoError(t, checkAmount(new(big.Int).Neg(widest)))
+	assert.NoError(t, checkAmount(nil))
+
+	tooWide := new(big.Int).Add(widest, big.NewInt(1))
+	assert.True(t, errors.Is(checkAmount(tooWide), db.ErrConstraintViolation))
+	assert.True(t, errors.Is(checkAmount(new(big.Int).Neg(tooWide)), db.ErrConstraintViolation))
+}
+
+func TestCheckTotal(t *testing.T) {
+	widest, _ := new(big.Int).SetString(strings.Repeat("9", maxAmountDigits), 10)
+	below := new(big.Int).Sub(widest, big.NewInt(1))
+	assert.NoError(t, checkTotal(below))
+	assert.NoError(t, checkTotal(new(big.Int).Neg(below)))
+	assert.NoError(t, checkTotal(nil))
+
+	// A total at the limit may have been clipped
+	assert.True(t, errors.Is(checkTotal(widest), db.ErrConstraintViolation))
+	assert.True(t, errors.Is(checkTotal(new(big.Int).Neg(widest)), db.ErrConstraintViolation))
+}
+
+func TestMigrationsRerunnable(t *testing.T) {
+	for _, migration := range migrations {
+		for _, statement := range migration.Statements {
+			words := strings.Fields(strings.ToUpper(statement))
+			switch {
+			case len(words) > 1 && words[0] == "CREATE" && words[1] == "TABLE":
+				assert.Equal(t, []string{"IF", "NOT", "EXISTS"}, words[2:5], "migration %d: %s", migration.Version, statement)
+			case len(words) > 0 && words[0] == "INSERT":
+				t.Errorf("migration %d inserts rows twice when run again: %s", migration.Version, statement)
+			case len(words) > 0 && words[0] == "CREATE":
+				t.Errorf("migration %d fails when run again, use createIndex: %s", migration.Version, statement)
+			}
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package mysqldb
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"regexp"
 
 	"github.com/sirupsen/logrus"
@@ -16,6 +18,11 @@ var dsnRegex = regexp.MustCompile(`\:(.+?)\@`)
 type Database struct {
 	DB               *sql.DB
 	ConnectionString string
+
+	// tx is set on the copy of the database handed to a unit of work, depth
+	// counts the savepoints opened inside it
+	tx    *sql.Tx
+	depth int
 }
 
 // Close closes the underlying database.
@@ -67,12 +74,12 @@ func redactPassword(rawDSNString string) string {
 func NewDB(connection_string string) (*Database, error) {
 	validatedString, err := ValidateConnectionString(connection_string)
 	if err != nil {
-		log.Fatal(err.Error())
+		log.Error(err.Error())
 		return nil, err
 	}
 	MySQLDB, err := sql.Open("mysql", validatedString)
 	if err != nil {
-		log.Fatal(err.Error())
+		log.Error(err.Error())
 		return nil, err
 	}
 
@@ -81,180 +88,12 @@ func NewDB(connection_string string) (*Database, error) {
 	return db, nil
 }
 
-func (db *Database) InitDB() error {
+func (db *Database) InitDB(ctx context.Context) error {
 	log.Info("Initialising DB Table")
 
-	//USERS
-	createDB := `
-	CREATE TABLE IF NOT EXISTS users (
-		user_id VARCHAR(255) NOT NULL,
-		username VARCHAR(255) NOT NULL,
-		PRIMARY KEY(user_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err := db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatalf("Creating users table failed: %s", err)
-	}
-
-	//ACCOUNTS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS accounts (
-		account_id VARCHAR(255) NOT NULL,
-		name VARCHAR(255) NOT NULL,
-		PRIMARY KEY(account_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatalf("Creating accounts table failed: %s", err)
-	}
-
-	//TAGS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS tags (
-		tag_id INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
-		tag_name VARCHAR(100) NOT NULL UNIQUE
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatalf("Creating tags table failed: %s", err)
-	}
-
-	//TAGS FOR ACCOUNTS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS account_tag (
-    account_id VARCHAR(255) NOT NULL,
-    tag_id INTEGER NOT NULL,
-    FOREIGN KEY (account_id) REFERENCES accounts (account_id) ON DELETE CASCADE ON UPDATE CASCADE,
-    FOREIGN KEY (tag_id) REFERENCES tags (tag_id) ON DELETE CASCADE ON UPDATE CASCADE,
-    PRIMARY KEY (account_id, tag_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
+	_, err := db.Migrate(ctx, false)
 	if err != nil {
-		log.Fatalf("Creating Account_Tag table failed: %s", err)
-	}
-
-	//CURRENCIES
-	createDB = `
-	CREATE TABLE IF NOT EXISTS currencies (
-		name VARCHAR(255) NOT NULL,
-		decimals INT NOT NULL,
-		PRIMARY KEY(name)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//TRANSACTIONS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS transactions (
-		transaction_id VARCHAR(255) NOT NULL,
-		postdate DATETIME NOT NULL,
-		description VARCHAR(255),
-		poster_user_id VARCHAR(255),
-		PRIMARY KEY(transaction_id),
-    FOREIGN KEY (poster_user_id) REFERENCES users (user_id) ON DELETE RESTRICT ON UPDATE CASCADE
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//TRANSACTIONS BODY
-	createDB = `
-	CREATE TABLE IF NOT EXISTS transactions_body (
-		transaction_id VARCHAR(255) NOT NULL,
-		body TEXT,
-		FOREIGN KEY(transaction_id) REFERENCES transactions(transaction_id) ON DELETE CASCADE ON UPDATE CASCADE
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//TAGS FOR Transactions
-	createDB = `
-	CREATE TABLE IF NOT EXISTS transaction_tag (
-    transaction_id VARCHAR(255) NOT NULL,
-    tag_id INTEGER NOT NULL,
-    FOREIGN KEY (transaction_id) REFERENCES transactions (transaction_id) ON DELETE CASCADE ON UPDATE CASCADE,
-    FOREIGN KEY (tag_id) REFERENCES tags (tag_id) ON DELETE CASCADE ON UPDATE CASCADE,
-    PRIMARY KEY (transaction_id, tag_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatalf("Creating Transaction_Tag table failed: %s", err)
-	}
-
-	//LINE ITEMS FOR TRANSACTIONS (SPLITS)
-	createDB = `
-	CREATE TABLE IF NOT EXISTS splits (
-		split_id VARCHAR(255) NOT NULL,
-		split_date DATETIME,
-		description VARCHAR(255),
-		currency VARCHAR(255),
-		amount BIGINT,
-		transaction_id VARCHAR(255),
-		FOREIGN KEY(transaction_id) REFERENCES transactions(transaction_id) ON DELETE CASCADE ON UPDATE CASCADE,
-		PRIMARY KEY(split_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//ACCOUNTS FOR SPLITS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS split_accounts (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		split_id VARCHAR(255),
-		account_id VARCHAR(255),
-		FOREIGN KEY(split_id) REFERENCES splits(split_id) ON DELETE CASCADE ON UPDATE CASCADE,
-		FOREIGN KEY(account_id) REFERENCES accounts(account_id) ON DELETE RESTRICT ON UPDATE CASCADE
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//RECONCILIATIONS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS reconciliations (
-		reconciliation_id VARCHAR(255) NOT NULL,
-		split_id VARCHAR(255) NOT NULL,
-		FOREIGN KEY (split_id) REFERENCES splits (split_id) ON DELETE CASCADE ON UPDATE CASCADE,
-		PRIMARY KEY (reconciliation_id, split_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//ENTITIES
-	createDB = `
-	CREATE TABLE IF NOT EXISTS entities (
-		entity_id VARCHAR(255) NOT NULL,
-		name VARCHAR(255) NOT NULL,
-		tag VARCHAR(255),
-		type VARCHAR(255),
-		description VARCHAR(255),
-		PRIMARY KEY(entity_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("Migrating database failed: %w", err)
 	}
 
 	//Default Currencies
@@ -268,20 +107,20 @@ func (db *Database) InitDB() error {
 			("OXEN",9);
 	`
 	log.Debug("Query: " + insertCurrency)
-	_, _ = db.DB.Exec(insertCurrency)
-	return err
+	_, _ = db.DB.ExecContext(ctx, insertCurrency)
+	return nil
 }
 
 // ClearDB drops all tables
-func (db *Database) ClearDB() error {
+func (db *Database) ClearDB(ctx context.Context) error {
 	//DROP TABLES
 	dropDB := `
 				DROP DATABASE ledger;
 			`
 	log.Debug("Query: " + dropDB)
-	_, err := db.DB.Exec(dropDB)
+	_, err := db.DB.ExecContext(ctx, dropDB)
 	if err != nil {
-		log.Fatalf("Dropping table failed with: %s", err)
+		log.Errorf("Dropping table failed with: %s", err)
 		return err
 	}
 
@@ -290,9 +129,9 @@ func (db *Database) ClearDB() error {
 				CREATE DATABASE ledger;
 			`
 	log.Debug("Query: " + newDB)
-	_, err = db.DB.Exec(newDB)
+	_, err = db.DB.ExecContext(ctx, newDB)
 	if err != nil {
-		log.Fatalf("Creating table failed with: %s", err)
+		log.Errorf("Creating table failed with: %s", err)
 		return err
 	}
 
@@ -301,9 +140,9 @@ func (db *Database) ClearDB() error {
 				USE ledger;
 			`
 	log.Debug("Query: " + newDB)
-	_, err = db.DB.Exec(newDB)
+	_, err = db.DB.ExecContext(ctx, newDB)
 	if err != nil {
-		log.Fatalf("Creating table failed with: %s", err)
+		log.Errorf("Creating table failed with: %s", err)
 		return err
 	}
 	return nil
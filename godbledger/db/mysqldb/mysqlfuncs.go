@@ -1,19 +1,30 @@
 package mysqldb
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
-func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
+func (db *Database) AddTransaction(ctx context.Context, txn *core.Transaction) (string, error) {
 	log.Debug("Adding Transaction to DB")
+	if _, balanced := txn.Balance(); !balanced {
+		return "", dberr.ErrUnbalanced
+	}
+	for _, split := range txn.Splits {
+		if err := checkAmount(split.Amount); err != nil {
+			return "", err
+		}
+	}
 
 	longDescription := false
 
@@ -22,60 +33,51 @@ func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
 	}
 
 	posterID := ""
-	err := db.DB.QueryRow(`SELECT user_id FROM users WHERE username = ? LIMIT 1`, txn.Poster.Name).Scan(&posterID)
+	err := db.conn().QueryRowContext(ctx, `SELECT user_id FROM users WHERE username = ? LIMIT 1`, txn.Poster.Name).Scan(&posterID)
 
 	if err != nil {
-		log.Fatal(err)
-		return "", err
+		log.Error(err)
+		return "", translateError(err)
 	}
 
 	insertTransaction := `
 		INSERT INTO transactions(transaction_id, postdate, description, poster_user_id)
 			VALUES(?,?,?,?);
 	`
-	tx, err := db.DB.Begin()
+	tx, err := db.begin(ctx)
 
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
 		return "", err
 	}
 
-	stmt, err := tx.Prepare(insertTransaction)
+	stmt, err := tx.PrepareContext(ctx, insertTransaction)
 	log.Debug("Query: " + insertTransaction)
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
 	var res sql.Result
 	if longDescription {
-		res, err = stmt.Exec(txn.Id, txn.Postdate, string(txn.Description[:255]), posterID)
+		res, err = stmt.ExecContext(ctx, txn.Id, txn.Postdate, string(txn.Description[:255]), posterID)
 	} else {
-		res, err = stmt.Exec(txn.Id, txn.Postdate, string(txn.Description[:]), posterID)
+		res, err = stmt.ExecContext(ctx, txn.Id, txn.Postdate, string(txn.Description[:]), posterID)
 	}
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
-		return "", err
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
@@ -83,11 +85,8 @@ func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
@@ -96,38 +95,29 @@ func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
 			INSERT INTO transactions_body(transaction_id, body)
 				VALUES(?,?);
 		`
-		stmt, err := tx.Prepare(insertLongDescriptionTransaction)
+		stmt, err := tx.PrepareContext(ctx, insertLongDescriptionTransaction)
 		log.Debug("Query: " + insertLongDescriptionTransaction)
 		log.Debug("Txn Id: " + txn.Id)
 
 		if err != nil {
-			log.Fatal(err)
-			err = tx.Rollback()
-			if err != nil {
-				log.Fatal(err)
-			}
+			log.Error(err)
+			tx.Rollback()
 			return "", err
 		}
 
-		res, err := stmt.Exec(txn.Id, string(txn.Description[:]))
+		res, err := stmt.ExecContext(ctx, txn.Id, string(txn.Description[:]))
 
 		if err != nil {
-			log.Fatal(err)
-			err = tx.Rollback()
-			if err != nil {
-				log.Fatal(err)
-			}
-			return "", err
+			log.Error(err)
+			tx.Rollback()
+			return "", translateError(err)
 		}
 
 		lastId, err := res.LastInsertId()
 
 		if err != nil {
-			log.Fatal(err)
-			err = tx.Rollback()
-			if err != nil {
-				log.Fatal(err)
-			}
+			log.Error(err)
+			tx.Rollback()
 			return "", err
 		}
 
@@ -136,11 +126,8 @@ func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
 		log.Debug("Saving Long Description into extended table")
 
 		if err != nil {
-			log.Fatal(err)
-			err = tx.Rollback()
-			if err != nil {
-				log.Fatal(err)
-			}
+			log.Error(err)
+			tx.Rollback()
 			return "", err
 		}
 	}
@@ -154,9 +141,9 @@ func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
 		sqlStr += "(?, ?, ?, ?, ?, ?),"
 		//Todo:(sean) split is truncated at 255 bytes but should be handled better
 		if len(split.Description) > 255 {
-			vals = append(vals, txn.Id, split.Id, split.Date, string(split.Description[:255]), split.Currency.Name, split.Amount.Int64())
+			vals = append(vals, txn.Id, split.Id, split.Date, string(split.Description[:255]), split.Currency.Name, dberr.AmountValue(split.Amount))
 		} else {
-			vals = append(vals, txn.Id, split.Id, split.Date, string(split.Description[:]), split.Currency.Name, split.Amount.Int64())
+			vals = append(vals, txn.Id, split.Id, split.Date, string(split.Description[:]), split.Currency.Name, dberr.AmountValue(split.Amount))
 		}
 		for _, acc := range split.Accounts {
 			sqlAccStr += "(?, ?),"
@@ -165,39 +152,30 @@ func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
 	}
 
 	sqlStr = strings.TrimSuffix(sqlStr, ",")
-	stmt, err = tx.Prepare(sqlStr)
+	stmt, err = tx.PrepareContext(ctx, sqlStr)
 	log.Debug("Query: " + sqlStr)
 	log.Debugf("NumberVals = %d", len(vals))
 	log.Debug("Adding Split to DB")
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
-	res, err = stmt.Exec(vals...)
+	res, err = stmt.ExecContext(ctx, vals...)
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
-		return "", err
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
 	}
 
 	lastId, err = res.LastInsertId()
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
@@ -205,47 +183,35 @@ func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
 	sqlAccStr = strings.TrimSuffix(sqlAccStr, ",")
-	accStmt, err := tx.Prepare(sqlAccStr)
+	accStmt, err := tx.PrepareContext(ctx, sqlAccStr)
 	log.Debug("Query: " + sqlAccStr)
 	log.Debug("Adding Split Accounts to DB")
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
-	res, err = accStmt.Exec(accVals...)
+	res, err = accStmt.ExecContext(ctx, accVals...)
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
-		return "", err
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
 	}
 
 	lastId, err = res.LastInsertId()
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
@@ -253,35 +219,56 @@ func (db *Database) AddTransaction(txn *core.Transaction) (string, error) {
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := applyBalances(ctx, tx, txn.Id, 1); err != nil {
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
 	err = tx.Commit()
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
-		return "", err
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
 	}
 
-	return txn.Id, err
+	return txn.Id, nil
+}
+
+// AddTransactions inserts the transactions in a single database transaction
+// and returns their ids. If any of them fails none are saved.
+func (db *Database) AddTransactions(ctx context.Context, txns []*core.Transaction) ([]string, error) {
+	log.Debugf("Adding %d Transactions to DB", len(txns))
+	ids := make([]string, 0, len(txns))
+	err := db.UnitOfWork(ctx, func(tx dberr.Database) error {
+		for _, txn := range txns {
+			id, err := tx.AddTransaction(ctx, txn)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
 }
 
-func (db *Database) FindTransaction(txnID string) (*core.Transaction, error) {
+func (db *Database) FindTransaction(ctx context.Context, txnID string) (*core.Transaction, error) {
 	var resp core.Transaction
 	var poster core.User
 	log.Debug("Searching Transaction in DB: ", txnID)
 
 	// Find the transaction body
-	err := db.DB.QueryRow(`
+	err := db.conn().QueryRowContext(ctx, `
 			SELECT t.transaction_id,
 						 t.postdate,
 						 t.description,
@@ -294,13 +281,13 @@ func (db *Database) FindTransaction(txnID string) (*core.Transaction, error) {
 			LIMIT  1 
 			`, txnID).Scan(&resp.Id, &resp.Postdate, &resp.Description, &poster.Id, &poster.Name)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	log.Debug("Searching Transaction splits in DB")
 
 	// Find all splits relating to that transaction
-	splits, err := db.Query(`
+	splits, err := db.Query(ctx, `
 			SELECT s.split_id,
 						 s.split_date,
 						 s.description,
@@ -326,13 +313,13 @@ func (db *Database) FindTransaction(txnID string) (*core.Transaction, error) {
 		var split core.Split
 		var account core.Account
 		var cur core.Currency
-		var amount int64
+		var amount *big.Int
 		// for each row, scan the result into our split object
-		err = splits.Scan(&split.Id, &split.Date, &split.Description, &account.Code, &account.Name, &cur.Name, &cur.Decimals, &amount)
+		err = splits.Scan(&split.Id, &split.Date, &split.Description, &account.Code, &account.Name, &cur.Name, &cur.Decimals, dberr.ScanAmount(&amount))
 		if err != nil {
 			return nil, err
 		}
-		split.Amount = big.NewInt(amount)
+		split.Amount = amount
 		split.Accounts = append(split.Accounts, &account)
 		split.Currency = &cur
 		resp.Splits = append(resp.Splits, &split)
@@ -341,60 +328,86 @@ func (db *Database) FindTransaction(txnID string) (*core.Transaction, error) {
 	return &resp, nil
 }
 
-func (db *Database) DeleteTransaction(txnID string) error {
+// DeleteTransaction moves a transaction into the trash, where it is excluded
+// from reports until it is restored or purged.
+func (db *Database) DeleteTransaction(ctx context.Context, txnID string) error {
+	return db.TrashTransaction(ctx, txnID, time.Now().UTC())
+}
+
+// TrashTransaction moves a transaction into the trash recording that it was
+// trashed at the given time.
+func (db *Database) TrashTransaction(ctx context.Context, txnID string, trashedAt time.Time) error {
+	return db.trackBalances(ctx, txnID, func(tx *Database) error {
+		return tx.deleteTransaction(ctx, txnID, trashedAt)
+	})
+}
+
+func (db *Database) deleteTransaction(ctx context.Context, txnID string, trashedAt time.Time) error {
+	var exists int
+	err := db.conn().QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT * FROM transactions
+									WHERE transaction_id = ?
+										AND transaction_id NOT IN (SELECT transaction_id FROM trashed_transactions));`, txnID).Scan(&exists)
+	if err != nil {
+		return translateError(err)
+	}
+	if exists == 0 {
+		return dberr.ErrNotFound
+	}
+
 	sqlStatement := `
-		DELETE FROM transactions
-		WHERE transaction_id = ?;`
-	_, err := db.DB.Exec(sqlStatement, txnID)
+	INSERT INTO trashed_transactions(transaction_id, trashed_at)
+		VALUES(?,?);`
+	log.Debug("Query: " + sqlStatement)
+	_, err = db.conn().ExecContext(ctx, sqlStatement, txnID, trashedAt.UTC())
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (db *Database) FindTag(tag string) (int, error) {
+func (db *Database) FindTag(ctx context.Context, tag string) (int, error) {
 	var resp int
 	log.Debug("Searching Tag in DB")
-	err := db.DB.QueryRow(`SELECT tag_id FROM tags WHERE tag_name = ? LIMIT 1`, tag).Scan(&resp)
+	err := db.conn().QueryRowContext(ctx, `SELECT tag_id FROM tags WHERE tag_name = ? LIMIT 1`, tag).Scan(&resp)
 	if err != nil {
 		log.Debug("Find Tag Failed: ", err)
-		return 0, err
+		return 0, translateError(err)
 	}
 	return resp, nil
 }
 
-func (db *Database) AddTag(tag string) error {
+func (db *Database) AddTag(ctx context.Context, tag string) error {
 	log.Debug("Adding Tag to DB")
 	insertTag := `
 		INSERT INTO tags(tag_name)
 			VALUES(?);
 	`
-	tx, _ := db.DB.Begin()
-	stmt, _ := tx.Prepare(insertTag)
 	log.Debug("Query: " + insertTag)
-	res, err := stmt.Exec(tag)
+	res, err := db.conn().ExecContext(ctx, insertTag, tag)
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	rowCnt, err := res.RowsAffected()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
-	tx.Commit()
-
 	return nil
 }
 
-func (db *Database) SafeAddTag(tag string) error {
-	u, _ := db.FindTag(strings.TrimSpace(tag))
+func (db *Database) SafeAddTag(ctx context.Context, tag string) error {
+	u, _ := db.FindTag(ctx, strings.TrimSpace(tag))
 	//if err != nil {
 	//log.Debug(err)
 	//return err
@@ -402,33 +415,33 @@ func (db *Database) SafeAddTag(tag string) error {
 	if u != 0 {
 		return nil
 	}
-	return db.AddTag(strings.TrimSpace(tag))
+	return db.AddTag(ctx, strings.TrimSpace(tag))
 }
 
-func (db *Database) SafeAddTagToAccount(account, tag string) error {
-	err := db.SafeAddTag(tag)
+func (db *Database) SafeAddTagToAccount(ctx context.Context, account, tag string) error {
+	err := db.SafeAddTag(ctx, tag)
 	if err != nil {
 		log.Debug(err)
 		return err
 	}
-	tagID, _ := db.FindTag(tag)
+	tagID, _ := db.FindTag(ctx, tag)
 
 	var accountID string
-	err = db.DB.QueryRow(`SELECT account_id FROM accounts WHERE name = ? LIMIT 1`, account).Scan(&accountID)
+	err = db.conn().QueryRowContext(ctx, `SELECT account_id FROM accounts WHERE name = ? LIMIT 1`, account).Scan(&accountID)
 	if err != nil {
 		log.Debug(err)
-		return err
+		return translateError(err)
 	}
 
-	return db.AddTagToAccount(accountID, tagID)
+	return db.AddTagToAccount(ctx, accountID, tagID)
 }
 
-func (db *Database) AddTagToAccount(accountID string, tag int) error {
+func (db *Database) AddTagToAccount(ctx context.Context, accountID string, tag int) error {
 	var exists int
-	err := db.DB.QueryRow(`SELECT EXISTS(SELECT * FROM account_tag where (account_id = ?) AND (tag_id = ?));`, accountID, tag).Scan(&exists)
+	err := db.conn().QueryRowContext(ctx, `SELECT EXISTS(SELECT * FROM account_tag where (account_id = ?) AND (tag_id = ?));`, accountID, tag).Scan(&exists)
 	if err != nil {
 		log.Debug(err)
-		return err
+		return translateError(err)
 	}
 	if exists == 1 {
 		return nil
@@ -438,13 +451,11 @@ func (db *Database) AddTagToAccount(accountID string, tag int) error {
 		INSERT INTO account_tag(account_id, tag_id)
 			VALUES(?,?);
 	`
-	tx, _ := db.DB.Begin()
-	stmt, _ := tx.Prepare(insertTag)
 	log.Debug("Query: " + insertTag)
-	res, err := stmt.Exec(accountID, tag)
+	res, err := db.conn().ExecContext(ctx, insertTag, accountID, tag)
 	if err != nil {
 		log.Debug(err)
-		return err
+		return translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
@@ -459,13 +470,11 @@ func (db *Database) AddTagToAccount(accountID string, tag int) error {
 	}
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
-	tx.Commit()
-
 	return nil
 }
 
-func (db *Database) DeleteTagFromAccount(account, tag string) error {
-	tagID, err := db.FindTag(tag)
+func (db *Database) DeleteTagFromAccount(ctx context.Context, account, tag string) error {
+	tagID, err := db.FindTag(ctx, tag)
 	if err != nil {
 		return err
 	}
@@ -477,31 +486,37 @@ func (db *Database) DeleteTagFromAccount(account, tag string) error {
 	AND
 		account_id = ?
 	;`
-	_, err = db.DB.Exec(sqlStatement, tagID, account)
+	_, err = db.conn().ExecContext(ctx, sqlStatement, tagID, account)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (db *Database) SafeAddTagToTransaction(txnID, tag string) error {
-	err := db.SafeAddTag(tag)
+func (db *Database) SafeAddTagToTransaction(ctx context.Context, txnID, tag string) error {
+	err := db.SafeAddTag(ctx, tag)
 	if err != nil {
 		log.Debug(err)
 		return err
 	}
-	tagID, _ := db.FindTag(tag)
+	tagID, _ := db.FindTag(ctx, tag)
 
-	return db.AddTagToTransaction(txnID, tagID)
+	return db.AddTagToTransaction(ctx, txnID, tagID)
 }
 
-func (db *Database) AddTagToTransaction(txnID string, tag int) error {
+func (db *Database) AddTagToTransaction(ctx context.Context, txnID string, tag int) error {
+	return db.trackBalances(ctx, txnID, func(tx *Database) error {
+		return tx.addTagToTransaction(ctx, txnID, tag)
+	})
+}
+
+func (db *Database) addTagToTransaction(ctx context.Context, txnID string, tag int) error {
 	var exists int
-	err := db.DB.QueryRow(`SELECT EXISTS(SELECT * FROM transaction_tag where (transaction_id = ?) AND (tag_id = ?));`, txnID, tag).Scan(&exists)
+	err := db.conn().QueryRowContext(ctx, `SELECT EXISTS(SELECT * FROM transaction_tag where (transaction_id = ?) AND (tag_id = ?));`, txnID, tag).Scan(&exists)
 	if err != nil {
 		log.Debug(err)
-		return err
+		return translateError(err)
 	}
 	if exists == 1 {
 		return nil
@@ -511,13 +526,11 @@ func (db *Database) AddTagToTransaction(txnID string, tag int) error {
 		INSERT INTO transaction_tag(transaction_id, tag_id)
 			VALUES(?,?);
 	`
-	tx, _ := db.DB.Begin()
-	stmt, _ := tx.Prepare(insertTag)
 	log.Debug("Query: " + insertTag)
-	res, err := stmt.Exec(txnID, tag)
+	res, err := db.conn().ExecContext(ctx, insertTag, txnID, tag)
 	if err != nil {
 		log.Debug(err)
-		return err
+		return translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
@@ -532,13 +545,17 @@ func (db *Database) AddTagToTransaction(txnID string, tag int) error {
 	}
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
-	err = tx.Commit()
+	return nil
+}
 
-	return err
+func (db *Database) DeleteTagFromTransaction(ctx context.Context, txnID, tag string) error {
+	return db.trackBalances(ctx, txnID, func(tx *Database) error {
+		return tx.deleteTagFromTransaction(ctx, txnID, tag)
+	})
 }
 
-func (db *Database) DeleteTagFromTransaction(txnID, tag string) error {
-	tagID, err := db.FindTag(tag)
+func (db *Database) deleteTagFromTransaction(ctx context.Context, txnID, tag string) error {
+	tagID, err := db.FindTag(ctx, tag)
 	if err != nil {
 		return err
 	}
@@ -550,250 +567,307 @@ func (db *Database) DeleteTagFromTransaction(txnID, tag string) error {
 	AND
 		transaction_id = ?
 	;`
-	_, err = db.DB.Exec(sqlStatement, tagID, txnID)
+	_, err = db.conn().ExecContext(ctx, sqlStatement, tagID, txnID)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (db *Database) FindCurrency(cur string) (*core.Currency, error) {
+func (db *Database) FindCurrency(ctx context.Context, cur string) (*core.Currency, error) {
 	var resp core.Currency
 	log.Debug("Searching Currency in DB: ", cur)
-	err := db.DB.QueryRow(`SELECT * FROM currencies WHERE name = ? LIMIT 1`, strings.TrimSpace(cur)).Scan(&resp.Name, &resp.Decimals)
+	err := db.conn().QueryRowContext(ctx, `SELECT * FROM currencies WHERE name = ? LIMIT 1`, strings.TrimSpace(cur)).Scan(&resp.Name, &resp.Decimals)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &resp, nil
 }
 
-func (db *Database) AddCurrency(cur *core.Currency) error {
+func (db *Database) AddCurrency(ctx context.Context, cur *core.Currency) error {
 	log.Debug("Adding Currency to DB")
 	insertCurrency := `
 		INSERT INTO currencies(name,decimals)
 			VALUES(?,?);
 	`
-	tx, _ := db.DB.Begin()
-	stmt, _ := tx.Prepare(insertCurrency)
 	log.Debug("Query: " + insertCurrency)
-	res, err := stmt.Exec(strings.TrimSpace(cur.Name), cur.Decimals)
+	res, err := db.conn().ExecContext(ctx, insertCurrency, strings.TrimSpace(cur.Name), cur.Decimals)
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	rowCnt, err := res.RowsAffected()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
-	tx.Commit()
-
-	return err
+	return nil
 }
 
-func (db *Database) SafeAddCurrency(cur *core.Currency) error {
-	u, _ := db.FindCurrency(cur.Name)
+func (db *Database) SafeAddCurrency(ctx context.Context, cur *core.Currency) error {
+	u, _ := db.FindCurrency(ctx, cur.Name)
 	if u != nil {
 		return nil
 	}
-	return db.AddCurrency(cur)
+	return db.AddCurrency(ctx, cur)
 }
 
-func (db *Database) DeleteCurrency(currency string) error {
+// DeleteCurrency removes a currency that no split is in.
+func (db *Database) DeleteCurrency(ctx context.Context, currency string) error {
 	sqlStatement := `
 	DELETE FROM currencies
-	WHERE name = ?;`
-	_, err := db.DB.Exec(sqlStatement, currency)
+	WHERE name = ?
+	AND NOT EXISTS (SELECT 1 FROM splits WHERE currency = ?);`
+	res, err := db.conn().ExecContext(ctx, sqlStatement, currency, currency)
+	if err != nil {
+		return translateError(err)
+	}
+	deleted, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if deleted == 0 {
+		var splits int
+		err := db.conn().QueryRowContext(ctx, `SELECT COUNT(*) FROM splits WHERE currency = ?`, currency).Scan(&splits)
+		if err != nil {
+			return translateError(err)
+		}
+		if splits > 0 {
+			return fmt.Errorf("%w: currency %s is used by %d splits", dberr.ErrConstraintViolation, currency, splits)
+		}
+	}
 
 	return nil
 }
 
-func (db *Database) FindAccount(code string) (*core.Account, error) {
+func (db *Database) FindAccount(ctx context.Context, code string) (*core.Account, error) {
 	var resp core.Account
 	log.Debug("Searching Account in DB")
-	err := db.DB.QueryRow(`SELECT * FROM accounts WHERE account_id = ? LIMIT 1`, strings.TrimSpace(code)).Scan(&resp.Code, &resp.Name)
+	err := db.conn().QueryRowContext(ctx, `SELECT * FROM accounts WHERE account_id = ? LIMIT 1`, strings.TrimSpace(code)).Scan(&resp.Code, &resp.Name)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &resp, nil
 }
 
-func (db *Database) AddAccount(acc *core.Account) error {
+func (db *Database) AddAccount(ctx context.Context, acc *core.Account) error {
 	log.Debug("Adding Account to DB")
 	insertAccount := `
 		INSERT INTO accounts(account_id, name)
 			VALUES(?,?);
 	`
-	tx, _ := db.DB.Begin()
-	stmt, _ := tx.Prepare(insertAccount)
 	log.Debug("Query: " + insertAccount)
-	res, err := stmt.Exec(strings.TrimSpace(acc.Code), strings.TrimSpace(acc.Name))
+	res, err := db.conn().ExecContext(ctx, insertAccount, strings.TrimSpace(acc.Code), strings.TrimSpace(acc.Name))
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	rowCnt, err := res.RowsAffected()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
-	tx.Commit()
-
-	return err
+	return nil
 }
 
-func (db *Database) SafeAddAccount(acc *core.Account) (bool, error) {
-	u, _ := db.FindAccount(strings.TrimSpace(acc.Code))
+func (db *Database) SafeAddAccount(ctx context.Context, acc *core.Account) (bool, error) {
+	u, _ := db.FindAccount(ctx, strings.TrimSpace(acc.Code))
 	if u != nil {
 		return false, nil
 	}
-	return true, db.AddAccount(acc)
+	return true, db.AddAccount(ctx, acc)
 }
 
-func (db *Database) DeleteAccount(account string) error {
+func (db *Database) DeleteAccount(ctx context.Context, account string) error {
 	sqlStatement := `
 	DELETE FROM accounts
 	WHERE 
 		name = ?
 	;`
-	_, err := db.DB.Exec(sqlStatement, account)
+	_, err := db.conn().ExecContext(ctx, sqlStatement, account)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (db *Database) FindUser(pubKey string) (*core.User, error) {
+func (db *Database) FindUser(ctx context.Context, pubKey string) (*core.User, error) {
 	var resp core.User
 	log.Debug("Searching User in DB")
-	err := db.DB.QueryRow(`SELECT * FROM users WHERE username = ? LIMIT 1`, pubKey).Scan(&resp.Id, &resp.Name)
+	err := db.conn().QueryRowContext(ctx, `SELECT * FROM users WHERE username = ? LIMIT 1`, pubKey).Scan(&resp.Id, &resp.Name)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &resp, nil
 }
 
-func (db *Database) AddUser(usr *core.User) error {
+func (db *Database) AddUser(ctx context.Context, usr *core.User) error {
 	log.Debug("Adding User to DB")
 	insertUser := `
 		INSERT INTO users(user_id, username)
 			VALUES(?,?);
 	`
-	tx, _ := db.DB.Begin()
-	stmt, _ := tx.Prepare(insertUser)
 	log.Debug("Query: " + insertUser)
-	res, err := stmt.Exec(usr.Id, usr.Name)
+	res, err := db.conn().ExecContext(ctx, insertUser, usr.Id, usr.Name)
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	rowCnt, err := res.RowsAffected()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
-	tx.Commit()
-
-	return err
+	return nil
 }
 
-func (db *Database) SafeAddUser(usr *core.User) error {
-	u, _ := db.FindUser(usr.Name)
+func (db *Database) SafeAddUser(ctx context.Context, usr *core.User) error {
+	u, _ := db.FindUser(ctx, usr.Name)
 	if u != nil {
 		return nil
 	}
-	return db.AddUser(usr)
+	return db.AddUser(ctx, usr)
 }
 
-func (db *Database) TestDB() error {
+func (db *Database) TestDB(ctx context.Context) error {
 	log.Debug("Testing DB")
 	createDB := "create table if not exists pages (title text, body blob, timestamp text)"
 	log.Debug("Query: " + createDB)
-	res, err := db.DB.Exec(createDB)
+	res, err := db.conn().ExecContext(ctx, createDB)
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	rowCnt, err := res.RowsAffected()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
-	tx, _ := db.DB.Begin()
+	tx, _ := db.begin(ctx)
 
 	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	stmt, _ := tx.Prepare("insert into pages (title, body, timestamp) values (?, ?, ?)")
+	stmt, _ := tx.PrepareContext(ctx, "insert into pages (title, body, timestamp) values (?, ?, ?)")
 	log.Debug("Query: Insert")
-	res, err = stmt.Exec("Sean", "Body", timestamp)
+	res, err = stmt.ExecContext(ctx, "Sean", "Body", timestamp)
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		tx.Rollback()
+		return translateError(err)
 	}
 
 	lastId, err = res.LastInsertId()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	rowCnt, err = res.RowsAffected()
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return err
 	}
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
-	tx.Commit()
+	return tx.Commit()
+}
 
-	return err
+// GetTB totals the balance summaries of the months before the date and adds
+// the splits of its own month up to the date.
+func (db *Database) GetTB(ctx context.Context, queryDate time.Time) (*[]core.TBAccount, error) {
+	accounts, err := db.trialBalance(ctx, queryDate, "")
+	if err != nil {
+		return nil, err
+	}
+	return &accounts, nil
 }
 
-func (db *Database) GetTB(queryDate time.Time) (*[]core.TBAccount, error) {
+// GetAccountBalance is the trial balance of a single account, reading only
+// its own summaries and splits.
+func (db *Database) GetAccountBalance(ctx context.Context, account string, queryDate time.Time) ([]core.TBAccount, error) {
+	return db.trialBalance(ctx, queryDate, account)
+}
+
+// trialBalance returns the lines of the trial balance at the date, only those
+// of the account unless it is blank.
+func (db *Database) trialBalance(ctx context.Context, queryDate time.Time, account string) ([]core.TBAccount, error) {
+	period := tbPeriod(queryDate)
+	balanceFilter, splitFilter := "", ""
+	args := []interface{}{period, period, queryDate}
+	if account != "" {
+		balanceFilter = " AND account_id = ?"
+		splitFilter = " AND split_accounts.account_id = ?"
+		args = []interface{}{period, account, period, queryDate, account}
+	}
+
 	queryDB := `
-		SELECT split_accounts.account_id,
-					 Sum(splits.amount),
-					 splits.currency,
+		SELECT balances.account_id,
+					 Sum(balances.amount),
+					 balances.currency,
 					 currencies.decimals
-		FROM   splits
-					 JOIN split_accounts
-						 ON splits.split_id = split_accounts.split_id
+		FROM   (SELECT account_id,
+									 currency,
+									 amount
+						FROM   account_balances
+						WHERE  period < ?` + balanceFilter + `
+						UNION ALL
+						SELECT split_accounts.account_id,
+									 splits.currency,
+									 splits.amount
+						FROM   splits
+									 JOIN split_accounts
+										 ON splits.split_id = split_accounts.split_id
+						WHERE  splits.split_date >= ?
+									 AND splits.split_date <= ?` + splitFilter + `
+									 AND splits.transaction_id NOT IN (SELECT transaction_id
+																										 FROM   trashed_transactions)
+									 AND splits.transaction_id NOT IN (SELECT tt.transaction_id
+																										 FROM   transaction_tag AS tt
+																														JOIN tags AS t
+																															ON tt.tag_id = t.tag_id
+																										 WHERE  Lower(t.tag_name) = 'void')) AS balances
 					 JOIN currencies
-						 ON splits.currency = currencies.name
-		WHERE  splits.split_date <= ?
-					 AND "void" NOT IN (SELECT t.tag_name
-															FROM   tags AS t
-																		 JOIN transaction_tag AS tt
-																			 ON tt.tag_id = t.tag_id
-															WHERE  tt.transaction_id = splits.transaction_id)
-		GROUP  BY split_accounts.account_id, splits.currency
+						 ON balances.currency = currencies.name
+		GROUP  BY balances.account_id, balances.currency
 		;`
 
 	log.Debug("Querying Database for Trial Balance")
 
-	rows, err := db.DB.Query(queryDB, queryDate)
+	rows, err := db.conn().QueryContext(ctx, queryDB, args...)
 	if err != nil {
-		log.Fatal("Trial Balance Query Failed with error: ", err)
+		return nil, translateError(err)
 	}
 	defer rows.Close()
 
@@ -801,13 +875,16 @@ func (db *Database) GetTB(queryDate time.Time) (*[]core.TBAccount, error) {
 
 	for rows.Next() {
 		var t core.TBAccount
-		if err := rows.Scan(&t.Account, &t.Amount, &t.Currency, &t.Decimals); err != nil {
-			log.Fatal(err)
+		if err := rows.Scan(&t.Account, dberr.ScanAmount(&t.Amount), &t.Currency, &t.Decimals); err != nil {
+			return nil, err
+		}
+		if err := checkTotal(t.Amount); err != nil {
+			return nil, fmt.Errorf("Trial balance of %s in %s: %w", t.Account, t.Currency, err)
 		}
 		accounts = append(accounts, t)
 	}
-	if rows.Err() != nil {
-		log.Fatal(err)
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	tagsQuery := `
@@ -821,32 +898,32 @@ func (db *Database) GetTB(queryDate time.Time) (*[]core.TBAccount, error) {
 		`
 
 	for index, element := range accounts {
-		rows, err = db.DB.Query(tagsQuery, element.Account)
+		rows, err = db.conn().QueryContext(ctx, tagsQuery, element.Account)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		for rows.Next() {
 			var tag string
 			if err := rows.Scan(&tag); err != nil {
-				log.Fatal(err)
+				return nil, err
 			}
 			accounts[index].Tags = append(accounts[index].Tags, tag)
 		}
 	}
 
-	return &accounts, nil
+	return accounts, nil
 }
 
-func (db *Database) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return db.DB.Query(query, args...)
+func (db *Database) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.conn().QueryContext(ctx, query, args...)
 }
 
-func (db *Database) ReconcileTransactions(reconciliationID string, splitIDs []string) (string, error) {
-	tx, err := db.DB.Begin()
+func (db *Database) ReconcileTransactions(ctx context.Context, reconciliationID string, splitIDs []string) (string, error) {
+	tx, err := db.begin(ctx)
 
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
 		return "", err
 	}
 
@@ -859,40 +936,31 @@ func (db *Database) ReconcileTransactions(reconciliationID string, splitIDs []st
 	}
 
 	sqlStr = strings.TrimSuffix(sqlStr, ",")
-	stmt, err := tx.Prepare(sqlStr)
+	stmt, err := tx.PrepareContext(ctx, sqlStr)
 	log.Debug("Query: " + sqlStr)
 	log.Debugf("NumberVals = %d", len(vals))
 	log.Debug("Adding Reconciliation to DB")
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
 	var res sql.Result
-	res, err = stmt.Exec(vals...)
+	res, err = stmt.ExecContext(ctx, vals...)
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
-		return "", err
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
 	}
 
 	lastId, err := res.LastInsertId()
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
@@ -900,107 +968,466 @@ func (db *Database) ReconcileTransactions(reconciliationID string, splitIDs []st
 	log.Debugf("ID = %d, affected = %d\n", lastId, rowCnt)
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
+		log.Error(err)
+		tx.Rollback()
 		return "", err
 	}
 
 	err = tx.Commit()
 
 	if err != nil {
-		log.Fatal(err)
-		err = tx.Rollback()
-		if err != nil {
-			log.Fatal(err)
-		}
-		return "", err
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
 	}
 
-	return reconciliationID, err
+	return reconciliationID, nil
 }
 
-func (db *Database) GetListing(startDate, endDate time.Time) (*[]core.Transaction, error) {
-	var txns []core.Transaction
+// listingQuery builds the single query behind GetListing. The derived table
+// picks the page of transactions matching the filter and the outer select
+// joins the splits of each that fall within the date range.
+func listingQuery(filter core.ListingFilter) (string, []interface{}) {
+	startDate := filter.StartDate.Format("2006-01-02")
+	endDate := filter.EndDate.Format("2006-01-02")
+
+	inRange := `EXISTS (
+				SELECT 1 FROM splits AS fs
+					JOIN split_accounts AS fsa ON fs.split_id = fsa.split_id
+				WHERE fs.transaction_id = t.transaction_id
+				AND fs.split_date BETWEEN ? AND ?`
+	args := []interface{}{startDate, endDate}
+	if len(filter.Accounts) > 0 {
+		inRange += " AND fsa.account_id IN " + questionMarks(len(filter.Accounts))
+		for _, account := range filter.Accounts {
+			args = append(args, strings.TrimSpace(account))
+		}
+	}
+	where := []string{
+		"t.transaction_id NOT IN (SELECT transaction_id FROM trashed_transactions)",
+		inRange + ")",
+	}
+	if len(filter.Tags) > 0 {
+		where = append(where, `EXISTS (
+				SELECT 1 FROM transaction_tag AS tt
+					JOIN tags AS g ON tt.tag_id = g.tag_id
+				WHERE tt.transaction_id = t.transaction_id
+				AND g.tag_name IN `+questionMarks(len(filter.Tags))+")")
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+	}
+	if filter.After != "" {
+		where = append(where, "t.transaction_id > ?")
+		args = append(args, filter.After)
+	}
+	page := "SELECT t.transaction_id FROM transactions AS t WHERE " + strings.Join(where, " AND ") + " ORDER BY t.transaction_id"
+	if filter.Limit > 0 {
+		page += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	query := `
+		SELECT t.transaction_id,
+					 t.postdate,
+					 t.description,
+					 u.user_id,
+					 u.username,
+					 s.split_id,
+					 s.split_date,
+					 s.description,
+					 a.account_id,
+					 a.name,
+					 s.currency,
+					 c.decimals,
+					 s.amount
+		FROM   (` + page + `) AS page
+					 JOIN transactions AS t
+						 ON t.transaction_id = page.transaction_id
+					 JOIN users AS u
+						 ON t.poster_user_id = u.user_id
+					 JOIN splits AS s
+						 ON s.transaction_id = t.transaction_id
+					 JOIN split_accounts AS sa
+						 ON s.split_id = sa.split_id
+					 JOIN accounts AS a
+						 ON sa.account_id = a.account_id
+					 JOIN currencies AS c
+						 ON s.currency = c.name
+		WHERE  s.split_date BETWEEN ? AND ?
+		ORDER  BY t.transaction_id, s.split_id;`
+	args = append(args, startDate, endDate)
+
+	return query, args
+}
 
-	log.Debugf("Searching Transactions in DB between %s & %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+func questionMarks(count int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", count), ", ") + ")"
+}
 
-	// Find the transaction bodys
-	rows, err := db.DB.Query(`
-		SELECT
-        t.transaction_id
-        ,t.postdate
-        ,t.description
-        ,u.user_id
-        ,u.username
-    FROM
-        transactions AS t JOIN users AS u
-            ON t.poster_user_id = u.user_id
-			;`)
+func (db *Database) GetListing(ctx context.Context, filter core.ListingFilter) (*[]core.Transaction, error) {
+	log.Debugf("Searching Transactions in DB between %s & %s", filter.StartDate.Format("2006-01-02"), filter.EndDate.Format("2006-01-02"))
 
+	query, args := listingQuery(filter)
+	log.Debug("Query: " + query)
+	rows, err := db.conn().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
+	txns, err := scanListing(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txns, nil
+}
+
+// scanListing reads the rows of a listing query into transactions holding the
+// splits returned for each.
+func scanListing(rows *sql.Rows) ([]core.Transaction, error) {
+	txns := []core.Transaction{}
+	var t *core.Transaction
+	var split *core.Split
 	for rows.Next() {
-		var t core.Transaction
+		var txnID, splitID string
+		var postdate time.Time
+		var description []byte
 		var poster core.User
+		var date time.Time
+		var splitDescription []byte
+		var account core.Account
+		var cur core.Currency
+		var amount *big.Int
+		err := rows.Scan(&txnID, &postdate, &description, &poster.Id, &poster.Name,
+			&splitID, &date, &splitDescription, &account.Code, &account.Name, &cur.Name, &cur.Decimals, dberr.ScanAmount(&amount))
+		if err != nil {
+			return nil, err
+		}
 
-		if err := rows.Scan(&t.Id, &t.Postdate, &t.Description, &poster.Id, &poster.Name); err != nil {
-			log.Fatal(err)
+		// Rows arrive grouped by transaction and split, a split posting to
+		// several accounts is returned once for each
+		if t == nil || t.Id != txnID {
+			txns = append(txns, core.Transaction{Id: txnID, Postdate: postdate, Description: description, Poster: &poster})
+			t = &txns[len(txns)-1]
+			split = nil
+		}
+		if split == nil || split.Id != splitID {
+			split = &core.Split{Id: splitID, Date: date, Description: splitDescription, Currency: &cur, Amount: amount}
+			t.Splits = append(t.Splits, split)
 		}
+		split.Accounts = append(split.Accounts, &account)
+	}
+	return txns, rows.Err()
+}
+
+func (db *Database) AddAllocationRule(ctx context.Context, rule *core.AllocationRule) error {
+	log.Debug("Adding Allocation Rule to DB")
+	tx, err := db.begin(ctx)
+	if err != nil {
+		return err
+	}
 
-		// Find all splits relating to that transaction
-		splits, err := db.Query(`
-				SELECT s.split_id,
-							 s.split_date,
-							 s.description,
-							 a.account_id,
-							 a.NAME,
-							 s.currency,
-							 c.decimals,
-							 s.amount
-				FROM   splits AS s
-							 JOIN split_accounts AS sa
-								 ON s.split_id = sa.split_id
-							 JOIN accounts AS a
-								 ON sa.account_id = a. account_id
-							 JOIN currencies AS c
-								 ON s.currency = c.NAME
-				WHERE  s.transaction_id = ?
-        AND    s.split_date BETWEEN ? AND ?
-				;`,
-			t.Id,
-			startDate.Format("2006-01-02"),
-			endDate.Format("2006-01-02"))
+	insertRule := `
+		INSERT INTO allocation_rules(rule_name)
+			VALUES(?);
+	`
+	log.Debug("Query: " + insertRule)
+	_, err = tx.ExecContext(ctx, insertRule, strings.TrimSpace(rule.Name))
+	if err != nil {
+		log.Debug(err)
+		tx.Rollback()
+		return translateError(err)
+	}
+
+	sqlStr := "INSERT INTO allocation_targets(rule_name, position, account_id, weight) VALUES "
+	vals := []interface{}{}
+	for i, target := range rule.Targets {
+		sqlStr += "(?, ?, ?, ?),"
+		vals = append(vals, strings.TrimSpace(rule.Name), i, strings.TrimSpace(target.Account.Code), target.Weight)
+	}
+	sqlStr = strings.TrimSuffix(sqlStr, ",")
+	log.Debug("Query: " + sqlStr)
+	_, err = tx.ExecContext(ctx, sqlStr, vals...)
+	if err != nil {
+		log.Debug(err)
+		tx.Rollback()
+		return translateError(err)
+	}
+
+	return tx.Commit()
+}
+
+func (db *Database) FindAllocationRule(ctx context.Context, name string) (*core.AllocationRule, error) {
+	log.Debugf("Searching Allocation Rule in DB: %s", name)
+	rule, err := core.NewAllocationRule(strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn().QueryContext(ctx, `
+			SELECT at.account_id,
+						 a.name,
+						 at.weight
+			FROM   allocation_targets AS at
+						 LEFT JOIN accounts AS a
+							 ON at.account_id = a.account_id
+			WHERE  at.rule_name = ?
+			ORDER  BY at.position
+			`, rule.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code string
+		var accountName sql.NullString
+		var weight int64
+		if err := rows.Scan(&code, &accountName, &weight); err != nil {
+			return nil, err
+		}
+		if !accountName.Valid {
+			accountName.String = code
+		}
+		acc, err := core.NewAccount(code, accountName.String)
 		if err != nil {
 			return nil, err
 		}
+		if err := rule.AppendTarget(acc, weight); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(rule.Targets) == 0 {
+		return nil, dberr.ErrNotFound
+	}
 
-		for splits.Next() {
-			var split core.Split
-			var account core.Account
-			var cur core.Currency
-			var amount int64
-			// for each row, scan the result into our split object
-			err = splits.Scan(&split.Id, &split.Date, &split.Description, &account.Code, &account.Name, &cur.Name, &cur.Decimals, &amount)
-			if err != nil {
-				return nil, err
-			}
-			split.Amount = big.NewInt(amount)
-			split.Accounts = append(split.Accounts, &account)
-			split.Currency = &cur
-			t.Splits = append(t.Splits, &split)
+	return rule, nil
+}
+
+func (db *Database) DeleteAllocationRule(ctx context.Context, name string) error {
+	sqlStatement := `
+	DELETE FROM allocation_rules
+	WHERE rule_name = ?;`
+	_, err := db.conn().ExecContext(ctx, sqlStatement, strings.TrimSpace(name))
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) AddPostingRule(ctx context.Context, rule *core.PostingRule) error {
+	log.Debug("Adding Posting Rule to DB")
+	if err := checkAmount(rule.Amount); err != nil {
+		return err
+	}
+	insertRule := `
+		INSERT INTO posting_rules(rule_name, rule_type, account, tag, currency, amount)
+			VALUES(?,?,?,?,?,?);
+	`
+	log.Debug("Query: " + insertRule)
+	_, err := db.conn().ExecContext(ctx, insertRule, strings.TrimSpace(rule.Name), rule.Type, rule.Account, rule.Tag, rule.Currency, dberr.AmountValue(rule.Amount))
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) GetPostingRules(ctx context.Context) ([]*core.PostingRule, error) {
+	log.Debug("Querying Database for Posting Rules")
+	rows, err := db.conn().QueryContext(ctx, `
+			SELECT rule_name,
+						 rule_type,
+						 account,
+						 tag,
+						 currency,
+						 amount
+			FROM   posting_rules
+			ORDER  BY rule_name
+			`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*core.PostingRule{}
+	for rows.Next() {
+		var rule core.PostingRule
+		if err := rows.Scan(&rule.Name, &rule.Type, &rule.Account, &rule.Tag, &rule.Currency, dberr.ScanAmount(&rule.Amount)); err != nil {
+			return nil, err
 		}
-		if len(t.Splits) > 0 {
-			txns = append(txns, t)
+		rules = append(rules, &rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (db *Database) DeletePostingRule(ctx context.Context, name string) error {
+	sqlStatement := `
+	DELETE FROM posting_rules
+	WHERE rule_name = ?;`
+	_, err := db.conn().ExecContext(ctx, sqlStatement, strings.TrimSpace(name))
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) FindAccountTags(ctx context.Context, account string) ([]string, error) {
+	log.Debugf("Querying Database for Tags on Account: %s", account)
+	rows, err := db.conn().QueryContext(ctx, `
+			SELECT tag_name
+			FROM   tags
+						 JOIN account_tag
+							 ON account_tag.tag_id = tags.tag_id
+						 JOIN accounts
+							 ON accounts.account_id = account_tag.account_id
+			WHERE  accounts.name = ?
+			`, strings.TrimSpace(account))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
 		}
+		tags = append(tags, tag)
 	}
-	if rows.Err() != nil {
-		log.Fatal(err)
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return &txns, nil
+	return tags, nil
+}
+
+// ListTrash returns every transaction currently in the trash.
+func (db *Database) ListTrash(ctx context.Context) ([]core.TrashedTransaction, error) {
+	log.Debug("Searching Trashed Transactions in DB")
+	rows, err := db.conn().QueryContext(ctx, `
+			SELECT t.transaction_id,
+						 t.postdate,
+						 t.description,
+						 u.user_id,
+						 u.username,
+						 tt.trashed_at
+			FROM   transactions AS t
+						 JOIN trashed_transactions AS tt
+							 ON t.transaction_id = tt.transaction_id
+						 JOIN users AS u
+							 ON t.poster_user_id = u.user_id
+			ORDER  BY tt.trashed_at
+			`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trash := []core.TrashedTransaction{}
+	for rows.Next() {
+		var t core.TrashedTransaction
+		var poster core.User
+		if err := rows.Scan(&t.Id, &t.Postdate, &t.Description, &poster.Id, &poster.Name, &t.TrashedAt); err != nil {
+			return nil, err
+		}
+		t.Poster = &poster
+		trash = append(trash, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return trash, nil
+}
+
+// IsTrashed reports whether a transaction is in the trash.
+func (db *Database) IsTrashed(ctx context.Context, txnID string) (bool, error) {
+	var trashed bool
+	err := db.conn().QueryRowContext(ctx, `SELECT EXISTS(SELECT * FROM trashed_transactions WHERE transaction_id = ?);`, txnID).Scan(&trashed)
+	if err != nil {
+		return false, translateError(err)
+	}
+	return trashed, nil
+}
+
+// RestoreTransaction takes a transaction back out of the trash.
+func (db *Database) RestoreTransaction(ctx context.Context, txnID string) error {
+	return db.trackBalances(ctx, txnID, func(tx *Database) error {
+		return tx.restoreTransaction(ctx, txnID)
+	})
+}
+
+func (db *Database) restoreTransaction(ctx context.Context, txnID string) error {
+	sqlStatement := `
+	DELETE FROM trashed_transactions
+	WHERE transaction_id = ?;`
+	res, err := db.conn().ExecContext(ctx, sqlStatement, txnID)
+	if err != nil {
+		return translateError(err)
+	}
+	rowCnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowCnt == 0 {
+		return dberr.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently deletes the transactions that were moved into the
+// trash before the given time and returns how many were removed.
+func (db *Database) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	tx, err := db.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT transaction_id FROM trashed_transactions WHERE trashed_at < ?`, before.UTC())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	txnIDs := []string{}
+	for rows.Next() {
+		var txnID string
+		if err := rows.Scan(&txnID); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, err
+		}
+		txnIDs = append(txnIDs, txnID)
+	}
+	rows.Close()
+
+	for _, txnID := range txnIDs {
+		log.Debugf("Purging Transaction: %s", txnID)
+		_, err = tx.ExecContext(ctx, `DELETE FROM transaction_tag WHERE transaction_id = ?`, txnID)
+		if err != nil {
+			tx.Rollback()
+			return 0, translateError(err)
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM transactions WHERE transaction_id = ?`, txnID)
+		if err != nil {
+			tx.Rollback()
+			return 0, translateError(err)
+		}
+	}
+
+	return len(txnIDs), tx.Commit()
 }
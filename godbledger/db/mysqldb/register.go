@@ -0,0 +1,63 @@
+package mysqldb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func (db *Database) GetAccountSplits(ctx context.Context, account string, start, end time.Time) ([]core.AccountSplit, error) {
+	log.Debugf("Querying Database for Splits of Account: %s", account)
+	query := `
+		SELECT s.transaction_id,
+					 s.split_id,
+					 s.split_date,
+					 s.description,
+					 s.currency,
+					 c.decimals,
+					 s.amount
+		FROM   splits AS s
+					 JOIN split_accounts AS sa
+						 ON s.split_id = sa.split_id
+					 JOIN currencies AS c
+						 ON s.currency = c.name
+		WHERE  sa.account_id = ?
+					 AND s.transaction_id NOT IN (SELECT transaction_id
+																				FROM   trashed_transactions)
+					 AND s.transaction_id NOT IN (SELECT tt.transaction_id
+																				FROM   transaction_tag AS tt
+																							 JOIN tags AS t
+																								 ON tt.tag_id = t.tag_id
+																				WHERE  Lower(t.tag_name) = 'void')`
+	args := []interface{}{account}
+	if !start.IsZero() {
+		query += " AND s.split_date >= ?"
+		args = append(args, start.Format("2006-01-02"))
+	}
+	if !end.IsZero() {
+		query += " AND s.split_date < ?"
+		args = append(args, end.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+	query += " ORDER BY s.split_date, s.transaction_id, s.split_id;"
+
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	splits := []core.AccountSplit{}
+	for rows.Next() {
+		var split core.AccountSplit
+		var description sql.NullString
+		if err := rows.Scan(&split.Transaction, &split.Split, &split.Date, &description, &split.Currency, &split.Decimals, dberr.ScanAmount(&split.Amount)); err != nil {
+			return nil, err
+		}
+		split.Description = description.String
+		splits = append(splits, split)
+	}
+	return splits, rows.Err()
+}
@@ -0,0 +1,113 @@
+package mysqldb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+// searchQuery builds the query behind SearchTransactions. Like the listing
+// query the derived table picks the page of matching transactions, and the
+// outer select joins all of their splits.
+func searchQuery(filter core.SearchFilter) (string, []interface{}) {
+	terms := core.SearchTerms(filter.Query)
+	where := []string{"t.transaction_id NOT IN (SELECT transaction_id FROM trashed_transactions)"}
+	args := []interface{}{}
+	// Each term must start a word of one of the full text indexes, which
+	// leaves out the words shorter than innodb_ft_min_token_size and the
+	// stopwords
+	for _, term := range terms {
+		where = append(where, `(MATCH (t.description) AGAINST (? IN BOOLEAN MODE)
+				OR EXISTS (SELECT 1 FROM transactions_body AS fb WHERE fb.transaction_id = t.transaction_id AND MATCH (fb.body) AGAINST (? IN BOOLEAN MODE))
+				OR EXISTS (SELECT 1 FROM splits AS fd WHERE fd.transaction_id = t.transaction_id AND MATCH (fd.description) AGAINST (? IN BOOLEAN MODE)))`)
+		match := term + "*"
+		args = append(args, match, match, match)
+	}
+
+	splitFilter := []string{}
+	if !filter.StartDate.IsZero() {
+		splitFilter = append(splitFilter, "fs.split_date >= ?")
+		args = append(args, filter.StartDate.Format("2006-01-02"))
+	}
+	if !filter.EndDate.IsZero() {
+		splitFilter = append(splitFilter, "fs.split_date < ?")
+		args = append(args, filter.EndDate.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+	if filter.MinAmount != nil {
+		splitFilter = append(splitFilter, "ABS(fs.amount) >= CAST(? AS DECIMAL(65,0))")
+		args = append(args, filter.MinAmount.String())
+	}
+	if filter.MaxAmount != nil {
+		splitFilter = append(splitFilter, "ABS(fs.amount) <= CAST(? AS DECIMAL(65,0))")
+		args = append(args, filter.MaxAmount.String())
+	}
+	if len(splitFilter) > 0 {
+		where = append(where, `EXISTS (
+				SELECT 1 FROM splits AS fs
+				WHERE fs.transaction_id = t.transaction_id
+				AND `+strings.Join(splitFilter, " AND ")+")")
+	}
+
+	if filter.After != "" {
+		where = append(where, "t.transaction_id > ?")
+		args = append(args, filter.After)
+	}
+	page := "SELECT t.transaction_id FROM transactions AS t WHERE " + strings.Join(where, " AND ") + " ORDER BY t.transaction_id"
+	if filter.Limit > 0 {
+		page += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	query := `
+		SELECT t.transaction_id,
+					 t.postdate,
+					 COALESCE(b.body, t.description),
+					 u.user_id,
+					 u.username,
+					 s.split_id,
+					 s.split_date,
+					 s.description,
+					 a.account_id,
+					 a.name,
+					 s.currency,
+					 c.decimals,
+					 s.amount
+		FROM   (` + page + `) AS page
+					 JOIN transactions AS t
+						 ON t.transaction_id = page.transaction_id
+					 JOIN users AS u
+						 ON t.poster_user_id = u.user_id
+					 LEFT JOIN transactions_body AS b
+						 ON b.transaction_id = t.transaction_id
+					 JOIN splits AS s
+						 ON s.transaction_id = t.transaction_id
+					 JOIN split_accounts AS sa
+						 ON s.split_id = sa.split_id
+					 JOIN accounts AS a
+						 ON sa.account_id = a.account_id
+					 JOIN currencies AS c
+						 ON s.currency = c.name
+		ORDER  BY t.transaction_id, s.split_id;`
+
+	return query, args
+}
+
+func (db *Database) SearchTransactions(ctx context.Context, filter core.SearchFilter) (*[]core.Transaction, error) {
+	log.Debugf("Searching Transactions in DB for %q", filter.Query)
+
+	query, args := searchQuery(filter)
+	log.Debug("Query: " + query)
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	txns, err := scanListing(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txns, nil
+}
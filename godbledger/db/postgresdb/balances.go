@@ -0,0 +1,167 @@
+package postgresdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+// The account_balances table holds the total of the splits posted to each
+// account, in each currency, for each month. It only counts transactions that
+// are neither void nor in the trash so GetTB can read the months before the
+// requested date from it and sum the splits of the last month alone.
+
+// periodOf is the first day of the month of a split date.
+const periodOf = `CAST(date_trunc('month', splits.split_date) AS DATE)`
+
+// summariseSplits totals the splits of counted transactions by account,
+// currency and month.
+const summariseSplits = `
+	SELECT split_accounts.account_id,
+				 splits.currency,
+				 ` + periodOf + `,
+				 Sum(splits.amount),
+				 Count(*)
+	FROM   splits
+				 JOIN split_accounts
+					 ON splits.split_id = split_accounts.split_id
+	WHERE  splits.transaction_id NOT IN (SELECT transaction_id
+																			 FROM   trashed_transactions)
+				 AND splits.transaction_id NOT IN (SELECT tt.transaction_id
+																					 FROM   transaction_tag AS tt
+																									JOIN tags AS t
+																										ON tt.tag_id = t.tag_id
+																					 WHERE  Lower(t.tag_name) = 'void')
+	GROUP  BY split_accounts.account_id, splits.currency, ` + periodOf
+
+// applyBalances adds the splits of a transaction to the balance summaries,
+// or takes them away when sign is -1.
+func applyBalances(ctx context.Context, conn execer, txnID string, sign int) error {
+	upsert := `
+		INSERT INTO account_balances(account_id, currency, period, amount, split_count)
+		SELECT split_accounts.account_id,
+					 splits.currency,
+					 ` + periodOf + `,
+					 Sum(splits.amount) * $1,
+					 Count(*) * $2
+		FROM   splits
+					 JOIN split_accounts
+						 ON splits.split_id = split_accounts.split_id
+		WHERE  splits.transaction_id = $3
+		GROUP  BY split_accounts.account_id, splits.currency, ` + periodOf + `
+		ON CONFLICT (account_id, currency, period) DO UPDATE SET
+			amount = account_balances.amount + EXCLUDED.amount,
+			split_count = account_balances.split_count + EXCLUDED.split_count;`
+	log.Debug("Query: " + upsert)
+	if _, err := conn.ExecContext(ctx, upsert, sign, sign, txnID); err != nil {
+		return translateError(err)
+	}
+	_, err := conn.ExecContext(ctx, `DELETE FROM account_balances WHERE split_count = 0;`)
+	return translateError(err)
+}
+
+// counted reports whether the transaction exists and is included in the
+// balance summaries.
+func counted(ctx context.Context, conn execer, txnID string) (bool, error) {
+	var included bool
+	err := conn.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT * FROM transactions
+									WHERE transaction_id = $1
+										AND transaction_id NOT IN (SELECT transaction_id FROM trashed_transactions)
+										AND transaction_id NOT IN (SELECT tt.transaction_id
+																							 FROM   transaction_tag AS tt
+																											JOIN tags AS t
+																												ON tt.tag_id = t.tag_id
+																							 WHERE  Lower(t.tag_name) = 'void'));`, txnID).Scan(&included)
+	if err != nil {
+		return false, translateError(err)
+	}
+	return included, nil
+}
+
+// trackBalances runs a change to a transaction in a unit of work and adds it
+// to, or takes it out of, the balance summaries when the change voids,
+// trashes or restores it.
+func (db *Database) trackBalances(ctx context.Context, txnID string, change func(tx *Database) error) error {
+	return db.UnitOfWork(ctx, func(unit dberr.Database) error {
+		tx := unit.(*Database)
+		before, err := counted(ctx, tx.conn(), txnID)
+		if err != nil {
+			return err
+		}
+		if err := change(tx); err != nil {
+			return err
+		}
+		after, err := counted(ctx, tx.conn(), txnID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case after && !before:
+			return applyBalances(ctx, tx.conn(), txnID, 1)
+		case before && !after:
+			return applyBalances(ctx, tx.conn(), txnID, -1)
+		}
+		return nil
+	})
+}
+
+// RebuildBalances replaces the balance summaries with totals calculated from
+// the splits.
+func (db *Database) RebuildBalances(ctx context.Context) error {
+	log.Debug("Rebuilding Account Balances")
+	return db.UnitOfWork(ctx, func(unit dberr.Database) error {
+		tx := unit.(*Database)
+		if _, err := tx.conn().ExecContext(ctx, `DELETE FROM account_balances;`); err != nil {
+			return translateError(err)
+		}
+		insert := `INSERT INTO account_balances(account_id, currency, period, amount, split_count) ` + summariseSplits + `;`
+		_, err := tx.conn().ExecContext(ctx, insert)
+		return translateError(err)
+	})
+}
+
+// VerifyBalances compares the balance summaries with totals calculated from
+// the splits and returns those that differ.
+func (db *Database) VerifyBalances(ctx context.Context) ([]core.BalanceDiscrepancy, error) {
+	log.Debug("Verifying Account Balances")
+	stored, err := db.balanceTotals(ctx, `SELECT account_id, currency, period, amount, split_count FROM account_balances;`)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := db.balanceTotals(ctx, summariseSplits+`;`)
+	if err != nil {
+		return nil, err
+	}
+	return dberr.CompareBalances(stored, actual), nil
+}
+
+func (db *Database) balanceTotals(ctx context.Context, query string) (map[dberr.BalanceKey]dberr.BalanceTotal, error) {
+	rows, err := db.conn().QueryContext(ctx, query)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	totals := make(map[dberr.BalanceKey]dberr.BalanceTotal)
+	for rows.Next() {
+		var key dberr.BalanceKey
+		var period string
+		var total dberr.BalanceTotal
+		if err := rows.Scan(&key.Account, &key.Currency, &period, dberr.ScanAmount(&total.Amount), &total.Splits); err != nil {
+			return nil, err
+		}
+		if key.Period, err = dberr.ParsePeriod(period); err != nil {
+			return nil, err
+		}
+		totals[key] = total
+	}
+	return totals, rows.Err()
+}
+
+// tbPeriod is the first day of the month of the trial balance date.
+func tbPeriod(date time.Time) string {
+	return date.Format("2006-01") + "-01"
+}
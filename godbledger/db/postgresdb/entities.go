@@ -0,0 +1,58 @@
+package postgresdb
+
+import (
+	"context"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func (db *Database) AddEntity(ctx context.Context, entity *core.Entity) error {
+	log.WithField("entity", entity.Id).Debug("Adding Entity to DB")
+	_, err := db.conn().ExecContext(ctx, `INSERT INTO entities(entity_id, name, description) VALUES($1, $2, $3)`, entity.Id, entity.Name, entity.Description)
+	return translateError(err)
+}
+
+func (db *Database) FindEntity(ctx context.Context, id string) (*core.Entity, error) {
+	var entity core.Entity
+	err := db.conn().QueryRowContext(ctx, `SELECT entity_id, name, COALESCE(description, '') FROM entities WHERE entity_id = $1`, id).Scan(&entity.Id, &entity.Name, &entity.Description)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &entity, nil
+}
+
+func (db *Database) ListEntities(ctx context.Context) ([]*core.Entity, error) {
+	rows, err := db.conn().QueryContext(ctx, `SELECT entity_id, name, COALESCE(description, '') FROM entities ORDER BY entity_id`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	entities := []*core.Entity{}
+	for rows.Next() {
+		var entity core.Entity
+		if err := rows.Scan(&entity.Id, &entity.Name, &entity.Description); err != nil {
+			return nil, err
+		}
+		entities = append(entities, &entity)
+	}
+	return entities, rows.Err()
+}
+
+// OpenEntity opens the ledger of an entity, kept in a schema of its own that
+// is put on the search path of its connections in place of public.
+func (db *Database) OpenEntity(ctx context.Context, id string) (dberr.Database, error) {
+	validated, err := ValidateConnectionString(db.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	// Entity identifiers are limited to characters that need no quoting
+	schema := "entity_" + id
+
+	log.WithField("schema", schema).Debug("Creating Entity Schema")
+	if _, err := db.DB.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+schema); err != nil {
+		return nil, translateError(err)
+	}
+	return NewDB(validated + " search_path=" + schema)
+}
@@ -0,0 +1,33 @@
+package postgresdb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/darcys22/godbledger/godbledger/db"
+)
+
+// translateError converts PostgreSQL errors into the errors defined by the db
+// package, anything it does not recognise is returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("%w: %v", db.ErrAlreadyExists, err)
+		}
+		// Class 23 covers every integrity constraint violation
+		if pqErr.Code.Class() == "23" {
+			return fmt.Errorf("%w: %v", db.ErrConstraintViolation, err)
+		}
+	}
+	return err
+}
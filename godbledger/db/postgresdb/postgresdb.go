@@ -0,0 +1,132 @@
+package postgresdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/lib/pq"
+)
+
+var log = logrus.WithField("prefix", "PostgreSQL")
+var urlPasswordRegex = regexp.MustCompile(`\:([^:@/]+?)\@`)
+var keywordPasswordRegex = regexp.MustCompile(`password=('[^']*'|\S+)`)
+
+type Database struct {
+	DB               *sql.DB
+	ConnectionString string
+
+	// tx is set on the copy of the database handed to a unit of work, depth
+	// counts the savepoints opened inside it
+	tx    *sql.Tx
+	depth int
+}
+
+// Close closes the underlying database.
+func (db *Database) Close() error {
+	return db.DB.Close()
+}
+
+// ValidateConnectionString accepts either a postgres:// URL or a keyword/value
+// connection string and returns it in the keyword/value form used by lib/pq.
+func ValidateConnectionString(dsn string) (string, error) {
+	if dsn == "" {
+		return "", errors.New("Connection string not provided")
+	}
+
+	dsnString := strings.TrimSpace(dsn)
+	if strings.HasPrefix(dsnString, "postgres://") || strings.HasPrefix(dsnString, "postgresql://") {
+		parsed, err := pq.ParseURL(dsnString)
+		if err != nil {
+			log.Warnf("Connection string could not be parsed: %s", err.Error())
+			return "", err
+		}
+		dsnString = parsed
+	}
+
+	if !strings.Contains(dsnString, "sslmode=") {
+		dsnString = dsnString + " sslmode=disable"
+	}
+
+	log.Debugf("DSN := %s", redactPassword(dsnString))
+
+	return dsnString, nil
+}
+
+func redactPassword(rawDSNString string) string {
+	cleanedDSNString := urlPasswordRegex.ReplaceAllString(rawDSNString, ":**REDACTED**@")
+	return keywordPasswordRegex.ReplaceAllString(cleanedDSNString, "password=**REDACTED**")
+}
+
+// NewDB initializes a new DB.
+func NewDB(connection_string string) (*Database, error) {
+	validatedString, err := ValidateConnectionString(connection_string)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+	PostgresDB, err := sql.Open("postgres", validatedString)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	db := &Database{DB: PostgresDB, ConnectionString: connection_string}
+
+	return db, nil
+}
+
+func (db *Database) InitDB(ctx context.Context) error {
+	log.Info("Initialising DB Table")
+
+	_, err := db.Migrate(ctx, false)
+	if err != nil {
+		return fmt.Errorf("Migrating database failed: %w", err)
+	}
+
+	//Default Currencies
+	insertCurrency := `
+		INSERT INTO currencies(name,decimals)
+			VALUES('USD',2),
+			('AUD',2),
+			('GBP',2),
+			('BTC',8),
+			('ETH',18),
+			('OXEN',9)
+		ON CONFLICT (name) DO NOTHING;
+	`
+	log.Debug("Query: " + insertCurrency)
+	_, _ = db.DB.ExecContext(ctx, insertCurrency)
+	return nil
+}
+
+// ClearDB drops all tables by recreating the public schema
+func (db *Database) ClearDB(ctx context.Context) error {
+	//DROP TABLES
+	dropDB := `
+				DROP SCHEMA public CASCADE;
+			`
+	log.Debug("Query: " + dropDB)
+	_, err := db.DB.ExecContext(ctx, dropDB)
+	if err != nil {
+		log.Errorf("Dropping schema failed with: %s", err)
+		return err
+	}
+
+	//CREATE NEW SCHEMA
+	newDB := `
+				CREATE SCHEMA public;
+			`
+	log.Debug("Query: " + newDB)
+	_, err = db.DB.ExecContext(ctx, newDB)
+	if err != nil {
+		log.Errorf("Creating schema failed with: %s", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,1246 @@
+package postgresdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+
+	_ "github.com/lib/pq"
+)
+
+func (db *Database) AddTransaction(ctx context.Context, txn *core.Transaction) (string, error) {
+	log.Debug("Adding Transaction to DB")
+	if _, balanced := txn.Balance(); !balanced {
+		return "", dberr.ErrUnbalanced
+	}
+
+	longDescription := false
+
+	if len(txn.Description) > 255 {
+		longDescription = true
+	}
+
+	posterID := ""
+	err := db.conn().QueryRowContext(ctx, `SELECT user_id FROM users WHERE username = $1 LIMIT 1`, txn.Poster.Name).Scan(&posterID)
+
+	if err != nil {
+		log.Error(err)
+		return "", translateError(err)
+	}
+
+	insertTransaction := `
+		INSERT INTO transactions(transaction_id, postdate, description, poster_user_id)
+			VALUES($1,$2,$3,$4);
+	`
+	tx, err := db.begin(ctx)
+
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	log.Debug("Query: " + insertTransaction)
+	var res sql.Result
+	if longDescription {
+		res, err = tx.ExecContext(ctx, insertTransaction, txn.Id, txn.Postdate, string(txn.Description[:255]), posterID)
+	} else {
+		res, err = tx.ExecContext(ctx, insertTransaction, txn.Id, txn.Postdate, string(txn.Description[:]), posterID)
+	}
+
+	if err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
+	}
+
+	rowCnt, err := res.RowsAffected()
+	log.Debugf("affected = %d\n", rowCnt)
+
+	if err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", err
+	}
+
+	if longDescription {
+		insertLongDescriptionTransaction := `
+			INSERT INTO transactions_body(transaction_id, body)
+				VALUES($1,$2);
+		`
+		log.Debug("Query: " + insertLongDescriptionTransaction)
+		log.Debug("Txn Id: " + txn.Id)
+		log.Debug("Saving Long Description into extended table")
+
+		_, err := tx.ExecContext(ctx, insertLongDescriptionTransaction, txn.Id, string(txn.Description[:]))
+
+		if err != nil {
+			log.Error(err)
+			tx.Rollback()
+			return "", translateError(err)
+		}
+	}
+
+	sqlStr := "INSERT INTO splits(transaction_id, split_id, split_date, description, currency, amount) VALUES "
+	vals := []interface{}{}
+	sqlAccStr := "INSERT INTO split_accounts(split_id, account_id) VALUES "
+	accVals := []interface{}{}
+
+	for _, split := range txn.Splits {
+		sqlStr += placeholders(len(vals), 6) + ","
+		//Todo:(sean) split is truncated at 255 bytes but should be handled better
+		if len(split.Description) > 255 {
+			vals = append(vals, txn.Id, split.Id, split.Date, string(split.Description[:255]), split.Currency.Name, dberr.AmountValue(split.Amount))
+		} else {
+			vals = append(vals, txn.Id, split.Id, split.Date, string(split.Description[:]), split.Currency.Name, dberr.AmountValue(split.Amount))
+		}
+		for _, acc := range split.Accounts {
+			sqlAccStr += placeholders(len(accVals), 2) + ","
+			accVals = append(accVals, split.Id, strings.TrimSpace(acc.Code))
+		}
+	}
+
+	sqlStr = strings.TrimSuffix(sqlStr, ",")
+	log.Debug("Query: " + sqlStr)
+	log.Debugf("NumberVals = %d", len(vals))
+	log.Debug("Adding Split to DB")
+
+	res, err = tx.ExecContext(ctx, sqlStr, vals...)
+
+	if err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
+	}
+
+	rowCnt, err = res.RowsAffected()
+	log.Debugf("affected = %d\n", rowCnt)
+
+	if err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", err
+	}
+
+	sqlAccStr = strings.TrimSuffix(sqlAccStr, ",")
+	log.Debug("Query: " + sqlAccStr)
+	log.Debug("Adding Split Accounts to DB")
+
+	res, err = tx.ExecContext(ctx, sqlAccStr, accVals...)
+
+	if err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
+	}
+
+	rowCnt, err = res.RowsAffected()
+	log.Debugf("affected = %d\n", rowCnt)
+
+	if err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := applyBalances(ctx, tx, txn.Id, 1); err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Error(err)
+		return "", translateError(err)
+	}
+
+	return txn.Id, nil
+}
+
+// AddTransactions inserts the transactions in a single database transaction
+// and returns their ids. If any of them fails none are saved.
+func (db *Database) AddTransactions(ctx context.Context, txns []*core.Transaction) ([]string, error) {
+	log.Debugf("Adding %d Transactions to DB", len(txns))
+	ids := make([]string, 0, len(txns))
+	err := db.UnitOfWork(ctx, func(tx dberr.Database) error {
+		for _, txn := range txns {
+			id, err := tx.AddTransaction(ctx, txn)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// placeholders returns a parenthesised group of count numbered parameters
+// starting after offset, e.g. placeholders(2, 3) returns "($3, $4, $5)".
+func placeholders(offset, count int) string {
+	params := make([]string, count)
+	for i := range params {
+		params[i] = "$" + strconv.Itoa(offset+i+1)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// rebind converts the ? placeholders used by the other backends into the
+// numbered parameters PostgreSQL expects.
+func rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (db *Database) FindTransaction(ctx context.Context, txnID string) (*core.Transaction, error) {
+	var resp core.Transaction
+	var poster core.User
+	log.Debug("Searching Transaction in DB: ", txnID)
+
+	// Find the transaction body
+	err := db.conn().QueryRowContext(ctx, `
+			SELECT t.transaction_id,
+						 t.postdate,
+						 t.description,
+						 u.user_id,
+						 u.username
+			FROM   transactions AS t
+						 JOIN users AS u
+							 ON t.poster_user_id = u.user_id
+			WHERE  t.transaction_id = $1
+			LIMIT  1
+			`, txnID).Scan(&resp.Id, &resp.Postdate, &resp.Description, &poster.Id, &poster.Name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	resp.Poster = &poster
+
+	log.Debug("Searching Transaction splits in DB")
+
+	// Find all splits relating to that transaction
+	splits, err := db.conn().QueryContext(ctx, `
+			SELECT s.split_id,
+						 s.split_date,
+						 s.description,
+						 a.account_id,
+						 a.name,
+						 s.currency,
+						 c.decimals,
+						 s.amount
+			FROM   splits AS s
+						 JOIN split_accounts AS sa
+							 ON s.split_id = sa.split_id
+						 JOIN accounts AS a
+							 ON sa.account_id = a.account_id
+						 JOIN currencies AS c
+							 ON s.currency = c.name
+			WHERE  s.transaction_id = $1
+			`, txnID)
+	if err != nil {
+		return nil, err
+	}
+	defer splits.Close()
+
+	for splits.Next() {
+		var split core.Split
+		var account core.Account
+		var cur core.Currency
+		var amount *big.Int
+		// for each row, scan the result into our split object
+		err = splits.Scan(&split.Id, &split.Date, &split.Description, &account.Code, &account.Name, &cur.Name, &cur.Decimals, dberr.ScanAmount(&amount))
+		if err != nil {
+			return nil, err
+		}
+		split.Amount = amount
+		split.Accounts = append(split.Accounts, &account)
+		split.Currency = &cur
+		resp.Splits = append(resp.Splits, &split)
+	}
+
+	return &resp, nil
+}
+
+// DeleteTransaction moves a transaction into the trash, where it is excluded
+// from reports until it is restored or purged.
+func (db *Database) DeleteTransaction(ctx context.Context, txnID string) error {
+	return db.TrashTransaction(ctx, txnID, time.Now().UTC())
+}
+
+// TrashTransaction moves a transaction into the trash recording that it was
+// trashed at the given time.
+func (db *Database) TrashTransaction(ctx context.Context, txnID string, trashedAt time.Time) error {
+	return db.trackBalances(ctx, txnID, func(tx *Database) error {
+		return tx.deleteTransaction(ctx, txnID, trashedAt)
+	})
+}
+
+func (db *Database) deleteTransaction(ctx context.Context, txnID string, trashedAt time.Time) error {
+	var exists bool
+	err := db.conn().QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT * FROM transactions
+									WHERE transaction_id = $1
+										AND transaction_id NOT IN (SELECT transaction_id FROM trashed_transactions));`, txnID).Scan(&exists)
+	if err != nil {
+		return translateError(err)
+	}
+	if !exists {
+		return dberr.ErrNotFound
+	}
+
+	sqlStatement := `
+	INSERT INTO trashed_transactions(transaction_id, trashed_at)
+		VALUES($1,$2);`
+	log.Debug("Query: " + sqlStatement)
+	_, err = db.conn().ExecContext(ctx, sqlStatement, txnID, trashedAt.UTC())
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) FindTag(ctx context.Context, tag string) (int, error) {
+	var resp int
+	log.Debug("Searching Tag in DB")
+	err := db.conn().QueryRowContext(ctx, `SELECT tag_id FROM tags WHERE tag_name = $1 LIMIT 1`, tag).Scan(&resp)
+	if err != nil {
+		log.Debug("Find Tag Failed: ", err)
+		return 0, translateError(err)
+	}
+	return resp, nil
+}
+
+func (db *Database) AddTag(ctx context.Context, tag string) error {
+	log.Debug("Adding Tag to DB")
+	insertTag := `
+		INSERT INTO tags(tag_name)
+			VALUES($1);
+	`
+	log.Debug("Query: " + insertTag)
+	res, err := db.conn().ExecContext(ctx, insertTag, tag)
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	rowCnt, err := res.RowsAffected()
+	if err != nil {
+		log.Debug(err)
+		return err
+	}
+	log.Debugf("affected = %d\n", rowCnt)
+
+	return nil
+}
+
+func (db *Database) SafeAddTag(ctx context.Context, tag string) error {
+	u, _ := db.FindTag(ctx, strings.TrimSpace(tag))
+	if u != 0 {
+		return nil
+	}
+	return db.AddTag(ctx, strings.TrimSpace(tag))
+}
+
+func (db *Database) SafeAddTagToAccount(ctx context.Context, account, tag string) error {
+	err := db.SafeAddTag(ctx, tag)
+	if err != nil {
+		log.Debug(err)
+		return err
+	}
+	tagID, _ := db.FindTag(ctx, tag)
+
+	var accountID string
+	err = db.conn().QueryRowContext(ctx, `SELECT account_id FROM accounts WHERE name = $1 LIMIT 1`, account).Scan(&accountID)
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	return db.AddTagToAccount(ctx, accountID, tagID)
+}
+
+func (db *Database) AddTagToAccount(ctx context.Context, accountID string, tag int) error {
+	insertTag := `
+		INSERT INTO account_tag(account_id, tag_id)
+			VALUES($1,$2)
+		ON CONFLICT DO NOTHING;
+	`
+	log.Debug("Query: " + insertTag)
+	res, err := db.conn().ExecContext(ctx, insertTag, accountID, tag)
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	rowCnt, err := res.RowsAffected()
+	if err != nil {
+		log.Debug(err)
+		return err
+	}
+	log.Debugf("affected = %d\n", rowCnt)
+
+	return nil
+}
+
+func (db *Database) DeleteTagFromAccount(ctx context.Context, account, tag string) error {
+	tagID, err := db.FindTag(ctx, tag)
+	if err != nil {
+		return err
+	}
+
+	sqlStatement := `
+	DELETE FROM account_tag
+	WHERE
+		tag_id = $1
+	AND
+		account_id = $2
+	;`
+	_, err = db.conn().ExecContext(ctx, sqlStatement, tagID, account)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) SafeAddTagToTransaction(ctx context.Context, txnID, tag string) error {
+	err := db.SafeAddTag(ctx, tag)
+	if err != nil {
+		log.Debug(err)
+		return err
+	}
+	tagID, _ := db.FindTag(ctx, tag)
+
+	return db.AddTagToTransaction(ctx, txnID, tagID)
+}
+
+func (db *Database) AddTagToTransaction(ctx context.Context, txnID string, tag int) error {
+	return db.trackBalances(ctx, txnID, func(tx *Database) error {
+		return tx.addTagToTransaction(ctx, txnID, tag)
+	})
+}
+
+func (db *Database) addTagToTransaction(ctx context.Context, txnID string, tag int) error {
+	insertTag := `
+		INSERT INTO transaction_tag(transaction_id, tag_id)
+			VALUES($1,$2)
+		ON CONFLICT DO NOTHING;
+	`
+	log.Debug("Query: " + insertTag)
+	res, err := db.conn().ExecContext(ctx, insertTag, txnID, tag)
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	rowCnt, err := res.RowsAffected()
+	if err != nil {
+		log.Debug(err)
+		return err
+	}
+	log.Debugf("affected = %d\n", rowCnt)
+
+	return nil
+}
+
+func (db *Database) DeleteTagFromTransaction(ctx context.Context, txnID, tag string) error {
+	return db.trackBalances(ctx, txnID, func(tx *Database) error {
+		return tx.deleteTagFromTransaction(ctx, txnID, tag)
+	})
+}
+
+func (db *Database) deleteTagFromTransaction(ctx context.Context, txnID, tag string) error {
+	tagID, err := db.FindTag(ctx, tag)
+	if err != nil {
+		return err
+	}
+
+	sqlStatement := `
+	DELETE FROM transaction_tag
+	WHERE
+		tag_id = $1
+	AND
+		transaction_id = $2
+	;`
+	_, err = db.conn().ExecContext(ctx, sqlStatement, tagID, txnID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) FindCurrency(ctx context.Context, cur string) (*core.Currency, error) {
+	var resp core.Currency
+	log.Debug("Searching Currency in DB: ", cur)
+	err := db.conn().QueryRowContext(ctx, `SELECT name, decimals FROM currencies WHERE name = $1 LIMIT 1`, strings.TrimSpace(cur)).Scan(&resp.Name, &resp.Decimals)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &resp, nil
+}
+
+func (db *Database) AddCurrency(ctx context.Context, cur *core.Currency) error {
+	log.Debug("Adding Currency to DB")
+	insertCurrency := `
+		INSERT INTO currencies(name,decimals)
+			VALUES($1,$2);
+	`
+	log.Debug("Query: " + insertCurrency)
+	res, err := db.conn().ExecContext(ctx, insertCurrency, strings.TrimSpace(cur.Name), cur.Decimals)
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	rowCnt, err := res.RowsAffected()
+	if err != nil {
+		log.Debug(err)
+		return err
+	}
+	log.Debugf("affected = %d\n", rowCnt)
+
+	return nil
+}
+
+func (db *Database) SafeAddCurrency(ctx context.Context, cur *core.Currency) error {
+	u, _ := db.FindCurrency(ctx, cur.Name)
+	if u != nil {
+		return nil
+	}
+	return db.AddCurrency(ctx, cur)
+}
+
+// DeleteCurrency removes a currency that no split is in.
+func (db *Database) DeleteCurrency(ctx context.Context, currency string) error {
+	sqlStatement := `
+	DELETE FROM currencies
+	WHERE name = $1
+	AND NOT EXISTS (SELECT 1 FROM splits WHERE currency = $2);`
+	res, err := db.conn().ExecContext(ctx, sqlStatement, currency, currency)
+	if err != nil {
+		return translateError(err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		var splits int
+		err := db.conn().QueryRowContext(ctx, `SELECT COUNT(*) FROM splits WHERE currency = $1`, currency).Scan(&splits)
+		if err != nil {
+			return translateError(err)
+		}
+		if splits > 0 {
+			return fmt.Errorf("%w: currency %s is used by %d splits", dberr.ErrConstraintViolation, currency, splits)
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) FindAccount(ctx context.Context, code string) (*core.Account, error) {
+	var resp core.Account
+	log.Debug("Searching Account in DB")
+	err := db.conn().QueryRowContext(ctx, `SELECT account_id, name FROM accounts WHERE account_id = $1 LIMIT 1`, strings.TrimSpace(code)).Scan(&resp.Code, &resp.Name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &resp, nil
+}
+
+func (db *Database) AddAccount(ctx context.Context, acc *core.Account) error {
+	log.Debug("Adding Account to DB")
+	insertAccount := `
+		INSERT INTO accounts(account_id, name)
+			VALUES($1,$2);
+	`
+	log.Debug("Query: " + insertAccount)
+	res, err := db.conn().ExecContext(ctx, insertAccount, strings.TrimSpace(acc.Code), strings.TrimSpace(acc.Name))
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	rowCnt, err := res.RowsAffected()
+	if err != nil {
+		log.Debug(err)
+		return err
+	}
+	log.Debugf("affected = %d\n", rowCnt)
+
+	return nil
+}
+
+func (db *Database) SafeAddAccount(ctx context.Context, acc *core.Account) (bool, error) {
+	u, _ := db.FindAccount(ctx, strings.TrimSpace(acc.Code))
+	if u != nil {
+		return false, nil
+	}
+	return true, db.AddAccount(ctx, acc)
+}
+
+func (db *Database) DeleteAccount(ctx context.Context, account string) error {
+	sqlStatement := `
+	DELETE FROM accounts
+	WHERE
+		name = $1
+	;`
+	_, err := db.conn().ExecContext(ctx, sqlStatement, account)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) FindUser(ctx context.Context, pubKey string) (*core.User, error) {
+	var resp core.User
+	log.Debug("Searching User in DB")
+	err := db.conn().QueryRowContext(ctx, `SELECT user_id, username FROM users WHERE username = $1 LIMIT 1`, pubKey).Scan(&resp.Id, &resp.Name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &resp, nil
+}
+
+func (db *Database) AddUser(ctx context.Context, usr *core.User) error {
+	log.Debug("Adding User to DB")
+	insertUser := `
+		INSERT INTO users(user_id, username)
+			VALUES($1,$2);
+	`
+	log.Debug("Query: " + insertUser)
+	res, err := db.conn().ExecContext(ctx, insertUser, usr.Id, usr.Name)
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	rowCnt, err := res.RowsAffected()
+	if err != nil {
+		log.Debug(err)
+		return err
+	}
+	log.Debugf("affected = %d\n", rowCnt)
+
+	return nil
+}
+
+func (db *Database) SafeAddUser(ctx context.Context, usr *core.User) error {
+	u, _ := db.FindUser(ctx, usr.Name)
+	if u != nil {
+		return nil
+	}
+	return db.AddUser(ctx, usr)
+}
+
+// GetTB totals the balance summaries of the months before the date and adds
+// the splits of its own month up to the date.
+func (db *Database) GetTB(ctx context.Context, queryDate time.Time) (*[]core.TBAccount, error) {
+	accounts, err := db.trialBalance(ctx, queryDate, "")
+	if err != nil {
+		return nil, err
+	}
+	return &accounts, nil
+}
+
+// GetAccountBalance is the trial balance of a single account, reading only
+// its own summaries and splits.
+func (db *Database) GetAccountBalance(ctx context.Context, account string, queryDate time.Time) ([]core.TBAccount, error) {
+	return db.trialBalance(ctx, queryDate, account)
+}
+
+// trialBalance returns the lines of the trial balance at the date, only those
+// of the account unless it is blank.
+func (db *Database) trialBalance(ctx context.Context, queryDate time.Time, account string) ([]core.TBAccount, error) {
+	period := tbPeriod(queryDate)
+	balanceFilter, splitFilter := "", ""
+	args := []interface{}{period, period, queryDate}
+	if account != "" {
+		balanceFilter = " AND account_id = $4"
+		splitFilter = " AND split_accounts.account_id = $4"
+		args = append(args, account)
+	}
+
+	queryDB := `
+		SELECT balances.account_id,
+					 Sum(balances.amount),
+					 balances.currency,
+					 currencies.decimals
+		FROM   (SELECT account_id,
+									 currency,
+									 amount
+						FROM   account_balances
+						WHERE  period < $1` + balanceFilter + `
+						UNION ALL
+						SELECT split_accounts.account_id,
+									 splits.currency,
+									 splits.amount
+						FROM   splits
+									 JOIN split_accounts
+										 ON splits.split_id = split_accounts.split_id
+						WHERE  splits.split_date >= $2
+									 AND splits.split_date <= $3` + splitFilter + `
+									 AND splits.transaction_id NOT IN (SELECT transaction_id
+																										 FROM   trashed_transactions)
+									 AND splits.transaction_id NOT IN (SELECT tt.transaction_id
+																										 FROM   transaction_tag AS tt
+																														JOIN tags AS t
+																															ON tt.tag_id = t.tag_id
+																										 WHERE  Lower(t.tag_name) = 'void')) AS balances
+					 JOIN currencies
+						 ON balances.currency = currencies.name
+		GROUP  BY balances.account_id, balances.currency, currencies.decimals
+		;`
+
+	log.Debug("Querying Database for Trial Balance")
+
+	rows, err := db.conn().QueryContext(ctx, queryDB, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Trial Balance Query Failed with error: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []core.TBAccount{}
+
+	for rows.Next() {
+		var t core.TBAccount
+		if err := rows.Scan(&t.Account, dberr.ScanAmount(&t.Amount), &t.Currency, &t.Decimals); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for index, element := range accounts {
+		tags, err := db.FindAccountTags(ctx, element.Account)
+		if err != nil {
+			return nil, err
+		}
+		accounts[index].Tags = append(accounts[index].Tags, tags...)
+	}
+
+	return accounts, nil
+}
+
+// Query runs an arbitrary query against the database. Queries written with
+// the ? placeholders of the other backends are rebound before being run.
+func (db *Database) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.conn().QueryContext(ctx, rebind(query), args...)
+}
+
+func (db *Database) ReconcileTransactions(ctx context.Context, reconciliationID string, splitIDs []string) (string, error) {
+	tx, err := db.begin(ctx)
+
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	sqlStr := "INSERT INTO reconciliations(reconciliation_id, split_id) VALUES "
+	vals := []interface{}{}
+
+	for _, split := range splitIDs {
+		sqlStr += placeholders(len(vals), 2) + ","
+		vals = append(vals, reconciliationID, split)
+	}
+
+	sqlStr = strings.TrimSuffix(sqlStr, ",")
+	log.Debug("Query: " + sqlStr)
+	log.Debugf("NumberVals = %d", len(vals))
+	log.Debug("Adding Reconciliation to DB")
+
+	res, err := tx.ExecContext(ctx, sqlStr, vals...)
+
+	if err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", translateError(err)
+	}
+
+	rowCnt, err := res.RowsAffected()
+	log.Debugf("affected = %d\n", rowCnt)
+
+	if err != nil {
+		log.Error(err)
+		tx.Rollback()
+		return "", err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Error(err)
+		return "", translateError(err)
+	}
+
+	return reconciliationID, nil
+}
+
+// listingQuery builds the single query behind GetListing. The derived table
+// picks the page of transactions matching the filter and the outer select
+// joins the splits of each that fall within the date range.
+func listingQuery(filter core.ListingFilter) (string, []interface{}) {
+	startDate := filter.StartDate.Format("2006-01-02")
+	endDate := filter.EndDate.Format("2006-01-02")
+
+	inRange := `EXISTS (
+				SELECT 1 FROM splits AS fs
+					JOIN split_accounts AS fsa ON fs.split_id = fsa.split_id
+				WHERE fs.transaction_id = t.transaction_id
+				AND fs.split_date BETWEEN ? AND ?`
+	args := []interface{}{startDate, endDate}
+	if len(filter.Accounts) > 0 {
+		inRange += " AND fsa.account_id IN " + questionMarks(len(filter.Accounts))
+		for _, account := range filter.Accounts {
+			args = append(args, strings.TrimSpace(account))
+		}
+	}
+	where := []string{
+		"t.transaction_id NOT IN (SELECT transaction_id FROM trashed_transactions)",
+		inRange + ")",
+	}
+	if len(filter.Tags) > 0 {
+		where = append(where, `EXISTS (
+				SELECT 1 FROM transaction_tag AS tt
+					JOIN tags AS g ON tt.tag_id = g.tag_id
+				WHERE tt.transaction_id = t.transaction_id
+				AND g.tag_name IN `+questionMarks(len(filter.Tags))+")")
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+	}
+	if filter.After != "" {
+		where = append(where, "t.transaction_id > ?")
+		args = append(args, filter.After)
+	}
+	page := "SELECT t.transaction_id FROM transactions AS t WHERE " + strings.Join(where, " AND ") + " ORDER BY t.transaction_id"
+	if filter.Limit > 0 {
+		page += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	query := `
+		SELECT t.transaction_id,
+					 t.postdate,
+					 t.description,
+					 u.user_id,
+					 u.username,
+					 s.split_id,
+					 s.split_date,
+					 s.description,
+					 a.account_id,
+					 a.name,
+					 s.currency,
+					 c.decimals,
+					 s.amount
+		FROM   (` + page + `) AS page
+					 JOIN transactions AS t
+						 ON t.transaction_id = page.transaction_id
+					 JOIN users AS u
+						 ON t.poster_user_id = u.user_id
+					 JOIN splits AS s
+						 ON s.transaction_id = t.transaction_id
+					 JOIN split_accounts AS sa
+						 ON s.split_id = sa.split_id
+					 JOIN accounts AS a
+						 ON sa.account_id = a.account_id
+					 JOIN currencies AS c
+						 ON s.currency = c.name
+		WHERE  s.split_date BETWEEN ? AND ?
+		ORDER  BY t.transaction_id, s.split_id;`
+	args = append(args, startDate, endDate)
+
+	return rebind(query), args
+}
+
+func questionMarks(count int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", count), ", ") + ")"
+}
+
+func (db *Database) GetListing(ctx context.Context, filter core.ListingFilter) (*[]core.Transaction, error) {
+	log.Debugf("Searching Transactions in DB between %s & %s", filter.StartDate.Format("2006-01-02"), filter.EndDate.Format("2006-01-02"))
+
+	query, args := listingQuery(filter)
+	log.Debug("Query: " + query)
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txns, err := scanListing(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txns, nil
+}
+
+// scanListing reads the rows of a listing query into transactions holding the
+// splits returned for each.
+func scanListing(rows *sql.Rows) ([]core.Transaction, error) {
+	txns := []core.Transaction{}
+	var t *core.Transaction
+	var split *core.Split
+	for rows.Next() {
+		var txnID, splitID string
+		var postdate time.Time
+		var description []byte
+		var poster core.User
+		var date time.Time
+		var splitDescription []byte
+		var account core.Account
+		var cur core.Currency
+		var amount *big.Int
+		err := rows.Scan(&txnID, &postdate, &description, &poster.Id, &poster.Name,
+			&splitID, &date, &splitDescription, &account.Code, &account.Name, &cur.Name, &cur.Decimals, dberr.ScanAmount(&amount))
+		if err != nil {
+			return nil, err
+		}
+
+		// Rows arrive grouped by transaction and split, a split posting to
+		// several accounts is returned once for each
+		if t == nil || t.Id != txnID {
+			txns = append(txns, core.Transaction{Id: txnID, Postdate: postdate, Description: description, Poster: &poster})
+			t = &txns[len(txns)-1]
+			split = nil
+		}
+		if split == nil || split.Id != splitID {
+			split = &core.Split{Id: splitID, Date: date, Description: splitDescription, Currency: &cur, Amount: amount}
+			t.Splits = append(t.Splits, split)
+		}
+		split.Accounts = append(split.Accounts, &account)
+	}
+	return txns, rows.Err()
+}
+
+func (db *Database) AddAllocationRule(ctx context.Context, rule *core.AllocationRule) error {
+	log.Debug("Adding Allocation Rule to DB")
+	tx, err := db.begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	insertRule := `
+		INSERT INTO allocation_rules(rule_name)
+			VALUES($1);
+	`
+	log.Debug("Query: " + insertRule)
+	_, err = tx.ExecContext(ctx, insertRule, strings.TrimSpace(rule.Name))
+	if err != nil {
+		log.Debug(err)
+		tx.Rollback()
+		return translateError(err)
+	}
+
+	sqlStr := "INSERT INTO allocation_targets(rule_name, position, account_id, weight) VALUES "
+	vals := []interface{}{}
+	for i, target := range rule.Targets {
+		sqlStr += placeholders(len(vals), 4) + ","
+		vals = append(vals, strings.TrimSpace(rule.Name), i, strings.TrimSpace(target.Account.Code), target.Weight)
+	}
+	sqlStr = strings.TrimSuffix(sqlStr, ",")
+	log.Debug("Query: " + sqlStr)
+	_, err = tx.ExecContext(ctx, sqlStr, vals...)
+	if err != nil {
+		log.Debug(err)
+		tx.Rollback()
+		return translateError(err)
+	}
+
+	return tx.Commit()
+}
+
+func (db *Database) FindAllocationRule(ctx context.Context, name string) (*core.AllocationRule, error) {
+	log.Debugf("Searching Allocation Rule in DB: %s", name)
+	rule, err := core.NewAllocationRule(strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn().QueryContext(ctx, `
+			SELECT at.account_id,
+						 a.name,
+						 at.weight
+			FROM   allocation_targets AS at
+						 LEFT JOIN accounts AS a
+							 ON at.account_id = a.account_id
+			WHERE  at.rule_name = $1
+			ORDER  BY at.position
+			`, rule.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code string
+		var accountName sql.NullString
+		var weight int64
+		if err := rows.Scan(&code, &accountName, &weight); err != nil {
+			return nil, err
+		}
+		if !accountName.Valid {
+			accountName.String = code
+		}
+		acc, err := core.NewAccount(code, accountName.String)
+		if err != nil {
+			return nil, err
+		}
+		if err := rule.AppendTarget(acc, weight); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(rule.Targets) == 0 {
+		return nil, dberr.ErrNotFound
+	}
+
+	return rule, nil
+}
+
+func (db *Database) DeleteAllocationRule(ctx context.Context, name string) error {
+	sqlStatement := `
+	DELETE FROM allocation_rules
+	WHERE rule_name = $1;`
+	_, err := db.conn().ExecContext(ctx, sqlStatement, strings.TrimSpace(name))
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) AddPostingRule(ctx context.Context, rule *core.PostingRule) error {
+	log.Debug("Adding Posting Rule to DB")
+	insertRule := `
+		INSERT INTO posting_rules(rule_name, rule_type, account, tag, currency, amount)
+			VALUES($1,$2,$3,$4,$5,$6);
+	`
+	log.Debug("Query: " + insertRule)
+	_, err := db.conn().ExecContext(ctx, insertRule, strings.TrimSpace(rule.Name), rule.Type, rule.Account, rule.Tag, rule.Currency, dberr.AmountValue(rule.Amount))
+	if err != nil {
+		log.Debug(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) GetPostingRules(ctx context.Context) ([]*core.PostingRule, error) {
+	log.Debug("Querying Database for Posting Rules")
+	rows, err := db.conn().QueryContext(ctx, `
+			SELECT rule_name,
+						 rule_type,
+						 account,
+						 tag,
+						 currency,
+						 amount
+			FROM   posting_rules
+			ORDER  BY rule_name
+			`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*core.PostingRule{}
+	for rows.Next() {
+		var rule core.PostingRule
+		if err := rows.Scan(&rule.Name, &rule.Type, &rule.Account, &rule.Tag, &rule.Currency, dberr.ScanAmount(&rule.Amount)); err != nil {
+			return nil, err
+		}
+		rules = append(rules, &rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (db *Database) DeletePostingRule(ctx context.Context, name string) error {
+	sqlStatement := `
+	DELETE FROM posting_rules
+	WHERE rule_name = $1;`
+	_, err := db.conn().ExecContext(ctx, sqlStatement, strings.TrimSpace(name))
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (db *Database) FindAccountTags(ctx context.Context, account string) ([]string, error) {
+	log.Debugf("Querying Database for Tags on Account: %s", account)
+	rows, err := db.conn().QueryContext(ctx, `
+			SELECT tag_name
+			FROM   tags
+						 JOIN account_tag
+							 ON account_tag.tag_id = tags.tag_id
+						 JOIN accounts
+							 ON accounts.account_id = account_tag.account_id
+			WHERE  accounts.name = $1
+			`, strings.TrimSpace(account))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// ListTrash returns every transaction currently in the trash.
+func (db *Database) ListTrash(ctx context.Context) ([]core.TrashedTransaction, error) {
+	log.Debug("Searching Trashed Transactions in DB")
+	rows, err := db.conn().QueryContext(ctx, `
+			SELECT t.transaction_id,
+						 t.postdate,
+						 t.description,
+						 u.user_id,
+						 u.username,
+						 tt.trashed_at
+			FROM   transactions AS t
+						 JOIN trashed_transactions AS tt
+							 ON t.transaction_id = tt.transaction_id
+						 JOIN users AS u
+							 ON t.poster_user_id = u.user_id
+			ORDER  BY tt.trashed_at
+			`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trash := []core.TrashedTransaction{}
+	for rows.Next() {
+		var t core.TrashedTransaction
+		var poster core.User
+		if err := rows.Scan(&t.Id, &t.Postdate, &t.Description, &poster.Id, &poster.Name, &t.TrashedAt); err != nil {
+			return nil, err
+		}
+		t.Poster = &poster
+		trash = append(trash, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return trash, nil
+}
+
+// IsTrashed reports whether a transaction is in the trash.
+func (db *Database) IsTrashed(ctx context.Context, txnID string) (bool, error) {
+	var trashed bool
+	err := db.conn().QueryRowContext(ctx, `SELECT EXISTS(SELECT * FROM trashed_transactions WHERE transaction_id = $1);`, txnID).Scan(&trashed)
+	if err != nil {
+		return false, translateError(err)
+	}
+	return trashed, nil
+}
+
+// RestoreTransaction takes a transaction back out of the trash.
+func (db *Database) RestoreTransaction(ctx context.Context, txnID string) error {
+	return db.trackBalances(ctx, txnID, func(tx *Database) error {
+		return tx.restoreTransaction(ctx, txnID)
+	})
+}
+
+func (db *Database) restoreTransaction(ctx context.Context, txnID string) error {
+	sqlStatement := `
+	DELETE FROM trashed_transactions
+	WHERE transaction_id = $1;`
+	res, err := db.conn().ExecContext(ctx, sqlStatement, txnID)
+	if err != nil {
+		return translateError(err)
+	}
+	rowCnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowCnt == 0 {
+		return dberr.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently deletes the transactions that were moved into the
+// trash before the given time and returns how many were removed.
+func (db *Database) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	tx, err := db.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT transaction_id FROM trashed_transactions WHERE trashed_at < $1`, before.UTC())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	txnIDs := []string{}
+	for rows.Next() {
+		var txnID string
+		if err := rows.Scan(&txnID); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, err
+		}
+		txnIDs = append(txnIDs, txnID)
+	}
+	rows.Close()
+
+	for _, txnID := range txnIDs {
+		log.Debugf("Purging Transaction: %s", txnID)
+		_, err = tx.ExecContext(ctx, `DELETE FROM transaction_tag WHERE transaction_id = $1`, txnID)
+		if err != nil {
+			tx.Rollback()
+			return 0, translateError(err)
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM transactions WHERE transaction_id = $1`, txnID)
+		if err != nil {
+			tx.Rollback()
+			return 0, translateError(err)
+		}
+	}
+
+	return len(txnIDs), tx.Commit()
+}
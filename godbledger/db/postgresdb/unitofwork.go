@@ -0,0 +1,102 @@
+package postgresdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/darcys22/godbledger/godbledger/db"
+)
+
+// execer is the part of *sql.DB and *sql.Tx the queries are run through so
+// that every operation can take part in a unit of work.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction of the current unit of work, or the database
+// itself when there is none.
+func (db *Database) conn() execer {
+	if db.tx != nil {
+		return db.tx
+	}
+	return db.DB
+}
+
+// sqlTx is a transaction opened by one of the database operations. When the
+// operation runs inside a unit of work it joins the outer transaction, and
+// committing or rolling back is left to the unit of work.
+type sqlTx struct {
+	*sql.Tx
+	owned bool
+}
+
+func (tx *sqlTx) Commit() error {
+	if !tx.owned {
+		return nil
+	}
+	return tx.Tx.Commit()
+}
+
+func (tx *sqlTx) Rollback() error {
+	if !tx.owned {
+		return nil
+	}
+	return tx.Tx.Rollback()
+}
+
+// begin starts a transaction for an operation that writes several rows.
+func (db *Database) begin(ctx context.Context) (*sqlTx, error) {
+	if db.tx != nil {
+		return &sqlTx{Tx: db.tx}, nil
+	}
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{Tx: tx, owned: true}, nil
+}
+
+// UnitOfWork runs fn against a copy of the database bound to a single
+// transaction. The transaction is committed if fn returns nil and rolled back
+// otherwise, so none of the changes made through tx outlive a failure. Inside
+// a unit of work it opens a savepoint instead, so a failing fn only undoes its
+// own changes.
+func (db *Database) UnitOfWork(ctx context.Context, fn func(tx db.Database) error) error {
+	if db.tx != nil {
+		return db.savepoint(ctx, fn)
+	}
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	bound := *db
+	bound.tx = tx
+	if err := fn(&bound); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return translateError(tx.Commit())
+}
+
+// savepoint runs fn inside a savepoint of the current transaction. Each level
+// of nesting gets its own name as MySQL replaces a savepoint of the same name.
+func (db *Database) savepoint(ctx context.Context, fn func(tx db.Database) error) error {
+	nested := *db
+	nested.depth++
+	name := fmt.Sprintf("unit_of_work_%d", nested.depth)
+	if _, err := db.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return translateError(err)
+	}
+	if err := fn(&nested); err != nil {
+		if _, rerr := db.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rerr != nil {
+			log.Errorf("Rolling back to savepoint failed: %s", rerr)
+		}
+		return err
+	}
+	_, err := db.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return translateError(err)
+}
@@ -0,0 +1,65 @@
+package sqlite3db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// BackupTo copies the ledger to a new SQLite database at path using the online
+// backup API, so the node can keep writing while it runs.
+func (db *Database) BackupTo(ctx context.Context, path string) error {
+	log.WithField("path", path).Debug("Backing up SQLite3 database")
+	dest, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	return copyDatabase(ctx, dest, db.DB)
+}
+
+// RestoreFrom replaces the ledger with the SQLite database at path, copying it
+// in through the online backup API.
+func (db *Database) RestoreFrom(ctx context.Context, path string) error {
+	log.WithField("path", path).Debug("Restoring SQLite3 database")
+	src, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return copyDatabase(ctx, db.DB, src)
+}
+
+// copyDatabase copies every page of the main database of src over dest.
+func copyDatabase(ctx context.Context, dest, src *sql.DB) error {
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			backup, err := destDriver.(*sqlite3.SQLiteConn).Backup("main", srcDriver.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			for done := false; !done; {
+				if done, err = backup.Step(-1); err != nil {
+					backup.Finish()
+					return err
+				}
+			}
+			return backup.Finish()
+		})
+	})
+}
@@ -0,0 +1,53 @@
+package sqlite3db
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	usr, _ := core.NewUser("Tester")
+	assert.NoError(t, ledgerdb.SafeAddUser(ctx, usr))
+	aud, _ := ledgerdb.FindCurrency(ctx, "AUD")
+	post := func() *core.Transaction {
+		txn, _ := core.NewTransaction(usr)
+		for _, line := range []struct {
+			account string
+			amount  int64
+		}{{"Expenses:Groceries", 1000}, {"Assets:Checking", -1000}} {
+			acc, _ := core.NewAccount(line.account, line.account)
+			_, err := ledgerdb.SafeAddAccount(ctx, acc)
+			assert.NoError(t, err)
+			spl, _ := core.NewSplit(time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC), []byte("Line"), []*core.Account{acc}, aud, big.NewInt(line.amount))
+			txn.AppendSplit(spl)
+		}
+		_, err := ledgerdb.AddTransaction(ctx, txn)
+		assert.NoError(t, err)
+		return txn
+	}
+
+	kept := post()
+	backup := filepath.Join(t.TempDir(), "ledger.backup")
+	assert.NoError(t, ledgerdb.BackupTo(ctx, backup))
+	lost := post()
+
+	assert.NoError(t, ledgerdb.RestoreFrom(ctx, backup))
+	_, err := ledgerdb.FindTransaction(ctx, kept.Id)
+	assert.NoError(t, err)
+	_, err = ledgerdb.FindTransaction(ctx, lost.Id)
+	assert.Error(t, err)
+
+	discrepancies, err := ledgerdb.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
@@ -0,0 +1,79 @@
+package sqlite3db
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db/dbtest"
+)
+
+func TestAccountBalances(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	date := time.Date(2011, 1, 20, 0, 0, 0, 0, time.UTC)
+	post := dbtest.NewLedger(t, ledgerdb).Post
+	post("Expenses:Groceries", "Assets:Checking", 1000, date)
+	voided := post("Expenses:Groceries", "Assets:Checking", 250, date.AddDate(0, 1, 0))
+	trashed := post("Expenses:Rent", "Assets:Checking", 500, date.AddDate(0, 1, 0))
+	post("Expenses:Groceries", "Assets:Checking", 75, date.AddDate(0, 1, 5))
+
+	assert.NoError(t, ledgerdb.SafeAddTagToTransaction(ctx, voided.Id, "Void"))
+	assert.NoError(t, ledgerdb.DeleteTransaction(ctx, trashed.Id))
+	assert.NoError(t, ledgerdb.RestoreTransaction(ctx, trashed.Id))
+	assert.NoError(t, ledgerdb.DeleteTransaction(ctx, trashed.Id))
+
+	discrepancies, err := ledgerdb.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+
+	balance := func(queryDate time.Time) map[string]string {
+		tb, err := ledgerdb.GetTB(ctx, queryDate)
+		assert.NoError(t, err)
+		amounts := make(map[string]string)
+		for _, account := range *tb {
+			amounts[account.Account] = account.Amount.String()
+		}
+		return amounts
+	}
+	assert.Equal(t, map[string]string{"Assets:Checking": "-1000", "Expenses:Groceries": "1000"}, balance(date.AddDate(0, 1, 0)))
+
+	assert.NoError(t, ledgerdb.DeleteTagFromTransaction(ctx, voided.Id, "Void"))
+	assert.Equal(t, map[string]string{"Assets:Checking": "-1325", "Expenses:Groceries": "1325"}, balance(date.AddDate(0, 2, 0)))
+
+	// An account balance totals only that account's summaries and splits
+	lines, err := ledgerdb.GetAccountBalance(ctx, "Expenses:Groceries", date.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	if assert.Len(t, lines, 1) {
+		assert.Equal(t, "Expenses:Groceries", lines[0].Account)
+		assert.Equal(t, "1250", lines[0].Amount.String())
+		assert.Equal(t, "AUD", lines[0].Currency)
+	}
+	lines, err = ledgerdb.GetAccountBalance(ctx, "Expenses:Rent", date.AddDate(0, 2, 0))
+	assert.NoError(t, err)
+	assert.Empty(t, lines)
+
+	_, err = ledgerdb.DB.ExecContext(ctx, `UPDATE account_balances SET amount = decimal_add(amount, 1) WHERE account_id = ?`, "Expenses:Groceries")
+	assert.NoError(t, err)
+	discrepancies, err = ledgerdb.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, discrepancies, 2) {
+		assert.Equal(t, core.BalanceDiscrepancy{
+			Account:  "Expenses:Groceries",
+			Currency: "AUD",
+			Period:   time.Date(2011, 1, 1, 0, 0, 0, 0, time.UTC),
+			Stored:   big.NewInt(1001),
+			Actual:   big.NewInt(1000),
+		}, discrepancies[0])
+	}
+
+	assert.NoError(t, ledgerdb.RebuildBalances(ctx))
+	discrepancies, err = ledgerdb.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
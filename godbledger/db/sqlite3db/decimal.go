@@ -0,0 +1,128 @@
+package sqlite3db
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+// driverName is the SQLite driver the ledger is opened with. SQLite integers
+// are limited to 64 bits so amounts are stored as base 10 text, and every
+// connection of the driver registers the functions that do arithmetic on
+// them:
+//
+//	decimal_sum(x)    sums a column of amounts, "0" when there are none
+//	decimal_add(x, y) adds two amounts
+//	decimal_mul(x, y) multiplies two amounts
+//	decimal_abs(x)    the amount without its sign
+//	decimal_cmp(x, y) -1, 0 or 1 as x is less than, equal to or greater than y
+const driverName = "sqlite3_godbledger"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterAggregator("decimal_sum", newDecimalSum, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("decimal_add", decimalAdd, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("decimal_mul", decimalMul, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("decimal_abs", decimalAbs, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("decimal_cmp", decimalCmp, true)
+		},
+	})
+}
+
+// decimalValue reads an argument of the decimal functions. Amounts written
+// before they were stored as text are still integers, and NULL is zero.
+func decimalValue(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case nil:
+		return new(big.Int), nil
+	case int64:
+		return big.NewInt(v), nil
+	case string:
+		return core.ParseAmount(v)
+	case []byte:
+		if v == nil {
+			return new(big.Int), nil
+		}
+		return core.ParseAmount(string(v))
+	default:
+		return nil, fmt.Errorf("Cannot use %v as an amount", value)
+	}
+}
+
+type decimalSum struct {
+	total *big.Int
+}
+
+func newDecimalSum() *decimalSum {
+	return &decimalSum{new(big.Int)}
+}
+
+func (s *decimalSum) Step(value interface{}) error {
+	amount, err := decimalValue(value)
+	if err != nil {
+		return err
+	}
+	s.total.Add(s.total, amount)
+	return nil
+}
+
+func (s *decimalSum) Done() (string, error) {
+	return s.total.String(), nil
+}
+
+func decimalAdd(x, y interface{}) (string, error) {
+	a, err := decimalValue(x)
+	if err != nil {
+		return "", err
+	}
+	b, err := decimalValue(y)
+	if err != nil {
+		return "", err
+	}
+	return new(big.Int).Add(a, b).String(), nil
+}
+
+func decimalMul(x, y interface{}) (string, error) {
+	a, err := decimalValue(x)
+	if err != nil {
+		return "", err
+	}
+	b, err := decimalValue(y)
+	if err != nil {
+		return "", err
+	}
+	return new(big.Int).Mul(a, b).String(), nil
+}
+
+func decimalAbs(x interface{}) (string, error) {
+	a, err := decimalValue(x)
+	if err != nil {
+		return "", err
+	}
+	return new(big.Int).Abs(a).String(), nil
+}
+
+func decimalCmp(x, y interface{}) (int, error) {
+	a, err := decimalValue(x)
+	if err != nil {
+		return 0, err
+	}
+	b, err := decimalValue(y)
+	if err != nil {
+		return 0, err
+	}
+	return a.Cmp(b), nil
+}
@@ -0,0 +1,115 @@
+package sqlite3db
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+func TestArbitraryPrecisionAmounts(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	usr, _ := core.NewUser("Tester")
+	assert.NoError(t, ledgerdb.SafeAddUser(ctx, usr))
+	eth, err := ledgerdb.FindCurrency(ctx, "ETH")
+	assert.NoError(t, err)
+	date := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Far beyond the 9223372036854775807 an int64 holds
+	amount, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	var first *core.Transaction
+	for _, month := range []int{0, 1} {
+		txn, _ := core.NewTransaction(usr)
+		for _, line := range []struct {
+			account string
+			amount  *big.Int
+		}{{"Assets:Wallet", amount}, {"Equity:Contributions", new(big.Int).Neg(amount)}} {
+			acc, _ := core.NewAccount(line.account, line.account)
+			_, err := ledgerdb.SafeAddAccount(ctx, acc)
+			assert.NoError(t, err)
+			spl, _ := core.NewSplit(date.AddDate(0, month, 0), []byte("Deposit"), []*core.Account{acc}, eth, line.amount)
+			txn.AppendSplit(spl)
+		}
+		_, err := ledgerdb.AddTransaction(ctx, txn)
+		assert.NoError(t, err)
+		if first == nil {
+			first = txn
+		}
+	}
+
+	found, err := ledgerdb.FindTransaction(ctx, first.Id)
+	assert.NoError(t, err)
+	amounts := []string{}
+	for _, split := range found.Splits {
+		amounts = append(amounts, split.Amount.String())
+	}
+	assert.ElementsMatch(t, []string{"123456789012345678901234567890", "-123456789012345678901234567890"}, amounts)
+
+	// The first month is read from the balance summaries and the second from
+	// the splits
+	tb, err := ledgerdb.GetTB(ctx, date.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	balances := make(map[string]string)
+	for _, account := range *tb {
+		balances[account.Account] = core.FormatAmount(account.Amount, account.Decimals)
+	}
+	assert.Equal(t, map[string]string{
+		"Assets:Wallet":        "246913578024.691357802469135780",
+		"Equity:Contributions": "-246913578024.691357802469135780",
+	}, balances)
+
+	discrepancies, err := ledgerdb.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+
+	rule := &core.PostingRule{Name: "whale", Type: core.RuleMaxAmount, Currency: "ETH", Amount: amount}
+	assert.NoError(t, ledgerdb.AddPostingRule(ctx, rule))
+	rules, err := ledgerdb.GetPostingRules(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, rules, 1) {
+		assert.Equal(t, amount.String(), rules[0].Amount.String())
+	}
+}
+
+func TestAmountsMigratedToText(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb, err := NewDB(t.TempDir(), "rwc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledgerdb.Close()
+
+	// A ledger written before amounts were stored as text
+	migrator := ledgerdb.migrator()
+	migrator.Migrations = migrations[:5]
+	_, err = migrator.Up(ctx, false)
+	assert.NoError(t, err)
+	for _, statement := range []string{
+		`INSERT INTO users(user_id, username) VALUES('u1', 'Tester')`,
+		`INSERT INTO currencies(name, decimals) VALUES('AUD', 2)`,
+		`INSERT INTO accounts(account_id, name) VALUES('Cash', 'Cash')`,
+		`INSERT INTO transactions(transaction_id, postdate, description, poster_user_id) VALUES('t1', '2021-06-01 00:00:00', 'Old', 'u1')`,
+		`INSERT INTO splits(split_id, split_date, description, currency, amount, transaction_id) VALUES('s1', '2021-06-01 00:00:00', 'Old', 'AUD', 9223372036854775807, 't1')`,
+		`INSERT INTO split_accounts(split_id, account_id) VALUES('s1', 'Cash')`,
+		`INSERT INTO account_balances(account_id, currency, period, amount, split_count) VALUES('Cash', 'AUD', '2021-06-01', 9223372036854775807, 1)`,
+	} {
+		_, err := ledgerdb.DB.ExecContext(ctx, statement)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, ledgerdb.InitDB(ctx))
+	var amount, storage string
+	assert.NoError(t, ledgerdb.DB.QueryRowContext(ctx, `SELECT amount, typeof(amount) FROM splits WHERE split_id = 's1'`).Scan(&amount, &storage))
+	assert.Equal(t, "9223372036854775807", amount)
+	assert.Equal(t, "text", storage)
+
+	discrepancies, err := ledgerdb.VerifyBalances(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
@@ -0,0 +1,32 @@
+package sqlite3db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/darcys22/godbledger/godbledger/db"
+)
+
+// translateError converts SQLite errors into the errors defined by the db
+// package, anything it does not recognise is returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.ErrNotFound
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return fmt.Errorf("%w: %v", db.ErrAlreadyExists, err)
+		default:
+			return fmt.Errorf("%w: %v", db.ErrConstraintViolation, err)
+		}
+	}
+	return err
+}
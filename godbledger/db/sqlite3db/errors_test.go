@@ -0,0 +1,27 @@
+package sqlite3db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db"
+)
+
+func TestTranslateError(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	_, err := ledgerdb.FindCurrency(ctx, "XYZ")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+
+	err = ledgerdb.AddCurrency(ctx, &core.Currency{Name: "AUD", Decimals: 2})
+	assert.True(t, errors.Is(err, db.ErrAlreadyExists))
+
+	// Tagging requires the account to exist
+	err = ledgerdb.AddTagToAccount(ctx, "Assets:Missing", 1)
+	assert.True(t, errors.Is(err, db.ErrConstraintViolation), "%v", err)
+}
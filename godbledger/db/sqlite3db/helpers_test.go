@@ -0,0 +1,21 @@
+package sqlite3db
+
+import (
+	"context"
+	"testing"
+)
+
+// newTestDB opens an initialised database in a temporary directory, closed
+// when the test ends.
+func newTestDB(t *testing.T) *Database {
+	t.Helper()
+	ledgerdb, err := NewDB(t.TempDir(), "rwc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ledgerdb.Close() })
+	if err := ledgerdb.InitDB(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return ledgerdb
+}
@@ -0,0 +1,96 @@
+package sqlite3db
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	usr, _ := core.NewUser("Tester")
+	assert.NoError(t, ledgerdb.SafeAddUser(ctx, usr))
+	nzd := &core.Currency{Name: "NZD", Decimals: 2}
+	assert.NoError(t, ledgerdb.AddCurrency(ctx, nzd))
+	date := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	post := func(currency *core.Currency) *core.Transaction {
+		txn, _ := core.NewTransaction(usr)
+		for _, line := range []struct {
+			account string
+			amount  int64
+		}{{"Expenses:General", 100}, {"Assets:Cash", -100}} {
+			acc, _ := core.NewAccount(line.account, line.account)
+			_, err := ledgerdb.SafeAddAccount(ctx, acc)
+			assert.NoError(t, err)
+			spl, _ := core.NewSplit(date, []byte("Line"), []*core.Account{acc}, currency, big.NewInt(line.amount))
+			txn.AppendSplit(spl)
+		}
+		_, err := ledgerdb.AddTransaction(ctx, txn)
+		assert.NoError(t, err)
+		return txn
+	}
+	usd, _ := ledgerdb.FindCurrency(ctx, "USD")
+	healthy := post(usd)
+	foreign := post(nzd)
+
+	violations, err := ledgerdb.CheckIntegrity(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+
+	// Currencies in use cannot be deleted
+	assert.True(t, errors.Is(ledgerdb.DeleteCurrency(ctx, "NZD"), dberr.ErrConstraintViolation))
+	assert.NoError(t, ledgerdb.DeleteCurrency(ctx, "GBP"))
+
+	// Break the ledger the ways older versions could
+	for _, statement := range []string{
+		`INSERT INTO splits(split_id, split_date, description, currency, amount, transaction_id) VALUES ('zero', '2021-06-01', '', 'USD', '0', '` + healthy.Id + `')`,
+		`INSERT INTO splits(split_id, split_date, description, currency, amount, transaction_id) VALUES ('stray', '2021-06-01', '', 'USD', '50', '` + healthy.Id + `')`,
+		`INSERT INTO transactions(transaction_id, postdate, description, poster_user_id) VALUES ('empty', '2021-06-01', 'Empty', '` + usr.Id + `')`,
+		`DELETE FROM currencies WHERE name = 'NZD'`,
+	} {
+		_, err := ledgerdb.DB.ExecContext(ctx, statement)
+		assert.NoError(t, err)
+	}
+
+	violations, err = ledgerdb.CheckIntegrity(ctx)
+	assert.NoError(t, err)
+	kinds := []string{}
+	for _, violation := range violations {
+		kinds = append(kinds, violation.Kind+" "+violation.Transaction+" "+violation.Split)
+	}
+	assert.ElementsMatch(t, []string{
+		core.OrphanSplitViolation + " " + healthy.Id + " stray",
+		core.OrphanSplitViolation + " " + healthy.Id + " zero",
+		core.UnbalancedTransactionViolation + " " + healthy.Id + " ",
+		core.MissingCurrencyViolation + " " + foreign.Id + " " + foreign.Splits[0].Id,
+		core.MissingCurrencyViolation + " " + foreign.Id + " " + foreign.Splits[1].Id,
+		core.EmptyTransactionViolation + " empty ",
+	}, kinds)
+
+	assert.NoError(t, ledgerdb.RepairIntegrity(ctx, violations))
+	violations, err = ledgerdb.CheckIntegrity(ctx)
+	assert.NoError(t, err)
+	kinds = []string{}
+	for _, violation := range violations {
+		assert.False(t, violation.Repairable)
+		kinds = append(kinds, violation.Kind)
+	}
+	assert.ElementsMatch(t, []string{
+		core.OrphanSplitViolation,
+		core.UnbalancedTransactionViolation,
+		core.MissingCurrencyViolation,
+		core.MissingCurrencyViolation,
+	}, kinds)
+	_, err = ledgerdb.FindTransaction(ctx, healthy.Id)
+	assert.NoError(t, err)
+}
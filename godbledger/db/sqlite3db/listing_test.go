@@ -0,0 +1,59 @@
+package sqlite3db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db/dbtest"
+)
+
+func TestGetListing(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+	post := dbtest.NewLedger(t, ledgerdb).Post
+	groceries := post("Expenses:Groceries", "Assets:Checking", 1000, date)
+	rent := post("Expenses:Rent", "Assets:Checking", 250, date)
+	fuel := post("Expenses:Fuel", "Assets:Savings", 75, date)
+	post("Expenses:Groceries", "Assets:Checking", 50, date.AddDate(0, 1, 0))
+	assert.NoError(t, ledgerdb.SafeAddTagToTransaction(ctx, rent.Id, "Home"))
+
+	end := date.AddDate(0, 0, 1)
+	listing, err := ledgerdb.GetListing(ctx, core.ListingFilter{StartDate: date, EndDate: end})
+	assert.NoError(t, err)
+	if assert.Len(t, *listing, 3) {
+		for _, txn := range *listing {
+			assert.Len(t, txn.Splits, 2)
+		}
+	}
+
+	listing, err = ledgerdb.GetListing(ctx, core.ListingFilter{StartDate: date, EndDate: end, Accounts: []string{"Assets:Checking"}})
+	assert.NoError(t, err)
+	assert.Len(t, *listing, 2)
+
+	listing, err = ledgerdb.GetListing(ctx, core.ListingFilter{StartDate: date, EndDate: end, Tags: []string{"Home"}})
+	assert.NoError(t, err)
+	if assert.Len(t, *listing, 1) {
+		assert.Equal(t, rent.Id, (*listing)[0].Id)
+	}
+
+	seen := []string{}
+	filter := core.ListingFilter{StartDate: date, EndDate: end, Limit: 2}
+	for {
+		listing, err = ledgerdb.GetListing(ctx, filter)
+		assert.NoError(t, err)
+		if len(*listing) == 0 {
+			break
+		}
+		for _, txn := range *listing {
+			seen = append(seen, txn.Id)
+		}
+		filter.After = seen[len(seen)-1]
+	}
+	assert.ElementsMatch(t, []string{groceries.Id, rent.Id, fuel.Id}, seen)
+}
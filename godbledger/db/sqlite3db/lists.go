@@ -0,0 +1,216 @@
+package sqlite3db
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func (db *Database) ListUsers(ctx context.Context) ([]*core.User, error) {
+	rows, err := db.conn().QueryContext(ctx, `SELECT user_id, username FROM users ORDER BY username`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	users := []*core.User{}
+	for rows.Next() {
+		var usr core.User
+		if err := rows.Scan(&usr.Id, &usr.Name); err != nil {
+			return nil, err
+		}
+		users = append(users, &usr)
+	}
+	return users, rows.Err()
+}
+
+func (db *Database) ListAccounts(ctx context.Context) ([]*core.Account, error) {
+	rows, err := db.conn().QueryContext(ctx, `SELECT account_id, name FROM accounts ORDER BY account_id`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	accounts := []*core.Account{}
+	for rows.Next() {
+		var acc core.Account
+		if err := rows.Scan(&acc.Code, &acc.Name); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, &acc)
+	}
+	return accounts, rows.Err()
+}
+
+func (db *Database) ListCurrencies(ctx context.Context) ([]*core.Currency, error) {
+	rows, err := db.conn().QueryContext(ctx, `SELECT name, decimals FROM currencies ORDER BY name`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	currencies := []*core.Currency{}
+	for rows.Next() {
+		var cur core.Currency
+		if err := rows.Scan(&cur.Name, &cur.Decimals); err != nil {
+			return nil, err
+		}
+		currencies = append(currencies, &cur)
+	}
+	return currencies, rows.Err()
+}
+
+func (db *Database) ListTags(ctx context.Context) ([]string, error) {
+	rows, err := db.conn().QueryContext(ctx, `SELECT tag_name FROM tags ORDER BY tag_name`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// ListTransactions reads a page of transactions with their splits in one
+// query, taking the description from the extended table when it was too
+// long for the transactions table, and their tags in another.
+func (db *Database) ListTransactions(ctx context.Context, after string, limit int) ([]*core.Transaction, error) {
+	page := "SELECT transaction_id FROM transactions WHERE transaction_id > ? ORDER BY transaction_id"
+	args := []interface{}{after}
+	if limit > 0 {
+		page += " LIMIT ?"
+		args = append(args, limit)
+	}
+	query := `
+		SELECT t.transaction_id,
+					 t.postdate,
+					 COALESCE(b.body, t.description),
+					 u.user_id,
+					 u.username,
+					 s.split_id,
+					 s.split_date,
+					 s.description,
+					 a.account_id,
+					 a.name,
+					 s.currency,
+					 c.decimals,
+					 s.amount
+		FROM   (` + page + `) AS page
+					 JOIN transactions AS t
+						 ON t.transaction_id = page.transaction_id
+					 JOIN users AS u
+						 ON t.poster_user_id = u.user_id
+					 LEFT JOIN transactions_body AS b
+						 ON b.transaction_id = t.transaction_id
+					 JOIN splits AS s
+						 ON s.transaction_id = t.transaction_id
+					 JOIN split_accounts AS sa
+						 ON s.split_id = sa.split_id
+					 JOIN accounts AS a
+						 ON sa.account_id = a.account_id
+					 JOIN currencies AS c
+						 ON s.currency = c.name
+		ORDER  BY t.transaction_id, s.split_id, a.account_id;`
+
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	txns := []*core.Transaction{}
+	byID := make(map[string]*core.Transaction)
+	var spl *core.Split
+	for rows.Next() {
+		var txn core.Transaction
+		var poster core.User
+		var split core.Split
+		var account core.Account
+		var cur core.Currency
+		var amount *big.Int
+		err := rows.Scan(&txn.Id, &txn.Postdate, &txn.Description, &poster.Id, &poster.Name,
+			&split.Id, &split.Date, &split.Description, &account.Code, &account.Name, &cur.Name, &cur.Decimals, dberr.ScanAmount(&amount))
+		if err != nil {
+			return nil, err
+		}
+		current, ok := byID[txn.Id]
+		if !ok {
+			txn.Poster = &poster
+			txn.Tags = []string{}
+			current = &txn
+			byID[txn.Id] = current
+			txns = append(txns, current)
+		}
+		// A split posted to several accounts comes back once for each
+		if spl == nil || spl.Id != split.Id {
+			split.Currency = &cur
+			split.Amount = amount
+			spl = &split
+			current.Splits = append(current.Splits, spl)
+		}
+		spl.Accounts = append(spl.Accounts, &account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(txns) == 0 {
+		return txns, nil
+	}
+
+	tagArgs := make([]interface{}, 0, len(txns))
+	for _, txn := range txns {
+		tagArgs = append(tagArgs, txn.Id)
+	}
+	tagRows, err := db.conn().QueryContext(ctx, `
+		SELECT tt.transaction_id,
+					 g.tag_name
+		FROM   transaction_tag AS tt
+					 JOIN tags AS g
+						 ON tt.tag_id = g.tag_id
+		WHERE  tt.transaction_id IN `+questionMarks(len(tagArgs))+`
+		ORDER  BY tt.transaction_id, g.tag_name;`, tagArgs...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var txnID, tag string
+		if err := tagRows.Scan(&txnID, &tag); err != nil {
+			return nil, err
+		}
+		byID[txnID].Tags = append(byID[txnID].Tags, tag)
+	}
+
+	return txns, tagRows.Err()
+}
+
+func (db *Database) ListReconciliations(ctx context.Context) ([]core.Reconciliation, error) {
+	rows, err := db.conn().QueryContext(ctx, `SELECT reconciliation_id, split_id FROM reconciliations ORDER BY reconciliation_id, split_id`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	reconciliations := []core.Reconciliation{}
+	for rows.Next() {
+		var id, splitID string
+		if err := rows.Scan(&id, &splitID); err != nil {
+			return nil, err
+		}
+		if n := len(reconciliations); n == 0 || reconciliations[n-1].Id != id {
+			reconciliations = append(reconciliations, core.Reconciliation{Id: id})
+		}
+		last := &reconciliations[len(reconciliations)-1]
+		last.Splits = append(last.Splits, splitID)
+	}
+	return reconciliations, rows.Err()
+}
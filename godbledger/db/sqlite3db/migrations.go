@@ -0,0 +1,274 @@
+package sqlite3db
+
+import (
+	"context"
+
+	"github.com/darcys22/godbledger/godbledger/db/migrate"
+)
+
+// migrations are applied in order by InitDB. Released migrations must not be
+// edited, schema changes are made by appending a new migration to the list.
+var migrations = []migrate.Migration{
+	{
+		Version:     1,
+		Description: "Initial schema",
+		Statements: []string{
+			//USERS
+			`
+				CREATE TABLE IF NOT EXISTS users (
+					user_id INT NOT NULL,
+					username VARCHAR(255) NOT NULL,
+					PRIMARY KEY(user_id)
+				);`,
+			//ACCOUNTS
+			`
+				CREATE TABLE IF NOT EXISTS accounts (
+					account_id VARCHAR(255) NOT NULL,
+					name VARCHAR(255) NOT NULL,
+					PRIMARY KEY(account_id)
+				);`,
+			//TAGS
+			`
+				CREATE TABLE IF NOT EXISTS tags (
+					tag_id INTEGER PRIMARY KEY,
+					tag_name VARCHAR(100) NOT NULL UNIQUE
+				);`,
+			//TAGS FOR ACCOUNTS
+			`
+				CREATE TABLE IF NOT EXISTS account_tag (
+					account_id VARCHAR(255) NOT NULL,
+					tag_id INTEGER NOT NULL,
+					FOREIGN KEY (account_id) REFERENCES accounts (account_id) ON DELETE CASCADE ON UPDATE CASCADE,
+					FOREIGN KEY (tag_id) REFERENCES tags (tag_id) ON DELETE CASCADE ON UPDATE CASCADE,
+					PRIMARY KEY (account_id, tag_id)
+				);`,
+			//CURRENCIES
+			`
+				CREATE TABLE IF NOT EXISTS currencies (
+					name VARCHAR(255) NOT NULL,
+					decimals INT NOT NULL,
+					PRIMARY KEY(name)
+				);`,
+			//TRANSACTIONS
+			`
+				CREATE TABLE IF NOT EXISTS transactions (
+					transaction_id VARCHAR(255) NOT NULL,
+					postdate DATETIME NOT NULL,
+					description VARCHAR(255),
+					poster_user_id VARCHAR(255),
+					PRIMARY KEY(transaction_id),
+					FOREIGN KEY (poster_user_id) REFERENCES users (user_id) ON DELETE RESTRICT ON UPDATE CASCADE
+				);`,
+			//TRANSACTIONS BODY
+			`
+				CREATE TABLE IF NOT EXISTS transactions_body (
+					transaction_id VARCHAR(255) NOT NULL,
+					body TEXT,
+					FOREIGN KEY(transaction_id) REFERENCES transactions(transaction_id) ON DELETE CASCADE ON UPDATE CASCADE
+				);`,
+			//TAGS FOR Transactions
+			`
+				CREATE TABLE IF NOT EXISTS transaction_tag (
+					transaction_id VARCHAR(255) NOT NULL,
+					tag_id INTEGER NOT NULL,
+					FOREIGN KEY (transaction_id) REFERENCES transactions (transaction_id) ON DELETE RESTRICT ON UPDATE CASCADE,
+					FOREIGN KEY (tag_id) REFERENCES tags (tag_id) ON DELETE CASCADE ON UPDATE CASCADE,
+					PRIMARY KEY (transaction_id, tag_id)
+				);`,
+			//LINE ITEMS FOR TRANSACTIONS (SPLITS)
+			`
+				CREATE TABLE IF NOT EXISTS splits (
+					split_id VARCHAR(255) NOT NULL,
+					split_date DATETIME,
+					description VARCHAR(255),
+					currency VARCHAR(255),
+					amount BIGINT,
+					transaction_id VARCHAR(255),
+					FOREIGN KEY(transaction_id) REFERENCES transactions(transaction_id) ON DELETE CASCADE ON UPDATE CASCADE,
+					PRIMARY KEY(split_id)
+				);`,
+			//ACCOUNTS FOR SPLITS
+			`
+				CREATE TABLE IF NOT EXISTS split_accounts (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					split_id VARCHAR(255),
+					account_id VARCHAR(255),
+					FOREIGN KEY(split_id) REFERENCES splits(split_id) ON DELETE CASCADE ON UPDATE CASCADE,
+					FOREIGN KEY(account_id) REFERENCES accounts(account_id) ON DELETE RESTRICT ON UPDATE CASCADE
+				);`,
+			//RECONCILIATIONS
+			`
+				CREATE TABLE IF NOT EXISTS reconciliations (
+					reconciliation_id VARCHAR(255) NOT NULL,
+					split_id VARCHAR(255) NOT NULL,
+					FOREIGN KEY (split_id) REFERENCES splits (split_id) ON DELETE CASCADE ON UPDATE CASCADE,
+					PRIMARY KEY (reconciliation_id, split_id)
+				);`,
+			//ENTITIES
+			`
+				CREATE TABLE IF NOT EXISTS entities (
+					entity_id VARCHAR(255) NOT NULL,
+					name VARCHAR(255) NOT NULL,
+					tag VARCHAR(255),
+					type VARCHAR(255),
+					description VARCHAR(255),
+					PRIMARY KEY(entity_id)
+				);`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "Allocation rules",
+		Statements: []string{
+			//ALLOCATION RULES
+			`
+				CREATE TABLE IF NOT EXISTS allocation_rules (
+					rule_name VARCHAR(255) NOT NULL,
+					PRIMARY KEY(rule_name)
+				);`,
+			//TARGET ACCOUNTS FOR ALLOCATION RULES
+			`
+				CREATE TABLE IF NOT EXISTS allocation_targets (
+					rule_name VARCHAR(255) NOT NULL,
+					position INT NOT NULL,
+					account_id VARCHAR(255) NOT NULL,
+					weight BIGINT NOT NULL,
+					FOREIGN KEY(rule_name) REFERENCES allocation_rules(rule_name) ON DELETE CASCADE ON UPDATE CASCADE,
+					PRIMARY KEY(rule_name, position)
+				);`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "Posting rules",
+		Statements: []string{
+			//POSTING RULES
+			`
+				CREATE TABLE IF NOT EXISTS posting_rules (
+					rule_name VARCHAR(255) NOT NULL,
+					rule_type VARCHAR(255) NOT NULL,
+					account VARCHAR(255),
+					tag VARCHAR(255),
+					currency VARCHAR(255),
+					amount BIGINT,
+					PRIMARY KEY(rule_name)
+				);`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "Transaction trash",
+		Statements: []string{
+			//TRASHED TRANSACTIONS
+			`
+				CREATE TABLE IF NOT EXISTS trashed_transactions (
+					transaction_id VARCHAR(255) NOT NULL,
+					trashed_at DATETIME NOT NULL,
+					FOREIGN KEY (transaction_id) REFERENCES transactions (transaction_id) ON DELETE CASCADE ON UPDATE CASCADE,
+					PRIMARY KEY (transaction_id)
+				);`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "Account balances",
+		Statements: []string{
+			//MONTHLY ACCOUNT BALANCES
+			`
+				CREATE TABLE IF NOT EXISTS account_balances (
+					account_id VARCHAR(255) NOT NULL,
+					currency VARCHAR(255) NOT NULL,
+					period DATE NOT NULL,
+					amount BIGINT NOT NULL,
+					split_count INT NOT NULL,
+					PRIMARY KEY (account_id, currency, period)
+				);`,
+			`
+				INSERT INTO account_balances(account_id, currency, period, amount, split_count)
+				SELECT split_accounts.account_id,
+							 splits.currency,
+							 substr(splits.split_date, 1, 7) || '-01',
+							 SUM(splits.amount),
+							 COUNT(*)
+				FROM   splits
+							 JOIN split_accounts
+								 ON splits.split_id = split_accounts.split_id
+				WHERE  splits.transaction_id NOT IN (SELECT transaction_id
+																						 FROM   trashed_transactions)
+							 AND splits.transaction_id NOT IN (SELECT tt.transaction_id
+																								 FROM   transaction_tag AS tt
+																												JOIN tags AS t
+																													ON tt.tag_id = t.tag_id
+																								 WHERE  LOWER(t.tag_name) = 'void')
+				GROUP  BY split_accounts.account_id, splits.currency, substr(splits.split_date, 1, 7) || '-01';`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "Arbitrary precision amounts",
+		Statements: []string{
+			// SQLite integers are limited to 64 bits, amounts are moved to text
+			// columns and totalled with the decimal functions of the driver
+			`ALTER TABLE splits RENAME COLUMN amount TO amount_int;`,
+			`ALTER TABLE splits ADD COLUMN amount TEXT;`,
+			`UPDATE splits SET amount = CAST(amount_int AS TEXT);`,
+			`ALTER TABLE splits DROP COLUMN amount_int;`,
+			`ALTER TABLE account_balances RENAME COLUMN amount TO amount_int;`,
+			`ALTER TABLE account_balances ADD COLUMN amount TEXT NOT NULL DEFAULT '0';`,
+			`UPDATE account_balances SET amount = CAST(amount_int AS TEXT);`,
+			`ALTER TABLE account_balances DROP COLUMN amount_int;`,
+			`ALTER TABLE posting_rules RENAME COLUMN amount TO amount_int;`,
+			`ALTER TABLE posting_rules ADD COLUMN amount TEXT;`,
+			`UPDATE posting_rules SET amount = CAST(amount_int AS TEXT);`,
+			`ALTER TABLE posting_rules DROP COLUMN amount_int;`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "Webhook deliveries",
+		Statements: []string{
+			//WEBHOOK DELIVERIES
+			`
+				CREATE TABLE IF NOT EXISTS webhook_deliveries (
+					delivery_id VARCHAR(255) NOT NULL,
+					webhook VARCHAR(255) NOT NULL,
+					event_kind VARCHAR(100) NOT NULL,
+					payload TEXT NOT NULL,
+					attempts INT NOT NULL DEFAULT 0,
+					next_attempt DATETIME NOT NULL,
+					last_error VARCHAR(1024) NOT NULL DEFAULT '',
+					dead BOOLEAN NOT NULL DEFAULT FALSE,
+					created_at DATETIME NOT NULL,
+					PRIMARY KEY (delivery_id)
+				);`,
+			`CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (dead, next_attempt);`,
+		},
+	},
+}
+
+func (db *Database) migrator() *migrate.Migrator {
+	return &migrate.Migrator{
+		DB:         db.DB,
+		Migrations: migrations,
+		CreateTable: `
+			CREATE TABLE IF NOT EXISTS schema_version (
+				version INT NOT NULL,
+				description VARCHAR(255) NOT NULL,
+				applied_at DATETIME NOT NULL,
+				PRIMARY KEY(version)
+			);`,
+		TableExists: `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'`,
+		Insert:      `INSERT INTO schema_version(version, description, applied_at) VALUES(?,?,?)`,
+	}
+}
+
+// Migrate applies any pending schema migrations and returns them, with dryRun
+// set the pending migrations are returned without being applied.
+func (db *Database) Migrate(ctx context.Context, dryRun bool) ([]migrate.Migration, error) {
+	return db.migrator().Up(ctx, dryRun)
+}
+
+// MigrationStatus lists every schema migration and whether it has been applied.
+func (db *Database) MigrationStatus(ctx context.Context) ([]migrate.Status, error) {
+	return db.migrator().Status(ctx)
+}
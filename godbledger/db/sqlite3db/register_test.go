@@ -0,0 +1,46 @@
+package sqlite3db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/db/dbtest"
+)
+
+func TestGetAccountSplits(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	date := time.Date(2011, 3, 1, 0, 0, 0, 0, time.UTC)
+	post := dbtest.NewLedger(t, ledgerdb).Post
+	feb := post("Assets:Cash", "Equity:Capital", 10000, date.AddDate(0, 0, -1))
+	first := post("Expenses:Rent", "Assets:Cash", 2500, date)
+	voided := post("Expenses:Rent", "Assets:Cash", 250, date.AddDate(0, 0, 10))
+	trashed := post("Expenses:Rent", "Assets:Cash", 500, date.AddDate(0, 0, 10))
+	last := post("Assets:Cash", "Income:Sales", 700, date.AddDate(0, 0, 30))
+	post("Assets:Cash", "Income:Sales", 100, date.AddDate(0, 1, 0))
+	assert.NoError(t, ledgerdb.SafeAddTagToTransaction(ctx, voided.Id, "Void"))
+	assert.NoError(t, ledgerdb.DeleteTransaction(ctx, trashed.Id))
+
+	splits, err := ledgerdb.GetAccountSplits(ctx, "Assets:Cash", date, date.AddDate(0, 0, 30))
+	assert.NoError(t, err)
+	lines := []string{}
+	for _, split := range splits {
+		assert.Equal(t, "Assets:Cash", split.Description)
+		assert.Equal(t, 2, split.Decimals)
+		lines = append(lines, split.Transaction+" "+split.Date.Format("2006-01-02")+" "+split.Currency+" "+split.Amount.String())
+	}
+	assert.Equal(t, []string{
+		first.Id + " 2011-03-01 AUD -2500",
+		last.Id + " 2011-03-31 AUD 700",
+	}, lines)
+
+	splits, err = ledgerdb.GetAccountSplits(ctx, "Assets:Cash", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	if assert.Len(t, splits, 4) {
+		assert.Equal(t, feb.Id, splits[0].Transaction)
+	}
+}
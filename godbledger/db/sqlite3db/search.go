@@ -0,0 +1,251 @@
+package sqlite3db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+)
+
+// The descriptions are indexed for searching in the transaction_search FTS5
+// table, one row for each transaction holding its description, long body and
+// the descriptions of its splits. FTS5 rows are found by rowid, which the
+// transaction_search_ids table maps to the transaction identifiers, and the
+// triggers keep both up to date as transactions are written and as splits are
+// deleted, by a repair or along with their transaction.
+//
+// The driver only includes FTS5 when built with the sqlite_fts5 tag. Without
+// it the triggers are dropped, so a ledger indexed by one build can still be
+// written by another, and searches scan the descriptions instead.
+var searchIndex = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS transaction_search USING fts5(description, body, splits);`,
+	`CREATE TABLE IF NOT EXISTS transaction_search_ids (
+				search_id INTEGER PRIMARY KEY,
+				transaction_id VARCHAR(255) NOT NULL UNIQUE
+			);`,
+	`DELETE FROM transaction_search;`,
+	`DELETE FROM transaction_search_ids;`,
+	`INSERT INTO transaction_search_ids(transaction_id)
+				SELECT transaction_id FROM transactions ORDER BY transaction_id;`,
+	`INSERT INTO transaction_search(rowid, description, body, splits)
+				SELECT i.search_id,
+							 CAST(COALESCE(t.description, '') AS TEXT),
+							 CAST(COALESCE(b.body, '') AS TEXT),
+							 COALESCE((SELECT group_concat(CAST(s.description AS TEXT), ' ')
+												 FROM splits AS s
+												 WHERE s.transaction_id = t.transaction_id), '')
+				FROM   transactions AS t
+							 JOIN transaction_search_ids AS i
+								 ON i.transaction_id = t.transaction_id
+							 LEFT JOIN transactions_body AS b
+								 ON b.transaction_id = t.transaction_id;`,
+	`CREATE TRIGGER transaction_search_insert AFTER INSERT ON transactions BEGIN
+				INSERT INTO transaction_search_ids(transaction_id) VALUES (new.transaction_id);
+				INSERT INTO transaction_search(rowid, description, body, splits)
+					VALUES ((SELECT search_id FROM transaction_search_ids WHERE transaction_id = new.transaction_id),
+									CAST(COALESCE(new.description, '') AS TEXT), '', '');
+			END;`,
+	`CREATE TRIGGER transaction_search_body AFTER INSERT ON transactions_body BEGIN
+				UPDATE transaction_search SET body = CAST(COALESCE(new.body, '') AS TEXT)
+					WHERE rowid = (SELECT search_id FROM transaction_search_ids WHERE transaction_id = new.transaction_id);
+			END;`,
+	`CREATE TRIGGER transaction_search_split AFTER INSERT ON splits BEGIN
+				UPDATE transaction_search SET splits = splits || ' ' || CAST(COALESCE(new.description, '') AS TEXT)
+					WHERE rowid = (SELECT search_id FROM transaction_search_ids WHERE transaction_id = new.transaction_id);
+			END;`,
+	`CREATE TRIGGER transaction_search_split_delete AFTER DELETE ON splits BEGIN
+				UPDATE transaction_search
+					SET splits = COALESCE((SELECT group_concat(CAST(s.description AS TEXT), ' ')
+																 FROM splits AS s
+																 WHERE s.transaction_id = old.transaction_id), '')
+					WHERE rowid = (SELECT search_id FROM transaction_search_ids WHERE transaction_id = old.transaction_id);
+			END;`,
+	`CREATE TRIGGER transaction_search_delete AFTER DELETE ON transactions BEGIN
+				DELETE FROM transaction_search
+					WHERE rowid = (SELECT search_id FROM transaction_search_ids WHERE transaction_id = old.transaction_id);
+				DELETE FROM transaction_search_ids WHERE transaction_id = old.transaction_id;
+			END;`,
+}
+
+var searchTriggers = []string{
+	"transaction_search_insert",
+	"transaction_search_body",
+	"transaction_search_split",
+	"transaction_search_split_delete",
+	"transaction_search_delete",
+}
+
+// initSearch builds the search index when FTS5 is available and it is
+// missing or was left behind by a build without FTS5.
+func (db *Database) initSearch(ctx context.Context) error {
+	err := db.DB.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&db.fts)
+	if err != nil {
+		return err
+	}
+	if !db.fts {
+		log.Debug("SQLite built without FTS5, searches will scan the descriptions")
+		for _, trigger := range searchTriggers {
+			if _, err := db.DB.ExecContext(ctx, "DROP TRIGGER IF EXISTS "+trigger); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	names := []interface{}{}
+	for _, trigger := range searchTriggers {
+		names = append(names, trigger)
+	}
+	var triggers int
+	err = db.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN `+questionMarks(len(names)), names...).Scan(&triggers)
+	if err != nil {
+		return err
+	}
+	if triggers == len(searchTriggers) {
+		return nil
+	}
+
+	log.Info("Building the transaction search index")
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, trigger := range searchTriggers {
+		if _, err := tx.ExecContext(ctx, "DROP TRIGGER IF EXISTS "+trigger); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, statement := range searchIndex {
+		log.Debug("Query: " + statement)
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// searchQuery builds the query behind SearchTransactions. Like the listing
+// query the derived table picks the page of matching transactions, and the
+// outer select joins all of their splits.
+func searchQuery(filter core.SearchFilter, fts bool) (string, []interface{}) {
+	terms := core.SearchTerms(filter.Query)
+	where := []string{"t.transaction_id NOT IN (SELECT transaction_id FROM trashed_transactions)"}
+	args := []interface{}{}
+	if fts && len(terms) > 0 {
+		// Each term is quoted and matches the words it starts
+		match := make([]string, len(terms))
+		for i, term := range terms {
+			match[i] = fmt.Sprintf(`"%s"*`, term)
+		}
+		where = append(where, `t.transaction_id IN (
+				SELECT i.transaction_id FROM transaction_search
+					JOIN transaction_search_ids AS i ON i.search_id = transaction_search.rowid
+				WHERE transaction_search MATCH ?)`)
+		args = append(args, strings.Join(match, " "))
+	} else {
+		for _, term := range terms {
+			where = append(where, `(t.description LIKE ? ESCAPE '\'
+				OR EXISTS (SELECT 1 FROM transactions_body AS fb WHERE fb.transaction_id = t.transaction_id AND fb.body LIKE ? ESCAPE '\')
+				OR EXISTS (SELECT 1 FROM splits AS fd WHERE fd.transaction_id = t.transaction_id AND fd.description LIKE ? ESCAPE '\'))`)
+			pattern := "%" + escapeLike(term) + "%"
+			args = append(args, pattern, pattern, pattern)
+		}
+	}
+
+	splitFilter := []string{}
+	if !filter.StartDate.IsZero() {
+		splitFilter = append(splitFilter, "fs.split_date >= ?")
+		args = append(args, filter.StartDate.Format("2006-01-02"))
+	}
+	if !filter.EndDate.IsZero() {
+		splitFilter = append(splitFilter, "fs.split_date < ?")
+		args = append(args, filter.EndDate.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+	if filter.MinAmount != nil {
+		splitFilter = append(splitFilter, "decimal_cmp(decimal_abs(fs.amount), ?) >= 0")
+		args = append(args, filter.MinAmount.String())
+	}
+	if filter.MaxAmount != nil {
+		splitFilter = append(splitFilter, "decimal_cmp(decimal_abs(fs.amount), ?) <= 0")
+		args = append(args, filter.MaxAmount.String())
+	}
+	if len(splitFilter) > 0 {
+		where = append(where, `EXISTS (
+				SELECT 1 FROM splits AS fs
+				WHERE fs.transaction_id = t.transaction_id
+				AND `+strings.Join(splitFilter, " AND ")+")")
+	}
+
+	if filter.After != "" {
+		where = append(where, "t.transaction_id > ?")
+		args = append(args, filter.After)
+	}
+	page := "SELECT t.transaction_id FROM transactions AS t WHERE " + strings.Join(where, " AND ") + " ORDER BY t.transaction_id"
+	if filter.Limit > 0 {
+		page += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	query := `
+		SELECT t.transaction_id,
+					 t.postdate,
+					 COALESCE(b.body, t.description),
+					 u.user_id,
+					 u.username,
+					 s.split_id,
+					 s.split_date,
+					 s.description,
+					 a.account_id,
+					 a.name,
+					 s.currency,
+					 c.decimals,
+					 s.amount
+		FROM   (` + page + `) AS page
+					 JOIN transactions AS t
+						 ON t.transaction_id = page.transaction_id
+					 JOIN users AS u
+						 ON t.poster_user_id = u.user_id
+					 LEFT JOIN transactions_body AS b
+						 ON b.transaction_id = t.transaction_id
+					 JOIN splits AS s
+						 ON s.transaction_id = t.transaction_id
+					 JOIN split_accounts AS sa
+						 ON s.split_id = sa.split_id
+					 JOIN accounts AS a
+						 ON sa.account_id = a.account_id
+					 JOIN currencies AS c
+						 ON s.currency = c.name
+		ORDER  BY t.transaction_id, s.split_id;`
+
+	return query, args
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, so that a term only
+// matches itself. The patterns are given '\' as their ESCAPE character.
+func escapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (db *Database) SearchTransactions(ctx context.Context, filter core.SearchFilter) (*[]core.Transaction, error) {
+	log.Debugf("Searching Transactions in DB for %q", filter.Query)
+
+	query, args := searchQuery(filter, db.fts)
+	log.Debug("Query: " + query)
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	txns, err := scanListing(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txns, nil
+}
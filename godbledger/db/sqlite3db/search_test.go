@@ -0,0 +1,131 @@
+package sqlite3db
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db/dbtest"
+)
+
+func TestSearchTransactions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ledgerdb, err := NewDB(dir, "rwc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledgerdb.Close()
+	assert.NoError(t, ledgerdb.InitDB(ctx))
+
+	usr, _ := core.NewUser("Tester")
+	assert.NoError(t, ledgerdb.SafeAddUser(ctx, usr))
+	aud, _ := ledgerdb.FindCurrency(ctx, "AUD")
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	post := func(description, line string, amount int64, date time.Time) *core.Transaction {
+		txn, _ := core.NewTransaction(usr)
+		txn.Description = []byte(description)
+		for _, l := range []struct {
+			account string
+			amount  int64
+		}{{"Expenses:General", amount}, {"Assets:Checking", -amount}} {
+			acc, _ := core.NewAccount(l.account, l.account)
+			_, err := ledgerdb.SafeAddAccount(ctx, acc)
+			assert.NoError(t, err)
+			spl, _ := core.NewSplit(date, []byte(line), []*core.Account{acc}, aud, big.NewInt(l.amount))
+			txn.AppendSplit(spl)
+		}
+		_, err := ledgerdb.AddTransaction(ctx, txn)
+		assert.NoError(t, err)
+		return txn
+	}
+	rent := post("Office rent", "March payment", 250, date)
+	groceries := post("Groceries", "Weekly shop at the market, paid", 1000, date.AddDate(0, 1, 0))
+	// Only the first 255 characters are kept in the transactions table
+	invoice := post("Invoice "+strings.Repeat("padding ", 40)+"consulting", "Fees paid", 5000, date)
+
+	search := func(filter core.SearchFilter) []string {
+		listing, err := ledgerdb.SearchTransactions(ctx, filter)
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, txn := range *listing {
+			ids = append(ids, txn.Id)
+		}
+		return ids
+	}
+	check := func() {
+		assert.Equal(t, []string{rent.Id}, search(core.SearchFilter{Query: "RENT off"}))
+		assert.Equal(t, []string{groceries.Id}, search(core.SearchFilter{Query: "market"}))
+		assert.Equal(t, []string{invoice.Id}, search(core.SearchFilter{Query: "consult"}))
+		assert.Empty(t, search(core.SearchFilter{Query: "rent market"}))
+
+		assert.Equal(t, []string{groceries.Id}, search(core.SearchFilter{Query: "pai", StartDate: date.AddDate(0, 0, 1)}))
+		assert.ElementsMatch(t, []string{rent.Id, invoice.Id}, search(core.SearchFilter{Query: "pa", EndDate: date}))
+		assert.ElementsMatch(t, []string{groceries.Id, invoice.Id}, search(core.SearchFilter{Query: "pa", MinAmount: big.NewInt(500)}))
+		assert.Equal(t, []string{rent.Id}, search(core.SearchFilter{Query: "pa", MaxAmount: big.NewInt(500)}))
+
+		// Paging through one transaction at a time returns each once
+		seen := []string{}
+		filter := core.SearchFilter{Query: "pa", Limit: 1}
+		for {
+			listing, err := ledgerdb.SearchTransactions(ctx, filter)
+			assert.NoError(t, err)
+			if len(*listing) == 0 {
+				break
+			}
+			assert.Len(t, (*listing)[0].Splits, 2)
+			filter.After = (*listing)[0].Id
+			seen = append(seen, filter.After)
+		}
+		assert.ElementsMatch(t, []string{rent.Id, groceries.Id, invoice.Id}, seen)
+	}
+	check()
+
+	listing, err := ledgerdb.SearchTransactions(ctx, core.SearchFilter{Query: "consulting"})
+	assert.NoError(t, err)
+	if assert.Len(t, *listing, 1) {
+		assert.True(t, strings.HasSuffix(string((*listing)[0].Description), "consulting"))
+	}
+
+	// Opening the ledger again keeps the index
+	reopened, err := NewDB(dir, "rwc")
+	assert.NoError(t, err)
+	defer reopened.Close()
+	assert.NoError(t, reopened.InitDB(ctx))
+	ledgerdb = reopened
+	check()
+
+	assert.NoError(t, ledgerdb.DeleteTransaction(ctx, rent.Id))
+	assert.Empty(t, search(core.SearchFilter{Query: "rent"}))
+}
+
+func TestSearchDeletedSplits(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+	txn := dbtest.NewLedger(t, ledgerdb).Post("Expenses:Stationery", "Assets:Cash", 40, time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC))
+
+	// A repair deletes the split, leaving it out of the searched descriptions
+	_, err := ledgerdb.DB.ExecContext(ctx, `DELETE FROM split_accounts WHERE split_id = ?`, txn.Splits[0].Id)
+	assert.NoError(t, err)
+	_, err = ledgerdb.DB.ExecContext(ctx, `DELETE FROM splits WHERE split_id = ?`, txn.Splits[0].Id)
+	assert.NoError(t, err)
+
+	listing, err := ledgerdb.SearchTransactions(ctx, core.SearchFilter{Query: "stationery"})
+	assert.NoError(t, err)
+	assert.Empty(t, *listing)
+	listing, err = ledgerdb.SearchTransactions(ctx, core.SearchFilter{Query: "cash"})
+	assert.NoError(t, err)
+	assert.Len(t, *listing, 1)
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\%`, escapeLike(`100%`))
+	assert.Equal(t, `a\_b`, escapeLike(`a_b`))
+	assert.Equal(t, `c:\\d`, escapeLike(`c:\d`))
+}
@@ -1,6 +1,7 @@
 package sqlite3db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -19,6 +20,15 @@ type Database struct {
 	DB           *sql.DB
 	DatabasePath string
 	Mode         string
+
+	// tx is set on the copy of the database handed to a unit of work, depth
+	// counts the savepoints opened inside it
+	tx    *sql.Tx
+	depth int
+
+	// fts is set when the driver includes FTS5 and the descriptions are
+	// indexed for searching
+	fts bool
 }
 
 // Close closes the underlying database.
@@ -37,7 +47,7 @@ func NewDB(dirPath, mode string) (*Database, error) {
 		datafile = fmt.Sprintf("%s?_foreign_keys=true&parseTime=true&mode=%s", ":memory:", mode)
 	}
 	log.WithField("datafile", datafile).Debug("Opening SQLite3 Datafile")
-	SqliteDB, err := sql.Open("sqlite3", datafile)
+	SqliteDB, err := sql.Open(driverName, datafile)
 	if err != nil {
 		return nil, err
 	}
@@ -47,180 +57,15 @@ func NewDB(dirPath, mode string) (*Database, error) {
 	return db, err
 }
 
-func (db *Database) InitDB() error {
+func (db *Database) InitDB(ctx context.Context) error {
 	log.Debug("Initialising DB Table")
 
-	//USERS
-	createDB := `
-	CREATE TABLE IF NOT EXISTS users (
-		user_id INT NOT NULL,
-		username VARCHAR(255) NOT NULL,
-		PRIMARY KEY(user_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err := db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//ACCOUNTS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS accounts (
-		account_id VARCHAR(255) NOT NULL,
-		name VARCHAR(255) NOT NULL,
-		PRIMARY KEY(account_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//TAGS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS tags (
-		tag_id INTEGER PRIMARY KEY,
-		tag_name VARCHAR(100) NOT NULL UNIQUE
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//TAGS FOR ACCOUNTS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS account_tag (
-    account_id VARCHAR(255) NOT NULL,
-    tag_id INTEGER NOT NULL,
-    FOREIGN KEY (account_id) REFERENCES accounts (account_id) ON DELETE CASCADE ON UPDATE CASCADE,
-    FOREIGN KEY (tag_id) REFERENCES tags (tag_id) ON DELETE CASCADE ON UPDATE CASCADE,
-    PRIMARY KEY (account_id, tag_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//CURRENCIES
-	createDB = `
-	CREATE TABLE IF NOT EXISTS currencies (
-		name VARCHAR(255) NOT NULL,
-		decimals INT NOT NULL,
-		PRIMARY KEY(name)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//TRANSACTIONS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS transactions (
-		transaction_id VARCHAR(255) NOT NULL,
-		postdate DATETIME NOT NULL,
-		description VARCHAR(255),
-		poster_user_id VARCHAR(255),
-		PRIMARY KEY(transaction_id),
-    FOREIGN KEY (poster_user_id) REFERENCES users (user_id) ON DELETE RESTRICT ON UPDATE CASCADE
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
+	_, err := db.Migrate(ctx, false)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("Migrating database failed: %w", err)
 	}
-
-	//TRANSACTIONS BODY
-	createDB = `
-	CREATE TABLE IF NOT EXISTS transactions_body (
-		transaction_id VARCHAR(255) NOT NULL,
-		body TEXT,
-		FOREIGN KEY(transaction_id) REFERENCES transactions(transaction_id) ON DELETE CASCADE ON UPDATE CASCADE
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//TAGS FOR Transactions
-	createDB = `
-	CREATE TABLE IF NOT EXISTS transaction_tag (
-    transaction_id VARCHAR(255) NOT NULL,
-    tag_id INTEGER NOT NULL,
-    FOREIGN KEY (transaction_id) REFERENCES transactions (transaction_id) ON DELETE RESTRICT ON UPDATE CASCADE,
-    FOREIGN KEY (tag_id) REFERENCES tags (tag_id) ON DELETE CASCADE ON UPDATE CASCADE,
-    PRIMARY KEY (transaction_id, tag_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//LINE ITEMS FOR TRANSACTIONS (SPLITS)
-	createDB = `
-	CREATE TABLE IF NOT EXISTS splits (
-		split_id VARCHAR(255) NOT NULL,
-		split_date DATETIME,
-		description VARCHAR(255),
-		currency VARCHAR(255),
-		amount BIGINT,
-		transaction_id VARCHAR(255),
-		FOREIGN KEY(transaction_id) REFERENCES transactions(transaction_id) ON DELETE CASCADE ON UPDATE CASCADE,
-		PRIMARY KEY(split_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//ACCOUNTS FOR SPLITS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS split_accounts (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		split_id VARCHAR(255),
-		account_id VARCHAR(255),
-		FOREIGN KEY(split_id) REFERENCES splits(split_id) ON DELETE CASCADE ON UPDATE CASCADE,
-		FOREIGN KEY(account_id) REFERENCES accounts(account_id) ON DELETE RESTRICT ON UPDATE CASCADE
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//RECONCILIATIONS
-	createDB = `
-	CREATE TABLE IF NOT EXISTS reconciliations (
-		reconciliation_id VARCHAR(255) NOT NULL,
-		split_id VARCHAR(255) NOT NULL,
-		FOREIGN KEY (split_id) REFERENCES splits (split_id) ON DELETE CASCADE ON UPDATE CASCADE,
-		PRIMARY KEY (reconciliation_id, split_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//ENTITIES
-	createDB = `
-	CREATE TABLE IF NOT EXISTS entities (
-		entity_id VARCHAR(255) NOT NULL,
-		name VARCHAR(255) NOT NULL,
-		tag VARCHAR(255),
-		type VARCHAR(255),
-		description VARCHAR(255),
-		PRIMARY KEY(entity_id)
-	);`
-	log.Debug("Query: " + createDB)
-	_, err = db.DB.Exec(createDB)
-	if err != nil {
-		log.Fatal(err)
+	if err := db.initSearch(ctx); err != nil {
+		return fmt.Errorf("Building the search index failed: %w", err)
 	}
 
 	//Default Currencies
@@ -234,8 +79,8 @@ func (db *Database) InitDB() error {
 			("OXEN",9);
 	`
 	log.Debug("Query: " + insertCurrency)
-	_, _ = db.DB.Exec(insertCurrency)
-	return err
+	_, _ = db.DB.ExecContext(ctx, insertCurrency)
+	return nil
 }
 
 // ClearDB removes the previously stored directory at the data directory.
@@ -0,0 +1,96 @@
+package sqlite3db
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db"
+)
+
+func TestUnitOfWorkRollback(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	usr, _ := core.NewUser("Tester")
+	aud, _ := ledgerdb.FindCurrency(ctx, "AUD")
+	txn, _ := core.NewTransaction(usr)
+	acc, _ := core.NewAccount("Assets:Orphan", "Assets:Orphan")
+	spl, _ := core.NewSplit(time.Now(), []byte{}, []*core.Account{acc}, aud, big.NewInt(1000))
+	txn.AppendSplit(spl)
+
+	err := ledgerdb.UnitOfWork(ctx, func(tx db.Database) error {
+		if err := tx.SafeAddUser(ctx, usr); err != nil {
+			return err
+		}
+		if _, err := tx.SafeAddAccount(ctx, acc); err != nil {
+			return err
+		}
+		if err := tx.SafeAddTagToAccount(ctx, "Assets:Orphan", "main"); err != nil {
+			return err
+		}
+		_, err := tx.AddTransaction(ctx, txn)
+		return err
+	})
+	assert.True(t, errors.Is(err, db.ErrUnbalanced))
+
+	_, err = ledgerdb.FindUser(ctx, "Tester")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+	_, err = ledgerdb.FindAccount(ctx, "Assets:Orphan")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+	_, err = ledgerdb.FindTag(ctx, "main")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+
+	// A balanced transaction commits along with everything it needs
+	offset, _ := core.NewAccount("Income:Sales", "Income:Sales")
+	other, _ := core.NewSplit(time.Now(), []byte{}, []*core.Account{offset}, aud, big.NewInt(-1000))
+	txn.AppendSplit(other)
+	err = ledgerdb.UnitOfWork(ctx, func(tx db.Database) error {
+		if err := tx.SafeAddUser(ctx, usr); err != nil {
+			return err
+		}
+		for _, a := range []*core.Account{acc, offset} {
+			if _, err := tx.SafeAddAccount(ctx, a); err != nil {
+				return err
+			}
+		}
+		_, err := tx.AddTransaction(ctx, txn)
+		return err
+	})
+	assert.NoError(t, err)
+	found, err := ledgerdb.FindTransaction(ctx, txn.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, txn.Id, found.Id)
+}
+
+func TestUnitOfWorkSavepoint(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	kept, _ := core.NewAccount("Assets:Kept", "Assets:Kept")
+	dropped, _ := core.NewAccount("Assets:Dropped", "Assets:Dropped")
+	err := ledgerdb.UnitOfWork(ctx, func(tx db.Database) error {
+		if err := tx.AddAccount(ctx, kept); err != nil {
+			return err
+		}
+		err := tx.UnitOfWork(ctx, func(item db.Database) error {
+			if err := item.AddAccount(ctx, dropped); err != nil {
+				return err
+			}
+			return item.AddAccount(ctx, kept)
+		})
+		assert.True(t, errors.Is(err, db.ErrAlreadyExists), "%v", err)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	_, err = ledgerdb.FindAccount(ctx, "Assets:Kept")
+	assert.NoError(t, err)
+	_, err = ledgerdb.FindAccount(ctx, "Assets:Dropped")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+}
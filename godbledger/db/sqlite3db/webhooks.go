@@ -0,0 +1,120 @@
+package sqlite3db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+const webhookDeliveryColumns = `
+		SELECT delivery_id,
+					 webhook,
+					 event_kind,
+					 payload,
+					 attempts,
+					 next_attempt,
+					 last_error,
+					 dead,
+					 created_at
+		FROM   webhook_deliveries`
+
+func (db *Database) AddWebhookDelivery(ctx context.Context, delivery *core.WebhookDelivery) error {
+	log.WithField("delivery", delivery.ID).Debug("Adding Webhook Delivery to DB")
+	sqlStatement := `
+	INSERT INTO webhook_deliveries(delivery_id, webhook, event_kind, payload, attempts, next_attempt, last_error, dead, created_at)
+		VALUES(?,?,?,?,?,?,?,?,?);`
+	_, err := db.conn().ExecContext(ctx, sqlStatement, delivery.ID, delivery.Webhook, delivery.Kind, string(delivery.Payload), delivery.Attempts,
+		delivery.NextAttempt.UTC(), delivery.LastError, delivery.Dead, delivery.Created.UTC())
+	if err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+func (db *Database) FindWebhookDelivery(ctx context.Context, id string) (*core.WebhookDelivery, error) {
+	deliveries, err := db.queryWebhookDeliveries(ctx, webhookDeliveryColumns+`
+		WHERE  delivery_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, dberr.ErrNotFound
+	}
+	return deliveries[0], nil
+}
+
+func (db *Database) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*core.WebhookDelivery, error) {
+	return db.queryWebhookDeliveries(ctx, webhookDeliveryColumns+`
+		WHERE  dead = ?
+					 AND next_attempt <= ?
+		ORDER  BY next_attempt, created_at
+		LIMIT  ?`, false, now.UTC(), limit)
+}
+
+func (db *Database) ListDeadWebhookDeliveries(ctx context.Context) ([]*core.WebhookDelivery, error) {
+	return db.queryWebhookDeliveries(ctx, webhookDeliveryColumns+`
+		WHERE  dead = ?
+		ORDER  BY created_at, delivery_id`, true)
+}
+
+func (db *Database) queryWebhookDeliveries(ctx context.Context, query string, args ...interface{}) ([]*core.WebhookDelivery, error) {
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	deliveries := []*core.WebhookDelivery{}
+	for rows.Next() {
+		var d core.WebhookDelivery
+		var payload string
+		if err := rows.Scan(&d.ID, &d.Webhook, &d.Kind, &payload, &d.Attempts, &d.NextAttempt, &d.LastError, &d.Dead, &d.Created); err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (db *Database) UpdateWebhookDelivery(ctx context.Context, delivery *core.WebhookDelivery) error {
+	sqlStatement := `
+	UPDATE webhook_deliveries
+	SET    attempts = ?,
+				 next_attempt = ?,
+				 last_error = ?,
+				 dead = ?
+	WHERE  delivery_id = ?;`
+	result, err := db.conn().ExecContext(ctx, sqlStatement, delivery.Attempts, delivery.NextAttempt.UTC(), delivery.LastError, delivery.Dead, delivery.ID)
+	return webhookDeliveryChanged(result, err)
+}
+
+func (db *Database) DeleteWebhookDelivery(ctx context.Context, id string) error {
+	sqlStatement := `
+	DELETE FROM webhook_deliveries
+	WHERE delivery_id = ?;`
+	result, err := db.conn().ExecContext(ctx, sqlStatement, id)
+	return webhookDeliveryChanged(result, err)
+}
+
+// webhookDeliveryChanged returns ErrNotFound when a statement on a single
+// delivery did not affect any rows.
+func webhookDeliveryChanged(result sql.Result, err error) error {
+	if err != nil {
+		return translateError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return dberr.ErrNotFound
+	}
+	return nil
+}
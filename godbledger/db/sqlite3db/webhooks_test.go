@@ -0,0 +1,70 @@
+package sqlite3db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	dberr "github.com/darcys22/godbledger/godbledger/db"
+)
+
+func TestWebhookDeliveries(t *testing.T) {
+	ctx := context.Background()
+	ledgerdb := newTestDB(t)
+
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"first", "second", "later"} {
+		assert.NoError(t, ledgerdb.AddWebhookDelivery(ctx, &core.WebhookDelivery{
+			ID:          id,
+			Webhook:     "books",
+			Kind:        "transaction_added",
+			Payload:     []byte(`{"transaction":"` + id + `"}`),
+			NextAttempt: now.Add(time.Duration(i-1) * time.Minute),
+			Created:     now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	due, err := ledgerdb.DueWebhookDeliveries(ctx, now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 2) {
+		assert.Equal(t, "first", due[0].ID)
+		assert.Equal(t, "second", due[1].ID)
+		assert.Equal(t, `{"transaction":"first"}`, string(due[0].Payload))
+		assert.Equal(t, "transaction_added", due[0].Kind)
+	}
+	due, err = ledgerdb.DueWebhookDeliveries(ctx, now, 1)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	// Running out of attempts moves the delivery to the dead letters
+	dead := due[0]
+	dead.Attempts = 3
+	dead.LastError = "503 Service Unavailable"
+	dead.Dead = true
+	assert.NoError(t, ledgerdb.UpdateWebhookDelivery(ctx, dead))
+	letters, err := ledgerdb.ListDeadWebhookDeliveries(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, letters, 1) {
+		assert.Equal(t, "first", letters[0].ID)
+		assert.Equal(t, 3, letters[0].Attempts)
+		assert.Equal(t, "503 Service Unavailable", letters[0].LastError)
+	}
+	due, err = ledgerdb.DueWebhookDeliveries(ctx, now.Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+
+	assert.NoError(t, ledgerdb.DeleteWebhookDelivery(ctx, "second"))
+	_, err = ledgerdb.FindWebhookDelivery(ctx, "second")
+	assert.True(t, errors.Is(err, dberr.ErrNotFound))
+	assert.True(t, errors.Is(ledgerdb.DeleteWebhookDelivery(ctx, "second"), dberr.ErrNotFound))
+	assert.True(t, errors.Is(ledgerdb.UpdateWebhookDelivery(ctx, &core.WebhookDelivery{ID: "second"}), dberr.ErrNotFound))
+
+	found, err := ledgerdb.FindWebhookDelivery(ctx, "first")
+	assert.NoError(t, err)
+	assert.True(t, found.Dead)
+	assert.True(t, found.Created.Equal(now), found.Created.String())
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+var exportCommand = &cli.Command{
+	Action:    exportLedger,
+	Name:      "export",
+	Usage:     "godbledger export [file]",
+	ArgsUsage: "[file]",
+	Category:  "MAINTENANCE COMMANDS",
+	Description: `The export command writes every account, tag, currency, user, transaction and
+reconciliation of the ledger to a JSON Lines file, or to standard output when no
+file is given. The file does not depend on the database it came from and can be
+read back into any database with the import command. The --ledger flag exports
+one of the other ledgers of the node in place of the default one.`,
+	Flags: []cli.Flag{cmd.LedgerFlag},
+}
+
+var importCommand = &cli.Command{
+	Action:    importLedger,
+	Name:      "import",
+	Usage:     "godbledger import <file>",
+	ArgsUsage: "<file>",
+	Category:  "MAINTENANCE COMMANDS",
+	Description: `The import command reads a file written by the export command into the
+database, keeping the identifiers of the transactions, splits, users and
+reconciliations in it. The whole file is imported in one database transaction
+so nothing is saved if any record fails. The --ledger flag imports into one of
+the other ledgers of the node in place of the default one.`,
+	Flags: []cli.Flag{cmd.LedgerFlag},
+}
+
+// exportLedger is the export command.
+func exportLedger(ctx *cli.Context) error {
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := ledger.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	ledger.Start()
+	defer ledger.Stop()
+
+	entity, err := ledger.Entity(ctx.Context, ctx.String(cmd.LedgerFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if ctx.NArg() > 0 {
+		file, err := os.Create(ctx.Args().First())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := entity.Export(ctx.Context, out); err != nil {
+		return err
+	}
+	if file, ok := out.(*os.File); ok && file != os.Stdout {
+		return file.Close()
+	}
+	return nil
+}
+
+// importLedger is the import command.
+func importLedger(ctx *cli.Context) error {
+	log := logrus.WithField("prefix", "main")
+	if ctx.NArg() != 1 {
+		return errors.New("Usage: godbledger import <file>")
+	}
+
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	ledger, err := ledger.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	ledger.Start()
+	defer ledger.Stop()
+
+	entity, err := ledger.Entity(ctx.Context, ctx.String(cmd.LedgerFlag.Name))
+	if err != nil {
+		return err
+	}
+	if err := entity.Import(ctx.Context, in); err != nil {
+		return err
+	}
+	log.Infof("Imported %s", ctx.Args().First())
+
+	return nil
+}
@@ -0,0 +1,391 @@
+// Package export moves a whole ledger between databases through a backend
+// neutral JSON Lines file.
+//
+// Every line of the file is a JSON object whose "type" field says what it
+// holds. The first line is the header and every record is written after those
+// it refers to:
+//
+//	{"type":"header","format":1,"godbledger_version":"0.7.8","exported_at":"2021-03-15T00:00:00Z"}
+//	{"type":"currency","name":"USD","decimals":2}
+//	{"type":"user","id":"c0v6b8i8d3b1rqmgl3p0","name":"Tester"}
+//	{"type":"tag","name":"main"}
+//	{"type":"account","code":"Assets:Cash","name":"Assets:Cash","tags":["main"]}
+//	{"type":"transaction","id":"c0v6b8i8d3b1rqmgl3pg","postdate":"2021-03-15T00:00:00Z",
+//	 "poster":"c0v6b8i8d3b1rqmgl3p0","description":"Coffee","tags":["Void"],
+//	 "trashed_at":"2021-03-16T00:00:00Z","splits":[{"id":"c0v6b8i8d3b1rqmgl3q0",
+//	 "date":"2021-03-15T00:00:00Z","description":"","accounts":["Assets:Cash"],
+//	 "currency":"USD","amount":"-450"}]}
+//	{"type":"reconciliation","id":"c0v6b8i8d3b1rqmgl3r0","splits":["c0v6b8i8d3b1rqmgl3q0"]}
+//
+// Amounts are integers in the smallest unit of their currency written as
+// strings so no precision is lost. The poster of a transaction is the id of a
+// user record. trashed_at is only present for transactions in the trash.
+// Imported transactions keep their ids and those of their splits, and those
+// in the trash keep trashed_at.
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db"
+	"github.com/darcys22/godbledger/godbledger/version"
+)
+
+// Format is the version of the file layout written by Export
+const Format = 1
+
+// pageSize is how many transactions are read from the database at a time
+const pageSize = 500
+
+// ErrInvalidFile is returned when importing a file that is not a ledger export
+var ErrInvalidFile = errors.New("Not a godbledger export")
+
+type header struct {
+	Type       string    `json:"type"`
+	Format     int       `json:"format"`
+	Version    string    `json:"godbledger_version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+type currency struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Decimals int    `json:"decimals"`
+}
+
+type user struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type tag struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type account struct {
+	Type string   `json:"type"`
+	Code string   `json:"code"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+type transaction struct {
+	Type        string     `json:"type"`
+	Id          string     `json:"id"`
+	Postdate    time.Time  `json:"postdate"`
+	Poster      string     `json:"poster"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+	TrashedAt   *time.Time `json:"trashed_at,omitempty"`
+	Splits      []split    `json:"splits"`
+}
+
+type split struct {
+	Id          string    `json:"id"`
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Accounts    []string  `json:"accounts"`
+	Currency    string    `json:"currency"`
+	Amount      string    `json:"amount"`
+}
+
+type reconciliation struct {
+	Type   string   `json:"type"`
+	Id     string   `json:"id"`
+	Splits []string `json:"splits"`
+}
+
+// Export writes every record of the database to w. It reads the database in
+// a single unit of work so the export is consistent while the node runs.
+func Export(ctx context.Context, database db.Database, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+
+	err := database.UnitOfWork(ctx, func(tx db.Database) error {
+		if err := enc.Encode(header{Type: "header", Format: Format, Version: version.Version, ExportedAt: time.Now().UTC()}); err != nil {
+			return err
+		}
+
+		currencies, err := tx.ListCurrencies(ctx)
+		if err != nil {
+			return err
+		}
+		for _, cur := range currencies {
+			if err := enc.Encode(currency{Type: "currency", Name: cur.Name, Decimals: cur.Decimals}); err != nil {
+				return err
+			}
+		}
+
+		users, err := tx.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		for _, usr := range users {
+			if err := enc.Encode(user{Type: "user", Id: usr.Id, Name: usr.Name}); err != nil {
+				return err
+			}
+		}
+
+		tags, err := tx.ListTags(ctx)
+		if err != nil {
+			return err
+		}
+		for _, name := range tags {
+			if err := enc.Encode(tag{Type: "tag", Name: name}); err != nil {
+				return err
+			}
+		}
+
+		accounts, err := tx.ListAccounts(ctx)
+		if err != nil {
+			return err
+		}
+		for _, acc := range accounts {
+			accTags, err := tx.FindAccountTags(ctx, acc.Name)
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(account{Type: "account", Code: acc.Code, Name: acc.Name, Tags: accTags}); err != nil {
+				return err
+			}
+		}
+
+		trash, err := tx.ListTrash(ctx)
+		if err != nil {
+			return err
+		}
+		trashedAt := make(map[string]time.Time, len(trash))
+		for _, trashed := range trash {
+			trashedAt[trashed.Id] = trashed.TrashedAt
+		}
+		for after := ""; ; {
+			txns, err := tx.ListTransactions(ctx, after, pageSize)
+			if err != nil {
+				return err
+			}
+			if len(txns) == 0 {
+				break
+			}
+			for _, txn := range txns {
+				record := toRecord(txn)
+				if at, ok := trashedAt[txn.Id]; ok {
+					record.TrashedAt = &at
+				}
+				if err := enc.Encode(record); err != nil {
+					return err
+				}
+			}
+			after = txns[len(txns)-1].Id
+		}
+
+		reconciliations, err := tx.ListReconciliations(ctx)
+		if err != nil {
+			return err
+		}
+		for _, rec := range reconciliations {
+			if err := enc.Encode(reconciliation{Type: "reconciliation", Id: rec.Id, Splits: rec.Splits}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+func toRecord(txn *core.Transaction) transaction {
+	record := transaction{
+		Type:        "transaction",
+		Id:          txn.Id,
+		Postdate:    txn.Postdate,
+		Poster:      txn.Poster.Id,
+		Description: string(txn.Description),
+		Tags:        txn.Tags,
+		Splits:      []split{},
+	}
+	for _, spl := range txn.Splits {
+		s := split{
+			Id:          spl.Id,
+			Date:        spl.Date,
+			Description: string(spl.Description),
+			Accounts:    []string{},
+			Currency:    spl.Currency.Name,
+			Amount:      spl.Amount.String(),
+		}
+		for _, acc := range spl.Accounts {
+			s.Accounts = append(s.Accounts, acc.Code)
+		}
+		record.Splits = append(record.Splits, s)
+	}
+	return record
+}
+
+// Import replays an export into the database in a single unit of work, so a
+// file that fails part way through leaves the database unchanged. Records
+// already in the database, such as the default currencies, are kept.
+func Import(ctx context.Context, database db.Database, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	return database.UnitOfWork(ctx, func(tx db.Database) error {
+		users := make(map[string]*core.User)
+		currencies := make(map[string]*core.Currency)
+		accounts := make(map[string]*core.Account)
+		line := 0
+		for scanner.Scan() {
+			line++
+			var kind struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &kind); err != nil {
+				return fmt.Errorf("%w: line %d: %s", ErrInvalidFile, line, err)
+			}
+			if line == 1 {
+				var h header
+				if err := json.Unmarshal(scanner.Bytes(), &h); err != nil || kind.Type != "header" {
+					return fmt.Errorf("%w: missing header", ErrInvalidFile)
+				}
+				if h.Format != Format {
+					return fmt.Errorf("%w: unknown format %d", ErrInvalidFile, h.Format)
+				}
+				continue
+			}
+			if err := importRecord(ctx, tx, kind.Type, scanner.Bytes(), users, currencies, accounts); err != nil {
+				return fmt.Errorf("Importing line %d failed: %w", line, err)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		if line == 0 {
+			return fmt.Errorf("%w: missing header", ErrInvalidFile)
+		}
+		return nil
+	})
+}
+
+func importRecord(ctx context.Context, tx db.Database, kind string, data []byte, users map[string]*core.User, currencies map[string]*core.Currency, accounts map[string]*core.Account) error {
+	switch kind {
+	case "currency":
+		var rec currency
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		cur := &core.Currency{Name: rec.Name, Decimals: rec.Decimals}
+		currencies[cur.Name] = cur
+		return tx.SafeAddCurrency(ctx, cur)
+	case "user":
+		var rec user
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		usr := &core.User{Id: rec.Id, Name: rec.Name}
+		users[usr.Id] = usr
+		return tx.SafeAddUser(ctx, usr)
+	case "tag":
+		var rec tag
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		return tx.SafeAddTag(ctx, rec.Name)
+	case "account":
+		var rec account
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		acc := &core.Account{Code: rec.Code, Name: rec.Name}
+		accounts[acc.Code] = acc
+		if _, err := tx.SafeAddAccount(ctx, acc); err != nil {
+			return err
+		}
+		for _, name := range rec.Tags {
+			if err := tx.SafeAddTagToAccount(ctx, acc.Name, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "transaction":
+		var rec transaction
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		txn, err := fromRecord(rec, users, currencies, accounts)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.AddTransaction(ctx, txn); err != nil {
+			return err
+		}
+		for _, name := range rec.Tags {
+			if err := tx.SafeAddTagToTransaction(ctx, txn.Id, name); err != nil {
+				return err
+			}
+		}
+		if rec.TrashedAt != nil {
+			return tx.TrashTransaction(ctx, txn.Id, *rec.TrashedAt)
+		}
+		return nil
+	case "reconciliation":
+		var rec reconciliation
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		_, err := tx.ReconcileTransactions(ctx, rec.Id, rec.Splits)
+		return err
+	default:
+		return fmt.Errorf("%w: unknown record type %q", ErrInvalidFile, kind)
+	}
+}
+
+func fromRecord(rec transaction, users map[string]*core.User, currencies map[string]*core.Currency, accounts map[string]*core.Account) (*core.Transaction, error) {
+	poster, ok := users[rec.Poster]
+	if !ok {
+		return nil, fmt.Errorf("%w: user %s", db.ErrNotFound, rec.Poster)
+	}
+	txn := &core.Transaction{
+		Id:          rec.Id,
+		Postdate:    rec.Postdate,
+		Poster:      poster,
+		Description: []byte(rec.Description),
+		Splits:      []*core.Split{},
+		Tags:        []string{},
+	}
+	for _, s := range rec.Splits {
+		cur, ok := currencies[s.Currency]
+		if !ok {
+			return nil, fmt.Errorf("%w: currency %s", db.ErrNotFound, s.Currency)
+		}
+		amount, ok := new(big.Int).SetString(s.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid amount %q", ErrInvalidFile, s.Amount)
+		}
+		spl := &core.Split{
+			Id:          s.Id,
+			Date:        s.Date,
+			Description: []byte(s.Description),
+			Currency:    cur,
+			Amount:      amount,
+		}
+		for _, code := range s.Accounts {
+			acc, ok := accounts[code]
+			if !ok {
+				return nil, fmt.Errorf("%w: account %s", db.ErrNotFound, code)
+			}
+			spl.Accounts = append(spl.Accounts, acc)
+		}
+		txn.AppendSplit(spl)
+	}
+	return txn, nil
+}
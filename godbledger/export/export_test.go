@@ -0,0 +1,123 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darcys22/godbledger/godbledger/db"
+	"github.com/darcys22/godbledger/godbledger/db/dbtest"
+	"github.com/darcys22/godbledger/godbledger/db/memorydb"
+	"github.com/darcys22/godbledger/godbledger/db/sqlite3db"
+)
+
+// populate fills the database with transactions across two months, one of
+// them voided, one in the trash and two reconciled against each other.
+func populate(t *testing.T, database db.Database) {
+	ctx := context.Background()
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+	post := dbtest.NewLedger(t, database).Post
+	groceries := post("Expenses:Groceries", "Assets:Checking", 1000, date)
+	voided := post("Expenses:Groceries", "Assets:Checking", 250, date)
+	trashed := post("Expenses:Rent", "Assets:Checking", 500, date.AddDate(0, 1, 0))
+	long := post("Expenses:Fuel", "Assets:Savings", 75, date.AddDate(0, 1, 0))
+	post("Assets:Checking", "Equity:Opening", 5000, date.AddDate(0, -1, 0))
+	for _, account := range []string{"Expenses:Groceries", "Expenses:Rent", "Expenses:Fuel", "Assets:Checking", "Assets:Savings", "Equity:Opening"} {
+		assert.NoError(t, database.SafeAddTagToAccount(ctx, account, "main"))
+	}
+
+	assert.NoError(t, database.SafeAddTagToTransaction(ctx, voided.Id, "Void"))
+	assert.NoError(t, database.DeleteTransaction(ctx, trashed.Id))
+	_, err := database.ReconcileTransactions(ctx, "reconciliation", []string{groceries.Splits[1].Id, long.Splits[1].Id})
+	assert.NoError(t, err)
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	source, err := sqlite3db.NewDB(t.TempDir(), "rwc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	assert.NoError(t, source.InitDB(ctx))
+	populate(t, source)
+
+	var exported bytes.Buffer
+	assert.NoError(t, Export(ctx, source, &exported))
+
+	memory := memorydb.NewDB()
+	assert.NoError(t, memory.InitDB(ctx))
+	assert.NoError(t, Import(ctx, memory, bytes.NewReader(exported.Bytes())))
+
+	// And back again into a fresh SQLite database
+	var reexported bytes.Buffer
+	assert.NoError(t, Export(ctx, memory, &reexported))
+	copied, err := sqlite3db.NewDB(t.TempDir(), "rwc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copied.Close()
+	assert.NoError(t, copied.InitDB(ctx))
+	assert.NoError(t, Import(ctx, copied, &reexported))
+
+	date := time.Date(2011, 5, 1, 0, 0, 0, 0, time.UTC)
+	want, err := source.GetTB(ctx, date)
+	assert.NoError(t, err)
+	for _, database := range []db.Database{memory, copied} {
+		got, err := database.GetTB(ctx, date)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, *want, *got)
+
+		wantTxns, err := source.ListTransactions(ctx, "", 0)
+		assert.NoError(t, err)
+		gotTxns, err := database.ListTransactions(ctx, "", 0)
+		assert.NoError(t, err)
+		if assert.Len(t, gotTxns, len(wantTxns)) {
+			for i := range wantTxns {
+				assert.Equal(t, wantTxns[i].Id, gotTxns[i].Id)
+				assert.Equal(t, wantTxns[i].Poster, gotTxns[i].Poster)
+				assert.Equal(t, wantTxns[i].Tags, gotTxns[i].Tags)
+				assert.Equal(t, len(wantTxns[i].Splits), len(gotTxns[i].Splits))
+			}
+		}
+
+		wantTrash, err := source.ListTrash(ctx)
+		assert.NoError(t, err)
+		trash, err := database.ListTrash(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, trash, 1) {
+			assert.Equal(t, wantTrash[0].Id, trash[0].Id)
+			assert.True(t, wantTrash[0].TrashedAt.Equal(trash[0].TrashedAt), "trashed at %s, want %s", trash[0].TrashedAt, wantTrash[0].TrashedAt)
+		}
+
+		reconciliations, err := database.ListReconciliations(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, reconciliations, 1) {
+			assert.Equal(t, "reconciliation", reconciliations[0].Id)
+			assert.Len(t, reconciliations[0].Splits, 2)
+		}
+	}
+}
+
+func TestImportRejectsInvalidFile(t *testing.T) {
+	ctx := context.Background()
+	memory := memorydb.NewDB()
+	assert.NoError(t, memory.InitDB(ctx))
+
+	err := Import(ctx, memory, strings.NewReader(`{"type":"currency","name":"USD","decimals":2}`+"\n"))
+	assert.True(t, errors.Is(err, ErrInvalidFile))
+
+	// A transaction from an unknown user fails and nothing is kept
+	err = Import(ctx, memory, strings.NewReader(`{"type":"header","format":1}
+{"type":"currency","name":"XYZ","decimals":2}
+{"type":"transaction","id":"abc","poster":"nobody","splits":[]}
+`))
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+	_, err = memory.FindCurrency(ctx, "XYZ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,193 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// The kinds of event published when the ledger changes.
+const (
+	TransactionAdded    = "transaction_added"
+	TransactionVoided   = "transaction_voided"
+	TransactionDeleted  = "transaction_deleted"
+	TransactionRestored = "transaction_restored"
+	AccountAdded        = "account_added"
+	AccountDeleted      = "account_deleted"
+	TagAdded            = "tag_added"
+	TagDeleted          = "tag_deleted"
+	CurrencyAdded       = "currency_added"
+	CurrencyDeleted     = "currency_deleted"
+)
+
+// EventKinds lists every kind of event.
+var EventKinds = []string{
+	TransactionAdded,
+	TransactionVoided,
+	TransactionDeleted,
+	TransactionRestored,
+	AccountAdded,
+	AccountDeleted,
+	TagAdded,
+	TagDeleted,
+	CurrencyAdded,
+	CurrencyDeleted,
+}
+
+// eventHistorySize is the number of past events the bus holds for
+// subscribers resuming after a reconnect.
+const eventHistorySize = 10000
+
+// subscriberBuffer is the number of events a subscriber can fall behind by
+// before it is dropped.
+const subscriberBuffer = 256
+
+var (
+	// ErrEventsExpired is returned when subscribing after a sequence whose
+	// following events are no longer held, or from another epoch.
+	ErrEventsExpired = errors.New("Events are no longer held")
+	// ErrSubscriberLagged ends a subscription that fell too far behind, it
+	// can be resumed from the sequence of the last event it received.
+	ErrSubscriberLagged = errors.New("Subscriber fell behind")
+)
+
+// Event is a change to a ledger. Sequence numbers increase by one with each
+// event across every ledger of the node, and start again from one with a new
+// epoch whenever the node restarts. Tag events name the account the tag was
+// added to or deleted from.
+type Event struct {
+	Sequence    uint64
+	Epoch       string
+	Kind        string
+	Ledger      string
+	Transaction string
+	Account     string
+	Tag         string
+	Currency    string
+	Time        time.Time
+}
+
+// EventBus fans the events of the ledgers out to their subscribers, keeping
+// the most recent of them so that a subscriber can resume where it left off.
+// The history is only kept in memory, so it does not outlive the node.
+type EventBus struct {
+	mu          sync.Mutex
+	epoch       string
+	sequence    uint64
+	history     []Event
+	subscribers map[*Subscription]struct{}
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		epoch:       xid.New().String(),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Epoch identifies this run of the bus, sequence numbers of another epoch
+// cannot be resumed from.
+func (b *EventBus) Epoch() string {
+	return b.epoch
+}
+
+// Sequence is the number of the latest event published.
+func (b *EventBus) Sequence() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sequence
+}
+
+// Publish numbers the event and sends it to every subscriber.
+func (b *EventBus) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sequence++
+	e.Sequence = b.sequence
+	e.Epoch = b.epoch
+	e.Time = time.Now().UTC()
+
+	b.history = append(b.history, e)
+	if len(b.history) > eventHistorySize {
+		b.history = append([]Event{}, b.history[len(b.history)-eventHistorySize:]...)
+	}
+	for s := range b.subscribers {
+		select {
+		case s.events <- e:
+		default:
+			log.WithField("sequence", e.Sequence).Warn("Dropping event subscriber that fell behind")
+			delete(b.subscribers, s)
+			close(s.events)
+		}
+	}
+	return e
+}
+
+// Subscribe starts a subscription receiving the held events after the given
+// sequence followed by every new event. A sequence of zero starts from the
+// oldest event held. epoch is that of the events the sequence was taken
+// from, left empty when starting out.
+func (b *EventBus) Subscribe(epoch string, after uint64) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(epoch) > 0 && epoch != b.epoch {
+		return nil, ErrEventsExpired
+	}
+	if after > b.sequence {
+		return nil, ErrEventsExpired
+	}
+	oldest := b.sequence - uint64(len(b.history)) + 1
+	if after > 0 && after+1 < oldest {
+		return nil, ErrEventsExpired
+	}
+
+	s := &Subscription{bus: b, events: make(chan Event, subscriberBuffer)}
+	for _, e := range b.history {
+		if e.Sequence > after {
+			s.backlog = append(s.backlog, e)
+		}
+	}
+	b.subscribers[s] = struct{}{}
+	return s, nil
+}
+
+// Subscription receives the events of a bus until it is closed.
+type Subscription struct {
+	bus     *EventBus
+	backlog []Event
+	events  chan Event
+}
+
+// Next waits for the next event, returning ErrSubscriberLagged when the
+// subscription was dropped for falling behind.
+func (s *Subscription) Next(ctx context.Context) (Event, error) {
+	if len(s.backlog) > 0 {
+		e := s.backlog[0]
+		s.backlog = s.backlog[1:]
+		return e, nil
+	}
+	select {
+	case e, ok := <-s.events:
+		if !ok {
+			return Event{}, ErrSubscriberLagged
+		}
+		return e, nil
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	}
+}
+
+// Close stops the subscription receiving events.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subscribers[s]; ok {
+		delete(s.bus.subscribers, s)
+		close(s.events)
+	}
+}
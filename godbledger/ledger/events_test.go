@@ -0,0 +1,78 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBusResume(t *testing.T) {
+	bus := NewEventBus()
+	bus.Publish(Event{Kind: AccountAdded, Account: "Assets:Cash"})
+	bus.Publish(Event{Kind: TransactionAdded, Transaction: "a"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	sub, err := bus.Subscribe("", 0)
+	assert.NoError(t, err)
+	defer sub.Close()
+	for _, kind := range []string{AccountAdded, TransactionAdded} {
+		e, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, kind, e.Kind)
+		assert.Equal(t, bus.Epoch(), e.Epoch)
+	}
+	bus.Publish(Event{Kind: TransactionVoided, Transaction: "a"})
+	e, err := sub.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), e.Sequence)
+
+	// Resuming after the first event gets the rest again
+	resumed, err := bus.Subscribe(bus.Epoch(), 1)
+	assert.NoError(t, err)
+	defer resumed.Close()
+	e, err = resumed.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), e.Sequence)
+
+	_, err = bus.Subscribe("another", 1)
+	assert.True(t, errors.Is(err, ErrEventsExpired))
+	_, err = bus.Subscribe(bus.Epoch(), 4)
+	assert.True(t, errors.Is(err, ErrEventsExpired))
+}
+
+func TestEventBusExpired(t *testing.T) {
+	bus := NewEventBus()
+	for i := 0; i < eventHistorySize+2; i++ {
+		bus.Publish(Event{Kind: TagAdded})
+	}
+	_, err := bus.Subscribe(bus.Epoch(), 1)
+	assert.True(t, errors.Is(err, ErrEventsExpired))
+
+	sub, err := bus.Subscribe(bus.Epoch(), 2)
+	assert.NoError(t, err)
+	defer sub.Close()
+	e, err := sub.Next(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), e.Sequence)
+}
+
+func TestEventBusLaggedSubscriber(t *testing.T) {
+	bus := NewEventBus()
+	sub, err := bus.Subscribe("", 0)
+	assert.NoError(t, err)
+	defer sub.Close()
+	for i := 0; i < subscriberBuffer+1; i++ {
+		bus.Publish(Event{Kind: TagAdded})
+	}
+	for i := 0; i < subscriberBuffer; i++ {
+		_, err = sub.Next(context.Background())
+		assert.NoError(t, err)
+	}
+	_, err = sub.Next(context.Background())
+	assert.True(t, errors.Is(err, ErrSubscriberLagged))
+}
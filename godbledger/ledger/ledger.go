@@ -1,18 +1,32 @@
 package ledger
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
+	"github.com/darcys22/godbledger/godbledger/backup"
 	"github.com/darcys22/godbledger/godbledger/cmd"
 	"github.com/darcys22/godbledger/godbledger/core"
 	"github.com/darcys22/godbledger/godbledger/db"
+	"github.com/darcys22/godbledger/godbledger/db/memorydb"
+	"github.com/darcys22/godbledger/godbledger/db/migrate"
 	"github.com/darcys22/godbledger/godbledger/db/mysqldb"
+	"github.com/darcys22/godbledger/godbledger/db/postgresdb"
 	"github.com/darcys22/godbledger/godbledger/db/sqlite3db"
+	"github.com/darcys22/godbledger/godbledger/export"
+	"github.com/darcys22/godbledger/godbledger/version"
 
 	"github.com/rs/xid"
 )
@@ -21,26 +35,53 @@ const ledgerDBName = "ledgerdata"
 
 var log = logrus.WithField("prefix", "ledger")
 
+// ErrBatchRolledBack is the result of the transactions in an atomic batch
+// that were rolled back because another transaction in it failed.
+var ErrBatchRolledBack = errors.New("Batch rolled back")
+
+// ErrEntityLedgers is returned by the operations that only cover the default
+// ledger when they are asked to run on a node with entity ledgers.
+var ErrEntityLedgers = errors.New("Entity ledgers are not supported")
+
+// ErrInvalidAccount is returned for an account that cannot be created from
+// the name given.
+var ErrInvalidAccount = errors.New("Invalid account")
+
+// ErrInvalidAllocationRule is returned for an allocation rule without targets
+// or with a target weight that is not positive.
+var ErrInvalidAllocationRule = errors.New("Invalid allocation rule")
+
 type Ledger struct {
 	LedgerDb db.Database
 	Config   *cmd.LedgerConfig
+
+	// id is the entity of the ledger, empty for the default ledger of the
+	// node, whose event bus is shared by the ledgers of every entity
+	id     string
+	events *EventBus
+
+	// entities caches the ledgers of the other entities served by the node,
+	// they are opened the first time they are used
+	mu       sync.Mutex
+	entities map[string]*Ledger
+	// root is the default ledger for the ledger of an entity, which resolves
+	// the other ledgers through it so that they share one registry
+	root *Ledger
 }
 
 func New(ctx *cli.Context, cfg *cmd.LedgerConfig) (*Ledger, error) {
 	ledger := &Ledger{
-		Config: cfg,
+		Config:   cfg,
+		events:   NewEventBus(),
+		entities: make(map[string]*Ledger),
 	}
 
 	switch strings.ToLower(cfg.DatabaseType) {
-	case "sqlite3", "memorydb":
+	case "sqlite3":
 
 		log.Debug("Using Sqlite3")
 		mode := "rwc"
 		dbPath := path.Join(cfg.DataDirectory, ledgerDBName)
-		if strings.ToLower(cfg.DatabaseType) == "memorydb" {
-			log.Debug("In Memory only Mode")
-			mode = "memory"
-		}
 		log.WithField("path", dbPath).Debug("Checking db path")
 		if ctx.Bool(cmd.ClearDB.Name) {
 			log.Info("Clearing SQLite3 DB")
@@ -53,114 +94,322 @@ func New(ctx *cli.Context, cfg *cmd.LedgerConfig) (*Ledger, error) {
 		if err != nil {
 			return nil, err
 		}
+	case "memorydb":
+		log.Debug("In Memory only Mode")
+		ledger.LedgerDb = memorydb.NewDB()
 	case "mysql":
 		log.Debug("Using MySQL")
 		ledgerdb, err := mysqldb.NewDB(cfg.DatabaseLocation)
+		if err != nil {
+			return nil, err
+		}
 		if ctx.Bool(cmd.ClearDB.Name) {
 			log.Info("Clearing MySQL DB")
-			if err := ledgerdb.ClearDB(); err != nil {
+			if err := ledgerdb.ClearDB(ctx.Context); err != nil {
 				return nil, err
 			}
 		}
 		ledger.LedgerDb = ledgerdb
+	case "postgres":
+		log.Debug("Using PostgreSQL")
+		ledgerdb, err := postgresdb.NewDB(cfg.DatabaseLocation)
 		if err != nil {
 			return nil, err
 		}
+		if ctx.Bool(cmd.ClearDB.Name) {
+			log.Info("Clearing PostgreSQL DB")
+			if err := ledgerdb.ClearDB(ctx.Context); err != nil {
+				return nil, err
+			}
+		}
+		ledger.LedgerDb = ledgerdb
 	default:
-		log.Fatal("No implementation available for that database.")
+		return nil, fmt.Errorf("No implementation available for the %q database", cfg.DatabaseType)
 	}
 
 	log.Debug("Initialised database configuration")
 
+	for i := range cfg.PostingRules {
+		if err := cfg.PostingRules[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	for i := range cfg.Webhooks {
+		if err := validateWebhook(&cfg.Webhooks[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	return ledger, nil
 }
 
-func (l *Ledger) Insert(txn *core.Transaction) (string, error) {
-	log.WithField("transaction", txn).Debug("Created Transaction")
-	l.LedgerDb.SafeAddUser(txn.Poster)
-	currencies, _ := l.GetCurrencies(txn)
-	for _, currency := range currencies {
-		l.LedgerDb.SafeAddCurrency(currency)
+// Insert adds the transaction along with any user, currencies, accounts and
+// tags it needs in a single unit of work, so a failure part way through
+// leaves nothing behind.
+func (l *Ledger) Insert(ctx context.Context, txn *core.Transaction) (string, error) {
+	var response string
+	err := l.change(ctx, func(tx db.Database, c *changes) error {
+		var err error
+		response, err = l.insert(ctx, tx, txn, true, c)
+		return err
+	})
+	if err != nil {
+		return "", err
 	}
-	accounts, _ := l.GetAccounts(txn)
+	return response, nil
+}
 
-	for _, account := range accounts {
-		newaccount, err := l.LedgerDb.SafeAddAccount(account)
-		if err != nil {
-			return "", err
-		}
-		if newaccount {
-			l.LedgerDb.SafeAddTagToAccount(account.Name, "main")
-		}
+// insert posts the transaction through tx, evaluating the posting rules first
+// when validate is set. Reversals of journals already in the ledger skip them.
+func (l *Ledger) insert(ctx context.Context, tx db.Database, txn *core.Transaction, validate bool, c *changes) (string, error) {
+	if err := l.prepare(ctx, tx, txn, validate, c); err != nil {
+		return "", err
 	}
 
-	response, err := l.LedgerDb.AddTransaction(txn)
+	response, err := tx.AddTransaction(ctx, txn)
 	if err != nil {
 		return "", err
 	}
 
-	return response, nil
-}
+	if err := tagTransaction(ctx, tx, response, txn.Tags); err != nil {
+		return "", err
+	}
 
-func (l *Ledger) Delete(txnID string) {
-	l.LedgerDb.DeleteTransaction(txnID)
+	c.add(Event{Kind: TransactionAdded, Transaction: response})
+	return response, nil
 }
 
-func (l *Ledger) Void(txnID string, usr *core.User) error {
-	txn, err := l.LedgerDb.FindTransaction(txnID)
+// prepare adds the user, currencies and accounts the transaction posts to and
+// checks it against the posting rules, leaving only the journal to be written.
+func (l *Ledger) prepare(ctx context.Context, tx db.Database, txn *core.Transaction, validate bool, c *changes) error {
+	log.WithField("transaction", txn).Debug("Created Transaction")
+	err := txn.ExpandSplits()
 	if err != nil {
 		return err
 	}
-
-	log.Debugf("Transaction Found to Void: %+v", txn)
-
-	newTxn, err := core.ReverseTransaction(txn, usr)
-	if err != nil {
+	if _, balanced := txn.Balance(); !balanced {
+		return db.ErrUnbalanced
+	}
+	if err := tx.SafeAddUser(ctx, txn.Poster); err != nil {
 		return err
 	}
-
-	log.Debugf("Reversed Transaction: %+v", newTxn)
-
-	newJournalID, err := l.Insert(newTxn)
+	currencies, err := l.GetCurrencies(txn)
 	if err != nil {
 		return err
 	}
-	log.Debug("Successful insert of reversing transaction")
-
-	err = l.LedgerDb.SafeAddTagToTransaction(newJournalID, "Void")
+	for _, currency := range currencies {
+		if err := tx.SafeAddCurrency(ctx, currency); err != nil {
+			return err
+		}
+	}
+	accounts, err := l.GetAccounts(txn)
 	if err != nil {
 		return err
 	}
-	log.Debug("New Transaction Tagged Void")
 
-	err = l.LedgerDb.SafeAddTagToTransaction(txnID, "Void")
-	if err != nil {
-		return err
+	for _, account := range accounts {
+		newaccount, err := tx.SafeAddAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+		if newaccount {
+			if err := tx.SafeAddTagToAccount(ctx, account.Name, "main"); err != nil {
+				return err
+			}
+			c.add(Event{Kind: AccountAdded, Account: account.Name})
+		}
+	}
+
+	if validate {
+		rules, err := l.postingRules(ctx, tx)
+		if err != nil {
+			return err
+		}
+		err = core.EvaluateRules(rules, txn, func(account string) ([]string, error) {
+			return tx.FindAccountTags(ctx, account)
+		})
+		if err != nil {
+			return err
+		}
 	}
-	log.Debug("Original Transaction Tagged Void")
 
 	return nil
 }
 
-func (l *Ledger) InsertTag(account, tag string) error {
-	return l.LedgerDb.SafeAddTagToAccount(account, tag)
+func tagTransaction(ctx context.Context, tx db.Database, txnID string, tags []string) error {
+	for _, tag := range tags {
+		if err := tx.SafeAddTagToTransaction(ctx, txnID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertBatch adds the transactions in a single unit of work and returns the
+// error of each, nil for those that were inserted. A transaction that fails
+// is rolled back on its own and the rest are still committed, unless atomic is
+// set in which case the first failure rolls back the whole batch and the
+// others are marked with ErrBatchRolledBack. The error returned alongside is
+// for failures that affect the whole batch.
+func (l *Ledger) InsertBatch(ctx context.Context, txns []*core.Transaction, atomic bool) ([]error, error) {
+	results := make([]error, len(txns))
+	failed := -1
+	var c changes
+	err := l.LedgerDb.UnitOfWork(ctx, func(tx db.Database) error {
+		c = nil
+		if !atomic {
+			// Each transaction is written in a unit of work of its own, so one
+			// the database refuses is rolled back without the others, along
+			// with the changes it made
+			for i, txn := range txns {
+				var item changes
+				results[i] = tx.UnitOfWork(ctx, func(itemTx db.Database) error {
+					_, err := l.insert(ctx, itemTx, txn, true, &item)
+					return err
+				})
+				if results[i] == nil {
+					c.add(item...)
+				}
+			}
+			return l.queueWebhooks(ctx, tx, c)
+		}
+
+		for i, txn := range txns {
+			if err := l.prepare(ctx, tx, txn, true, &c); err != nil {
+				results[i] = err
+				failed = i
+				return err
+			}
+		}
+		ids, err := tx.AddTransactions(ctx, txns)
+		if err != nil {
+			return err
+		}
+		for i, txn := range txns {
+			if err := tagTransaction(ctx, tx, ids[i], txn.Tags); err != nil {
+				return err
+			}
+			c.add(Event{Kind: TransactionAdded, Transaction: ids[i]})
+		}
+		return l.queueWebhooks(ctx, tx, c)
+	})
+	if failed >= 0 {
+		for i := range results {
+			if i != failed {
+				results[i] = ErrBatchRolledBack
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.publish(c...)
+	return results, nil
+}
+
+// Delete moves the transaction into the trash.
+func (l *Ledger) Delete(ctx context.Context, txnID string) error {
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		c.add(Event{Kind: TransactionDeleted, Transaction: txnID})
+		return tx.DeleteTransaction(ctx, txnID)
+	})
+}
+
+func (l *Ledger) ListTrash(ctx context.Context) ([]core.TrashedTransaction, error) {
+	return l.LedgerDb.ListTrash(ctx)
+}
+
+func (l *Ledger) Restore(ctx context.Context, txnID string) error {
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		c.add(Event{Kind: TransactionRestored, Transaction: txnID})
+		return tx.RestoreTransaction(ctx, txnID)
+	})
+}
+
+// PurgeTrash permanently deletes transactions that have been in the trash for
+// longer than the retention period.
+func (l *Ledger) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	return l.LedgerDb.PurgeTrash(ctx, time.Now().Add(-retention))
+}
+
+// Void posts a reversal of the transaction and tags both of them Void in a
+// single unit of work.
+func (l *Ledger) Void(ctx context.Context, txnID string, usr *core.User) error {
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		txn, err := tx.FindTransaction(ctx, txnID)
+		if err != nil {
+			return err
+		}
+
+		log.Debugf("Transaction Found to Void: %+v", txn)
+
+		newTxn, err := core.ReverseTransaction(txn, usr)
+		if err != nil {
+			return err
+		}
+
+		log.Debugf("Reversed Transaction: %+v", newTxn)
+
+		newJournalID, err := l.insert(ctx, tx, newTxn, false, c)
+		if err != nil {
+			return err
+		}
+		log.Debug("Successful insert of reversing transaction")
+
+		err = tx.SafeAddTagToTransaction(ctx, newJournalID, "Void")
+		if err != nil {
+			return err
+		}
+		log.Debug("New Transaction Tagged Void")
+
+		err = tx.SafeAddTagToTransaction(ctx, txnID, "Void")
+		if err != nil {
+			return err
+		}
+		log.Debug("Original Transaction Tagged Void")
+
+		c.add(Event{Kind: TransactionVoided, Transaction: txnID})
+		return nil
+	})
+}
+
+func (l *Ledger) InsertTag(ctx context.Context, account, tag string) error {
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		c.add(Event{Kind: TagAdded, Account: account, Tag: tag})
+		return tx.SafeAddTagToAccount(ctx, account, tag)
+	})
 }
 
-func (l *Ledger) DeleteTag(account, tag string) error {
-	return l.LedgerDb.DeleteTagFromAccount(account, tag)
+func (l *Ledger) DeleteTag(ctx context.Context, account, tag string) error {
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		c.add(Event{Kind: TagDeleted, Account: account, Tag: tag})
+		return tx.DeleteTagFromAccount(ctx, account, tag)
+	})
 }
 
-func (l *Ledger) InsertAccount(accountStr string) error {
+func (l *Ledger) InsertAccount(ctx context.Context, accountStr string) error {
+	if strings.TrimSpace(accountStr) == "" {
+		return fmt.Errorf("%w: the name is blank", ErrInvalidAccount)
+	}
 	acc, err := core.NewAccount(accountStr, accountStr)
 	if err != nil {
-		log.Error(err)
+		return fmt.Errorf("%w: %s", ErrInvalidAccount, err)
 	}
-	_, err = l.LedgerDb.SafeAddAccount(acc)
-	return err
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		added, err := tx.SafeAddAccount(ctx, acc)
+		if added {
+			c.add(Event{Kind: AccountAdded, Account: acc.Name})
+		}
+		return err
+	})
 }
 
-func (l *Ledger) DeleteAccount(accountStr string) error {
-	return l.LedgerDb.DeleteAccount(accountStr)
+func (l *Ledger) DeleteAccount(ctx context.Context, accountStr string) error {
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		c.add(Event{Kind: AccountDeleted, Account: accountStr})
+		return tx.DeleteAccount(ctx, accountStr)
+	})
 }
 
 func (l *Ledger) GetCurrencies(txn *core.Transaction) ([]*core.Currency, error) {
@@ -184,23 +433,32 @@ func (l *Ledger) GetCurrencies(txn *core.Transaction) ([]*core.Currency, error)
 	return currencies, nil
 }
 
-func (l *Ledger) InsertCurrency(curr *core.Currency) error {
-	return l.LedgerDb.SafeAddCurrency(curr)
+func (l *Ledger) InsertCurrency(ctx context.Context, curr *core.Currency) error {
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		existing, _ := tx.FindCurrency(ctx, curr.Name)
+		if existing == nil {
+			c.add(Event{Kind: CurrencyAdded, Currency: curr.Name})
+		}
+		return tx.SafeAddCurrency(ctx, curr)
+	})
 }
 
-func (l *Ledger) DeleteCurrency(currency string) error {
-	return l.LedgerDb.DeleteCurrency(currency)
+func (l *Ledger) DeleteCurrency(ctx context.Context, currency string) error {
+	return l.change(ctx, func(tx db.Database, c *changes) error {
+		c.add(Event{Kind: CurrencyDeleted, Currency: currency})
+		return tx.DeleteCurrency(ctx, currency)
+	})
 }
 
 func (l *Ledger) GetDefaultCurrency() *core.Currency {
 	return &core.Currency{Name: "USD", Decimals: 2}
 }
 
-func (l *Ledger) GetCurrency(currency string) (*core.Currency, error) {
+func (l *Ledger) GetCurrency(ctx context.Context, currency string) (*core.Currency, error) {
 	curr := l.GetDefaultCurrency()
 	var err error
 	if len(currency) > 0 {
-		curr, err = l.LedgerDb.FindCurrency(currency)
+		curr, err = l.LedgerDb.FindCurrency(ctx, currency)
 		if err != nil {
 			return nil, err
 		}
@@ -208,6 +466,112 @@ func (l *Ledger) GetCurrency(currency string) (*core.Currency, error) {
 	return curr, nil
 }
 
+func (l *Ledger) InsertAllocationRule(ctx context.Context, rule *core.AllocationRule) error {
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAllocationRule, err)
+	}
+	return l.LedgerDb.AddAllocationRule(ctx, rule)
+}
+
+func (l *Ledger) DeleteAllocationRule(ctx context.Context, name string) error {
+	return l.LedgerDb.DeleteAllocationRule(ctx, name)
+}
+
+// AllocateSplit expands a split into weighted splits using the saved
+// allocation rule of the given name.
+func (l *Ledger) AllocateSplit(ctx context.Context, spl *core.Split, ruleName string) ([]*core.Split, error) {
+	rule, err := l.LedgerDb.FindAllocationRule(ctx, ruleName)
+	if err != nil {
+		return nil, err
+	}
+	return rule.Allocate(spl)
+}
+
+// GetPostingRules returns the posting rules declared in the config file
+// followed by those saved in the database.
+func (l *Ledger) GetPostingRules(ctx context.Context) ([]*core.PostingRule, error) {
+	return l.postingRules(ctx, l.LedgerDb)
+}
+
+func (l *Ledger) postingRules(ctx context.Context, database db.Database) ([]*core.PostingRule, error) {
+	rules := []*core.PostingRule{}
+	for i := range l.Config.PostingRules {
+		rules = append(rules, &l.Config.PostingRules[i])
+	}
+	saved, err := database.GetPostingRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, saved...), nil
+}
+
+func (l *Ledger) InsertPostingRule(ctx context.Context, rule *core.PostingRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return l.LedgerDb.AddPostingRule(ctx, rule)
+}
+
+func (l *Ledger) DeletePostingRule(ctx context.Context, name string) error {
+	return l.LedgerDb.DeletePostingRule(ctx, name)
+}
+
+// validateWebhook checks the webhook is well formed and is only sent kinds of
+// event that are published.
+func validateWebhook(hook *core.Webhook) error {
+	if err := hook.Validate(); err != nil {
+		return err
+	}
+	for _, kind := range hook.Events {
+		known := false
+		for _, k := range EventKinds {
+			known = known || k == kind
+		}
+		if !known {
+			return fmt.Errorf("Webhook %s has unknown event %s", hook.Name, kind)
+		}
+	}
+	return nil
+}
+
+// ListDeadWebhookDeliveries returns the webhook deliveries that ran out of
+// attempts.
+func (l *Ledger) ListDeadWebhookDeliveries(ctx context.Context) ([]*core.WebhookDelivery, error) {
+	return l.LedgerDb.ListDeadWebhookDeliveries(ctx)
+}
+
+// RetryWebhookDelivery gives a dead letter a fresh set of attempts starting
+// straight away.
+func (l *Ledger) RetryWebhookDelivery(ctx context.Context, id string) error {
+	delivery, err := l.deadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	delivery.Attempts = 0
+	delivery.Dead = false
+	delivery.NextAttempt = time.Now().UTC()
+	return l.LedgerDb.UpdateWebhookDelivery(ctx, delivery)
+}
+
+// DiscardWebhookDelivery deletes a dead letter without sending it.
+func (l *Ledger) DiscardWebhookDelivery(ctx context.Context, id string) error {
+	if _, err := l.deadLetter(ctx, id); err != nil {
+		return err
+	}
+	return l.LedgerDb.DeleteWebhookDelivery(ctx, id)
+}
+
+func (l *Ledger) deadLetter(ctx context.Context, id string) (*core.WebhookDelivery, error) {
+	delivery, err := l.LedgerDb.FindWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !delivery.Dead {
+		return nil, fmt.Errorf("%w: webhook delivery %s is not a dead letter", db.ErrNotFound, id)
+	}
+	return delivery, nil
+}
+
 func (l *Ledger) GetAccounts(txn *core.Transaction) ([]*core.Account, error) {
 	accounts := []*core.Account{}
 
@@ -230,27 +594,596 @@ func (l *Ledger) GetAccounts(txn *core.Transaction) ([]*core.Account, error) {
 	return accounts, nil
 }
 
-func (l *Ledger) ReconcileTransactions(splitIDs []string) (string, error) {
+func (l *Ledger) ReconcileTransactions(ctx context.Context, splitIDs []string) (string, error) {
 	//TODO sean loop here to check that splits exist
 	//for _, splitID := range splitIDs {
 	//}
 	guid := xid.New()
-	return l.LedgerDb.ReconcileTransactions(guid.String(), splitIDs)
+	return l.LedgerDb.ReconcileTransactions(ctx, guid.String(), splitIDs)
 }
 
-func (l *Ledger) GetTB(date time.Time) (*[]core.TBAccount, error) {
-	return l.LedgerDb.GetTB(date)
+func (l *Ledger) GetTB(ctx context.Context, date time.Time) (*[]core.TBAccount, error) {
+	return l.LedgerDb.GetTB(ctx, date)
 }
 
-func (l *Ledger) GetListing(enddate, startdate time.Time) (*[]core.Transaction, error) {
-	return l.LedgerDb.GetListing(enddate, startdate)
+// GetTransaction finds a transaction by identifier, those in the trash are
+// not found like they are left out of listings.
+func (l *Ledger) GetTransaction(ctx context.Context, txnID string) (*core.Transaction, error) {
+	txn, err := l.LedgerDb.FindTransaction(ctx, txnID)
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := l.LedgerDb.IsTrashed(ctx, txnID)
+	if err != nil {
+		return nil, err
+	}
+	if trashed {
+		return nil, fmt.Errorf("%w: transaction %s is in the trash", db.ErrNotFound, txnID)
+	}
+	return txn, nil
+}
+
+func (l *Ledger) ListAccounts(ctx context.Context) ([]*core.Account, error) {
+	return l.LedgerDb.ListAccounts(ctx)
+}
+
+func (l *Ledger) GetAccountTags(ctx context.Context, account string) ([]string, error) {
+	return l.LedgerDb.FindAccountTags(ctx, account)
+}
+
+func (l *Ledger) ListCurrencies(ctx context.Context) ([]*core.Currency, error) {
+	return l.LedgerDb.ListCurrencies(ctx)
+}
+
+func (l *Ledger) ListTags(ctx context.Context) ([]string, error) {
+	return l.LedgerDb.ListTags(ctx)
+}
+
+// GetAccountBalance returns the trial balance lines of an account at date,
+// one for each currency it holds.
+func (l *Ledger) GetAccountBalance(ctx context.Context, account string, date time.Time) ([]core.TBAccount, error) {
+	acc, err := l.LedgerDb.FindAccount(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	return l.LedgerDb.GetAccountBalance(ctx, acc.Code, date)
+}
+
+// GetAccountRegister returns the registers of an account from start to end,
+// one for each currency. The opening balances come from the trial balance of
+// the day before start, and are zero when start is left zero.
+func (l *Ledger) GetAccountRegister(ctx context.Context, account string, start, end time.Time) ([]core.Register, error) {
+	acc, err := l.LedgerDb.FindAccount(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	opening := []core.TBAccount{}
+	if !start.IsZero() {
+		opening, err = l.GetAccountBalance(ctx, acc.Code, start.AddDate(0, 0, -1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	splits, err := l.LedgerDb.GetAccountSplits(ctx, acc.Code, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return core.BuildRegisters(acc.Name, opening, splits), nil
+}
+
+// RebuildBalances recalculates the account balance summaries behind the trial
+// balance from the splits.
+func (l *Ledger) RebuildBalances(ctx context.Context) error {
+	return l.LedgerDb.RebuildBalances(ctx)
+}
+
+// VerifyBalances lists the account balance summaries that do not match the
+// splits they are built from.
+func (l *Ledger) VerifyBalances(ctx context.Context) ([]core.BalanceDiscrepancy, error) {
+	return l.LedgerDb.VerifyBalances(ctx)
+}
+
+// CheckIntegrity checks the ledger for integrity violations, including
+// account balance summaries that do not match their splits. With repair set
+// the repairable violations are fixed in a unit of work and the ledger is
+// checked again, returning the violations left and how many were repaired.
+func (l *Ledger) CheckIntegrity(ctx context.Context, repair bool) ([]core.IntegrityViolation, int, error) {
+	violations, err := checkIntegrity(ctx, l.LedgerDb)
+	if err != nil || !repair {
+		return violations, 0, err
+	}
+
+	repairable, rebuild := 0, false
+	for _, violation := range violations {
+		if violation.Repairable {
+			repairable++
+			rebuild = rebuild || violation.Kind == core.BalanceMismatchViolation
+		}
+	}
+	if repairable == 0 {
+		return violations, 0, nil
+	}
+
+	var remaining []core.IntegrityViolation
+	err = l.LedgerDb.UnitOfWork(ctx, func(tx db.Database) error {
+		if err := tx.RepairIntegrity(ctx, violations); err != nil {
+			return err
+		}
+		if rebuild {
+			if err := tx.RebuildBalances(ctx); err != nil {
+				return err
+			}
+		}
+		remaining, err = checkIntegrity(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	repaired := len(violations) - len(remaining)
+	if repaired < 0 {
+		repaired = 0
+	}
+	return remaining, repaired, nil
+}
+
+func checkIntegrity(ctx context.Context, database db.Database) ([]core.IntegrityViolation, error) {
+	violations, err := database.CheckIntegrity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	discrepancies, err := database.VerifyBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, discrepancy := range discrepancies {
+		violations = append(violations, core.BalanceMismatch(discrepancy))
+	}
+	return violations, nil
+}
+
+func (l *Ledger) GetListing(ctx context.Context, filter core.ListingFilter) (*[]core.Transaction, error) {
+	return l.LedgerDb.GetListing(ctx, filter)
+}
+
+// SearchTransactions finds the transactions whose descriptions match the
+// query of the filter.
+func (l *Ledger) SearchTransactions(ctx context.Context, filter core.SearchFilter) (*[]core.Transaction, error) {
+	return l.LedgerDb.SearchTransactions(ctx, filter)
+}
+
+// LedgerMigrations are the migrations of one ledger of the node, identified
+// by its entity and blank for the default ledger.
+type LedgerMigrations struct {
+	Ledger     string
+	Migrations []migrate.Migration
+}
+
+// LedgerMigrationStatus is the status of the migrations of one ledger.
+type LedgerMigrationStatus struct {
+	Ledger   string
+	Statuses []migrate.Status
+}
+
+// Migrate applies any pending schema migrations to the default ledger and
+// then to the ledger of each entity, with dryRun set the pending migrations
+// are returned without being applied.
+func (l *Ledger) Migrate(ctx context.Context, dryRun bool) ([]LedgerMigrations, error) {
+	ledgers := []LedgerMigrations{}
+	err := l.eachDatabase(ctx, func(id string, database db.Database) error {
+		migrations, err := database.Migrate(ctx, dryRun)
+		if err != nil {
+			return err
+		}
+		ledgers = append(ledgers, LedgerMigrations{Ledger: id, Migrations: migrations})
+		return nil
+	})
+	return ledgers, err
+}
+
+// MigrationStatus lists the migrations of the default ledger and of the
+// ledger of each entity.
+func (l *Ledger) MigrationStatus(ctx context.Context) ([]LedgerMigrationStatus, error) {
+	ledgers := []LedgerMigrationStatus{}
+	err := l.eachDatabase(ctx, func(id string, database db.Database) error {
+		statuses, err := database.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		ledgers = append(ledgers, LedgerMigrationStatus{Ledger: id, Statuses: statuses})
+		return nil
+	})
+	return ledgers, err
+}
+
+// eachDatabase calls fn with the database of the default ledger and then
+// with that of each entity. The entity databases are opened without being
+// initialised, so they are seen as they are, and closed once fn returns.
+func (l *Ledger) eachDatabase(ctx context.Context, fn func(id string, database db.Database) error) error {
+	if err := fn("", l.LedgerDb); err != nil {
+		return err
+	}
+	opener, ok := l.LedgerDb.(db.EntityOpener)
+	if !ok {
+		return nil
+	}
+	entities, err := l.entityList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		database, err := opener.OpenEntity(ctx, entity.Id)
+		if err != nil {
+			return fmt.Errorf("Opening ledger %s failed: %w", entity.Id, err)
+		}
+		err = fn(entity.Id, database)
+		database.Close()
+		if err != nil {
+			return fmt.Errorf("Ledger %s: %w", entity.Id, err)
+		}
+	}
+	return nil
+}
+
+// entityList lists the entities of the node, none when the default ledger
+// has yet to be migrated to hold them.
+func (l *Ledger) entityList(ctx context.Context) ([]*core.Entity, error) {
+	applied, _, err := l.schemaVersions(ctx)
+	if err != nil || applied == 0 {
+		return nil, err
+	}
+	return l.ListEntities(ctx)
+}
+
+// onlyDefaultLedger refuses an operation that only covers the default ledger
+// when the node keeps the ledgers of other entities too, as they would be
+// left out without notice.
+func (l *Ledger) onlyDefaultLedger(ctx context.Context, operation string) error {
+	if _, ok := l.LedgerDb.(db.EntityOpener); !ok {
+		return nil
+	}
+	entities, err := l.entityList(ctx)
+	if err != nil {
+		return err
+	}
+	if len(entities) > 0 {
+		return fmt.Errorf("%w: the %s only covers the default ledger and this node also keeps %d entity ledgers", ErrEntityLedgers, operation, len(entities))
+	}
+	return nil
+}
+
+// schemaVersions returns the newest migration applied to the database and
+// the newest one this version knows about.
+func (l *Ledger) schemaVersions(ctx context.Context) (applied, latest int, err error) {
+	statuses, err := l.LedgerDb.MigrationStatus(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, status := range statuses {
+		if status.Applied && status.Version > applied {
+			applied = status.Version
+		}
+		if status.Version > latest {
+			latest = status.Version
+		}
+	}
+	return applied, latest, nil
+}
+
+func (l *Ledger) backuper() (db.Backuper, error) {
+	backuper, ok := l.LedgerDb.(db.Backuper)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", backup.ErrNotSupported, l.Config.DatabaseType)
+	}
+	return backuper, nil
+}
+
+// Backup writes a backup archive of the database, with a manifest recording
+// its schema version and checksum, while the node keeps running. Only the
+// default ledger is backed up, so a node with entity ledgers is refused.
+func (l *Ledger) Backup(ctx context.Context, w io.Writer) (*backup.Manifest, error) {
+	backuper, err := l.backuper()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.onlyDefaultLedger(ctx, "backup"); err != nil {
+		return nil, err
+	}
+	applied, _, err := l.schemaVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "godbledger-backup")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	data := filepath.Join(dir, "data")
+
+	manifest := &backup.Manifest{
+		DatabaseType:  strings.ToLower(l.Config.DatabaseType),
+		SchemaVersion: applied,
+		Version:       version.Version,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := backuper.BackupTo(ctx, data); err != nil {
+		return nil, err
+	}
+	if err := backup.Write(w, manifest, data); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// RestoreBackup replaces the database with a backup archive. The backup is checked
+// against its manifest before any data is replaced, and migrated to the
+// current schema afterwards. Like Backup it refuses a node with entity ledgers.
+func (l *Ledger) RestoreBackup(ctx context.Context, r io.Reader) (*backup.Manifest, error) {
+	backuper, err := l.backuper()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.onlyDefaultLedger(ctx, "restore"); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "godbledger-restore")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	data := filepath.Join(dir, "data")
+
+	manifest, err := backup.Read(r, data)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.DatabaseType != strings.ToLower(l.Config.DatabaseType) {
+		return nil, fmt.Errorf("Backup is of a %s database and cannot be restored to %s", manifest.DatabaseType, l.Config.DatabaseType)
+	}
+	_, latest, err := l.schemaVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.SchemaVersion > latest {
+		return nil, fmt.Errorf("Backup has schema version %d but this version of godbledger only supports up to %d", manifest.SchemaVersion, latest)
+	}
+
+	if err := backuper.RestoreFrom(ctx, data); err != nil {
+		return nil, err
+	}
+	if _, err := l.LedgerDb.Migrate(ctx, false); err != nil {
+		return nil, fmt.Errorf("Migrating restored database failed: %w", err)
+	}
+	return manifest, nil
+}
+
+// Export writes the whole ledger to w in the backend neutral JSON Lines
+// format of the export package.
+func (l *Ledger) Export(ctx context.Context, w io.Writer) error {
+	return export.Export(ctx, l.LedgerDb, w)
+}
+
+// Import replays a ledger written by Export into the database, keeping the
+// identifiers of its records.
+func (l *Ledger) Import(ctx context.Context, r io.Reader) error {
+	return export.Import(ctx, l.LedgerDb, r)
+}
+
+// Entity returns the ledger of the entity with the given identifier, an empty
+// identifier is the default ledger of the node itself. The database of the
+// entity is opened and migrated the first time it is used. The ledgers of
+// entities resolve identifiers through the default ledger, so every ledger
+// finds the same ones.
+func (l *Ledger) Entity(ctx context.Context, id string) (*Ledger, error) {
+	if l.root != nil {
+		return l.root.Entity(ctx, id)
+	}
+	if len(id) == 0 {
+		return l, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entity, ok := l.entities[id]; ok {
+		return entity, nil
+	}
+	if _, err := l.LedgerDb.FindEntity(ctx, id); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, fmt.Errorf("%w: ledger %s", db.ErrNotFound, id)
+		}
+		return nil, err
+	}
+	return l.openEntity(ctx, id)
+}
+
+// CreateEntity registers a new entity and creates its empty ledger.
+func (l *Ledger) CreateEntity(ctx context.Context, entity *core.Entity) error {
+	if l.root != nil {
+		return l.root.CreateEntity(ctx, entity)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.LedgerDb.(db.EntityOpener); !ok {
+		return fmt.Errorf("The %s database does not support multiple ledgers", l.Config.DatabaseType)
+	}
+	// The ledger is opened before the entity is recorded, so one that cannot
+	// be opened is not left behind
+	if _, err := l.LedgerDb.FindEntity(ctx, entity.Id); err == nil {
+		return fmt.Errorf("%w: ledger %s", db.ErrAlreadyExists, entity.Id)
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+	opened, err := l.openEntity(ctx, entity.Id)
+	if err != nil {
+		return err
+	}
+	if err := l.LedgerDb.AddEntity(ctx, entity); err != nil {
+		delete(l.entities, entity.Id)
+		opened.LedgerDb.Close()
+		return err
+	}
+	return nil
+}
+
+func (l *Ledger) ListEntities(ctx context.Context) ([]*core.Entity, error) {
+	if l.root != nil {
+		return l.root.ListEntities(ctx)
+	}
+	return l.LedgerDb.ListEntities(ctx)
+}
+
+// Ledgers returns the default ledger of the node followed by the ledger of
+// every entity, opening those not used yet. Ledgers that cannot be opened are
+// logged and left out.
+func (l *Ledger) Ledgers(ctx context.Context) ([]*Ledger, error) {
+	if l.root != nil {
+		return l.root.Ledgers(ctx)
+	}
+	ledgers := []*Ledger{l}
+	if _, ok := l.LedgerDb.(db.EntityOpener); !ok {
+		return ledgers, nil
+	}
+	entities, err := l.ListEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range entities {
+		ld, err := l.Entity(ctx, entity.Id)
+		if err != nil {
+			log.WithField("ledger", entity.Id).Errorf("Opening ledger failed: %s", err)
+			continue
+		}
+		ledgers = append(ledgers, ld)
+	}
+	return ledgers, nil
+}
+
+// openEntity opens and initialises the database of an entity and caches its
+// ledger, the caller holds the lock.
+func (l *Ledger) openEntity(ctx context.Context, id string) (*Ledger, error) {
+	opener, ok := l.LedgerDb.(db.EntityOpener)
+	if !ok {
+		return nil, fmt.Errorf("The %s database does not support multiple ledgers", l.Config.DatabaseType)
+	}
+	database, err := opener.OpenEntity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.InitDB(ctx); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("Initialising ledger %s failed: %w", id, err)
+	}
+	log.WithField("ledger", id).Debug("Opened ledger")
+
+	entity := &Ledger{LedgerDb: database, Config: l.Config, id: id, events: l.events, root: l}
+	l.entities[id] = entity
+	return entity, nil
+}
+
+// Events returns the bus the changes to the ledgers of the node are
+// published on.
+func (l *Ledger) Events() *EventBus {
+	return l.events
+}
+
+// changes collects the events of a unit of work, which are published once
+// it has committed.
+type changes []Event
+
+func (c *changes) add(events ...Event) {
+	*c = append(*c, events...)
+}
+
+// change runs fn in a unit of work along with queueing the webhook deliveries
+// of the events it adds, so they are sent exactly when the change is
+// committed. The events are published once it has been.
+func (l *Ledger) change(ctx context.Context, fn func(tx db.Database, c *changes) error) error {
+	var c changes
+	err := l.LedgerDb.UnitOfWork(ctx, func(tx db.Database) error {
+		c = nil
+		if err := fn(tx, &c); err != nil {
+			return err
+		}
+		return l.queueWebhooks(ctx, tx, c)
+	})
+	if err != nil {
+		return err
+	}
+	l.publish(c...)
+	return nil
+}
+
+// queueWebhooks adds a delivery of each event for every webhook wanting it,
+// which the webhook service sends from the database of the ledger.
+func (l *Ledger) queueWebhooks(ctx context.Context, tx db.Database, events []Event) error {
+	if l.Config == nil || len(l.Config.Webhooks) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	for _, e := range events {
+		for _, hook := range l.Config.Webhooks {
+			if !hook.Wants(e.Kind) {
+				continue
+			}
+			delivery := &core.WebhookDelivery{
+				ID:          xid.New().String(),
+				Webhook:     hook.Name,
+				Kind:        e.Kind,
+				NextAttempt: now,
+				Created:     now,
+			}
+			payload, err := json.Marshal(core.WebhookPayload{
+				Delivery:    delivery.ID,
+				Kind:        e.Kind,
+				Ledger:      l.id,
+				Transaction: e.Transaction,
+				Account:     e.Account,
+				Tag:         e.Tag,
+				Currency:    e.Currency,
+				Time:        now.Format(time.RFC3339Nano),
+			})
+			if err != nil {
+				return err
+			}
+			delivery.Payload = payload
+			if err := tx.AddWebhookDelivery(ctx, delivery); err != nil {
+				return fmt.Errorf("Queueing webhook %s failed: %w", hook.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// publish sends the events on the bus as changes to this ledger.
+func (l *Ledger) publish(events ...Event) {
+	if l.events == nil {
+		return
+	}
+	for _, e := range events {
+		e.Ledger = l.id
+		l.events.Publish(e)
+	}
 }
 
 func (l *Ledger) Start() {
-	l.LedgerDb.InitDB()
+	if err := l.LedgerDb.InitDB(context.Background()); err != nil {
+		log.Fatalf("Initialising database failed: %s", err)
+	}
 }
 
 func (l *Ledger) Stop() error {
+	l.mu.Lock()
+	for id, entity := range l.entities {
+		if err := entity.LedgerDb.Close(); err != nil {
+			log.WithField("ledger", id).Errorf("Closing ledger failed: %s", err)
+		}
+	}
+	l.entities = make(map[string]*Ledger)
+	l.mu.Unlock()
+
 	err := l.LedgerDb.Close()
 	return err
 }
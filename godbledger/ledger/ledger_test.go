@@ -0,0 +1,176 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db"
+	"github.com/darcys22/godbledger/godbledger/db/dbtest"
+)
+
+func newTestLedger(t *testing.T) *Ledger {
+	ctx := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	ld, err := New(ctx, &cmd.LedgerConfig{DatabaseType: "sqlite3", DataDirectory: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ld.Start()
+	t.Cleanup(func() { ld.Stop() })
+	return ld
+}
+
+func TestCreateEntity(t *testing.T) {
+	ctx := context.Background()
+	ld := newTestLedger(t)
+
+	entity, _ := core.NewEntity("subsidiary", "Subsidiary", "")
+	assert.NoError(t, ld.CreateEntity(ctx, entity))
+	assert.True(t, errors.Is(ld.CreateEntity(ctx, entity), db.ErrAlreadyExists))
+
+	// A ledger whose database cannot be created is not recorded, so it can
+	// be created again once the problem is fixed
+	blocked := path.Join(ld.Config.DataDirectory, ledgerDBName, "entities", "broken")
+	assert.NoError(t, os.WriteFile(blocked, []byte{}, 0600))
+	broken, _ := core.NewEntity("broken", "Broken", "")
+	assert.Error(t, ld.CreateEntity(ctx, broken))
+	_, err := ld.LedgerDb.FindEntity(ctx, "broken")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+
+	assert.NoError(t, os.Remove(blocked))
+	assert.NoError(t, ld.CreateEntity(ctx, broken))
+	entities, err := ld.ListEntities(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, entities, 2)
+}
+
+func TestGetTransaction(t *testing.T) {
+	ctx := context.Background()
+	ld := newTestLedger(t)
+	txn := dbtest.NewLedger(t, ld.LedgerDb).Post("Expenses:Rent", "Assets:Cash", 250, time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC))
+
+	found, err := ld.GetTransaction(ctx, txn.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, txn.Id, found.Id)
+
+	assert.NoError(t, ld.Delete(ctx, txn.Id))
+	_, err = ld.GetTransaction(ctx, txn.Id)
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+
+	assert.NoError(t, ld.Restore(ctx, txn.Id))
+	_, err = ld.GetTransaction(ctx, txn.Id)
+	assert.NoError(t, err)
+}
+
+func TestInsertBatch(t *testing.T) {
+	ctx := context.Background()
+	ld := newTestLedger(t)
+	journals := dbtest.NewLedger(t, ld.LedgerDb)
+	date := time.Date(2011, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	first := journals.Journal("Expenses:Rent", "Assets:Cash", 250, date)
+	unbalanced := journals.Journal("Expenses:Rent", "Assets:Cash", 100, date)
+	unbalanced.Splits[0].Amount = big.NewInt(90)
+	// The database refuses a second journal with the identifier of the first
+	duplicate := journals.Journal("Expenses:Power", "Assets:Bank", 75, date)
+	duplicate.Id = first.Id
+	last := journals.Journal("Expenses:Power", "Assets:Cash", 40, date)
+
+	results, err := ld.InsertBatch(ctx, []*core.Transaction{first, unbalanced, duplicate, last}, false)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 4) {
+		assert.NoError(t, results[0])
+		assert.True(t, errors.Is(results[1], db.ErrUnbalanced))
+		assert.Error(t, results[2])
+		assert.NoError(t, results[3])
+	}
+	for _, txn := range []*core.Transaction{first, last} {
+		_, err := ld.GetTransaction(ctx, txn.Id)
+		assert.NoError(t, err)
+	}
+	// Nothing the refused journal added is kept
+	_, err = ld.LedgerDb.FindAccount(ctx, "Assets:Bank")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+
+	results, err = ld.InsertBatch(ctx, []*core.Transaction{journals.Journal("Expenses:Rent", "Assets:Cash", 10, date), unbalanced}, true)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.True(t, errors.Is(results[0], ErrBatchRolledBack))
+		assert.True(t, errors.Is(results[1], db.ErrUnbalanced))
+	}
+}
+
+func TestEntityMaintenance(t *testing.T) {
+	ctx := context.Background()
+	ld := newTestLedger(t)
+
+	ledgers, err := ld.Migrate(ctx, true)
+	assert.NoError(t, err)
+	if assert.Len(t, ledgers, 1) {
+		assert.Empty(t, ledgers[0].Migrations)
+	}
+	var out bytes.Buffer
+	_, err = ld.Backup(ctx, &out)
+	assert.NoError(t, err)
+
+	entity, _ := core.NewEntity("subsidiary", "Subsidiary", "")
+	assert.NoError(t, ld.CreateEntity(ctx, entity))
+
+	// Every ledger is migrated, the entity ledger after the default
+	statuses, err := ld.MigrationStatus(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, "", statuses[0].Ledger)
+		assert.Equal(t, "subsidiary", statuses[1].Ledger)
+		assert.Equal(t, len(statuses[0].Statuses), len(statuses[1].Statuses))
+		for _, status := range statuses[1].Statuses {
+			assert.True(t, status.Applied)
+		}
+	}
+
+	// A backup would leave the entity ledger out, so it is refused
+	_, err = ld.Backup(ctx, &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrEntityLedgers))
+	_, err = ld.RestoreBackup(ctx, &out)
+	assert.True(t, errors.Is(err, ErrEntityLedgers))
+}
+
+func TestEntityResolvesLedgers(t *testing.T) {
+	ctx := context.Background()
+	ld := newTestLedger(t)
+
+	first, _ := core.NewEntity("first", "First", "")
+	assert.NoError(t, ld.CreateEntity(ctx, first))
+	entity, err := ld.Entity(ctx, "first")
+	assert.NoError(t, err)
+
+	// An entity ledger finds the default ledger and the other entities
+	found, err := entity.Entity(ctx, "")
+	assert.NoError(t, err)
+	assert.Same(t, ld, found)
+	second, _ := core.NewEntity("second", "Second", "")
+	assert.NoError(t, entity.CreateEntity(ctx, second))
+	fromEntity, err := entity.Entity(ctx, "second")
+	assert.NoError(t, err)
+	fromDefault, err := ld.Entity(ctx, "second")
+	assert.NoError(t, err)
+	assert.Same(t, fromDefault, fromEntity)
+
+	entities, err := entity.ListEntities(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, entities, 2)
+	ledgers, err := entity.Ledgers(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, ledgers, 3)
+}
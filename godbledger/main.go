@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	"github.com/sirupsen/logrus"
@@ -13,6 +14,7 @@ import (
 	"github.com/darcys22/godbledger/godbledger/node"
 	"github.com/darcys22/godbledger/godbledger/rpc"
 	"github.com/darcys22/godbledger/godbledger/version"
+	"github.com/darcys22/godbledger/godbledger/webhook"
 )
 
 func startNode(ctx *cli.Context) error {
@@ -20,6 +22,10 @@ func startNode(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	queryTimeout, err := cfg.QueryTimeoutDuration()
+	if err != nil {
+		return fmt.Errorf("Invalid query timeout %q: %w", cfg.QueryTimeout, err)
+	}
 
 	fullnode, err := node.New(ctx, cfg)
 	if err != nil {
@@ -30,14 +36,22 @@ func startNode(ctx *cli.Context) error {
 		return err
 	}
 	fullnode.Register(ledger)
-	rpc := rpc.NewRPCService(context.Background(), &rpc.Config{
-		Host:       cfg.Host,
-		Port:       cfg.RPCPort,
-		CACertFlag: cfg.CACert,
-		CertFlag:   cfg.Cert,
-		KeyFlag:    cfg.Key,
-	}, ledger)
-	fullnode.Register(rpc)
+	if len(cfg.Webhooks) > 0 {
+		fullnode.Register(webhook.NewService(context.Background(), &webhook.Config{}, ledger))
+	}
+	rpcConfig := &rpc.Config{
+		Host:         cfg.Host,
+		Port:         cfg.RPCPort,
+		CACertFlag:   cfg.CACert,
+		CertFlag:     cfg.Cert,
+		KeyFlag:      cfg.Key,
+		QueryTimeout: queryTimeout,
+		RESTPort:     cfg.RESTPort,
+	}
+	fullnode.Register(rpc.NewRPCService(context.Background(), rpcConfig, ledger))
+	if len(cfg.RESTPort) > 0 {
+		fullnode.Register(rpc.NewGatewayService(context.Background(), rpcConfig))
+	}
 	fullnode.Start()
 
 	return nil
@@ -71,6 +85,20 @@ func main() {
 		// See cmd/config.go
 		cmd.DumpConfigCommand,
 		cmd.GenConfigCommand,
+		// See trash.go
+		purgeTrashCommand,
+		// See migrate.go
+		migrateCommand,
+		// See balances.go
+		balancesCommand,
+		// See check.go
+		checkCommand,
+		// See backup.go
+		backupCommand,
+		restoreCommand,
+		// See export.go
+		exportCommand,
+		importCommand,
 	}
 
 	app.Flags = []cli.Flag{
@@ -81,6 +109,7 @@ func main() {
 		cmd.ConfigFileFlag,
 		cmd.RPCHost,
 		cmd.RPCPort,
+		cmd.RESTPort,
 		cmd.CACertFlag,
 		cmd.CertFlag,
 		cmd.KeyFlag,
@@ -88,6 +117,7 @@ func main() {
 		cmd.DatabaseTypeFlag,
 		cmd.DatabaseLocationFlag,
 		cmd.PidFileFlag,
+		cmd.QueryTimeoutFlag,
 	}
 
 	if err := app.Run(os.Args); err != nil {
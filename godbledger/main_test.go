@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"math/big"
 	"testing"
@@ -67,7 +68,7 @@ func TestNewTransaction(t *testing.T) {
 	ledger.Start()
 
 	//response, err := ledger.Insert(txn)
-	_, err = ledger.Insert(txn)
+	_, err = ledger.Insert(context.Background(), txn)
 	if err != nil {
 		t.Fatalf("Inserting to ledger Failed: %v", err)
 	}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+var migrateCommand = &cli.Command{
+	Action:    migrateDatabase,
+	Name:      "migrate",
+	Usage:     "godbledger migrate [--status] [--dry-run]",
+	ArgsUsage: "",
+	Category:  "MAINTENANCE COMMANDS",
+	Description: `The migrate command upgrades the database schema by applying any pending
+migrations in order. Migrations are also applied automatically when the server
+starts, use --status to list which have been applied or --dry-run to show the
+SQL that would be run without changing the database. The default ledger is
+migrated first and then the ledger of each entity.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "status",
+			Usage: "list every migration and whether it has been applied",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "show the pending migrations without applying them",
+		},
+	},
+}
+
+// migrateDatabase is the migrate command.
+func migrateDatabase(ctx *cli.Context) error {
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := ledger.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer ledger.Stop()
+
+	if ctx.Bool("status") {
+		ledgers, err := ledger.MigrationStatus(ctx.Context)
+		if err != nil {
+			return err
+		}
+		for _, ld := range ledgers {
+			printLedger(ld.Ledger)
+			for _, status := range ld.Statuses {
+				applied := "pending"
+				if status.Applied {
+					applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				fmt.Printf("%4d  %-30s %s\n", status.Version, status.Description, applied)
+			}
+		}
+		return nil
+	}
+
+	dryRun := ctx.Bool("dry-run")
+	ledgers, err := ledger.Migrate(ctx.Context, dryRun)
+	if err != nil {
+		return err
+	}
+	for _, ld := range ledgers {
+		printLedger(ld.Ledger)
+		if len(ld.Migrations) == 0 {
+			fmt.Println("Database schema is up to date")
+			continue
+		}
+		for _, migration := range ld.Migrations {
+			if !dryRun {
+				fmt.Printf("Applied migration %d: %s\n", migration.Version, migration.Description)
+				continue
+			}
+			fmt.Printf("-- Migration %d: %s\n", migration.Version, migration.Description)
+			for _, statement := range migration.Statements {
+				fmt.Println(statement)
+			}
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
+
+// printLedger heads the output of the ledger of an entity, the default
+// ledger is printed without one so a node without entities reads as before.
+func printLedger(id string) {
+	if id != "" {
+		fmt.Printf("\nLedger %s:\n", id)
+	}
+}
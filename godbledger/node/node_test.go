@@ -70,7 +70,7 @@ func TestClearDB(t *testing.T) {
 	assert.NoError(t, err)
 	err, cfg := cmd.MakeConfig(ctx)
 	assert.NoError(t, err)
-	cfg.DatabaseType = "memorydb"
+	cfg.DatabaseType = "sqlite3"
 	cfg.DataDirectory = tmp
 
 	node, err := New(ctx, cfg)
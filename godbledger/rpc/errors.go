@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/db"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+	"github.com/darcys22/godbledger/proto/transaction"
+)
+
+// toStatusError converts errors from the ledger into gRPC status errors so
+// clients can inspect the code and any structured details rather than parse
+// the message. Errors without a specific mapping are returned unchanged.
+func toStatusError(err error) error {
+	var violations *core.RuleViolationError
+	if errors.As(err, &violations) {
+		st := status.New(codes.InvalidArgument, err.Error())
+		badRequest := &errdetails.BadRequest{}
+		for _, violation := range violations.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations,
+				&errdetails.BadRequest_FieldViolation{
+					Field:       violation.Field,
+					Description: violation.Rule + ": " + violation.Description,
+				})
+		}
+		detailed, detailErr := st.WithDetails(badRequest)
+		if detailErr != nil {
+			return st.Err()
+		}
+		return detailed.Err()
+	}
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, db.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, db.ErrConstraintViolation):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, db.ErrUnbalanced):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ledger.ErrInvalidAccount):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ledger.ErrInvalidAllocationRule):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ledger.ErrBatchRolledBack):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, ledger.ErrEventsExpired):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, ledger.ErrSubscriberLagged):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return err
+}
+
+// toTransactionResult reports the outcome of one journal of a batch using the
+// same codes as toStatusError.
+func toTransactionResult(id string, err error) *transaction.TransactionResult {
+	if err == nil {
+		return &transaction.TransactionResult{Identifier: id}
+	}
+	st := status.Convert(toStatusError(err))
+	return &transaction.TransactionResult{Identifier: id, Code: uint32(st.Code()), Error: st.Message()}
+}
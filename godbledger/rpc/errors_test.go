@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/darcys22/godbledger/godbledger/db"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+func TestToStatusError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code codes.Code
+	}{
+		{db.ErrNotFound, codes.NotFound},
+		{fmt.Errorf("%w: account 1000", db.ErrAlreadyExists), codes.AlreadyExists},
+		{fmt.Errorf("%w: FOREIGN KEY constraint failed", db.ErrConstraintViolation), codes.FailedPrecondition},
+		{db.ErrUnbalanced, codes.InvalidArgument},
+		{ledger.ErrInvalidAccount, codes.InvalidArgument},
+		{ledger.ErrInvalidAllocationRule, codes.InvalidArgument},
+		{ledger.ErrBatchRolledBack, codes.Aborted},
+		{ledger.ErrEventsExpired, codes.OutOfRange},
+		{ledger.ErrSubscriberLagged, codes.Aborted},
+		{errors.New("unexpected"), codes.Unknown},
+	} {
+		assert.Equal(t, tc.code, status.Code(toStatusError(tc.err)), tc.err.Error())
+	}
+	assert.NoError(t, toStatusError(nil))
+}
+
+func TestToTransactionResult(t *testing.T) {
+	result := toTransactionResult("abc", nil)
+	assert.Equal(t, "abc", result.Identifier)
+	assert.Equal(t, uint32(codes.OK), result.Code)
+
+	result = toTransactionResult("", db.ErrUnbalanced)
+	assert.Equal(t, uint32(codes.InvalidArgument), result.Code)
+	assert.Equal(t, db.ErrUnbalanced.Error(), result.Error)
+}
@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+)
+
+// OpenAPIPath is where the gateway serves the OpenAPI description of its
+// endpoints.
+const OpenAPIPath = "/openapi.json"
+
+// shutdownTimeout bounds how long Stop waits for requests in flight.
+const shutdownTimeout = 5 * time.Second
+
+// GatewayService serves every Transactor method as JSON over HTTP, for
+// clients without gRPC. Each method is a POST to /transaction.Transactor/<Method>
+// with the request message as the JSON body, and is forwarded to the gRPC
+// server of the node so it is handled exactly as the RPC would be. Streamed
+// requests and responses are sent as newline delimited JSON messages.
+//
+// With the TLS settings of the RPC Service the gateway serves HTTPS using the
+// same certificate, and clients must present a certificate signed by the CA.
+type GatewayService struct {
+	ctx             context.Context
+	cancel          context.CancelFunc
+	server          *http.Server
+	listener        net.Listener
+	port            string
+	host            string
+	rpcPort         string
+	withCACert      string
+	withCert        string
+	withKey         string
+	credentialError error
+	startError      error
+}
+
+func NewGatewayService(ctx context.Context, cfg *Config) *GatewayService {
+	ctx, cancel := context.WithCancel(ctx)
+	return &GatewayService{
+		ctx:        ctx,
+		cancel:     cancel,
+		port:       cfg.RESTPort,
+		host:       cfg.Host,
+		rpcPort:    cfg.Port,
+		withCACert: cfg.CACertFlag,
+		withCert:   cfg.CertFlag,
+		withKey:    cfg.KeyFlag,
+	}
+}
+
+// Start the HTTP server.
+func (g *GatewayService) Start() {
+	log.Debug("Starting gateway")
+	secure := g.withCACert != "" && g.withCert != "" && g.withKey != ""
+
+	var serverTLS *tls.Config
+	dialOpts := []grpc.DialOption{grpc.WithInsecure()}
+	if secure {
+		var err error
+		serverTLS, err = loadTLSConfig(g.withCACert, g.withCert, g.withKey)
+		if err != nil {
+			log.Errorf("Could not load TLS keys: %s", err)
+			g.credentialError = err
+			return
+		}
+		// The gateway is a client of the gRPC server, it presents the server's
+		// own certificate which the CA has signed
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates: serverTLS.Certificates,
+			RootCAs:      serverTLS.ClientCAs,
+			ServerName:   g.serverName(serverTLS.Certificates[0]),
+		}))}
+	} else {
+		log.Warn("You are using an insecure REST gateway. If you are running your GoDBLedger Server and " +
+			"client on the same machine, you can ignore this message.")
+	}
+
+	gateway := runtime.NewServeMux()
+	if err := transaction.RegisterTransactorHandlerFromEndpoint(g.ctx, gateway, g.rpcAddress(), dialOpts); err != nil {
+		log.Errorf("Could not register the REST gateway: %v", err)
+		g.startError = err
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(OpenAPIPath, serveOpenAPI)
+	mux.Handle("/", gateway)
+
+	address := fmt.Sprintf("%s:%s", g.host, g.port)
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Errorf("Could not listen to port in Start() %s: %v", address, err)
+		g.startError = err
+		return
+	}
+	g.listener = lis
+	log.WithField("address", address).Info("REST gateway listening on port")
+
+	// There is no write timeout as subscriptions stream for as long as the
+	// client stays connected
+	g.server = &http.Server{
+		Handler:           mux,
+		TLSConfig:         serverTLS,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		var err error
+		if secure {
+			err = g.server.ServeTLS(g.listener, "", "")
+		} else {
+			err = g.server.Serve(g.listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Errorf("Could not serve REST gateway: %v", err)
+		}
+	}()
+}
+
+// Stop the gateway, closing its connection to the gRPC server ends any
+// subscriptions still streaming.
+func (g *GatewayService) Stop() error {
+	log.Info("Stopping gateway")
+	g.cancel()
+	if g.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := g.server.Shutdown(ctx); err != nil {
+		return g.server.Close()
+	}
+	return nil
+}
+
+// Status returns nil, credentialError or the error that stopped the gateway
+// from registering or listening.
+func (g *GatewayService) Status() error {
+	if g.credentialError != nil {
+		return g.credentialError
+	}
+	return g.startError
+}
+
+// rpcAddress is the address the gateway dials the gRPC server on, which
+// listens on every interface when its host is unspecified.
+func (g *GatewayService) rpcAddress() string {
+	host := g.host
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, g.rpcPort)
+}
+
+// serverName is the name the gateway checks the certificate of the gRPC server
+// against. It is the configured host when one is set. When the host is
+// unspecified the gateway dials the loopback address, so it is localhost or
+// 127.0.0.1, whichever the certificate covers, or else the first name the
+// certificate was issued for.
+func (g *GatewayService) serverName(cert tls.Certificate) string {
+	if ip := net.ParseIP(g.host); g.host != "" && (ip == nil || !ip.IsUnspecified()) {
+		return g.host
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return "localhost"
+	}
+	for _, name := range []string{"localhost", "127.0.0.1"} {
+		if leaf.VerifyHostname(name) == nil {
+			return name
+		}
+	}
+	if len(leaf.DNSNames) > 0 {
+		return leaf.DNSNames[0]
+	}
+	return "localhost"
+}
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(transaction.OpenAPI)
+}
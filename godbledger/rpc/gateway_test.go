@@ -0,0 +1,248 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func startGateway(t *testing.T, cfg *Config) func() {
+	ctx := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	ld, err := ledger.New(ctx, &cmd.LedgerConfig{DatabaseType: "memorydb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ld.Start()
+
+	rpcService := NewRPCService(context.Background(), cfg, ld)
+	rpcService.Start()
+	gateway := NewGatewayService(context.Background(), cfg)
+	gateway.Start()
+	assert.NoError(t, gateway.Status())
+	return func() {
+		assert.NoError(t, gateway.Stop())
+		assert.NoError(t, rpcService.Stop())
+		assert.NoError(t, ld.Stop())
+	}
+}
+
+func post(t *testing.T, client *http.Client, url, body string) (int, []byte) {
+	res, err := client.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	out, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	return res.StatusCode, out
+}
+
+func TestGateway_Insecure(t *testing.T) {
+	stop := startGateway(t, &Config{Host: "127.0.0.1", Port: "7391", RESTPort: "7392"})
+	defer stop()
+	base := "http://127.0.0.1:7392"
+
+	code, body := post(t, http.DefaultClient, base+"/transaction.Transactor/AddTransaction", `{
+		"date": "2021-03-01",
+		"description": "Capital",
+		"lines": [
+			{"accountname": "Assets:Cash", "currency": "USD", "amount": 100},
+			{"accountname": "Equity:Capital", "currency": "USD", "amount": -100}
+		]
+	}`)
+	assert.Equal(t, http.StatusOK, code, string(body))
+	var added transaction.TransactionResponse
+	assert.NoError(t, protojson.Unmarshal(body, &added))
+	assert.NotEmpty(t, added.GetMessage())
+
+	code, body = post(t, http.DefaultClient, base+"/transaction.Transactor/GetTB", `{"date": "2021-03-31"}`)
+	assert.Equal(t, http.StatusOK, code, string(body))
+	var tb transaction.TBResponse
+	assert.NoError(t, protojson.Unmarshal(body, &tb))
+	assert.Len(t, tb.GetLines(), 2)
+
+	// Status codes of the RPC are returned as their HTTP equivalents
+	code, _ = post(t, http.DefaultClient, base+"/transaction.Transactor/GetAccountBalance", `{"account": "Assets:Cash", "date": "yesterday"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	res, err := http.Get(base + OpenAPIPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var description struct {
+		Swagger string
+		Paths   map[string]interface{}
+	}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&description))
+	assert.Equal(t, "2.0", description.Swagger)
+	assert.Contains(t, description.Paths, "/transaction.Transactor/AddTransaction")
+	assert.Contains(t, description.Paths, "/transaction.Transactor/Subscribe")
+}
+
+func TestGateway_TLS(t *testing.T) {
+	certs := "../../tests/certs/"
+	stop := startGateway(t, &Config{
+		Host:       "127.0.0.1",
+		Port:       "7393",
+		RESTPort:   "7394",
+		CACertFlag: certs + "ca-cert.pem",
+		CertFlag:   certs + "server-cert.pem",
+		KeyFlag:    certs + "server-key.pem",
+	})
+	defer stop()
+	url := "https://127.0.0.1:7394/transaction.Transactor/AddCurrency"
+
+	pemCA, err := os.ReadFile(certs + "ca-cert.pem")
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	assert.True(t, pool.AppendCertsFromPEM(pemCA))
+	clientCert, err := tls.LoadX509KeyPair(certs+"client-cert.pem", certs+"client-key.pem")
+	assert.NoError(t, err)
+
+	// Clients must present a certificate signed by the CA, as for the RPC
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	_, err = anonymous.Post(url, "application/json", strings.NewReader(`{}`))
+	assert.Error(t, err)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{clientCert},
+	}}}
+	code, body := post(t, client, url, `{"currency": "USD", "decimals": 2}`)
+	assert.Equal(t, http.StatusOK, code, string(body))
+	assert.Contains(t, string(body), "Accepted")
+}
+
+func TestGateway_PortInUse(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:7395")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	gateway := NewGatewayService(context.Background(), &Config{Host: "127.0.0.1", Port: "7396", RESTPort: "7395"})
+	gateway.Start()
+	assert.Error(t, gateway.Status())
+	assert.NoError(t, gateway.Stop())
+}
+
+// writeCerts writes a CA and a certificate it signed for the given DNS names
+// to dir, returning their paths.
+func writeCerts(t *testing.T, dir string, names ...string) (string, string, string) {
+	write := func(name, kind string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "GoDBLedger Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, cert, ca, &key.PublicKey, caKey)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return write("ca-cert.pem", "CERTIFICATE", caDER), write("cert.pem", "CERTIFICATE", certDER), write("key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+func TestGateway_TLSHostname(t *testing.T) {
+	caCert, cert, key := writeCerts(t, t.TempDir(), "localhost")
+	stop := startGateway(t, &Config{
+		Host:       "0.0.0.0",
+		Port:       "7397",
+		RESTPort:   "7398",
+		CACertFlag: caCert,
+		CertFlag:   cert,
+		KeyFlag:    key,
+	})
+	defer stop()
+
+	pemCA, err := os.ReadFile(caCert)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	assert.True(t, pool.AppendCertsFromPEM(pemCA))
+	clientCert, err := tls.LoadX509KeyPair(cert, key)
+	assert.NoError(t, err)
+
+	// The gateway dials the loopback address but the certificate only covers
+	// the hostname
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{clientCert},
+		ServerName:   "localhost",
+	}}}
+	code, body := post(t, client, "https://127.0.0.1:7398/transaction.Transactor/AddCurrency", `{"currency": "USD", "decimals": 2}`)
+	assert.Equal(t, http.StatusOK, code, string(body))
+	assert.Contains(t, string(body), "Accepted")
+}
+
+func TestGateway_ServerName(t *testing.T) {
+	certs := "../../tests/certs/"
+	ipCert, err := tls.LoadX509KeyPair(certs+"server-cert.pem", certs+"server-key.pem")
+	assert.NoError(t, err)
+	_, cert, key := writeCerts(t, t.TempDir(), "ledger.example.com")
+	hostCert, err := tls.LoadX509KeyPair(cert, key)
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		host string
+		cert tls.Certificate
+		name string
+	}{
+		{"ledger.internal", hostCert, "ledger.internal"},
+		{"10.0.0.5", ipCert, "10.0.0.5"},
+		{"0.0.0.0", ipCert, "127.0.0.1"},
+		{"", ipCert, "127.0.0.1"},
+		{"::", hostCert, "ledger.example.com"},
+	} {
+		g := &GatewayService{host: tc.host}
+		assert.Equal(t, tc.name, g.serverName(tc.cert), tc.host)
+	}
+}
@@ -2,10 +2,13 @@ package rpc
 
 import (
 	"context"
+	"io"
 	"math/big"
-	"strconv"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/darcys22/godbledger/proto/transaction"
 
 	"github.com/darcys22/godbledger/godbledger/core"
@@ -13,32 +16,141 @@ import (
 	"github.com/darcys22/godbledger/godbledger/version"
 )
 
+// listingPageSize is the number of transactions StreamListing reads from the
+// database at a time.
+const listingPageSize = 500
+
 type LedgerServer struct {
 	transaction.UnimplementedTransactorServer
 	ld *ledger.Ledger
+
+	// ctx is cancelled when the service stops, ending the subscriptions that
+	// would otherwise hold up a graceful stop
+	ctx context.Context
+}
+
+// ledger returns the ledger a request is for, the default ledger of the node
+// when the identifier is empty.
+func (s *LedgerServer) ledger(ctx context.Context, id string) (*ledger.Ledger, error) {
+	ld, err := s.ld.Entity(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return ld, nil
 }
 
 func (s *LedgerServer) AddTransaction(ctx context.Context, in *transaction.TransactionRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Add Transaction Request")
 
-	usr, err := core.NewUser("MainUser")
+	ld, err := s.ledger(ctx, in.GetLedger())
 	if err != nil {
 		log.Infof("Add Transaction error: %s", err.Error())
 		return &transaction.TransactionResponse{}, err
 	}
 
-	txn, err := core.NewTransaction(usr)
+	txn, err := s.newTransaction(ctx, ld, in)
 	if err != nil {
 		log.Infof("Add Transaction error: %s", err.Error())
-		return &transaction.TransactionResponse{}, err
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
+	response, err := ld.Insert(ctx, txn)
+	if err != nil {
+		log.Infof("Add Transaction error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
+	return &transaction.TransactionResponse{Message: response}, nil
+}
+
+// AddTransactions reads a stream of journals and inserts them in a single
+// database transaction once the client has finished sending, replying with the
+// result of each.
+func (s *LedgerServer) AddTransactions(stream transaction.Transactor_AddTransactionsServer) error {
+	ctx := stream.Context()
+	log.Info("Received New Add Transactions Stream")
+
+	results := []*transaction.TransactionResult{}
+	txns := []*core.Transaction{}
+	// index of each transaction in txns within the results
+	positions := []int{}
+	atomic := false
+	var ld *ledger.Ledger
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			atomic = in.GetAtomic()
+			ld, err = s.ledger(ctx, in.GetLedger())
+			if err != nil {
+				log.Infof("Add Transactions error: %s", err.Error())
+				return err
+			}
+		}
+
+		txn, err := s.newTransaction(ctx, ld, in.GetTransaction())
+		if err != nil {
+			log.Infof("Add Transactions error: %s", err.Error())
+			results = append(results, toTransactionResult("", err))
+			continue
+		}
+		results = append(results, &transaction.TransactionResult{Identifier: txn.Id})
+		txns = append(txns, txn)
+		positions = append(positions, len(results)-1)
+	}
+	log.Infof("Received %d transactions to add", len(results))
+
+	if atomic && len(txns) < len(results) {
+		for i, result := range results {
+			if result.Code == uint32(codes.OK) {
+				results[i] = toTransactionResult("", ledger.ErrBatchRolledBack)
+			}
+		}
+		return stream.SendAndClose(&transaction.BatchTransactionResponse{Results: results})
+	}
+
+	if ld == nil {
+		return stream.SendAndClose(&transaction.BatchTransactionResponse{Results: results})
+	}
+	errs, err := ld.InsertBatch(ctx, txns, atomic)
+	if err != nil {
+		log.Infof("Add Transactions error: %s", err.Error())
+		return toStatusError(err)
+	}
+	for i, err := range errs {
+		if err != nil {
+			log.Infof("Add Transactions error: %s", err.Error())
+			results[positions[i]] = toTransactionResult("", err)
+		}
+	}
+
+	return stream.SendAndClose(&transaction.BatchTransactionResponse{Results: results})
+}
+
+// newTransaction builds the journal described by a request, expanding lines
+// that name an allocation rule.
+func (s *LedgerServer) newTransaction(ctx context.Context, ld *ledger.Ledger, in *transaction.TransactionRequest) (*core.Transaction, error) {
+	usr, err := core.NewUser("MainUser")
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := core.NewTransaction(usr)
+	if err != nil {
+		return nil, err
 	}
 	txn.Description = []byte(in.GetDescription())
+	txn.Tags = in.GetTags()
 
 	layout := "2006-01-02"
 	t, err := time.Parse(layout, in.GetDate())
 	if err != nil {
-		log.Infof("Add Transaction error: %s", err.Error())
-		return &transaction.TransactionResponse{}, err
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	lines := in.GetLines()
@@ -46,42 +158,106 @@ func (s *LedgerServer) AddTransaction(ctx context.Context, in *transaction.Trans
 		a := line.GetAccountname()
 		acc, err := core.NewAccount(a, a)
 		if err != nil {
-			log.Infof("Add Transaction error: %s", err.Error())
-			return &transaction.TransactionResponse{}, err
+			return nil, err
 		}
 
 		b := line.GetCurrency()
-		curr, err := s.ld.GetCurrency(b)
+		curr, err := ld.GetCurrency(ctx, b)
 		if err != nil {
-			log.Infof("Add Transaction error: %s", err.Error())
-			return &transaction.TransactionResponse{}, err
+			return nil, err
 		}
 
-		s, err := core.NewSplit(t, txn.Description, []*core.Account{acc}, curr, big.NewInt(line.GetAmount()))
+		amount, err := requestAmount(line.GetAmount(), line.GetExactAmount())
 		if err != nil {
-			log.Infof("Add Transaction error: %s", err.Error())
-			return &transaction.TransactionResponse{}, err
+			return nil, err
 		}
 
-		err = txn.AppendSplit(s)
+		split, err := core.NewSplit(t, txn.Description, []*core.Account{acc}, curr, amount)
 		if err != nil {
-			log.Infof("Add Transaction error: %s", err.Error())
-			return &transaction.TransactionResponse{}, err
+			return nil, err
+		}
+
+		splits := []*core.Split{split}
+		if len(line.GetAllocation()) > 0 {
+			splits, err = ld.AllocateSplit(ctx, split, line.GetAllocation())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, split := range splits {
+			err = txn.AppendSplit(split)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	response, err := s.ld.Insert(txn)
+	return txn, nil
+}
+
+func (s *LedgerServer) DeleteTransaction(ctx context.Context, in *transaction.DeleteRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Delete Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
 	if err != nil {
-		log.Infof("Add Transaction error: %s", err.Error())
+		log.Infof("Delete Transaction error: %s", err.Error())
 		return &transaction.TransactionResponse{}, err
 	}
 
-	return &transaction.TransactionResponse{Message: response}, nil
+	err = ld.Delete(ctx, in.GetIdentifier())
+	if err != nil {
+		log.Infof("Delete Transaction error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
+	return &transaction.TransactionResponse{Message: "Accepted"}, nil
 }
 
-func (s *LedgerServer) DeleteTransaction(ctx context.Context, in *transaction.DeleteRequest) (*transaction.TransactionResponse, error) {
-	log.WithField("Request", in).Info("Received New Delete Request")
-	s.ld.Delete(in.GetIdentifier())
+func (s *LedgerServer) ListTrash(ctx context.Context, in *transaction.TrashRequest) (*transaction.TrashResponse, error) {
+	log.WithField("Request", in).Info("Received New List Trash Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("List Trash error: %s", err.Error())
+		return &transaction.TrashResponse{}, err
+	}
+
+	response := transaction.TrashResponse{}
+
+	trash, err := ld.ListTrash(ctx)
+	if err != nil {
+		log.Infof("List Trash error: %s", err.Error())
+		return &transaction.TrashResponse{}, toStatusError(err)
+	}
+
+	for _, txn := range trash {
+		response.Transactions = append(response.Transactions,
+			&transaction.TrashedTransaction{
+				Identifier:  txn.Id,
+				Date:        txn.Postdate.Format("2006-01-02 15:04:05"),
+				Description: string(txn.Description),
+				Trashed:     txn.TrashedAt.Format("2006-01-02 15:04:05"),
+			})
+	}
+
+	return &response, nil
+}
+
+func (s *LedgerServer) RestoreTransaction(ctx context.Context, in *transaction.DeleteRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Restore Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Restore Transaction error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
+	err = ld.Restore(ctx, in.GetIdentifier())
+	if err != nil {
+		log.Infof("Restore Transaction error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
 
 	return &transaction.TransactionResponse{Message: "Accepted"}, nil
 }
@@ -89,22 +265,65 @@ func (s *LedgerServer) DeleteTransaction(ctx context.Context, in *transaction.De
 func (s *LedgerServer) VoidTransaction(ctx context.Context, in *transaction.DeleteRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Void Request")
 
-	usr, err := core.NewUser("MainUser")
+	ld, err := s.ledger(ctx, in.GetLedger())
 	if err != nil {
 		log.Infof("Void Transaction error: %s", err.Error())
 		return &transaction.TransactionResponse{}, err
 	}
 
+	usr, err := core.NewUser("MainUser")
+	if err != nil {
+		log.Infof("Void Transaction error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
 	message := "Accepted"
-	err = s.ld.Void(in.GetIdentifier(), usr)
+	err = ld.Void(ctx, in.GetIdentifier(), usr)
 	if err != nil {
 		log.Infof("Void Transaction error: %s", err.Error())
-		return &transaction.TransactionResponse{}, err
+		return &transaction.TransactionResponse{}, toStatusError(err)
 	}
 
 	return &transaction.TransactionResponse{Message: message}, nil
 }
 
+func (s *LedgerServer) CreateLedger(ctx context.Context, in *transaction.CreateLedgerRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Create Ledger Request")
+
+	entity, err := core.NewEntity(in.GetIdentifier(), in.GetName(), in.GetDescription())
+	if err != nil {
+		log.Infof("Create Ledger error: %s", err.Error())
+		return &transaction.TransactionResponse{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.ld.CreateEntity(ctx, entity)
+	if err != nil {
+		log.Infof("Create Ledger error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+	return &transaction.TransactionResponse{Message: entity.Id}, nil
+}
+
+func (s *LedgerServer) ListLedgers(ctx context.Context, in *transaction.ListLedgersRequest) (*transaction.ListLedgersResponse, error) {
+	log.WithField("Request", in).Info("Received New List Ledgers Request")
+	response := transaction.ListLedgersResponse{}
+
+	entities, err := s.ld.ListEntities(ctx)
+	if err != nil {
+		log.Infof("List Ledgers error: %s", err.Error())
+		return &transaction.ListLedgersResponse{}, toStatusError(err)
+	}
+	for _, entity := range entities {
+		response.Ledgers = append(response.Ledgers,
+			&transaction.Ledger{
+				Identifier:  entity.Id,
+				Name:        entity.Name,
+				Description: entity.Description,
+			})
+	}
+	return &response, nil
+}
+
 func (s *LedgerServer) NodeVersion(ctx context.Context, in *transaction.VersionRequest) (*transaction.VersionResponse, error) {
 	log.WithField("Request", in).Info("Received New Version Request")
 	return &transaction.VersionResponse{Message: version.Version}, nil
@@ -113,9 +332,19 @@ func (s *LedgerServer) NodeVersion(ctx context.Context, in *transaction.VersionR
 func (s *LedgerServer) AddTag(ctx context.Context, in *transaction.AccountTagRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Add Tag Request")
 
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Add Tag error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
 	tags := in.GetTag()
 	for i := 0; i < len(tags); i++ {
-		s.ld.InsertTag(in.GetAccount(), tags[i])
+		err = ld.InsertTag(ctx, in.GetAccount(), tags[i])
+		if err != nil {
+			log.Infof("Add Tag error: %s", err.Error())
+			return &transaction.TransactionResponse{}, toStatusError(err)
+		}
 	}
 
 	return &transaction.TransactionResponse{Message: "Accepted"}, nil
@@ -124,9 +353,19 @@ func (s *LedgerServer) AddTag(ctx context.Context, in *transaction.AccountTagReq
 func (s *LedgerServer) DeleteTag(ctx context.Context, in *transaction.DeleteAccountTagRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Delete Tag Request")
 
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Delete Tag error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
 	tags := in.GetTag()
 	for i := 0; i < len(tags); i++ {
-		s.ld.DeleteTag(in.GetAccount(), tags[i])
+		err = ld.DeleteTag(ctx, in.GetAccount(), tags[i])
+		if err != nil {
+			log.Infof("Delete Tag error: %s", err.Error())
+			return &transaction.TransactionResponse{}, toStatusError(err)
+		}
 	}
 
 	return &transaction.TransactionResponse{Message: "Accepted"}, nil
@@ -135,16 +374,26 @@ func (s *LedgerServer) DeleteTag(ctx context.Context, in *transaction.DeleteAcco
 func (s *LedgerServer) AddAccount(ctx context.Context, in *transaction.AccountTagRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Add Account Request")
 
-	accountRequested := in.GetAccount()
-	err := s.ld.InsertAccount(accountRequested)
+	ld, err := s.ledger(ctx, in.GetLedger())
 	if err != nil {
 		log.Infof("Add Account error: %s", err.Error())
 		return &transaction.TransactionResponse{}, err
 	}
 
+	accountRequested := in.GetAccount()
+	err = ld.InsertAccount(ctx, accountRequested)
+	if err != nil {
+		log.Infof("Add Account error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
 	tags := in.GetTag()
 	for i := 0; i < len(tags); i++ {
-		s.ld.InsertTag(accountRequested, tags[i])
+		err = ld.InsertTag(ctx, accountRequested, tags[i])
+		if err != nil {
+			log.Infof("Add Account error: %s", err.Error())
+			return &transaction.TransactionResponse{}, toStatusError(err)
+		}
 	}
 
 	return &transaction.TransactionResponse{Message: "Accepted"}, nil
@@ -153,17 +402,27 @@ func (s *LedgerServer) AddAccount(ctx context.Context, in *transaction.AccountTa
 func (s *LedgerServer) DeleteAccount(ctx context.Context, in *transaction.DeleteAccountTagRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Delete Account Request")
 
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Delete Account error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
 	accountRequested := in.GetAccount()
 
 	tags := in.GetTag()
 	for i := 0; i < len(tags); i++ {
-		s.ld.DeleteTag(accountRequested, tags[i])
+		err = ld.DeleteTag(ctx, accountRequested, tags[i])
+		if err != nil {
+			log.Infof("Delete Account error: %s", err.Error())
+			return &transaction.TransactionResponse{}, toStatusError(err)
+		}
 	}
 
-	err := s.ld.DeleteAccount(accountRequested)
+	err = ld.DeleteAccount(ctx, accountRequested)
 	if err != nil {
 		log.Infof("Delete Account error: %s", err.Error())
-		return &transaction.TransactionResponse{}, err
+		return &transaction.TransactionResponse{}, toStatusError(err)
 	}
 
 	return &transaction.TransactionResponse{Message: "Accepted"}, nil
@@ -172,132 +431,805 @@ func (s *LedgerServer) DeleteAccount(ctx context.Context, in *transaction.Delete
 func (s *LedgerServer) AddCurrency(ctx context.Context, in *transaction.CurrencyRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Add Currency Request")
 
-	curr, err := core.NewCurrency(in.GetCurrency(), int(in.GetDecimals()))
+	ld, err := s.ledger(ctx, in.GetLedger())
 	if err != nil {
 		log.Infof("Add Currency error: %s", err.Error())
 		return &transaction.TransactionResponse{}, err
 	}
 
-	err = s.ld.InsertCurrency(curr)
+	curr, err := core.NewCurrency(in.GetCurrency(), int(in.GetDecimals()))
+	if err != nil {
+		log.Infof("Add Currency error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
+	err = ld.InsertCurrency(ctx, curr)
 	if err != nil {
 		log.Infof("Add Currency error: %s", err.Error())
-		return &transaction.TransactionResponse{}, err
+		return &transaction.TransactionResponse{}, toStatusError(err)
 	}
 	return &transaction.TransactionResponse{Message: "Accepted"}, nil
 }
 
 func (s *LedgerServer) DeleteCurrency(ctx context.Context, in *transaction.DeleteCurrencyRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Delete Currency Request")
-	s.ld.DeleteCurrency(in.GetCurrency())
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Delete Currency error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
+	err = ld.DeleteCurrency(ctx, in.GetCurrency())
+	if err != nil {
+		log.Infof("Delete Currency error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
 
 	return &transaction.TransactionResponse{Message: "Accepted"}, nil
 }
 
 func (s *LedgerServer) ReconcileTransactions(ctx context.Context, in *transaction.ReconciliationRequest) (*transaction.TransactionResponse, error) {
 	log.WithField("Request", in).Info("Received New Reconciliation Request")
-	response := transaction.TransactionResponse{}
-	reconciliationID, err := s.ld.ReconcileTransactions(in.GetSplitID())
 
+	ld, err := s.ledger(ctx, in.GetLedger())
 	if err != nil {
 		log.Infof("Reconcile Transactions error: %s", err.Error())
 		return &transaction.TransactionResponse{}, err
 	}
 
+	response := transaction.TransactionResponse{}
+	reconciliationID, err := ld.ReconcileTransactions(ctx, in.GetSplitID())
+
+	if err != nil {
+		log.Infof("Reconcile Transactions error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
 	log.WithField("reconciliation ID", reconciliationID).Debug("Created Reconciliation")
 
 	response.Message = reconciliationID
 	return &response, nil
 }
 
+func (s *LedgerServer) AddAllocationRule(ctx context.Context, in *transaction.AllocationRuleRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Add Allocation Rule Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Add Allocation Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
+	rule, err := core.NewAllocationRule(in.GetName())
+	if err != nil {
+		log.Infof("Add Allocation Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	for _, target := range in.GetTargets() {
+		acc, err := core.NewAccount(target.GetAccount(), target.GetAccount())
+		if err != nil {
+			log.Infof("Add Allocation Rule error: %s", err.Error())
+			return &transaction.TransactionResponse{}, toStatusError(err)
+		}
+		err = rule.AppendTarget(acc, target.GetWeight())
+		if err != nil {
+			log.Infof("Add Allocation Rule error: %s", err.Error())
+			return &transaction.TransactionResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	err = ld.InsertAllocationRule(ctx, rule)
+	if err != nil {
+		log.Infof("Add Allocation Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+	return &transaction.TransactionResponse{Message: "Accepted"}, nil
+}
+
+func (s *LedgerServer) DeleteAllocationRule(ctx context.Context, in *transaction.DeleteAllocationRuleRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Delete Allocation Rule Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Delete Allocation Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
+	err = ld.DeleteAllocationRule(ctx, in.GetName())
+	if err != nil {
+		log.Infof("Delete Allocation Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+	return &transaction.TransactionResponse{Message: "Accepted"}, nil
+}
+
+func (s *LedgerServer) AddPostingRule(ctx context.Context, in *transaction.PostingRuleRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Add Posting Rule Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Add Posting Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
+	amount, err := requestAmount(in.GetAmount(), in.GetExactAmount())
+	if err != nil {
+		return &transaction.TransactionResponse{}, err
+	}
+	rule := &core.PostingRule{
+		Name:     in.GetName(),
+		Type:     in.GetType(),
+		Account:  in.GetAccount(),
+		Tag:      in.GetTag(),
+		Currency: in.GetCurrency(),
+		Amount:   amount,
+	}
+	err = ld.InsertPostingRule(ctx, rule)
+	if err != nil {
+		log.Infof("Add Posting Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+	return &transaction.TransactionResponse{Message: "Accepted"}, nil
+}
+
+func (s *LedgerServer) DeletePostingRule(ctx context.Context, in *transaction.DeletePostingRuleRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Delete Posting Rule Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Delete Posting Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
+	err = ld.DeletePostingRule(ctx, in.GetName())
+	if err != nil {
+		log.Infof("Delete Posting Rule error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+	return &transaction.TransactionResponse{Message: "Accepted"}, nil
+}
+
 func (s *LedgerServer) GetTB(ctx context.Context, in *transaction.TBRequest) (*transaction.TBResponse, error) {
 	log.WithField("Request", in).Info("Received New Get Trial Balance Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Get Trial Balance error: %s", err.Error())
+		return &transaction.TBResponse{}, err
+	}
+
 	response := transaction.TBResponse{}
 
 	querydate, err := time.Parse("2006-01-02", in.Date)
 	if err != nil {
 		log.Infof("Get Trial Balance error: %s", err.Error())
-		return &transaction.TBResponse{}, err
+		return &transaction.TBResponse{}, status.Error(codes.InvalidArgument, err.Error())
 	}
-	accounts, err := s.ld.GetTB(querydate)
+	accounts, err := ld.GetTB(ctx, querydate)
 	if err != nil {
 		log.Infof("Get Trial Balance error: %s", err.Error())
-		return &transaction.TBResponse{}, err
+		return &transaction.TBResponse{}, toStatusError(err)
 	}
 
 	log.Debug("Building TB Response")
 	for _, account := range *accounts {
 		log.Debugf("Account: %s", account.Account)
-		amt := strconv.Itoa(account.Amount)
-		if len(amt) > account.Decimals {
-			amt = amt[:len(amt)-account.Decimals] + "." + amt[len(amt)-account.Decimals:]
-		} else {
-			prefix := "0."
-			for i := 1; i <= account.Decimals-len(amt); i++ {
-				prefix = prefix + "0"
-			}
-			amt = prefix + amt
-		}
-		response.Lines = append(response.Lines,
-			&transaction.TBLine{
-				Accountname: account.Account,
-				Amount:      int64(account.Amount),
-				Tags:        account.Tags,
-				Currency:    account.Currency,
-				Decimals:    int64(account.Decimals),
-				AmountStr:   amt,
+		response.Lines = append(response.Lines, toTBLine(account))
+	}
+
+	return &response, nil
+}
+
+func toTBLine(account core.TBAccount) *transaction.TBLine {
+	amount, exact := responseAmount(account.Amount)
+	return &transaction.TBLine{
+		Accountname: account.Account,
+		Amount:      amount,
+		Tags:        account.Tags,
+		Currency:    account.Currency,
+		Decimals:    int64(account.Decimals),
+		AmountStr:   core.FormatAmount(account.Amount, account.Decimals),
+		ExactAmount: exact,
+	}
+}
+
+func (s *LedgerServer) GetTransaction(ctx context.Context, in *transaction.GetTransactionRequest) (*transaction.Transaction, error) {
+	log.WithField("Request", in).Info("Received New Get Transaction Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Get Transaction error: %s", err.Error())
+		return &transaction.Transaction{}, err
+	}
+
+	txn, err := ld.GetTransaction(ctx, in.GetIdentifier())
+	if err != nil {
+		log.Infof("Get Transaction error: %s", err.Error())
+		return &transaction.Transaction{}, toStatusError(err)
+	}
+
+	return toListingTransaction(*txn), nil
+}
+
+func (s *LedgerServer) ListAccounts(ctx context.Context, in *transaction.ListAccountsRequest) (*transaction.ListAccountsResponse, error) {
+	log.WithField("Request", in).Info("Received New List Accounts Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("List Accounts error: %s", err.Error())
+		return &transaction.ListAccountsResponse{}, err
+	}
+
+	response := transaction.ListAccountsResponse{}
+
+	accounts, err := ld.ListAccounts(ctx)
+	if err != nil {
+		log.Infof("List Accounts error: %s", err.Error())
+		return &transaction.ListAccountsResponse{}, toStatusError(err)
+	}
+	for _, account := range accounts {
+		tags, err := ld.GetAccountTags(ctx, account.Name)
+		if err != nil {
+			log.Infof("List Accounts error: %s", err.Error())
+			return &transaction.ListAccountsResponse{}, toStatusError(err)
+		}
+		response.Accounts = append(response.Accounts,
+			&transaction.Account{
+				Code: account.Code,
+				Name: account.Name,
+				Tags: tags,
+			})
+	}
+
+	return &response, nil
+}
+
+func (s *LedgerServer) ListCurrencies(ctx context.Context, in *transaction.ListCurrenciesRequest) (*transaction.ListCurrenciesResponse, error) {
+	log.WithField("Request", in).Info("Received New List Currencies Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("List Currencies error: %s", err.Error())
+		return &transaction.ListCurrenciesResponse{}, err
+	}
+
+	response := transaction.ListCurrenciesResponse{}
+
+	currencies, err := ld.ListCurrencies(ctx)
+	if err != nil {
+		log.Infof("List Currencies error: %s", err.Error())
+		return &transaction.ListCurrenciesResponse{}, toStatusError(err)
+	}
+	for _, currency := range currencies {
+		response.Currencies = append(response.Currencies,
+			&transaction.Currency{
+				Name:     currency.Name,
+				Decimals: int64(currency.Decimals),
 			})
 	}
 
 	return &response, nil
 }
 
+func (s *LedgerServer) ListTags(ctx context.Context, in *transaction.ListTagsRequest) (*transaction.ListTagsResponse, error) {
+	log.WithField("Request", in).Info("Received New List Tags Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("List Tags error: %s", err.Error())
+		return &transaction.ListTagsResponse{}, err
+	}
+
+	tags, err := ld.ListTags(ctx)
+	if err != nil {
+		log.Infof("List Tags error: %s", err.Error())
+		return &transaction.ListTagsResponse{}, toStatusError(err)
+	}
+
+	return &transaction.ListTagsResponse{Tags: tags}, nil
+}
+
+// GetAccountBalance returns the trial balance lines of one account, its
+// balance in each currency at the end of the requested day.
+func (s *LedgerServer) GetAccountBalance(ctx context.Context, in *transaction.AccountBalanceRequest) (*transaction.TBResponse, error) {
+	log.WithField("Request", in).Info("Received New Get Account Balance Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Get Account Balance error: %s", err.Error())
+		return &transaction.TBResponse{}, err
+	}
+
+	response := transaction.TBResponse{}
+
+	querydate := time.Now()
+	if len(in.GetDate()) > 0 {
+		querydate, err = time.Parse("2006-01-02", in.GetDate())
+		if err != nil {
+			log.Infof("Get Account Balance error: %s", err.Error())
+			return &transaction.TBResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	balances, err := ld.GetAccountBalance(ctx, in.GetAccount(), querydate)
+	if err != nil {
+		log.Infof("Get Account Balance error: %s", err.Error())
+		return &transaction.TBResponse{}, toStatusError(err)
+	}
+	for _, balance := range balances {
+		response.Lines = append(response.Lines, toTBLine(balance))
+	}
+
+	return &response, nil
+}
+
+// GetAccountRegister returns the splits of one account over a date range with
+// its running balance, a register for each currency.
+func (s *LedgerServer) GetAccountRegister(ctx context.Context, in *transaction.RegisterRequest) (*transaction.RegisterResponse, error) {
+	log.WithField("Request", in).Info("Received New Get Account Register Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Get Account Register error: %s", err.Error())
+		return &transaction.RegisterResponse{}, err
+	}
+
+	response := transaction.RegisterResponse{}
+
+	var start, end time.Time
+	for _, date := range []struct {
+		value string
+		field *time.Time
+	}{{in.GetStartdate(), &start}, {in.GetDate(), &end}} {
+		if len(date.value) == 0 {
+			continue
+		}
+		*date.field, err = time.Parse("2006-01-02", date.value)
+		if err != nil {
+			log.Infof("Get Account Register error: %s", err.Error())
+			return &transaction.RegisterResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	registers, err := ld.GetAccountRegister(ctx, in.GetAccount(), start, end)
+	if err != nil {
+		log.Infof("Get Account Register error: %s", err.Error())
+		return &transaction.RegisterResponse{}, toStatusError(err)
+	}
+
+	for _, register := range registers {
+		r := &transaction.AccountRegister{
+			Account:  register.Account,
+			Currency: register.Currency,
+			Decimals: int64(register.Decimals),
+		}
+		r.Opening, r.ExactOpening = responseAmount(register.Opening)
+		r.Closing, r.ExactClosing = responseAmount(register.Closing)
+		for _, line := range register.Lines {
+			l := &transaction.RegisterLine{
+				Identifier:  line.Transaction,
+				Split:       line.Split,
+				Date:        line.Date.Format("2006-01-02"),
+				Description: line.Description,
+			}
+			l.Amount, l.ExactAmount = responseAmount(line.Amount)
+			l.Balance, l.ExactBalance = responseAmount(line.Balance)
+			r.Lines = append(r.Lines, l)
+		}
+		response.Registers = append(response.Registers, r)
+	}
+
+	return &response, nil
+}
+
 func (s *LedgerServer) GetListing(ctx context.Context, in *transaction.ReportRequest) (*transaction.ListingResponse, error) {
 	log.WithField("Request", in).Info("Received New Get Listing Request")
-	response := transaction.ListingResponse{}
 
-	startdate, err := time.Parse("2006-01-02", in.Startdate)
+	ld, err := s.ledger(ctx, in.GetLedger())
 	if err != nil {
 		log.Infof("Get Listing error: %s", err.Error())
 		return &transaction.ListingResponse{}, err
 	}
-	enddate, err := time.Parse("2006-01-02", in.Date)
+
+	response := transaction.ListingResponse{}
+
+	filter, err := listingFilter(in)
 	if err != nil {
 		log.Infof("Get Listing error: %s", err.Error())
-		return &transaction.ListingResponse{}, err
+		return &transaction.ListingResponse{}, toStatusError(err)
+	}
+	// One more than the page is fetched to tell whether there is another
+	if in.GetLimit() > 0 {
+		filter.Limit = int(in.GetLimit()) + 1
 	}
-	txns, err := s.ld.GetListing(startdate, enddate)
+	txns, err := ld.GetListing(ctx, filter)
 	if err != nil {
 		log.Infof("Get Listing error: %s", err.Error())
-		return &transaction.ListingResponse{}, err
+		return &transaction.ListingResponse{}, toStatusError(err)
+	}
+	if in.GetLimit() > 0 && len(*txns) > int(in.GetLimit()) {
+		page := (*txns)[:in.GetLimit()]
+		txns = &page
+		response.Cursor = page[len(page)-1].Id
 	}
 
 	log.Debug("Building Listing Response")
 
 	for _, txn := range *txns {
-		splits := []*transaction.LineItem{}
-		date := ""
-
-		if len(txn.Splits) > 0 {
-			date = txn.Splits[0].Date.Format("2006-01-02 15:04:05")
-			for _, split := range txn.Splits {
-				splits = append(splits,
-					&transaction.LineItem{
-						Accountname: split.Accounts[0].Name,
-						Description: string(split.Description),
-						Currency:    split.Currency.Name,
-						Amount:      split.Amount.Int64(),
-					})
+		response.Transactions = append(response.Transactions, toListingTransaction(txn))
+	}
+
+	return &response, nil
+}
+
+// StreamListing sends the transactions of a listing one at a time, reading
+// them from the database a page at a time so that neither the server nor the
+// client holds the whole listing.
+func (s *LedgerServer) StreamListing(in *transaction.ReportRequest, stream transaction.Transactor_StreamListingServer) error {
+	log.WithField("Request", in).Info("Received New Stream Listing Request")
+	ctx := stream.Context()
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Stream Listing error: %s", err.Error())
+		return err
+	}
+
+	filter, err := listingFilter(in)
+	if err != nil {
+		log.Infof("Stream Listing error: %s", err.Error())
+		return toStatusError(err)
+	}
+
+	remaining := int(in.GetLimit())
+	for {
+		filter.Limit = listingPageSize
+		if in.GetLimit() > 0 && remaining < listingPageSize {
+			filter.Limit = remaining
+		}
+		txns, err := ld.GetListing(ctx, filter)
+		if err != nil {
+			log.Infof("Stream Listing error: %s", err.Error())
+			return toStatusError(err)
+		}
+		for _, txn := range *txns {
+			if err := stream.Send(toListingTransaction(txn)); err != nil {
+				return err
 			}
-		} else {
-			date = txn.Postdate.Format("2006-01-02 15:04:05")
 		}
-		response.Transactions = append(response.Transactions,
-			&transaction.Transaction{
-				Date:        date,
-				Description: string(txn.Description),
-				Lines:       splits,
+		remaining -= len(*txns)
+		if len(*txns) < filter.Limit || (in.GetLimit() > 0 && remaining == 0) {
+			return nil
+		}
+		filter.After = (*txns)[len(*txns)-1].Id
+	}
+}
+
+// Subscribe streams the events of a ledger, starting with those held after
+// the requested sequence, until the client goes away or the server stops.
+// Only the events held in memory since the node started can be resumed from.
+func (s *LedgerServer) Subscribe(in *transaction.SubscribeRequest, stream transaction.Transactor_SubscribeServer) error {
+	log.WithField("Request", in).Info("Received New Subscribe Request")
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	if s.ctx != nil {
+		go func() {
+			select {
+			case <-s.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	if _, err := s.ledger(ctx, in.GetLedger()); err != nil {
+		log.Infof("Subscribe error: %s", err.Error())
+		return err
+	}
+	bus := s.ld.Events()
+	if bus == nil {
+		return status.Error(codes.Unavailable, "Events are not published by this ledger")
+	}
+
+	kinds := make(map[string]bool)
+	for _, kind := range in.GetKinds() {
+		kinds[kind] = true
+	}
+	for kind := range kinds {
+		known := false
+		for _, k := range ledger.EventKinds {
+			known = known || k == kind
+		}
+		if !known {
+			return status.Errorf(codes.InvalidArgument, "Unknown event kind %q", kind)
+		}
+	}
+
+	sub, err := bus.Subscribe(in.GetEpoch(), in.GetAfter())
+	if err != nil {
+		log.Infof("Subscribe error: %s", err.Error())
+		return toStatusError(err)
+	}
+	defer sub.Close()
+
+	for {
+		e, err := sub.Next(ctx)
+		if err != nil {
+			if s.ctx != nil && s.ctx.Err() != nil {
+				return status.Error(codes.Unavailable, "Server is stopping")
+			}
+			return toStatusError(err)
+		}
+		if e.Ledger != in.GetLedger() || (len(kinds) > 0 && !kinds[e.Kind]) {
+			continue
+		}
+		err = stream.Send(&transaction.Event{
+			Sequence:    e.Sequence,
+			Epoch:       e.Epoch,
+			Kind:        e.Kind,
+			Ledger:      e.Ledger,
+			Transaction: e.Transaction,
+			Account:     e.Account,
+			Tag:         e.Tag,
+			Currency:    e.Currency,
+			Time:        e.Time.Format(time.RFC3339Nano),
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *LedgerServer) ListWebhookDeadLetters(ctx context.Context, in *transaction.WebhookDeadLettersRequest) (*transaction.WebhookDeadLettersResponse, error) {
+	log.WithField("Request", in).Info("Received New List Webhook Dead Letters Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("List Webhook Dead Letters error: %s", err.Error())
+		return &transaction.WebhookDeadLettersResponse{}, err
+	}
+
+	deliveries, err := ld.ListDeadWebhookDeliveries(ctx)
+	if err != nil {
+		log.Infof("List Webhook Dead Letters error: %s", err.Error())
+		return &transaction.WebhookDeadLettersResponse{}, toStatusError(err)
+	}
+
+	response := transaction.WebhookDeadLettersResponse{}
+	for _, d := range deliveries {
+		response.Deliveries = append(response.Deliveries,
+			&transaction.WebhookDelivery{
+				Identifier: d.ID,
+				Webhook:    d.Webhook,
+				Kind:       d.Kind,
+				Payload:    string(d.Payload),
+				Attempts:   int32(d.Attempts),
+				LastError:  d.LastError,
+				Created:    d.Created.Format("2006-01-02 15:04:05"),
+			})
+	}
+
+	return &response, nil
+}
+
+func (s *LedgerServer) RetryWebhookDelivery(ctx context.Context, in *transaction.WebhookDeliveryRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Retry Webhook Delivery Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Retry Webhook Delivery error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
+	err = ld.RetryWebhookDelivery(ctx, in.GetIdentifier())
+	if err != nil {
+		log.Infof("Retry Webhook Delivery error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
+	return &transaction.TransactionResponse{Message: "Accepted"}, nil
+}
+
+func (s *LedgerServer) DiscardWebhookDelivery(ctx context.Context, in *transaction.WebhookDeliveryRequest) (*transaction.TransactionResponse, error) {
+	log.WithField("Request", in).Info("Received New Discard Webhook Delivery Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Discard Webhook Delivery error: %s", err.Error())
+		return &transaction.TransactionResponse{}, err
+	}
+
+	err = ld.DiscardWebhookDelivery(ctx, in.GetIdentifier())
+	if err != nil {
+		log.Infof("Discard Webhook Delivery error: %s", err.Error())
+		return &transaction.TransactionResponse{}, toStatusError(err)
+	}
+
+	return &transaction.TransactionResponse{Message: "Accepted"}, nil
+}
+
+// listingFilter reads the date range, filters and cursor of a listing request.
+func listingFilter(in *transaction.ReportRequest) (core.ListingFilter, error) {
+	startdate, err := time.Parse("2006-01-02", in.Startdate)
+	if err != nil {
+		return core.ListingFilter{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	enddate, err := time.Parse("2006-01-02", in.Date)
+	if err != nil {
+		return core.ListingFilter{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if in.GetLimit() < 0 {
+		return core.ListingFilter{}, status.Error(codes.InvalidArgument, "Limit cannot be negative")
+	}
+	return core.ListingFilter{
+		StartDate: startdate,
+		EndDate:   enddate,
+		Accounts:  in.GetAccounts(),
+		Tags:      in.GetTags(),
+		After:     in.GetCursor(),
+	}, nil
+}
+
+// SearchTransactions finds the transactions matching the words of the query,
+// a page at a time like GetListing.
+func (s *LedgerServer) SearchTransactions(ctx context.Context, in *transaction.SearchRequest) (*transaction.ListingResponse, error) {
+	log.WithField("Request", in).Info("Received New Search Transactions Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Search Transactions error: %s", err.Error())
+		return &transaction.ListingResponse{}, err
+	}
+
+	response := transaction.ListingResponse{}
+
+	filter, err := searchFilter(in)
+	if err != nil {
+		log.Infof("Search Transactions error: %s", err.Error())
+		return &transaction.ListingResponse{}, err
+	}
+	// One more than the page is fetched to tell whether there is another
+	if in.GetLimit() > 0 {
+		filter.Limit = int(in.GetLimit()) + 1
+	}
+	txns, err := ld.SearchTransactions(ctx, filter)
+	if err != nil {
+		log.Infof("Search Transactions error: %s", err.Error())
+		return &transaction.ListingResponse{}, toStatusError(err)
+	}
+	if in.GetLimit() > 0 && len(*txns) > int(in.GetLimit()) {
+		page := (*txns)[:in.GetLimit()]
+		txns = &page
+		response.Cursor = page[len(page)-1].Id
+	}
+
+	for _, txn := range *txns {
+		response.Transactions = append(response.Transactions, toListingTransaction(txn))
+	}
+
+	return &response, nil
+}
+
+// CheckIntegrity reports the integrity violations of the ledger, repairing
+// the safe ones first when asked to.
+func (s *LedgerServer) CheckIntegrity(ctx context.Context, in *transaction.IntegrityRequest) (*transaction.IntegrityResponse, error) {
+	log.WithField("Request", in).Info("Received New Check Integrity Request")
+
+	ld, err := s.ledger(ctx, in.GetLedger())
+	if err != nil {
+		log.Infof("Check Integrity error: %s", err.Error())
+		return &transaction.IntegrityResponse{}, err
+	}
+
+	violations, repaired, err := ld.CheckIntegrity(ctx, in.GetRepair())
+	if err != nil {
+		log.Infof("Check Integrity error: %s", err.Error())
+		return &transaction.IntegrityResponse{}, toStatusError(err)
+	}
+
+	response := transaction.IntegrityResponse{Repaired: int32(repaired)}
+	for _, violation := range violations {
+		response.Violations = append(response.Violations,
+			&transaction.IntegrityViolation{
+				Kind:        violation.Kind,
+				Transaction: violation.Transaction,
+				Split:       violation.Split,
+				Currency:    violation.Currency,
+				Detail:      violation.Detail,
+				Repairable:  violation.Repairable,
 			})
 	}
 
 	return &response, nil
 }
+
+// searchFilter reads the query, ranges and cursor of a search request, the
+// ranges being unbounded where they are left empty.
+func searchFilter(in *transaction.SearchRequest) (core.SearchFilter, error) {
+	if len(core.SearchTerms(in.GetQuery())) == 0 {
+		return core.SearchFilter{}, status.Error(codes.InvalidArgument, "Query must hold at least one word")
+	}
+	if in.GetLimit() < 0 {
+		return core.SearchFilter{}, status.Error(codes.InvalidArgument, "Limit cannot be negative")
+	}
+	filter := core.SearchFilter{Query: in.GetQuery(), After: in.GetCursor()}
+	for _, date := range []struct {
+		value string
+		field *time.Time
+	}{{in.GetStartdate(), &filter.StartDate}, {in.GetDate(), &filter.EndDate}} {
+		if len(date.value) == 0 {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", date.value)
+		if err != nil {
+			return core.SearchFilter{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		*date.field = parsed
+	}
+	for _, amount := range []struct {
+		value string
+		field **big.Int
+	}{{in.GetMinAmount(), &filter.MinAmount}, {in.GetMaxAmount(), &filter.MaxAmount}} {
+		if len(amount.value) == 0 {
+			continue
+		}
+		parsed, err := core.ParseAmount(amount.value)
+		if err != nil {
+			return core.SearchFilter{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		*amount.field = parsed
+	}
+	return filter, nil
+}
+
+func toListingTransaction(txn core.Transaction) *transaction.Transaction {
+	splits := []*transaction.LineItem{}
+	date := ""
+
+	if len(txn.Splits) > 0 {
+		date = txn.Splits[0].Date.Format("2006-01-02 15:04:05")
+		for _, split := range txn.Splits {
+			amount, exact := responseAmount(split.Amount)
+			splits = append(splits,
+				&transaction.LineItem{
+					Accountname: split.Accounts[0].Name,
+					Description: string(split.Description),
+					Currency:    split.Currency.Name,
+					Amount:      amount,
+					Decimals:    int64(split.Currency.Decimals),
+					ExactAmount: exact,
+				})
+		}
+	} else {
+		date = txn.Postdate.Format("2006-01-02 15:04:05")
+	}
+	return &transaction.Transaction{
+		Date:        date,
+		Description: string(txn.Description),
+		Lines:       splits,
+		Identifier:  txn.Id,
+	}
+}
+
+// requestAmount reads the amount of a request line, taking the exact amount
+// in place of the int64 one when it is set.
+func requestAmount(amount int64, exact string) (*big.Int, error) {
+	if len(exact) == 0 {
+		return big.NewInt(amount), nil
+	}
+	value, err := core.ParseAmount(exact)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return value, nil
+}
+
+// responseAmount returns the int64 and exact forms of an amount, the int64
+// one is zero when the amount does not fit in it.
+func responseAmount(amount *big.Int) (int64, string) {
+	if !amount.IsInt64() {
+		return 0, amount.String()
+	}
+	return amount.Int64(), amount.String()
+}
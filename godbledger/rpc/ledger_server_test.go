@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+	"github.com/darcys22/godbledger/proto/transaction"
+)
+
+func newTestServer(t *testing.T) *LedgerServer {
+	ctx := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	ld, err := ledger.New(ctx, &cmd.LedgerConfig{DatabaseType: "memorydb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ld.Start()
+	t.Cleanup(func() { ld.Stop() })
+	return &LedgerServer{ld: ld, ctx: context.Background()}
+}
+
+func TestAccountErrors(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+
+	_, err := s.AddTag(ctx, &transaction.AccountTagRequest{Account: "Assets:Missing", Tag: []string{"bank"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.DeleteTag(ctx, &transaction.DeleteAccountTagRequest{Account: "Assets:Missing", Tag: []string{"bank"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.AddAccount(ctx, &transaction.AccountTagRequest{Account: " "})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := s.AddAccount(ctx, &transaction.AccountTagRequest{Account: "Assets:Cash", Tag: []string{"bank"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Accepted", res.GetMessage())
+	_, err = s.AddTag(ctx, &transaction.AccountTagRequest{Account: "Assets:Cash", Tag: []string{"main"}})
+	assert.NoError(t, err)
+}
+
+func TestMalformedDates(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+
+	_, err := s.GetTB(ctx, &transaction.TBRequest{Date: "31/03/2021"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.GetListing(ctx, &transaction.ReportRequest{Startdate: "2021-03-01", Date: "yesterday"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.AddTransaction(ctx, &transaction.TransactionRequest{Date: "soon"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAllocationRuleErrors(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+
+	_, err := s.AddAllocationRule(ctx, &transaction.AllocationRuleRequest{Name: "rent"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	for _, weight := range []int64{0, -10} {
+		_, err = s.AddAllocationRule(ctx, &transaction.AllocationRuleRequest{
+			Name:    "rent",
+			Targets: []*transaction.AllocationTarget{{Account: "Rent:Admin", Weight: 60}, {Account: "Rent:Sales", Weight: weight}},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	res, err := s.AddAllocationRule(ctx, &transaction.AllocationRuleRequest{
+		Name:    "rent",
+		Targets: []*transaction.AllocationTarget{{Account: "Rent:Admin", Weight: 60}, {Account: "Rent:Sales", Weight: 40}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Accepted", res.GetMessage())
+}
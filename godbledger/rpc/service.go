@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"github.com/darcys22/godbledger/proto/transaction"
 
@@ -36,6 +37,7 @@ type Service struct {
 	withCACert      string
 	withCert        string
 	withKey         string
+	queryTimeout    time.Duration
 	credentialError error
 }
 
@@ -45,19 +47,28 @@ type Config struct {
 	CACertFlag string
 	CertFlag   string
 	KeyFlag    string
+
+	// QueryTimeout limits how long a single request may spend querying the
+	// database, zero leaves requests unbounded
+	QueryTimeout time.Duration
+
+	// RESTPort is the port of the REST gateway, see GatewayService, blank
+	// when it is not served
+	RESTPort string
 }
 
 func NewRPCService(ctx context.Context, cfg *Config, l *ledger.Ledger) *Service {
 	ctx, cancel := context.WithCancel(ctx)
 	return &Service{
-		ld:         l,
-		ctx:        ctx,
-		cancel:     cancel,
-		port:       cfg.Port,
-		host:       cfg.Host,
-		withCACert: cfg.CACertFlag,
-		withCert:   cfg.CertFlag,
-		withKey:    cfg.KeyFlag,
+		ld:           l,
+		ctx:          ctx,
+		cancel:       cancel,
+		port:         cfg.Port,
+		host:         cfg.Host,
+		withCACert:   cfg.CACertFlag,
+		withCert:     cfg.CertFlag,
+		withKey:      cfg.KeyFlag,
+		queryTimeout: cfg.QueryTimeout,
 	}
 }
 
@@ -91,7 +102,7 @@ func (s *Service) Start() {
 
 	s.grpcServer = grpc.NewServer(opts...)
 
-	ledgerServer := &LedgerServer{ld: s.ld}
+	ledgerServer := &LedgerServer{ld: s.ld, ctx: s.ctx}
 
 	transaction.RegisterTransactorServer(s.grpcServer, ledgerServer)
 
@@ -126,17 +137,39 @@ func (s *Service) Status() error {
 	return nil
 }
 
+// subscriptionMethods are the streams that stay open waiting for changes
+// rather than querying the database, the query timeout does not apply.
+var subscriptionMethods = map[string]bool{
+	"/transaction.Transactor/Subscribe": true,
+}
+
 // Stream interceptor for new stream client connections to GRPC.
 func (s *Service) streamConnectionInterceptor(
 	srv interface{},
 	ss grpc.ServerStream,
-	_ *grpc.StreamServerInfo,
+	info *grpc.StreamServerInfo,
 	handler grpc.StreamHandler,
 ) error {
 	s.logNewClientConnection(ss.Context())
+	if s.queryTimeout > 0 && !subscriptionMethods[info.FullMethod] {
+		ctx, cancel := context.WithTimeout(ss.Context(), s.queryTimeout)
+		defer cancel()
+		ss = &timeoutStream{ServerStream: ss, ctx: ctx}
+	}
 	return handler(srv, ss)
 }
 
+// timeoutStream replaces the context of a server stream with one bounded by
+// the query timeout.
+type timeoutStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ts *timeoutStream) Context() context.Context {
+	return ts.ctx
+}
+
 // Unary interceptor for new unary client connections to GRPC.
 func (s *Service) unaryConnectionInterceptor(
 	ctx context.Context,
@@ -145,6 +178,11 @@ func (s *Service) unaryConnectionInterceptor(
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
 	s.logNewClientConnection(ctx)
+	if s.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.queryTimeout)
+		defer cancel()
+	}
 	return handler(ctx, req)
 }
 
@@ -157,8 +195,18 @@ func (s *Service) logNewClientConnection(ctx context.Context) {
 }
 
 func (s *Service) loadTLSCredentials() (credentials.TransportCredentials, error) {
+	config, err := loadTLSConfig(s.withCACert, s.withCert, s.withKey)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// loadTLSConfig builds the server side TLS settings, presenting the server's
+// certificate and requiring clients to have one signed by the CA.
+func loadTLSConfig(caCert, cert, key string) (*tls.Config, error) {
 	// Load certificate of the CA who signed client's certificate
-	pemClientCA, err := os.ReadFile(s.withCACert)
+	pemClientCA, err := os.ReadFile(caCert)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +216,7 @@ func (s *Service) loadTLSCredentials() (credentials.TransportCredentials, error)
 	}
 
 	// Load server's certificate and private key
-	serverCert, err := tls.LoadX509KeyPair(s.withCert, s.withKey)
+	serverCert, err := tls.LoadX509KeyPair(cert, key)
 	if err != nil {
 		return nil, err
 	}
@@ -180,5 +228,5 @@ func (s *Service) loadTLSCredentials() (credentials.TransportCredentials, error)
 		ClientCAs:    certPool,
 	}
 
-	return credentials.NewTLS(config), nil
+	return config, nil
 }
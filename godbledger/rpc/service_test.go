@@ -6,6 +6,7 @@ import (
 	"flag"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/darcys22/godbledger/godbledger/cmd"
 	"github.com/darcys22/godbledger/godbledger/ledger"
@@ -15,6 +16,8 @@ import (
 	logTest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func init() {
@@ -89,3 +92,23 @@ func TestRPC_InsecureEndpoint(t *testing.T) {
 	internal.LogsContain(t.Fatalf, hook, "You are using an insecure gRPC server", true)
 	assert.NoError(t, rpcService.Stop())
 }
+
+func TestUnaryInterceptor_QueryTimeout(t *testing.T) {
+	s := &Service{queryTimeout: time.Millisecond}
+
+	_, err := s.unaryConnectionInterceptor(context.Background(), nil, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		<-ctx.Done()
+		return nil, toStatusError(ctx.Err())
+	})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	s.queryTimeout = 0
+	_, err = s.unaryConnectionInterceptor(context.Background(), nil, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+}
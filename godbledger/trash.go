@@ -0,0 +1,53 @@
+package main
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+var purgeTrashCommand = &cli.Command{
+	Action:    purgeTrash,
+	Name:      "purge",
+	Usage:     "godbledger purge [--retention=720h]",
+	ArgsUsage: "",
+	Category:  "MAINTENANCE COMMANDS",
+	Description: `The purge command permanently deletes transactions that have been in the trash
+for longer than the retention period. Deleted transactions are otherwise kept
+in the trash so they can be restored.`,
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "retention",
+			Value: 30 * 24 * time.Hour,
+			Usage: "how long deleted transactions are kept in the trash before being purged",
+		},
+	},
+}
+
+// purgeTrash is the purge command.
+func purgeTrash(ctx *cli.Context) error {
+	log := logrus.WithField("prefix", "main")
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := ledger.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	ledger.Start()
+	defer ledger.Stop()
+
+	purged, err := ledger.PurgeTrash(ctx.Context, ctx.Duration("retention"))
+	if err != nil {
+		return err
+	}
+	log.Infof("Purged %d transactions from the trash", purged)
+
+	return nil
+}
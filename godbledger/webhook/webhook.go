@@ -0,0 +1,281 @@
+// Package webhook sends the events of the ledger to HTTP endpoints. The
+// ledger queues a delivery of each event in its database in the same unit of
+// work as the change that raised it, and this service sends them. Deliveries
+// that fail are retried with backoff, including across restarts, until they
+// run out of attempts and are kept as dead letters.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+	"github.com/darcys22/godbledger/godbledger/version"
+)
+
+var log = logrus.WithField("prefix", "webhook")
+
+// Headers sent with every delivery. The signature is that of core.SignWebhook
+// over the timestamp header and the body.
+const (
+	HeaderDelivery  = "X-Godbledger-Delivery"
+	HeaderEvent     = "X-Godbledger-Event"
+	HeaderTimestamp = "X-Godbledger-Timestamp"
+	HeaderSignature = "X-Godbledger-Signature"
+)
+
+// Defaults used for the settings left zero in the Config.
+const (
+	DefaultBackoff      = 5 * time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultPollInterval = time.Second
+	DefaultTimeout      = 10 * time.Second
+)
+
+// batchSize is the number of due deliveries read from the database at a time.
+const batchSize = 100
+
+// maxErrorLength bounds the error kept with a delivery, which the databases
+// store in a limited column.
+const maxErrorLength = 1024
+
+// Config holds the settings of the service, the webhooks themselves are those
+// of the ledger configuration, which queues their deliveries.
+type Config struct {
+	// Backoff is the wait before the second attempt of a delivery, it
+	// doubles with each attempt after that up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// PollInterval is how often the queue is checked for retries that are due
+	PollInterval time.Duration
+	// Timeout limits each request to an endpoint
+	Timeout time.Duration
+}
+
+// Service is the core.Service sending the webhook deliveries queued in the
+// databases of the ledgers.
+type Service struct {
+	ld       *ledger.Ledger
+	ctx      context.Context
+	cancel   context.CancelFunc
+	webhooks map[string]core.Webhook
+	client   *http.Client
+	wake     chan struct{}
+	wg       sync.WaitGroup
+
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+}
+
+func NewService(ctx context.Context, cfg *Config, l *ledger.Ledger) *Service {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Service{
+		ld:           l,
+		ctx:          ctx,
+		cancel:       cancel,
+		webhooks:     make(map[string]core.Webhook),
+		client:       &http.Client{Timeout: orDefault(cfg.Timeout, DefaultTimeout)},
+		wake:         make(chan struct{}, 1),
+		backoff:      orDefault(cfg.Backoff, DefaultBackoff),
+		maxBackoff:   orDefault(cfg.MaxBackoff, DefaultMaxBackoff),
+		pollInterval: orDefault(cfg.PollInterval, DefaultPollInterval),
+	}
+	for _, hook := range l.Config.Webhooks {
+		s.webhooks[hook.Name] = hook
+	}
+	return s
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// Start sends the queued deliveries, watching the events of the ledger to
+// send new ones straight away rather than at the next poll.
+func (s *Service) Start() {
+	log.WithField("webhooks", len(s.webhooks)).Info("Starting webhook service")
+	if bus := s.ld.Events(); bus != nil {
+		sub, err := bus.Subscribe("", bus.Sequence())
+		if err != nil {
+			log.Errorf("Could not subscribe to the ledger events: %s", err)
+		} else {
+			s.wg.Add(1)
+			go s.watch(bus, sub)
+		}
+	}
+	s.wg.Add(1)
+	go s.deliver()
+}
+
+// Stop waits for the delivery in flight to be abandoned, it is left queued
+// and sent again when the node next starts.
+func (s *Service) Stop() error {
+	log.Info("Stopping webhook service")
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Service) Status() error {
+	return nil
+}
+
+// watch wakes the sender whenever the ledger changes. The deliveries are
+// already queued, so events missed after falling behind only wait for the
+// next poll.
+func (s *Service) watch(bus *ledger.EventBus, sub *ledger.Subscription) {
+	defer s.wg.Done()
+	for {
+		_, err := sub.Next(s.ctx)
+		if errors.Is(err, ledger.ErrSubscriberLagged) {
+			sub, err = bus.Subscribe("", bus.Sequence())
+		}
+		if err != nil {
+			if s.ctx.Err() == nil {
+				log.Errorf("Stopped watching the ledger events: %s", err)
+			}
+			sub.Close()
+			return
+		}
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// deliver sends the due deliveries whenever the ledger changes and on every
+// poll for retries.
+func (s *Service) deliver() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		s.sendDue()
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDue attempts a batch of the due deliveries of each ledger, a larger
+// backlog is worked through a batch at each poll.
+func (s *Service) sendDue() {
+	ledgers, err := s.ld.Ledgers(s.ctx)
+	if err != nil {
+		if s.ctx.Err() == nil {
+			log.Errorf("Could not list the ledgers: %s", err)
+		}
+		return
+	}
+	for _, ld := range ledgers {
+		due, err := ld.LedgerDb.DueWebhookDeliveries(s.ctx, time.Now().UTC(), batchSize)
+		if err != nil {
+			if s.ctx.Err() == nil {
+				log.Errorf("Could not read the webhook deliveries: %s", err)
+			}
+			continue
+		}
+		for _, delivery := range due {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.attempt(ld, delivery)
+		}
+	}
+}
+
+// attempt sends the delivery, deleting it once it is accepted and otherwise
+// scheduling the next attempt or making it a dead letter.
+func (s *Service) attempt(ld *ledger.Ledger, delivery *core.WebhookDelivery) {
+	fields := logrus.Fields{"delivery": delivery.ID, "webhook": delivery.Webhook, "event": delivery.Kind}
+	hook, ok := s.webhooks[delivery.Webhook]
+	var err error
+	if ok {
+		err = s.send(&hook, delivery)
+	} else {
+		err = fmt.Errorf("Webhook %s is no longer configured", delivery.Webhook)
+	}
+	if s.ctx.Err() != nil {
+		return
+	}
+	if err == nil {
+		log.WithFields(fields).Debug("Delivered webhook")
+		if err := ld.LedgerDb.DeleteWebhookDelivery(s.ctx, delivery.ID); err != nil {
+			log.WithFields(fields).Errorf("Could not remove delivered webhook: %s", err)
+		}
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > maxErrorLength {
+		delivery.LastError = delivery.LastError[:maxErrorLength]
+	}
+	if !ok || delivery.Attempts >= hook.Attempts() {
+		delivery.Dead = true
+		log.WithFields(fields).Warnf("Webhook delivery is a dead letter after %d attempts: %s", delivery.Attempts, err)
+	} else {
+		delivery.NextAttempt = time.Now().UTC().Add(s.retryAfter(delivery.Attempts))
+		log.WithFields(fields).Infof("Webhook delivery failed, retrying at %s: %s", delivery.NextAttempt.Format(time.RFC3339), err)
+	}
+	if err := ld.LedgerDb.UpdateWebhookDelivery(s.ctx, delivery); err != nil {
+		log.WithFields(fields).Errorf("Could not save webhook delivery: %s", err)
+	}
+}
+
+// retryAfter is the backoff following the given number of failed attempts.
+func (s *Service) retryAfter(attempts int) time.Duration {
+	wait := s.backoff
+	for i := 1; i < attempts && wait < s.maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > s.maxBackoff {
+		wait = s.maxBackoff
+	}
+	return wait
+}
+
+// send posts the payload of the delivery to the webhook, any response other
+// than a 2xx status is a failure.
+func (s *Service) send(hook *core.Webhook, delivery *core.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoDBLedger/"+version.Version)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.Kind)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, "sha256="+core.SignWebhook(hook.Secret, timestamp, delivery.Payload))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("Webhook responded %s", res.Status)
+	}
+	return nil
+}
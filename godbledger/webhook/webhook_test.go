@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+func init() {
+	logrus.SetOutput(io.Discard)
+}
+
+// endpoint is a stand-in for a webhook receiver, it responds with status and
+// records the payloads whose signatures check out.
+type endpoint struct {
+	mu       sync.Mutex
+	status   int
+	payloads []core.WebhookPayload
+	attempts int
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	signature := "sha256=" + core.SignWebhook("shh", time.Unix(timestamp, 0), body)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.attempts++
+	if r.Header.Get(HeaderSignature) != signature {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if e.status != http.StatusOK {
+		w.WriteHeader(e.status)
+		return
+	}
+	var payload core.WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Kind != r.Header.Get(HeaderEvent) || payload.Delivery != r.Header.Get(HeaderDelivery) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	e.payloads = append(e.payloads, payload)
+}
+
+func (e *endpoint) received() []core.WebhookPayload {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]core.WebhookPayload{}, e.payloads...)
+}
+
+func (e *endpoint) respond(status int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status = status
+}
+
+func newTestLedger(t *testing.T, cfg *cmd.LedgerConfig) *ledger.Ledger {
+	ctx := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
+	ld, err := ledger.New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ld.Start()
+	return ld
+}
+
+// newWebhookLedger is an in memory ledger queueing deliveries for a webhook
+// at url of the kinds of event given, every kind when there are none.
+func newWebhookLedger(t *testing.T, url string, events ...string) *ledger.Ledger {
+	return newTestLedger(t, &cmd.LedgerConfig{DatabaseType: "memorydb", Webhooks: []core.Webhook{webhookAt(url, events...)}})
+}
+
+func webhookAt(url string, events ...string) core.Webhook {
+	return core.Webhook{Name: "books", URL: url, Secret: "shh", Events: events, MaxAttempts: 3}
+}
+
+func newTestService(ld *ledger.Ledger) *Service {
+	return NewService(context.Background(), &Config{
+		Backoff:      10 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	}, ld)
+}
+
+func queued(t *testing.T, ld *ledger.Ledger) []*core.WebhookDelivery {
+	due, err := ld.LedgerDb.DueWebhookDeliveries(context.Background(), time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	return due
+}
+
+func eventually(t *testing.T, condition func() bool, msg string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+	t.Fatal(msg)
+}
+
+func TestDelivery(t *testing.T) {
+	ctx := context.Background()
+	receiver := &endpoint{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	ld := newWebhookLedger(t, server.URL, ledger.AccountAdded)
+	s := newTestService(ld)
+	s.Start()
+	defer s.Stop()
+
+	assert.NoError(t, ld.InsertAccount(ctx, "Assets:Cash"))
+	assert.NoError(t, ld.InsertTag(ctx, "Assets:Cash", "Balance Sheet"))
+
+	eventually(t, func() bool { return len(receiver.received()) == 1 }, "Account was not delivered")
+	payload := receiver.received()[0]
+	assert.Equal(t, ledger.AccountAdded, payload.Kind)
+	assert.Equal(t, "Assets:Cash", payload.Account)
+	assert.Empty(t, payload.Ledger)
+
+	eventually(t, func() bool { return len(queued(t, ld)) == 0 }, "Delivered webhook is still queued")
+}
+
+func TestDeliveryQueuedWithChange(t *testing.T) {
+	ctx := context.Background()
+	receiver := &endpoint{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	cfg := &cmd.LedgerConfig{DatabaseType: "sqlite3", DataDirectory: t.TempDir(), Webhooks: []core.Webhook{webhookAt(server.URL)}}
+	ld := newTestLedger(t, cfg)
+	s := newTestService(ld)
+	s.Start()
+	assert.NoError(t, s.Stop())
+
+	// A change that fails queues nothing
+	assert.Error(t, ld.Delete(ctx, "missing"))
+	assert.Empty(t, queued(t, ld))
+
+	// The delivery is committed with the change while the service is stopped
+	// and sent once the node has restarted
+	assert.NoError(t, ld.InsertAccount(ctx, "Assets:Cash"))
+	assert.Len(t, queued(t, ld), 1)
+	assert.NoError(t, ld.Stop())
+
+	ld = newTestLedger(t, cfg)
+	defer ld.Stop()
+	s = newTestService(ld)
+	s.Start()
+	defer s.Stop()
+
+	eventually(t, func() bool { return len(receiver.received()) == 1 }, "Queued delivery was not sent after the restart")
+	assert.Equal(t, ledger.AccountAdded, receiver.received()[0].Kind)
+	assert.Equal(t, "Assets:Cash", receiver.received()[0].Account)
+}
+
+func TestDeadLetterAndRetry(t *testing.T) {
+	ctx := context.Background()
+	receiver := &endpoint{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	ld := newWebhookLedger(t, server.URL)
+	s := newTestService(ld)
+	s.Start()
+	defer s.Stop()
+
+	assert.NoError(t, ld.InsertAccount(ctx, "Assets:Cash"))
+
+	var dead []*core.WebhookDelivery
+	eventually(t, func() bool {
+		var err error
+		dead, err = ld.ListDeadWebhookDeliveries(ctx)
+		return err == nil && len(dead) == 1
+	}, "Failing delivery did not become a dead letter")
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Contains(t, dead[0].LastError, "503")
+	assert.Equal(t, 3, receiver.attempts)
+
+	receiver.respond(http.StatusOK)
+	assert.NoError(t, ld.RetryWebhookDelivery(ctx, dead[0].ID))
+	eventually(t, func() bool { return len(receiver.received()) == 1 }, "Retried dead letter was not delivered")
+	assert.Equal(t, dead[0].ID, receiver.received()[0].Delivery)
+
+	letters, err := ld.ListDeadWebhookDeliveries(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, letters)
+	assert.Error(t, ld.RetryWebhookDelivery(ctx, dead[0].ID))
+}
+
+func TestQueuedBeforeStart(t *testing.T) {
+	ctx := context.Background()
+	receiver := &endpoint{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	// A delivery left in the queue when the node stopped is sent once it
+	// starts again
+	ld := newWebhookLedger(t, server.URL)
+	assert.NoError(t, ld.LedgerDb.AddWebhookDelivery(ctx, &core.WebhookDelivery{
+		ID:          "left-over",
+		Webhook:     "books",
+		Kind:        ledger.TransactionAdded,
+		Payload:     []byte(`{"delivery":"left-over","kind":"transaction_added"}`),
+		NextAttempt: time.Now().UTC(),
+		Created:     time.Now().UTC(),
+	}))
+	s := newTestService(ld)
+	s.Start()
+	defer s.Stop()
+
+	eventually(t, func() bool { return len(receiver.received()) == 1 }, "Queued delivery was not sent")
+	assert.Equal(t, "left-over", receiver.received()[0].Delivery)
+}
+
+func TestRetryAfter(t *testing.T) {
+	s := &Service{backoff: time.Second, maxBackoff: 5 * time.Second}
+	for attempts, wait := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 20: 5 * time.Second} {
+		assert.Equal(t, wait, s.retryAfter(attempts), attempts)
+	}
+}
+
+func TestEntityDelivery(t *testing.T) {
+	ctx := context.Background()
+	receiver := &endpoint{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	ld := newTestLedger(t, &cmd.LedgerConfig{DatabaseType: "sqlite3", DataDirectory: t.TempDir(), Webhooks: []core.Webhook{webhookAt(server.URL)}})
+	defer ld.Stop()
+	entity, _ := core.NewEntity("subsidiary", "Subsidiary", "")
+	assert.NoError(t, ld.CreateEntity(ctx, entity))
+	subsidiary, err := ld.Entity(ctx, "subsidiary")
+	assert.NoError(t, err)
+
+	// Deliveries are queued in the database of the ledger that changed
+	assert.NoError(t, subsidiary.InsertAccount(ctx, "Assets:Cash"))
+	assert.Empty(t, queued(t, ld))
+	assert.Len(t, queued(t, subsidiary), 1)
+
+	s := newTestService(ld)
+	s.Start()
+	defer s.Stop()
+	eventually(t, func() bool { return len(receiver.received()) == 1 }, "Delivery of the entity was not sent")
+	assert.Equal(t, "subsidiary", receiver.received()[0].Ledger)
+}
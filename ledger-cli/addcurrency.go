@@ -58,6 +58,7 @@ var commandAddCurrency = &cli.Command{
 			if ctx.Bool("delete") {
 				req := &transaction.DeleteCurrencyRequest{
 					Currency: ctx.Args().Get(0),
+					Ledger:   ctx.String(cmd.LedgerFlag.Name),
 				}
 
 				r, err := client.DeleteCurrency(ctxtimeout, req)
@@ -77,6 +78,7 @@ var commandAddCurrency = &cli.Command{
 					req := &transaction.CurrencyRequest{
 						Currency: ctx.Args().Get(0),
 						Decimals: decimals,
+						Ledger:   ctx.String(cmd.LedgerFlag.Name),
 					}
 
 					r, err := client.AddCurrency(ctxtimeout, req)
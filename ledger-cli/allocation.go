@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+
+	"google.golang.org/grpc"
+
+	"github.com/urfave/cli/v2"
+)
+
+var commandAllocation = &cli.Command{
+	Name:      "allocation",
+	Usage:     "ledger-cli allocation <rule name> <account>=<weight>...",
+	ArgsUsage: "[]",
+	Description: `
+	Saves an allocation rule that spreads a single line across several accounts
+	in proportion to the weights given. Lines posted with the rule are expanded
+	into weighted splits by the server.
+
+	Example
+
+	ledger-cli allocation rent Expenses:Rent:Admin=60 Expenses:Rent:Sales=30 Expenses:Rent:Warehouse=10
+`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "delete",
+			Aliases: []string{"d"},
+			Usage:   "deletes the allocation rule rather than creates",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		err, cfg := cmd.MakeConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("Could not make config (%v)", err)
+		}
+
+		if ctx.NArg() > 0 {
+
+			// Set up a connection to the server.
+			address := fmt.Sprintf("%s:%s", cfg.Host, cfg.RPCPort)
+			log.WithField("address", address).Info("GRPC Dialing on port")
+			conn, err := grpc.Dial(address, grpc.WithInsecure())
+			if err != nil {
+				return fmt.Errorf("Could not connect to GRPC (%v)", err)
+			}
+			defer conn.Close()
+			client := transaction.NewTransactorClient(conn)
+
+			ctxtimeout, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			if ctx.Bool("delete") {
+				req := &transaction.DeleteAllocationRuleRequest{
+					Name:   ctx.Args().Get(0),
+					Ledger: ctx.String(cmd.LedgerFlag.Name),
+				}
+
+				r, err := client.DeleteAllocationRule(ctxtimeout, req)
+				if err != nil {
+					return fmt.Errorf("Could not call Delete Allocation Rule Method (%v)", err)
+				}
+
+				log.Infof("Delete Allocation Rule Response: %s", r.GetMessage())
+			} else {
+
+				if ctx.NArg() > 1 {
+					req := &transaction.AllocationRuleRequest{
+						Name:   ctx.Args().Get(0),
+						Ledger: ctx.String(cmd.LedgerFlag.Name),
+					}
+
+					for _, arg := range ctx.Args().Slice()[1:] {
+						index := strings.LastIndex(arg, "=")
+						if index < 1 {
+							return fmt.Errorf("Could not parse the allocation target %s, expected <account>=<weight>", arg)
+						}
+						weight, err := strconv.ParseInt(arg[index+1:], 0, 64)
+						if err != nil {
+							return fmt.Errorf("Could not parse the weight provided (%v)", err)
+						}
+						req.Targets = append(req.Targets, &transaction.AllocationTarget{
+							Account: arg[:index],
+							Weight:  weight,
+						})
+					}
+
+					r, err := client.AddAllocationRule(ctxtimeout, req)
+					if err != nil {
+						return fmt.Errorf("Could not call Add Allocation Rule Method (%v)", err)
+					}
+
+					log.Infof("Create Allocation Rule Response: %s", r.GetMessage())
+
+				} else {
+					return errors.New("This command requires at least two arguments")
+				}
+			}
+
+		} else {
+			return errors.New("This command requires an argument")
+		}
+
+		return nil
+	},
+}
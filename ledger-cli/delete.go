@@ -57,6 +57,7 @@ var commandDeleteTransaction = &cli.Command{
 
 			req := &transaction.DeleteRequest{
 				Identifier: ctx.Args().Get(0),
+				Ledger:     ctx.String(cmd.LedgerFlag.Name),
 			}
 			r, err := client.DeleteTransaction(ctxtimeout, req)
 			if err != nil {
@@ -113,6 +114,7 @@ var commandVoidTransaction = &cli.Command{
 
 			req := &transaction.DeleteRequest{
 				Identifier: ctx.Args().Get(0),
+				Ledger:     ctx.String(cmd.LedgerFlag.Name),
 			}
 			r, err := client.VoidTransaction(ctxtimeout, req)
 			if err != nil {
@@ -126,3 +128,111 @@ var commandVoidTransaction = &cli.Command{
 		return nil
 	},
 }
+
+var commandRestoreTransaction = &cli.Command{
+	Name:      "restore",
+	Usage:     "ledger-cli restore <transaction_id>",
+	ArgsUsage: "[]",
+	Description: `
+	Restores a deleted transaction from the trash
+`,
+	Flags: []cli.Flag{},
+	Action: func(ctx *cli.Context) error {
+		err, cfg := cmd.MakeConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("Could not make config (%v)", err)
+		}
+
+		if ctx.NArg() > 0 {
+			address := fmt.Sprintf("%s:%s", cfg.Host, cfg.RPCPort)
+			log.WithField("address", address).Info("GRPC Dialing on port")
+			opts := []grpc.DialOption{}
+
+			if cfg.CACert != "" && cfg.Cert != "" && cfg.Key != "" {
+				tlsCredentials, err := loadTLSCredentials(cfg)
+				if err != nil {
+					return fmt.Errorf("Could not load TLS credentials (%v)", err)
+				}
+				opts = append(opts, grpc.WithTransportCredentials(tlsCredentials))
+			} else {
+				opts = append(opts, grpc.WithInsecure())
+			}
+
+			// Set up a connection to the server.
+			conn, err := grpc.Dial(address, opts...)
+			if err != nil {
+				return fmt.Errorf("Could not connect to GRPC (%v)", err)
+			}
+			defer conn.Close()
+			client := transaction.NewTransactorClient(conn)
+
+			ctxtimeout, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			req := &transaction.DeleteRequest{
+				Identifier: ctx.Args().Get(0),
+				Ledger:     ctx.String(cmd.LedgerFlag.Name),
+			}
+			r, err := client.RestoreTransaction(ctxtimeout, req)
+			if err != nil {
+				return fmt.Errorf("Could not call Restore Transaction Method (%v)", err)
+			}
+			log.Infof("Restore Transaction Response: %s", r.GetMessage())
+		} else {
+			return errors.New("This command requires an argument")
+		}
+
+		return nil
+	},
+}
+
+var commandListTrash = &cli.Command{
+	Name:      "trash",
+	Usage:     "ledger-cli trash",
+	ArgsUsage: "[]",
+	Description: `
+	Lists the deleted transactions held in the trash
+`,
+	Flags: []cli.Flag{},
+	Action: func(ctx *cli.Context) error {
+		err, cfg := cmd.MakeConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("Could not make config (%v)", err)
+		}
+
+		address := fmt.Sprintf("%s:%s", cfg.Host, cfg.RPCPort)
+		log.WithField("address", address).Info("GRPC Dialing on port")
+		opts := []grpc.DialOption{}
+
+		if cfg.CACert != "" && cfg.Cert != "" && cfg.Key != "" {
+			tlsCredentials, err := loadTLSCredentials(cfg)
+			if err != nil {
+				return fmt.Errorf("Could not load TLS credentials (%v)", err)
+			}
+			opts = append(opts, grpc.WithTransportCredentials(tlsCredentials))
+		} else {
+			opts = append(opts, grpc.WithInsecure())
+		}
+
+		// Set up a connection to the server.
+		conn, err := grpc.Dial(address, opts...)
+		if err != nil {
+			return fmt.Errorf("Could not connect to GRPC (%v)", err)
+		}
+		defer conn.Close()
+		client := transaction.NewTransactorClient(conn)
+
+		ctxtimeout, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		r, err := client.ListTrash(ctxtimeout, &transaction.TrashRequest{Ledger: ctx.String(cmd.LedgerFlag.Name)})
+		if err != nil {
+			return fmt.Errorf("Could not call List Trash Method (%v)", err)
+		}
+		for _, txn := range r.GetTransactions() {
+			fmt.Printf("%s %s %s (deleted %s)\n", txn.GetIdentifier(), txn.GetDate(), txn.GetDescription(), txn.GetTrashed())
+		}
+
+		return nil
+	},
+}
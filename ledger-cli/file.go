@@ -16,12 +16,17 @@ package main
 
 import (
 	//"flag"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/proto/transaction"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
 	"github.com/urfave/cli/v2"
 )
@@ -45,6 +50,10 @@ var commandFile = &cli.Command{
 			Value:   "USD",
 			Usage:   "Specify the currency that the ledger file will be in, default to USD",
 		},
+		&cli.BoolFlag{
+			Name:  "atomic",
+			Usage: "Reject the whole file if any transaction in it fails",
+		},
 	},
 	Action: func(ctx *cli.Context) error {
 		err, cfg := cmd.MakeConfig(ctx)
@@ -73,7 +82,10 @@ var commandFile = &cli.Command{
 			}
 
 			PrintLedger(generalLedger, columnWidth)
-			SendLedger(cfg, generalLedger)
+			err = SendLedger(cfg, ctx.String(cmd.LedgerFlag.Name), generalLedger, ctx.Bool("atomic"))
+			if err != nil {
+				return fmt.Errorf("Could not send ledger (%v)", err)
+			}
 		} else {
 			return errors.New("This command requires an argument")
 		}
@@ -100,8 +112,63 @@ func PrintLedger(generalLedger []*Transaction, columns int) {
 	}
 }
 
-func SendLedger(cfg *cmd.LedgerConfig, generalLedger []*Transaction) {
+// SendLedger streams every transaction to a ledger on the server, which
+// commits them in a single database transaction, and logs the result of each.
+func SendLedger(cfg *cmd.LedgerConfig, ledger string, generalLedger []*Transaction, atomic bool) error {
+	address := fmt.Sprintf("%s:%s", cfg.Host, cfg.RPCPort)
+	log.WithField("address", address).Info("GRPC Dialing on port")
+	opts := []grpc.DialOption{}
+
+	if cfg.CACert != "" && cfg.Cert != "" && cfg.Key != "" {
+		tlsCredentials, err := loadTLSCredentials(cfg)
+		if err != nil {
+			return fmt.Errorf("Could not load TLS credentials (%v)", err)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(tlsCredentials))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	conn, err := grpc.Dial(address, opts...)
+	if err != nil {
+		return fmt.Errorf("Could not connect to GRPC (%v)", err)
+	}
+	defer conn.Close()
+	client := transaction.NewTransactorClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	stream, err := client.AddTransactions(ctx)
+	if err != nil {
+		return fmt.Errorf("Could not call Add Transactions Method (%v)", err)
+	}
 	for _, trans := range generalLedger {
-		Send(cfg, trans)
+		req := &transaction.BatchTransactionRequest{
+			Transaction: newTransactionRequest(ledger, trans),
+			Atomic:      atomic,
+			Ledger:      ledger,
+		}
+		if err := stream.Send(req); err != nil {
+			return fmt.Errorf("Could not send transaction (%v)", err)
+		}
+	}
+	r, err := stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("Could not add transactions (%v)", err)
+	}
+
+	failed := 0
+	for i, result := range r.GetResults() {
+		if result.GetCode() != uint32(codes.OK) {
+			failed++
+			log.Errorf("Transaction %d (%s) failed: %s", i+1, generalLedger[i].Payee, result.GetError())
+			continue
+		}
+		log.Infof("Add Transaction Response: %s", result.GetIdentifier())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d transactions failed", failed, len(generalLedger))
 	}
+	return nil
 }
@@ -37,7 +37,7 @@ var commandJSONJournal = &cli.Command{
 
 		log.Debugf("Transaction: %v\n", req)
 
-		err = Send(cfg, &req)
+		err = Send(cfg, ctx.String(cmd.LedgerFlag.Name), &req)
 		if err != nil {
 			return fmt.Errorf("Could not send transaction (%v)", err)
 		}
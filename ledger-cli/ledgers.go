@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+
+	"google.golang.org/grpc"
+
+	"github.com/urfave/cli/v2"
+)
+
+var commandLedgers = &cli.Command{
+	Name:      "ledgers",
+	Usage:     "ledger-cli ledgers [--create <identifier> [name]]",
+	ArgsUsage: "[]",
+	Description: `
+	Lists the ledgers served by the node, or creates a new one for another entity
+
+	Example
+
+	ledger-cli ledgers --create acme_pty_ltd "Acme Pty Ltd"
+`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "create",
+			Aliases: []string{"c"},
+			Usage:   "creates the ledger named in the arguments rather than listing them",
+		},
+		&cli.StringFlag{
+			Name:  "description",
+			Usage: "description of the ledger being created",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		err, cfg := cmd.MakeConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("Could not make config (%v)", err)
+		}
+
+		if ctx.Bool("create") && ctx.NArg() == 0 {
+			return errors.New("Creating a ledger requires an identifier")
+		}
+
+		address := fmt.Sprintf("%s:%s", cfg.Host, cfg.RPCPort)
+		log.WithField("address", address).Info("GRPC Dialing on port")
+		opts := []grpc.DialOption{}
+
+		if cfg.CACert != "" && cfg.Cert != "" && cfg.Key != "" {
+			tlsCredentials, err := loadTLSCredentials(cfg)
+			if err != nil {
+				return fmt.Errorf("Could not load TLS credentials (%v)", err)
+			}
+			opts = append(opts, grpc.WithTransportCredentials(tlsCredentials))
+		} else {
+			opts = append(opts, grpc.WithInsecure())
+		}
+
+		// Set up a connection to the server.
+		conn, err := grpc.Dial(address, opts...)
+		if err != nil {
+			return fmt.Errorf("Could not connect to GRPC (%v)", err)
+		}
+		defer conn.Close()
+		client := transaction.NewTransactorClient(conn)
+
+		ctxtimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if ctx.Bool("create") {
+			req := &transaction.CreateLedgerRequest{
+				Identifier:  ctx.Args().Get(0),
+				Name:        ctx.Args().Get(1),
+				Description: ctx.String("description"),
+			}
+
+			r, err := client.CreateLedger(ctxtimeout, req)
+			if err != nil {
+				return fmt.Errorf("Could not call Create Ledger Method (%v)", err)
+			}
+
+			log.Infof("Create Ledger Response: %s", r.GetMessage())
+			return nil
+		}
+
+		r, err := client.ListLedgers(ctxtimeout, &transaction.ListLedgersRequest{})
+		if err != nil {
+			return fmt.Errorf("Could not call List Ledgers Method (%v)", err)
+		}
+		for _, ledger := range r.GetLedgers() {
+			if len(ledger.GetDescription()) > 0 {
+				fmt.Printf("%s %s (%s)\n", ledger.GetIdentifier(), ledger.GetName(), ledger.GetDescription())
+				continue
+			}
+			fmt.Printf("%s %s\n", ledger.GetIdentifier(), ledger.GetName())
+		}
+
+		return nil
+	},
+}
@@ -55,10 +55,18 @@ func init() {
 		// delete.go
 		commandDeleteTransaction,
 		commandVoidTransaction,
+		commandRestoreTransaction,
+		commandListTrash,
 		// tagaccount.go
 		commandTagAccount,
 		// addcurrency.go
 		commandAddCurrency,
+		// allocation.go
+		commandAllocation,
+		// ledgers.go
+		commandLedgers,
+		// search.go
+		commandSearch,
 	}
 	app.Flags = []cli.Flag{
 		cmd.VerbosityFlag,
@@ -68,6 +76,7 @@ func init() {
 		cmd.CACertFlag,
 		cmd.CertFlag,
 		cmd.KeyFlag,
+		cmd.LedgerFlag,
 	}
 	//app.Action = transaction
 }
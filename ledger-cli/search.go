@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/core"
+
+	"google.golang.org/grpc"
+
+	"github.com/urfave/cli/v2"
+)
+
+// searchPageSize is the number of transactions requested at a time while
+// paging through the results of a search.
+const searchPageSize = 100
+
+var commandSearch = &cli.Command{
+	Name:      "search",
+	Usage:     "ledger-cli search [--start <date>] [--end <date>] [--min-amount <amount>] [--max-amount <amount>] <query>",
+	ArgsUsage: "<query>",
+	Description: `
+	Finds the transactions with a description, long description or line
+	description holding a word starting with each word of the query
+
+	Example
+
+	ledger-cli search --start 2021-06-01 --end 2021-06-30 --min-amount 100 office rent
+`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "start",
+			Usage: "only transactions with a line dated on or after this date (YYYY-MM-DD)",
+		},
+		&cli.StringFlag{
+			Name:  "end",
+			Usage: "only transactions with a line dated on or before this date (YYYY-MM-DD)",
+		},
+		&cli.StringFlag{
+			Name:  "min-amount",
+			Usage: "only transactions with a line of at least this amount, ignoring its sign",
+		},
+		&cli.StringFlag{
+			Name:  "max-amount",
+			Usage: "only transactions with a line of at most this amount, ignoring its sign",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "the most transactions to show, all of them when zero",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		err, cfg := cmd.MakeConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("Could not make config (%v)", err)
+		}
+
+		if ctx.NArg() == 0 {
+			return errors.New("Searching requires a query")
+		}
+		req := &transaction.SearchRequest{
+			Query:     strings.Join(ctx.Args().Slice(), " "),
+			Startdate: ctx.String("start"),
+			Date:      ctx.String("end"),
+			Ledger:    ctx.String(cmd.LedgerFlag.Name),
+		}
+		if req.MinAmount, err = searchAmount(ctx.String("min-amount")); err != nil {
+			return err
+		}
+		if req.MaxAmount, err = searchAmount(ctx.String("max-amount")); err != nil {
+			return err
+		}
+
+		address := fmt.Sprintf("%s:%s", cfg.Host, cfg.RPCPort)
+		log.WithField("address", address).Info("GRPC Dialing on port")
+		opts := []grpc.DialOption{}
+
+		if cfg.CACert != "" && cfg.Cert != "" && cfg.Key != "" {
+			tlsCredentials, err := loadTLSCredentials(cfg)
+			if err != nil {
+				return fmt.Errorf("Could not load TLS credentials (%v)", err)
+			}
+			opts = append(opts, grpc.WithTransportCredentials(tlsCredentials))
+		} else {
+			opts = append(opts, grpc.WithInsecure())
+		}
+
+		// Set up a connection to the server.
+		conn, err := grpc.Dial(address, opts...)
+		if err != nil {
+			return fmt.Errorf("Could not connect to GRPC (%v)", err)
+		}
+		defer conn.Close()
+		client := transaction.NewTransactorClient(conn)
+
+		ctxtimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		remaining := ctx.Int("limit")
+		for {
+			req.Limit = searchPageSize
+			if remaining > 0 && remaining < searchPageSize {
+				req.Limit = int32(remaining)
+			}
+			r, err := client.SearchTransactions(ctxtimeout, req)
+			if err != nil {
+				return fmt.Errorf("Could not call Search Transactions Method (%v)", err)
+			}
+			for _, txn := range r.GetTransactions() {
+				fmt.Printf("%s %s %s\n", txn.GetIdentifier(), txn.GetDate(), txn.GetDescription())
+				for _, line := range txn.GetLines() {
+					amount := big.NewInt(line.GetAmount())
+					if len(line.GetExactAmount()) > 0 {
+						if exact, err := core.ParseAmount(line.GetExactAmount()); err == nil {
+							amount = exact
+						}
+					}
+					fmt.Printf("    %-40s %20s %s  %s\n", line.GetAccountname(), core.FormatAmount(amount, int(line.GetDecimals())), line.GetCurrency(), line.GetDescription())
+				}
+			}
+			remaining -= len(r.GetTransactions())
+			if len(r.GetCursor()) == 0 || (ctx.Int("limit") > 0 && remaining <= 0) {
+				return nil
+			}
+			req.Cursor = r.GetCursor()
+		}
+	},
+}
+
+// searchAmount converts an amount flag into cents like the amounts of the
+// transactions ledger-cli sends, empty when the flag is not set.
+func searchAmount(value string) (string, error) {
+	if len(value) == 0 {
+		return "", nil
+	}
+	amount, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return "", fmt.Errorf("Could not parse the amount %s", value)
+	}
+	cents := new(big.Int).Mul(amount.Num(), big.NewInt(100))
+	cents.Quo(cents, amount.Denom())
+	return cents.String(), nil
+}
@@ -62,6 +62,7 @@ var commandTagAccount = &cli.Command{
 			req := &transaction.DeleteAccountTagRequest{
 				Account: ctx.Args().Get(0),
 				Tag:     []string{ctx.Args().Get(1)},
+				Ledger:  ctx.String(cmd.LedgerFlag.Name),
 			}
 
 			r, err := client.DeleteTag(ctxtimeout, req)
@@ -74,6 +75,7 @@ var commandTagAccount = &cli.Command{
 			req := &transaction.AccountTagRequest{
 				Account: ctx.Args().Get(0),
 				Tag:     []string{ctx.Args().Get(1)},
+				Ledger:  ctx.String(cmd.LedgerFlag.Name),
 			}
 
 			r, err := client.AddTag(ctxtimeout, req)
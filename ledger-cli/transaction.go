@@ -64,7 +64,7 @@ var commandSingleTestTransaction = &cli.Command{
 			AccountChanges: transactionLines,
 		}
 
-		err = Send(cfg, req)
+		err = Send(cfg, ctx.String(cmd.LedgerFlag.Name), req)
 		if err != nil {
 			return fmt.Errorf("Could not send transaction (%v)", err)
 		}
@@ -73,7 +73,7 @@ var commandSingleTestTransaction = &cli.Command{
 	},
 }
 
-func Send(cfg *cmd.LedgerConfig, t *Transaction) error {
+func Send(cfg *cmd.LedgerConfig, ledger string, t *Transaction) error {
 	address := fmt.Sprintf("%s:%s", cfg.Host, cfg.RPCPort)
 	log.WithField("address", address).Info("GRPC Dialing on port")
 	opts := []grpc.DialOption{}
@@ -100,30 +100,44 @@ func Send(cfg *cmd.LedgerConfig, t *Transaction) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	transactionLines := make([]*transaction.LineItem, 2)
+	req := newTransactionRequest(ledger, t)
+	r, err := client.AddTransaction(ctx, req)
+	if err != nil {
+		return fmt.Errorf("Could not call Add Transaction Method (%v)", err)
+	}
+	log.Infof("Add Transaction Response: %s", r.GetMessage())
+	return nil
+}
+
+// newTransactionRequest converts a parsed transaction into the request sent
+// to a ledger on the server, with amounts in cents. Amounts are sent exactly
+// as strings and also as int64 when they fit, for servers that only read
+// those.
+func newTransactionRequest(ledger string, t *Transaction) *transaction.TransactionRequest {
+	transactionLines := make([]*transaction.LineItem, len(t.AccountChanges))
 
 	for i, accChange := range t.AccountChanges {
-		amountInt64 := accChange.Balance.Num().Int64() * int64(100) / accChange.Balance.Denom().Int64()
+		cents := new(big.Int).Mul(accChange.Balance.Num(), big.NewInt(100))
+		cents.Quo(cents, accChange.Balance.Denom())
 		transactionLines[i] = &transaction.LineItem{
 			Accountname: accChange.Name,
 			Description: accChange.Description,
-			Amount:      amountInt64,
 			Currency:    accChange.Currency,
+			ExactAmount: cents.String(),
+		}
+		if cents.IsInt64() {
+			transactionLines[i].Amount = cents.Int64()
 		}
 	}
 
-	req := &transaction.TransactionRequest{
+	return &transaction.TransactionRequest{
 		Date:        t.Date.Format("2006-01-02"),
 		Description: t.Payee,
 		Lines:       transactionLines,
+		Ledger:      ledger,
 	}
-	r, err := client.AddTransaction(ctx, req)
-	if err != nil {
-		return fmt.Errorf("Could not call Add Transaction Method (%v)", err)
-	}
-	log.Infof("Add Transaction Response: %s", r.GetMessage())
-	return nil
 }
+
 func loadTLSCredentials(cfg *cmd.LedgerConfig) (credentials.TransportCredentials, error) {
 	// Load certificate of the CA who signed server's certificate
 	pemServerCA, err := os.ReadFile(cfg.CACert)
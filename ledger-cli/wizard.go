@@ -91,7 +91,7 @@ var commandWizardJournal = &cli.Command{
 		}
 		log.Debugf("Transaction: %v => %v, '%v'\n", req, bytes, string(bytes))
 
-		err = Send(cfg, req)
+		err = Send(cfg, ctx.String(cmd.LedgerFlag.Name), req)
 		if err != nil {
 			return fmt.Errorf("Could not send transaction (%v)", err)
 		}
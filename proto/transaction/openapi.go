@@ -0,0 +1,9 @@
+package transaction
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI (Swagger 2.0) description of the REST gateway to the
+// Transactor service, generated from transaction.proto alongside this package.
+//
+//go:embed transaction.swagger.json
+var OpenAPI []byte
@@ -18,6 +18,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TransactorClient interface {
 	AddTransaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	AddTransactions(ctx context.Context, opts ...grpc.CallOption) (Transactor_AddTransactionsClient, error)
 	DeleteTransaction(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
 	VoidTransaction(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
 	NodeVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
@@ -27,9 +28,30 @@ type TransactorClient interface {
 	DeleteCurrency(ctx context.Context, in *DeleteCurrencyRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
 	GetTB(ctx context.Context, in *TBRequest, opts ...grpc.CallOption) (*TBResponse, error)
 	GetListing(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*ListingResponse, error)
+	StreamListing(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (Transactor_StreamListingClient, error)
 	AddAccount(ctx context.Context, in *AccountTagRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
 	DeleteAccount(ctx context.Context, in *DeleteAccountTagRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
 	ReconcileTransactions(ctx context.Context, in *ReconciliationRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	AddAllocationRule(ctx context.Context, in *AllocationRuleRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	DeleteAllocationRule(ctx context.Context, in *DeleteAllocationRuleRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	AddPostingRule(ctx context.Context, in *PostingRuleRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	DeletePostingRule(ctx context.Context, in *DeletePostingRuleRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	ListTrash(ctx context.Context, in *TrashRequest, opts ...grpc.CallOption) (*TrashResponse, error)
+	RestoreTransaction(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	CreateLedger(ctx context.Context, in *CreateLedgerRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	ListLedgers(ctx context.Context, in *ListLedgersRequest, opts ...grpc.CallOption) (*ListLedgersResponse, error)
+	SearchTransactions(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*ListingResponse, error)
+	CheckIntegrity(ctx context.Context, in *IntegrityRequest, opts ...grpc.CallOption) (*IntegrityResponse, error)
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	ListCurrencies(ctx context.Context, in *ListCurrenciesRequest, opts ...grpc.CallOption) (*ListCurrenciesResponse, error)
+	ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error)
+	GetAccountBalance(ctx context.Context, in *AccountBalanceRequest, opts ...grpc.CallOption) (*TBResponse, error)
+	GetAccountRegister(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Transactor_SubscribeClient, error)
+	ListWebhookDeadLetters(ctx context.Context, in *WebhookDeadLettersRequest, opts ...grpc.CallOption) (*WebhookDeadLettersResponse, error)
+	RetryWebhookDelivery(ctx context.Context, in *WebhookDeliveryRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	DiscardWebhookDelivery(ctx context.Context, in *WebhookDeliveryRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
 }
 
 type transactorClient struct {
@@ -49,6 +71,40 @@ func (c *transactorClient) AddTransaction(ctx context.Context, in *TransactionRe
 	return out, nil
 }
 
+func (c *transactorClient) AddTransactions(ctx context.Context, opts ...grpc.CallOption) (Transactor_AddTransactionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Transactor_ServiceDesc.Streams[0], "/transaction.Transactor/AddTransactions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &transactorAddTransactionsClient{stream}
+	return x, nil
+}
+
+type Transactor_AddTransactionsClient interface {
+	Send(*BatchTransactionRequest) error
+	CloseAndRecv() (*BatchTransactionResponse, error)
+	grpc.ClientStream
+}
+
+type transactorAddTransactionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *transactorAddTransactionsClient) Send(m *BatchTransactionRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *transactorAddTransactionsClient) CloseAndRecv() (*BatchTransactionResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BatchTransactionResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *transactorClient) DeleteTransaction(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
 	out := new(TransactionResponse)
 	err := c.cc.Invoke(ctx, "/transaction.Transactor/DeleteTransaction", in, out, opts...)
@@ -130,6 +186,38 @@ func (c *transactorClient) GetListing(ctx context.Context, in *ReportRequest, op
 	return out, nil
 }
 
+func (c *transactorClient) StreamListing(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (Transactor_StreamListingClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Transactor_ServiceDesc.Streams[1], "/transaction.Transactor/StreamListing", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &transactorStreamListingClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Transactor_StreamListingClient interface {
+	Recv() (*Transaction, error)
+	grpc.ClientStream
+}
+
+type transactorStreamListingClient struct {
+	grpc.ClientStream
+}
+
+func (x *transactorStreamListingClient) Recv() (*Transaction, error) {
+	m := new(Transaction)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *transactorClient) AddAccount(ctx context.Context, in *AccountTagRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
 	out := new(TransactionResponse)
 	err := c.cc.Invoke(ctx, "/transaction.Transactor/AddAccount", in, out, opts...)
@@ -157,11 +245,215 @@ func (c *transactorClient) ReconcileTransactions(ctx context.Context, in *Reconc
 	return out, nil
 }
 
+func (c *transactorClient) AddAllocationRule(ctx context.Context, in *AllocationRuleRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/AddAllocationRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) DeleteAllocationRule(ctx context.Context, in *DeleteAllocationRuleRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/DeleteAllocationRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) AddPostingRule(ctx context.Context, in *PostingRuleRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/AddPostingRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) DeletePostingRule(ctx context.Context, in *DeletePostingRuleRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/DeletePostingRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) ListTrash(ctx context.Context, in *TrashRequest, opts ...grpc.CallOption) (*TrashResponse, error) {
+	out := new(TrashResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/ListTrash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) RestoreTransaction(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/RestoreTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) CreateLedger(ctx context.Context, in *CreateLedgerRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/CreateLedger", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) ListLedgers(ctx context.Context, in *ListLedgersRequest, opts ...grpc.CallOption) (*ListLedgersResponse, error) {
+	out := new(ListLedgersResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/ListLedgers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) SearchTransactions(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*ListingResponse, error) {
+	out := new(ListingResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/SearchTransactions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) CheckIntegrity(ctx context.Context, in *IntegrityRequest, opts ...grpc.CallOption) (*IntegrityResponse, error) {
+	out := new(IntegrityResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/CheckIntegrity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/GetTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error) {
+	out := new(ListAccountsResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/ListAccounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) ListCurrencies(ctx context.Context, in *ListCurrenciesRequest, opts ...grpc.CallOption) (*ListCurrenciesResponse, error) {
+	out := new(ListCurrenciesResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/ListCurrencies", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error) {
+	out := new(ListTagsResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/ListTags", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) GetAccountBalance(ctx context.Context, in *AccountBalanceRequest, opts ...grpc.CallOption) (*TBResponse, error) {
+	out := new(TBResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/GetAccountBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) GetAccountRegister(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/GetAccountRegister", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Transactor_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Transactor_ServiceDesc.Streams[2], "/transaction.Transactor/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &transactorSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Transactor_SubscribeClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type transactorSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *transactorSubscribeClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *transactorClient) ListWebhookDeadLetters(ctx context.Context, in *WebhookDeadLettersRequest, opts ...grpc.CallOption) (*WebhookDeadLettersResponse, error) {
+	out := new(WebhookDeadLettersResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/ListWebhookDeadLetters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) RetryWebhookDelivery(ctx context.Context, in *WebhookDeliveryRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/RetryWebhookDelivery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactorClient) DiscardWebhookDelivery(ctx context.Context, in *WebhookDeliveryRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, "/transaction.Transactor/DiscardWebhookDelivery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TransactorServer is the server API for Transactor service.
 // All implementations must embed UnimplementedTransactorServer
 // for forward compatibility
 type TransactorServer interface {
 	AddTransaction(context.Context, *TransactionRequest) (*TransactionResponse, error)
+	AddTransactions(Transactor_AddTransactionsServer) error
 	DeleteTransaction(context.Context, *DeleteRequest) (*TransactionResponse, error)
 	VoidTransaction(context.Context, *DeleteRequest) (*TransactionResponse, error)
 	NodeVersion(context.Context, *VersionRequest) (*VersionResponse, error)
@@ -171,9 +463,30 @@ type TransactorServer interface {
 	DeleteCurrency(context.Context, *DeleteCurrencyRequest) (*TransactionResponse, error)
 	GetTB(context.Context, *TBRequest) (*TBResponse, error)
 	GetListing(context.Context, *ReportRequest) (*ListingResponse, error)
+	StreamListing(*ReportRequest, Transactor_StreamListingServer) error
 	AddAccount(context.Context, *AccountTagRequest) (*TransactionResponse, error)
 	DeleteAccount(context.Context, *DeleteAccountTagRequest) (*TransactionResponse, error)
 	ReconcileTransactions(context.Context, *ReconciliationRequest) (*TransactionResponse, error)
+	AddAllocationRule(context.Context, *AllocationRuleRequest) (*TransactionResponse, error)
+	DeleteAllocationRule(context.Context, *DeleteAllocationRuleRequest) (*TransactionResponse, error)
+	AddPostingRule(context.Context, *PostingRuleRequest) (*TransactionResponse, error)
+	DeletePostingRule(context.Context, *DeletePostingRuleRequest) (*TransactionResponse, error)
+	ListTrash(context.Context, *TrashRequest) (*TrashResponse, error)
+	RestoreTransaction(context.Context, *DeleteRequest) (*TransactionResponse, error)
+	CreateLedger(context.Context, *CreateLedgerRequest) (*TransactionResponse, error)
+	ListLedgers(context.Context, *ListLedgersRequest) (*ListLedgersResponse, error)
+	SearchTransactions(context.Context, *SearchRequest) (*ListingResponse, error)
+	CheckIntegrity(context.Context, *IntegrityRequest) (*IntegrityResponse, error)
+	GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error)
+	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	ListCurrencies(context.Context, *ListCurrenciesRequest) (*ListCurrenciesResponse, error)
+	ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error)
+	GetAccountBalance(context.Context, *AccountBalanceRequest) (*TBResponse, error)
+	GetAccountRegister(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	Subscribe(*SubscribeRequest, Transactor_SubscribeServer) error
+	ListWebhookDeadLetters(context.Context, *WebhookDeadLettersRequest) (*WebhookDeadLettersResponse, error)
+	RetryWebhookDelivery(context.Context, *WebhookDeliveryRequest) (*TransactionResponse, error)
+	DiscardWebhookDelivery(context.Context, *WebhookDeliveryRequest) (*TransactionResponse, error)
 	mustEmbedUnimplementedTransactorServer()
 }
 
@@ -184,6 +497,9 @@ type UnimplementedTransactorServer struct {
 func (UnimplementedTransactorServer) AddTransaction(context.Context, *TransactionRequest) (*TransactionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddTransaction not implemented")
 }
+func (UnimplementedTransactorServer) AddTransactions(Transactor_AddTransactionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method AddTransactions not implemented")
+}
 func (UnimplementedTransactorServer) DeleteTransaction(context.Context, *DeleteRequest) (*TransactionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteTransaction not implemented")
 }
@@ -211,6 +527,9 @@ func (UnimplementedTransactorServer) GetTB(context.Context, *TBRequest) (*TBResp
 func (UnimplementedTransactorServer) GetListing(context.Context, *ReportRequest) (*ListingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetListing not implemented")
 }
+func (UnimplementedTransactorServer) StreamListing(*ReportRequest, Transactor_StreamListingServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamListing not implemented")
+}
 func (UnimplementedTransactorServer) AddAccount(context.Context, *AccountTagRequest) (*TransactionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddAccount not implemented")
 }
@@ -220,6 +539,66 @@ func (UnimplementedTransactorServer) DeleteAccount(context.Context, *DeleteAccou
 func (UnimplementedTransactorServer) ReconcileTransactions(context.Context, *ReconciliationRequest) (*TransactionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReconcileTransactions not implemented")
 }
+func (UnimplementedTransactorServer) AddAllocationRule(context.Context, *AllocationRuleRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddAllocationRule not implemented")
+}
+func (UnimplementedTransactorServer) DeleteAllocationRule(context.Context, *DeleteAllocationRuleRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAllocationRule not implemented")
+}
+func (UnimplementedTransactorServer) AddPostingRule(context.Context, *PostingRuleRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPostingRule not implemented")
+}
+func (UnimplementedTransactorServer) DeletePostingRule(context.Context, *DeletePostingRuleRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePostingRule not implemented")
+}
+func (UnimplementedTransactorServer) ListTrash(context.Context, *TrashRequest) (*TrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrash not implemented")
+}
+func (UnimplementedTransactorServer) RestoreTransaction(context.Context, *DeleteRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreTransaction not implemented")
+}
+func (UnimplementedTransactorServer) CreateLedger(context.Context, *CreateLedgerRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateLedger not implemented")
+}
+func (UnimplementedTransactorServer) ListLedgers(context.Context, *ListLedgersRequest) (*ListLedgersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLedgers not implemented")
+}
+func (UnimplementedTransactorServer) SearchTransactions(context.Context, *SearchRequest) (*ListingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchTransactions not implemented")
+}
+func (UnimplementedTransactorServer) CheckIntegrity(context.Context, *IntegrityRequest) (*IntegrityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckIntegrity not implemented")
+}
+func (UnimplementedTransactorServer) GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedTransactorServer) ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccounts not implemented")
+}
+func (UnimplementedTransactorServer) ListCurrencies(context.Context, *ListCurrenciesRequest) (*ListCurrenciesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCurrencies not implemented")
+}
+func (UnimplementedTransactorServer) ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTags not implemented")
+}
+func (UnimplementedTransactorServer) GetAccountBalance(context.Context, *AccountBalanceRequest) (*TBResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountBalance not implemented")
+}
+func (UnimplementedTransactorServer) GetAccountRegister(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountRegister not implemented")
+}
+func (UnimplementedTransactorServer) Subscribe(*SubscribeRequest, Transactor_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedTransactorServer) ListWebhookDeadLetters(context.Context, *WebhookDeadLettersRequest) (*WebhookDeadLettersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeadLetters not implemented")
+}
+func (UnimplementedTransactorServer) RetryWebhookDelivery(context.Context, *WebhookDeliveryRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryWebhookDelivery not implemented")
+}
+func (UnimplementedTransactorServer) DiscardWebhookDelivery(context.Context, *WebhookDeliveryRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiscardWebhookDelivery not implemented")
+}
 func (UnimplementedTransactorServer) mustEmbedUnimplementedTransactorServer() {}
 
 // UnsafeTransactorServer may be embedded to opt out of forward compatibility for this service.
@@ -251,6 +630,32 @@ func _Transactor_AddTransaction_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Transactor_AddTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TransactorServer).AddTransactions(&transactorAddTransactionsServer{stream})
+}
+
+type Transactor_AddTransactionsServer interface {
+	SendAndClose(*BatchTransactionResponse) error
+	Recv() (*BatchTransactionRequest, error)
+	grpc.ServerStream
+}
+
+type transactorAddTransactionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *transactorAddTransactionsServer) SendAndClose(m *BatchTransactionResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *transactorAddTransactionsServer) Recv() (*BatchTransactionRequest, error) {
+	m := new(BatchTransactionRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Transactor_DeleteTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteRequest)
 	if err := dec(in); err != nil {
@@ -413,6 +818,27 @@ func _Transactor_GetListing_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Transactor_StreamListing_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TransactorServer).StreamListing(m, &transactorStreamListingServer{stream})
+}
+
+type Transactor_StreamListingServer interface {
+	Send(*Transaction) error
+	grpc.ServerStream
+}
+
+type transactorStreamListingServer struct {
+	grpc.ServerStream
+}
+
+func (x *transactorStreamListingServer) Send(m *Transaction) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Transactor_AddAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AccountTagRequest)
 	if err := dec(in); err != nil {
@@ -467,44 +893,407 @@ func _Transactor_ReconcileTransactions_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
-// Transactor_ServiceDesc is the grpc.ServiceDesc for Transactor service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var Transactor_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "transaction.Transactor",
-	HandlerType: (*TransactorServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "AddTransaction",
-			Handler:    _Transactor_AddTransaction_Handler,
-		},
-		{
-			MethodName: "DeleteTransaction",
-			Handler:    _Transactor_DeleteTransaction_Handler,
-		},
-		{
-			MethodName: "VoidTransaction",
-			Handler:    _Transactor_VoidTransaction_Handler,
-		},
-		{
-			MethodName: "NodeVersion",
-			Handler:    _Transactor_NodeVersion_Handler,
-		},
-		{
-			MethodName: "AddTag",
-			Handler:    _Transactor_AddTag_Handler,
-		},
-		{
-			MethodName: "DeleteTag",
-			Handler:    _Transactor_DeleteTag_Handler,
-		},
-		{
-			MethodName: "AddCurrency",
-			Handler:    _Transactor_AddCurrency_Handler,
-		},
-		{
-			MethodName: "DeleteCurrency",
-			Handler:    _Transactor_DeleteCurrency_Handler,
+func _Transactor_AddAllocationRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocationRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).AddAllocationRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/AddAllocationRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).AddAllocationRule(ctx, req.(*AllocationRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_DeleteAllocationRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAllocationRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).DeleteAllocationRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/DeleteAllocationRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).DeleteAllocationRule(ctx, req.(*DeleteAllocationRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_AddPostingRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostingRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).AddPostingRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/AddPostingRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).AddPostingRule(ctx, req.(*PostingRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_DeletePostingRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePostingRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).DeletePostingRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/DeletePostingRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).DeletePostingRule(ctx, req.(*DeletePostingRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_ListTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).ListTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/ListTrash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).ListTrash(ctx, req.(*TrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_RestoreTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).RestoreTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/RestoreTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).RestoreTransaction(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_CreateLedger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateLedgerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).CreateLedger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/CreateLedger",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).CreateLedger(ctx, req.(*CreateLedgerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_ListLedgers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLedgersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).ListLedgers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/ListLedgers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).ListLedgers(ctx, req.(*ListLedgersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_SearchTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).SearchTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/SearchTransactions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).SearchTransactions(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_CheckIntegrity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IntegrityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).CheckIntegrity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/CheckIntegrity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).CheckIntegrity(ctx, req.(*IntegrityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/GetTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_ListAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).ListAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/ListAccounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).ListAccounts(ctx, req.(*ListAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_ListCurrencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCurrenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).ListCurrencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/ListCurrencies",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).ListCurrencies(ctx, req.(*ListCurrenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_ListTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).ListTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/ListTags",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).ListTags(ctx, req.(*ListTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_GetAccountBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).GetAccountBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/GetAccountBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).GetAccountBalance(ctx, req.(*AccountBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_GetAccountRegister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).GetAccountRegister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/GetAccountRegister",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).GetAccountRegister(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TransactorServer).Subscribe(m, &transactorSubscribeServer{stream})
+}
+
+type Transactor_SubscribeServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type transactorSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *transactorSubscribeServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Transactor_ListWebhookDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookDeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).ListWebhookDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/ListWebhookDeadLetters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).ListWebhookDeadLetters(ctx, req.(*WebhookDeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_RetryWebhookDelivery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookDeliveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).RetryWebhookDelivery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/RetryWebhookDelivery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).RetryWebhookDelivery(ctx, req.(*WebhookDeliveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transactor_DiscardWebhookDelivery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookDeliveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactorServer).DiscardWebhookDelivery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/transaction.Transactor/DiscardWebhookDelivery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactorServer).DiscardWebhookDelivery(ctx, req.(*WebhookDeliveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Transactor_ServiceDesc is the grpc.ServiceDesc for Transactor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Transactor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "transaction.Transactor",
+	HandlerType: (*TransactorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddTransaction",
+			Handler:    _Transactor_AddTransaction_Handler,
+		},
+		{
+			MethodName: "DeleteTransaction",
+			Handler:    _Transactor_DeleteTransaction_Handler,
+		},
+		{
+			MethodName: "VoidTransaction",
+			Handler:    _Transactor_VoidTransaction_Handler,
+		},
+		{
+			MethodName: "NodeVersion",
+			Handler:    _Transactor_NodeVersion_Handler,
+		},
+		{
+			MethodName: "AddTag",
+			Handler:    _Transactor_AddTag_Handler,
+		},
+		{
+			MethodName: "DeleteTag",
+			Handler:    _Transactor_DeleteTag_Handler,
+		},
+		{
+			MethodName: "AddCurrency",
+			Handler:    _Transactor_AddCurrency_Handler,
+		},
+		{
+			MethodName: "DeleteCurrency",
+			Handler:    _Transactor_DeleteCurrency_Handler,
 		},
 		{
 			MethodName: "GetTB",
@@ -526,7 +1315,99 @@ var Transactor_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ReconcileTransactions",
 			Handler:    _Transactor_ReconcileTransactions_Handler,
 		},
+		{
+			MethodName: "AddAllocationRule",
+			Handler:    _Transactor_AddAllocationRule_Handler,
+		},
+		{
+			MethodName: "DeleteAllocationRule",
+			Handler:    _Transactor_DeleteAllocationRule_Handler,
+		},
+		{
+			MethodName: "AddPostingRule",
+			Handler:    _Transactor_AddPostingRule_Handler,
+		},
+		{
+			MethodName: "DeletePostingRule",
+			Handler:    _Transactor_DeletePostingRule_Handler,
+		},
+		{
+			MethodName: "ListTrash",
+			Handler:    _Transactor_ListTrash_Handler,
+		},
+		{
+			MethodName: "RestoreTransaction",
+			Handler:    _Transactor_RestoreTransaction_Handler,
+		},
+		{
+			MethodName: "CreateLedger",
+			Handler:    _Transactor_CreateLedger_Handler,
+		},
+		{
+			MethodName: "ListLedgers",
+			Handler:    _Transactor_ListLedgers_Handler,
+		},
+		{
+			MethodName: "SearchTransactions",
+			Handler:    _Transactor_SearchTransactions_Handler,
+		},
+		{
+			MethodName: "CheckIntegrity",
+			Handler:    _Transactor_CheckIntegrity_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _Transactor_GetTransaction_Handler,
+		},
+		{
+			MethodName: "ListAccounts",
+			Handler:    _Transactor_ListAccounts_Handler,
+		},
+		{
+			MethodName: "ListCurrencies",
+			Handler:    _Transactor_ListCurrencies_Handler,
+		},
+		{
+			MethodName: "ListTags",
+			Handler:    _Transactor_ListTags_Handler,
+		},
+		{
+			MethodName: "GetAccountBalance",
+			Handler:    _Transactor_GetAccountBalance_Handler,
+		},
+		{
+			MethodName: "GetAccountRegister",
+			Handler:    _Transactor_GetAccountRegister_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeadLetters",
+			Handler:    _Transactor_ListWebhookDeadLetters_Handler,
+		},
+		{
+			MethodName: "RetryWebhookDelivery",
+			Handler:    _Transactor_RetryWebhookDelivery_Handler,
+		},
+		{
+			MethodName: "DiscardWebhookDelivery",
+			Handler:    _Transactor_DiscardWebhookDelivery_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AddTransactions",
+			Handler:       _Transactor_AddTransactions_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamListing",
+			Handler:       _Transactor_StreamListing_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Transactor_Subscribe_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/transaction/transaction.proto",
 }
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"encoding/csv"
+	"encoding/json"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+)
+
+type RegisterLine struct {
+	ID          string `json:"id"`
+	Date        string `json:"date"`
+	Description string `json:"desc"`
+	Amount      string `json:"amount"`
+	Balance     string `json:"balance"`
+}
+
+type Register struct {
+	Account  string         `json:"account"`
+	Currency string         `json:"currency"`
+	Opening  string         `json:"opening"`
+	Closing  string         `json:"closing"`
+	Lines    []RegisterLine `json:"lines"`
+}
+
+var commandAccountRegister = &cli.Command{
+	Name:      "account",
+	Usage:     "reporter account [--start <date>] [--end <date>] [(--json | --csv) <output-filename> ] <account>",
+	ArgsUsage: "<account>",
+	Description: `
+Lists every split posted to an account with the running balance of the
+account, between its opening balance before the start date and its closing
+balance at the end date. Each currency of the account has its own register.
+
+The register is read from the GoDBLedger server, so it must be running.
+
+Example
+
+reporter account --start 2021-03-01 --end 2021-03-31 Assets:Cash
+`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "start",
+			Usage: "only splits dated on or after this date (YYYY-MM-DD)",
+		},
+		&cli.StringFlag{
+			Name:  "end",
+			Usage: "only splits dated on or before this date (YYYY-MM-DD)",
+		},
+		csvFlag,
+		jsonFlag,
+		formattingFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		err, cfg := cmd.MakeConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("Could not make config (%v)", err)
+		}
+		if ctx.NArg() != 1 {
+			return errors.New("The register needs the name of one account")
+		}
+
+		conn, err := dialServer(cfg)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		client := transaction.NewTransactorClient(conn)
+
+		req := &transaction.RegisterRequest{
+			Account:   ctx.Args().First(),
+			Startdate: ctx.String("start"),
+			Date:      ctx.String("end"),
+			Ledger:    ctx.String(cmd.LedgerFlag.Name),
+		}
+		log.Debug("Querying Register")
+		res, err := client.GetAccountRegister(ctx.Context, req)
+		if err != nil {
+			return fmt.Errorf("Could not call Get Account Register Method (%v)", err)
+		}
+
+		unformatted := ctx.Bool("unformatted")
+		registers := []Register{}
+		for _, r := range res.GetRegisters() {
+			decimals := int(r.GetDecimals())
+			register := Register{
+				Account:  r.GetAccount(),
+				Currency: r.GetCurrency(),
+				Lines:    []RegisterLine{},
+			}
+			amounts := []struct {
+				amount int64
+				exact  string
+				field  *string
+			}{{r.GetOpening(), r.GetExactOpening(), &register.Opening}, {r.GetClosing(), r.GetExactClosing(), &register.Closing}}
+			for _, a := range amounts {
+				amount, err := responseAmount(a.amount, a.exact)
+				if err != nil {
+					return fmt.Errorf("Could not read the balance of %s (%v)", r.GetAccount(), err)
+				}
+				*a.field = formatAmount(amount, decimals, unformatted)
+			}
+			for _, l := range r.GetLines() {
+				line := RegisterLine{ID: l.GetIdentifier(), Date: l.GetDate(), Description: l.GetDescription()}
+				amount, err := responseAmount(l.GetAmount(), l.GetExactAmount())
+				if err != nil {
+					return fmt.Errorf("Could not read the amount of transaction %s (%v)", l.GetIdentifier(), err)
+				}
+				balance, err := responseAmount(l.GetBalance(), l.GetExactBalance())
+				if err != nil {
+					return fmt.Errorf("Could not read the balance after transaction %s (%v)", l.GetIdentifier(), err)
+				}
+				line.Amount = formatAmount(amount, decimals, unformatted)
+				line.Balance = formatAmount(balance, decimals, unformatted)
+				register.Lines = append(register.Lines, line)
+			}
+			registers = append(registers, register)
+		}
+
+		//Output some information.
+		if len(ctx.String(csvFlag.Name)) > 0 {
+			log.Infof("Exporting CSV to %s", ctx.String(csvFlag.Name))
+			file, err := os.OpenFile(ctx.String(csvFlag.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+			if err != nil {
+				return fmt.Errorf("opening csv file errored with (%v)", err)
+			}
+			defer file.Close()
+
+			csvWriter := csv.NewWriter(file)
+			defer csvWriter.Flush()
+			csvWriter.Write([]string{"Account", "Currency", "Date", "ID", "Description", "Amount", "Balance"})
+
+			for _, register := range registers {
+				rows := [][]string{{register.Account, register.Currency, "", "", "Opening Balance", "", register.Opening}}
+				for _, line := range register.Lines {
+					rows = append(rows, []string{register.Account, register.Currency, line.Date, line.ID, line.Description, line.Amount, line.Balance})
+				}
+				rows = append(rows, []string{register.Account, register.Currency, "", "", "Closing Balance", "", register.Closing})
+				if err := csvWriter.WriteAll(rows); err != nil {
+					return fmt.Errorf("could not write to csv file (%v)", err)
+				}
+			}
+
+		} else if len(ctx.String(jsonFlag.Name)) > 0 {
+			log.Infof("Exporting JSON to %s", ctx.String(jsonFlag.Name))
+			file, err := os.OpenFile(ctx.String(jsonFlag.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+			if err != nil {
+				return fmt.Errorf("could not open json file (%v)", err)
+			}
+			defer file.Close()
+
+			bytes, err := json.Marshal(registers)
+			if err != nil {
+				return fmt.Errorf("could not serialise json (%v)", err)
+			}
+			_, err = file.Write(bytes)
+			if err != nil {
+				return fmt.Errorf("could not write to json file (%v)", err)
+			}
+		} else {
+			if len(registers) == 0 {
+				fmt.Printf("\nNo splits posted to %s\n\n", req.Account)
+			}
+			for _, register := range registers {
+				fmt.Printf("\n%s (%s)\n\n", register.Account, register.Currency)
+				table := tablewriter.NewWriter(os.Stdout)
+				table.SetHeader([]string{"Date", "ID", "Description", "Amount", "Balance"})
+				table.SetBorder(false)
+				table.SetAlignment(tablewriter.ALIGN_RIGHT)
+				table.Append([]string{"", "", "Opening Balance", "", register.Opening})
+				for _, line := range register.Lines {
+					table.Append([]string{line.Date, line.ID, line.Description, line.Amount, line.Balance})
+				}
+				table.SetFooter([]string{"", "", "Closing Balance", "", register.Closing})
+				table.Render()
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// dialServer connects to the GoDBLedger server named in the config, using TLS
+// when certificates have been provided.
+func dialServer(cfg *cmd.LedgerConfig) (*grpc.ClientConn, error) {
+	address := fmt.Sprintf("%s:%s", cfg.Host, cfg.RPCPort)
+	log.WithField("address", address).Debug("GRPC Dialing on port")
+	opts := []grpc.DialOption{}
+
+	if cfg.CACert != "" && cfg.Cert != "" && cfg.Key != "" {
+		tlsCredentials, err := loadTLSCredentials(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load TLS credentials (%v)", err)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(tlsCredentials))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	conn, err := grpc.Dial(address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("Could not connect to GRPC (%v)", err)
+	}
+	return conn, nil
+}
+
+func loadTLSCredentials(cfg *cmd.LedgerConfig) (credentials.TransportCredentials, error) {
+	// Load certificate of the CA who signed server's certificate
+	pemServerCA, err := os.ReadFile(cfg.CACert)
+	if err != nil {
+		return nil, err
+	}
+
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(pemServerCA) {
+		return nil, fmt.Errorf("failed to add server CA's certificate")
+	}
+
+	// Load client's certificate and private key
+	clientCert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      certPool,
+	}
+
+	return credentials.NewTLS(config), nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/godbledger/ledger"
+)
+
+type ConsolidatedAccount struct {
+	Account      string            `json:"account"`
+	Entities     map[string]string `json:"entities"`
+	Eliminations string            `json:"eliminations"`
+	Consolidated string            `json:"consolidated"`
+}
+
+type ConsolidationOutput struct {
+	Currency  string                `json:"currency"`
+	Entities  []string              `json:"entities"`
+	Accounts  []ConsolidatedAccount `json:"accounts"`
+	Statement map[string]string     `json:"statement"`
+}
+
+var commandConsolidation = &cli.Command{
+	Name:  "consolidate",
+	Usage: "reporter consolidate [--entity <ledger> ...] [--currency <currency>] [--rate <currency>=<rate> ...] [(--json | --csv) <output-filename> ]",
+	Description: `
+Consolidates the trial balances of a group of entities served by the node
+
+Each ledger named with --entity, or every ledger of the node when none are,
+is converted into the group reporting currency and combined into a single
+trial balance. The balances of accounts tagged with the intercompany tag are
+eliminated. Each currency of the group needs a --rate giving the value of one
+unit of it in the reporting currency, such as --rate NZD=0.61.
+
+Intercompany balances that do not cancel out are left in an "Intercompany
+Difference" account and the rounding of converted amounts in a "Translation
+Difference" account. The consolidated balances are then totalled by the top
+level of the account names, such as Assets and Revenue, as a summary
+statement.
+`,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "entity",
+			Aliases: []string{"e"},
+			Usage:   "ledger to include in the consolidation, may be repeated",
+		},
+		&cli.StringFlag{
+			Name:  "currency",
+			Value: "USD",
+			Usage: "the group reporting currency",
+		},
+		&cli.StringSliceFlag{
+			Name:  "rate",
+			Usage: "exchange rate into the reporting currency as <currency>=<rate>, may be repeated",
+		},
+		&cli.StringFlag{
+			Name:  "intercompany-tag",
+			Value: "intercompany",
+			Usage: "tag of the intercompany accounts to eliminate",
+		},
+		csvFlag,
+		jsonFlag,
+		formattingFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		err, cfg := cmd.MakeConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("Could not make config (%v)", err)
+		}
+
+		rates, err := parseRates(ctx.StringSlice("rate"))
+		if err != nil {
+			return err
+		}
+
+		ledger, err := ledger.New(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("Could not make new ledger (%v)", err)
+		}
+		defer ledger.Stop()
+
+		currency, err := ledger.GetCurrency(ctx.Context, ctx.String("currency"))
+		if err != nil {
+			return fmt.Errorf("Could not find the reporting currency %s (%v)", ctx.String("currency"), err)
+		}
+
+		entities := ctx.StringSlice("entity")
+		if len(entities) == 0 {
+			registered, err := ledger.ListEntities(ctx.Context)
+			if err != nil {
+				return fmt.Errorf("Could not list ledgers (%v)", err)
+			}
+			for _, entity := range registered {
+				entities = append(entities, entity.Id)
+			}
+		}
+		if len(entities) == 0 {
+			return fmt.Errorf("There are no ledgers to consolidate")
+		}
+
+		queryDate := time.Now()
+		log.Debug("Querying Database")
+		group := []core.EntityTB{}
+		for _, id := range entities {
+			entity, err := ledger.Entity(ctx.Context, id)
+			if err != nil {
+				return fmt.Errorf("Could not open ledger %s (%v)", id, err)
+			}
+			accounts, err := entity.GetTB(ctx.Context, queryDate)
+			if err != nil {
+				return fmt.Errorf("Could not query ledger %s (%v)", id, err)
+			}
+			group = append(group, core.EntityTB{Entity: id, Accounts: *accounts})
+		}
+
+		consolidation, err := core.Consolidate(group, *currency, rates, ctx.String("intercompany-tag"))
+		if err != nil {
+			return fmt.Errorf("Could not consolidate (%v)", err)
+		}
+
+		unformatted := ctx.Bool(formattingFlag.Name)
+		amount := func(value *big.Int) string {
+			return formatAmount(value, currency.Decimals, unformatted)
+		}
+		output := ConsolidationOutput{
+			Currency:  currency.Name,
+			Entities:  consolidation.Entities,
+			Statement: make(map[string]string),
+		}
+		for _, acc := range consolidation.Accounts {
+			line := ConsolidatedAccount{
+				Account:      acc.Account,
+				Entities:     make(map[string]string),
+				Eliminations: amount(acc.Eliminated),
+				Consolidated: amount(acc.Amount),
+			}
+			for _, entity := range consolidation.Entities {
+				line.Entities[entity] = amount(acc.Entities[entity])
+			}
+			output.Accounts = append(output.Accounts, line)
+		}
+		for class, total := range consolidation.Statement() {
+			output.Statement[class] = amount(total)
+		}
+
+		header := append([]string{"Account"}, consolidation.Entities...)
+		header = append(header, "Eliminations", "Consolidated")
+		row := func(line ConsolidatedAccount) []string {
+			values := []string{line.Account}
+			for _, entity := range consolidation.Entities {
+				values = append(values, line.Entities[entity])
+			}
+			return append(values, line.Eliminations, line.Consolidated)
+		}
+
+		if len(ctx.String(csvFlag.Name)) > 0 {
+			log.Infof("Exporting CSV to %s", ctx.String(csvFlag.Name))
+			file, err := os.OpenFile(ctx.String(csvFlag.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+			if err != nil {
+				return fmt.Errorf("opening csv file errored with (%v)", err)
+			}
+			defer file.Close()
+
+			csvWriter := csv.NewWriter(file)
+			defer csvWriter.Flush()
+			csvWriter.Write(header)
+
+			for _, line := range output.Accounts {
+				if err := csvWriter.Write(row(line)); err != nil {
+					return fmt.Errorf("could not write to csv file (%v)", err)
+				}
+			}
+		} else if len(ctx.String(jsonFlag.Name)) > 0 {
+			log.Infof("Exporting JSON to %s", ctx.String(jsonFlag.Name))
+			file, err := os.OpenFile(ctx.String(jsonFlag.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+			if err != nil {
+				return fmt.Errorf("could not open json file (%v)", err)
+			}
+			defer file.Close()
+
+			bytes, err := json.Marshal(output)
+			if err != nil {
+				return fmt.Errorf("could not serialise json (%v)", err)
+			}
+			if _, err := file.Write(bytes); err != nil {
+				return fmt.Errorf("could not write to json file (%v)", err)
+			}
+		} else {
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader(header)
+			table.SetBorder(false)
+			table.SetAlignment(tablewriter.ALIGN_RIGHT)
+			for _, line := range output.Accounts {
+				table.Append(row(line))
+			}
+
+			statement := tablewriter.NewWriter(os.Stdout)
+			statement.SetHeader([]string{"Statement", fmt.Sprintf("Balance at %s", queryDate.Format("02 January 2006"))})
+			statement.SetBorder(false)
+			statement.SetAlignment(tablewriter.ALIGN_RIGHT)
+			classes := []string{}
+			for class := range output.Statement {
+				classes = append(classes, class)
+			}
+			sort.Strings(classes)
+			for _, class := range classes {
+				statement.Append([]string{class, output.Statement[class]})
+			}
+
+			fmt.Println()
+			table.Render()
+			fmt.Println()
+			statement.Render()
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+// parseRates reads the exchange rates given as <currency>=<rate>, the rate
+// being a decimal or fraction such as 0.61 or 61/100.
+func parseRates(values []string) (map[string]*big.Rat, error) {
+	rates := make(map[string]*big.Rat)
+	for _, value := range values {
+		index := strings.LastIndex(value, "=")
+		if index < 1 {
+			return nil, fmt.Errorf("Could not parse the exchange rate %s, expected <currency>=<rate>", value)
+		}
+		rate, ok := new(big.Rat).SetString(value[index+1:])
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("Could not parse the exchange rate %s, the rate must be a positive number", value)
+		}
+		rates[strings.TrimSpace(value[:index])] = rate
+	}
+	return rates, nil
+}
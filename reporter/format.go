@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/darcys22/godbledger/godbledger/core"
+	"github.com/darcys22/godbledger/proto/transaction"
+)
+
+// formatAmount writes an amount in the smallest unit of its currency with
+// every decimal of the currency. Unless unformatted is set the whole part is
+// grouped in thousands behind a dollar sign, so 123456789 in a currency with
+// two decimals is "$1,234,567.89".
+func formatAmount(amount *big.Int, decimals int, unformatted bool) string {
+	formatted := core.FormatAmount(amount, decimals)
+	if unformatted {
+		return formatted
+	}
+
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+	whole, fraction := formatted, ""
+	if i := strings.Index(formatted, "."); i >= 0 {
+		whole, fraction = formatted[:i], formatted[i:]
+	}
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return "$" + sign + whole + fraction
+}
+
+// lineAmount reads the amount of a listing line, which is only exact in the
+// int64 field when it fits.
+func lineAmount(line *transaction.LineItem) (*big.Int, error) {
+	return responseAmount(line.GetAmount(), line.GetExactAmount())
+}
+
+// responseAmount reads an amount sent in both an int64 and an exact field,
+// taking the exact one when it is set.
+func responseAmount(amount int64, exact string) (*big.Int, error) {
+	if len(exact) == 0 {
+		return big.NewInt(amount), nil
+	}
+	return core.ParseAmount(exact)
+}
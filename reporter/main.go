@@ -29,14 +29,20 @@ func init() {
 		commandTrialBalance,
 		// pdfgenerator.go
 		commandPDFGenerate,
+		// consolidation.go
+		commandConsolidation,
+		// accountregister.go
+		commandAccountRegister,
 	}
 	app.Flags = []cli.Flag{
 		cmd.VerbosityFlag,
 		cmd.ConfigFileFlag,
 		cmd.RPCHost,
 		cmd.RPCPort,
+		cmd.CACertFlag,
 		cmd.CertFlag,
 		cmd.KeyFlag,
+		cmd.LedgerFlag,
 	}
 	app.Action = reporterConsole
 }
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -20,13 +22,13 @@ var log = logrus.WithField("prefix", "Reporter")
 
 type Tag struct {
 	Name     string       `json:"Name"`
-	Total    int          `json:"Total"`
+	Total    *big.Int     `json:"Total"`
 	Accounts []PDFAccount `json:"Accounts"`
 }
 
 type PDFAccount struct {
-	Account string `json:"Account"`
-	Amount  int    `json:"Amount"`
+	Account string   `json:"Account"`
+	Amount  *big.Int `json:"Amount"`
 }
 
 var reporteroutput struct {
@@ -63,55 +65,30 @@ requires Nodejs on the machine and also handlebars (npm install -g handlebars) a
 		if err != nil {
 			return fmt.Errorf("Could not make new ledger (%v)", err)
 		}
-
-		queryDB := `
-			SELECT
-					tags.tag_name,
-					Table_Aggregate.account_id,
-					sums
-			FROM account_tag
-					join ((SELECT
-							split_accounts.account_id as account_id,
-							SUM(splits.amount) as sums
-						FROM splits 
-							JOIN split_accounts 
-							ON splits.split_id = split_accounts.split_id
-						GROUP  BY split_accounts.account_id
-							
-						)) AS Table_Aggregate
-						on account_tag.account_id = Table_Aggregate.account_id
-					join tags
-						on tags.tag_id = account_tag.tag_id
-			order BY tags.tag_name
-		;`
+		ledger, err = ledger.Entity(ctx.Context, ctx.String(cmd.LedgerFlag.Name))
+		if err != nil {
+			return fmt.Errorf("Could not open ledger (%v)", err)
+		}
 
 		log.Debugf("Quering the Database")
-		rows, err := ledger.LedgerDb.Query(queryDB)
+		balances, err := ledger.GetTB(ctx.Context, time.Now())
 		if err != nil {
 			return fmt.Errorf("Could not query database (%v)", err)
 		}
-		defer rows.Close()
 		accounts := make(map[string][]PDFAccount)
-		totals := make(map[string]int)
+		totals := make(map[string]*big.Int)
 
-		for rows.Next() {
-			var t PDFAccount
-			var name string
-			if err := rows.Scan(&name, &t.Account, &t.Amount); err != nil {
-				return fmt.Errorf("Could not scan rows of query (%v)", err)
-			}
+		for _, balance := range *balances {
+			t := PDFAccount{Account: balance.Account, Amount: balance.Amount}
 			log.Debugf("%v", t)
-			if val, ok := accounts[name]; ok {
-				accounts[name] = append(val, t)
-				totals[name] = totals[name] + t.Amount
-			} else {
-				accounts[name] = []PDFAccount{t}
-				totals[name] = t.Amount
+			for _, name := range balance.Tags {
+				accounts[name] = append(accounts[name], t)
+				if _, ok := totals[name]; !ok {
+					totals[name] = new(big.Int)
+				}
+				totals[name].Add(totals[name], t.Amount)
 			}
 		}
-		if rows.Err() != nil {
-			return fmt.Errorf("rows errored with (%v)", rows.Err())
-		}
 
 		for k, v := range accounts {
 			reporteroutput.Data = append(reporteroutput.Data, Tag{k, totals[k], v})
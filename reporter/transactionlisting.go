@@ -1,20 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
-	"math"
+	"io"
 	"os"
-	"strconv"
 	"time"
 
-	"golang.org/x/text/language"
-	"golang.org/x/text/message"
-
 	"encoding/csv"
 	"encoding/json"
 
 	"github.com/darcys22/godbledger/godbledger/cmd"
-	"github.com/darcys22/godbledger/godbledger/ledger"
+	"github.com/darcys22/godbledger/proto/transaction"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
@@ -29,10 +26,6 @@ type Transaction struct {
 	Currency    string `json:"currency"`
 }
 
-var output struct {
-	Data []Transaction `json:"data"`
-}
-
 var commandTransactionListing = &cli.Command{
 	Name:  "transactions",
 	Usage: "reporter transactions [(--json | --csv) <output-filename> ]",
@@ -40,6 +33,9 @@ var commandTransactionListing = &cli.Command{
 Lists all Transactions in the Database
 
 If you want to see all the transactions in the database, or export to CSV/JSON
+
+The transactions are streamed from the GoDBLedger server and exports are written
+as they arrive, so the server must be running.
 `,
 	Flags: []cli.Flag{
 		csvFlag,
@@ -47,136 +43,192 @@ If you want to see all the transactions in the database, or export to CSV/JSON
 		formattingFlag,
 	},
 	Action: func(ctx *cli.Context) error {
-		//Check if keyfile path given and make sure it doesn't already exist.
-
 		err, cfg := cmd.MakeConfig(ctx)
 		if err != nil {
 			return fmt.Errorf("Could not make config (%v)", err)
 		}
-		ledger, err := ledger.New(ctx, cfg)
+
+		conn, err := dialServer(cfg)
 		if err != nil {
-			return fmt.Errorf("Could not make new ledger (%v)", err)
+			return err
 		}
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Date", "ID", "Account", "Description", "Currency", "Amount"})
-		table.SetBorder(false)
-		table.SetAlignment(tablewriter.ALIGN_RIGHT)
+		defer conn.Close()
+		client := transaction.NewTransactorClient(conn)
 
 		queryDateStart := time.Now().Add(time.Hour * 24 * 365 * -100)
 		queryDateEnd := time.Now().Add(time.Hour * 24 * 365 * 100)
+		req := &transaction.ReportRequest{
+			Startdate: queryDateStart.Format("2006-01-02"),
+			Date:      queryDateEnd.Format("2006-01-02"),
+			Ledger:    ctx.String(cmd.LedgerFlag.Name),
+		}
 
-		queryDB := `
-			SELECT
-				transactions.transaction_id,
-				splits.split_date,
-				splits.description,
-				splits.currency,
-				currency.decimals,
-				splits.amount,
-				split_accounts.account_id
-			FROM
-				splits
-				JOIN split_accounts ON splits.split_id = split_accounts.split_id
-				JOIN transactions on splits.transaction_id = transactions.transaction_id
-				JOIN currencies AS currency ON splits.currency = currency.NAME
-			WHERE
-				splits.split_date >= ?
-				AND splits.split_date <= ?
-				AND "void" NOT IN(
-					SELECT
-						t.tag_name
-					FROM
-						tags AS t
-						JOIN transaction_tag AS tt ON tt.tag_id = t.tag_id
-					WHERE
-						tt.transaction_id = splits.transaction_id
-				)
-				AND "main" IN (
-				  SELECT
-					  t.tag_name
-					FROM
-					  tags AS t
-					  JOIN account_tag AS at ON at.tag_id = t.tag_id
-					WHERE
-						at.account_id = split_accounts.account_id)
-		;`
-
-		log.Debug("Querying Database")
-		rows, err := ledger.LedgerDb.Query(queryDB, queryDateStart, queryDateEnd)
+		log.Debug("Streaming Listing")
+		stream, err := client.StreamListing(ctx.Context, req)
+		if err != nil {
+			return fmt.Errorf("Could not call Stream Listing Method (%v)", err)
+		}
 
+		var writer listingWriter
+		if len(ctx.String(csvFlag.Name)) > 0 {
+			log.Infof("Exporting CSV to %s", ctx.String(csvFlag.Name))
+			writer, err = newCSVListingWriter(ctx.String(csvFlag.Name))
+		} else if len(ctx.String(jsonFlag.Name)) > 0 {
+			log.Infof("Exporting JSON to %s", ctx.String(jsonFlag.Name))
+			writer, err = newJSONListingWriter(ctx.String(jsonFlag.Name))
+		} else {
+			writer = newTableListingWriter()
+		}
 		if err != nil {
-			return fmt.Errorf("Could not query database (%v)", err)
+			return err
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			// Scan one customer record
-			var t Transaction
-			var decimals float64
-			if err := rows.Scan(&t.ID, &t.Date, &t.Description, &t.Currency, &decimals, &t.Amount, &t.Account); err != nil {
-				return fmt.Errorf("Could not scan rows of query (%v)", err)
+
+		for {
+			txn, err := stream.Recv()
+			if err == io.EOF {
+				break
 			}
-			centsAmount, err := strconv.ParseFloat(t.Amount, 64)
 			if err != nil {
-				return fmt.Errorf("Could not process the amount as a float (%v)", err)
+				writer.Close()
+				return fmt.Errorf("Could not receive listing (%v)", err)
 			}
-			if ctx.Bool("unformatted") {
-				t.Amount = fmt.Sprintf("%.2f", centsAmount/math.Pow(10, decimals))
-			} else {
-				p := message.NewPrinter(language.English)
-				t.Amount = p.Sprintf("$%.2f", centsAmount/math.Pow(10, decimals))
+			for _, line := range txn.GetLines() {
+				t := Transaction{
+					Account:     line.GetAccountname(),
+					ID:          txn.GetIdentifier(),
+					Date:        txn.GetDate(),
+					Description: line.GetDescription(),
+					Currency:    line.GetCurrency(),
+				}
+				amount, err := lineAmount(line)
+				if err != nil {
+					writer.Close()
+					return fmt.Errorf("Could not read the amount of transaction %s (%v)", txn.GetIdentifier(), err)
+				}
+				t.Amount = formatAmount(amount, int(line.GetDecimals()), ctx.Bool("unformatted"))
+				if err := writer.Write(t); err != nil {
+					writer.Close()
+					return err
+				}
 			}
-			output.Data = append(output.Data, t)
-			table.Append([]string{t.Date, t.ID, t.Account, t.Description, t.Currency, t.Amount})
-		}
-		if rows.Err() != nil {
-			return fmt.Errorf("rows errored with (%v)", rows.Err())
 		}
 
-		//Output some information.
-		if len(ctx.String(csvFlag.Name)) > 0 {
-			log.Infof("Exporting CSV to %s", ctx.String(csvFlag.Name))
+		return writer.Close()
+	},
+}
 
-			file, err := os.OpenFile(ctx.String(csvFlag.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
-			if err != nil {
-				return fmt.Errorf("opening csv file errored with (%v)", err)
-			}
-			defer file.Close()
+// listingWriter outputs the lines of a listing as they are received.
+type listingWriter interface {
+	Write(t Transaction) error
+	Close() error
+}
 
-			csvWriter := csv.NewWriter(file)
-			defer csvWriter.Flush()
-			csvWriter.Write([]string{"Date", "ID", "Account", "Description", "Currency", "Amount"})
+type tableListingWriter struct {
+	table *tablewriter.Table
+}
 
-			for _, element := range output.Data {
-				err := csvWriter.Write([]string{element.Date, element.ID, element.Account, element.Description, element.Currency, element.Amount})
-				if err != nil {
-					return fmt.Errorf("could not write to csv file (%v)", err)
-				}
-			}
+func newTableListingWriter() *tableListingWriter {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Date", "ID", "Account", "Description", "Currency", "Amount"})
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	return &tableListingWriter{table: table}
+}
 
-		} else if len(ctx.String(jsonFlag.Name)) > 0 {
-			log.Infof("Exporting JSON to %s", ctx.String(jsonFlag.Name))
-			file, err := os.OpenFile(ctx.String(jsonFlag.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+func (w *tableListingWriter) Write(t Transaction) error {
+	w.table.Append([]string{t.Date, t.ID, t.Account, t.Description, t.Currency, t.Amount})
+	return nil
+}
 
-			if err != nil {
-				return fmt.Errorf("could not open json file (%v)", err)
-			}
-			defer file.Close()
+// Close renders the table, which needs every row to size its columns.
+func (w *tableListingWriter) Close() error {
+	fmt.Println()
+	w.table.Render()
+	fmt.Println()
+	return nil
+}
 
-			bytes, err := json.Marshal(output.Data)
-			if err != nil {
-				return fmt.Errorf("could not serialise json (%v)", err)
-			}
-			_, err = file.Write(bytes)
-			if err != nil {
-				return fmt.Errorf("could not write to json file (%v)", err)
-			}
+type csvListingWriter struct {
+	file   *os.File
+	writer *csv.Writer
+}
 
-		} else {
-			fmt.Println()
-			table.Render()
-			fmt.Println()
-		}
-		return nil
-	},
+func newCSVListingWriter(filename string) (*csvListingWriter, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening csv file errored with (%v)", err)
+	}
+	w := &csvListingWriter{file: file, writer: csv.NewWriter(file)}
+	if err := w.writer.Write([]string{"Date", "ID", "Account", "Description", "Currency", "Amount"}); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not write to csv file (%v)", err)
+	}
+	return w, nil
+}
+
+func (w *csvListingWriter) Write(t Transaction) error {
+	if err := w.writer.Write([]string{t.Date, t.ID, t.Account, t.Description, t.Currency, t.Amount}); err != nil {
+		return fmt.Errorf("could not write to csv file (%v)", err)
+	}
+	return nil
+}
+
+func (w *csvListingWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("could not write to csv file (%v)", err)
+	}
+	return w.file.Close()
+}
+
+// jsonListingWriter writes the same array a single json.Marshal of every line
+// would, one element at a time.
+type jsonListingWriter struct {
+	file   *os.File
+	writer *bufio.Writer
+	count  int
+}
+
+func newJSONListingWriter(filename string) (*jsonListingWriter, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open json file (%v)", err)
+	}
+	return &jsonListingWriter{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (w *jsonListingWriter) Write(t Transaction) error {
+	bytes, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("could not serialise json (%v)", err)
+	}
+	separator := ","
+	if w.count == 0 {
+		separator = "["
+	}
+	w.count++
+	if _, err := w.writer.WriteString(separator); err != nil {
+		return fmt.Errorf("could not write to json file (%v)", err)
+	}
+	if _, err := w.writer.Write(bytes); err != nil {
+		return fmt.Errorf("could not write to json file (%v)", err)
+	}
+	return nil
+}
+
+func (w *jsonListingWriter) Close() error {
+	end := "]"
+	if w.count == 0 {
+		end = "null"
+	}
+	if _, err := w.writer.WriteString(end); err != nil {
+		w.file.Close()
+		return fmt.Errorf("could not write to json file (%v)", err)
+	}
+	if err := w.writer.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("could not write to json file (%v)", err)
+	}
+	return w.file.Close()
 }
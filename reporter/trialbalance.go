@@ -2,14 +2,9 @@ package main
 
 import (
 	"fmt"
-	"math"
 	"os"
-	"strconv"
 	"time"
 
-	"golang.org/x/text/language"
-	"golang.org/x/text/message"
-
 	"encoding/csv"
 	"encoding/json"
 
@@ -53,6 +48,10 @@ If you want to see all the transactions in the database, or export to CSV
 		if err != nil {
 			return fmt.Errorf("Could not make new ledger (%v)", err)
 		}
+		ledger, err = ledger.Entity(ctx.Context, ctx.String(cmd.LedgerFlag.Name))
+		if err != nil {
+			return fmt.Errorf("Could not open ledger (%v)", err)
+		}
 		queryDate := time.Now()
 
 		table := tablewriter.NewWriter(os.Stdout)
@@ -60,58 +59,23 @@ If you want to see all the transactions in the database, or export to CSV
 		table.SetBorder(false)
 		table.SetAlignment(tablewriter.ALIGN_RIGHT)
 
-		queryDB := `
-			SELECT split_accounts.account_id,
-						 Sum(splits.amount),
-						 currency.decimals
-			FROM   splits
-						 JOIN split_accounts ON splits.split_id = split_accounts.split_id
-						 JOIN currencies AS currency ON splits.currency = currency.NAME
-			WHERE  splits.split_date <= ?
-						 AND "void" NOT IN (SELECT t.tag_name
-																FROM   tags AS t
-																			 JOIN transaction_tag AS tt
-																				 ON tt.tag_id = t.tag_id
-																WHERE  tt.transaction_id = splits.transaction_id)
-						 AND "main" IN (SELECT t.tag_name
-																FROM   tags AS t
-																			 JOIN account_tag AS at
-																				 ON at.tag_id = t.tag_id
-																WHERE  at.account_id = split_accounts.account_id)
-			GROUP  BY split_accounts.account_id, splits.currency
-
-			;`
-
 		log.Debug("Querying Database")
-		rows, err := ledger.LedgerDb.Query(queryDB, queryDate)
+		accounts, err := ledger.GetTB(ctx.Context, queryDate)
 		if err != nil {
 			return fmt.Errorf("Could not query database (%v)", err)
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			// Scan one customer record
-			var t Account
-			var decimals float64
-			if err := rows.Scan(&t.Account, &t.Amount, &decimals); err != nil {
-				return fmt.Errorf("Could not scan rows of query (%v)", err)
-			}
-			centsAmount, err := strconv.ParseFloat(t.Amount, 64)
-			if err != nil {
-				return fmt.Errorf("Could not process the amount as a float (%v)", err)
+
+		for _, account := range *accounts {
+			if !containsTag(account.Tags, "main") {
+				continue
 			}
-			if ctx.Bool("unformatted") {
-				t.Amount = fmt.Sprintf("%.2f", centsAmount/math.Pow(10, decimals))
-			} else {
-				p := message.NewPrinter(language.English)
-				t.Amount = p.Sprintf("$%.2f", centsAmount/math.Pow(10, decimals))
+			t := Account{
+				Account: account.Account,
+				Amount:  formatAmount(account.Amount, account.Decimals, ctx.Bool("unformatted")),
 			}
 			tboutput.Data = append(tboutput.Data, t)
 			table.Append([]string{t.Account, t.Amount})
 		}
-		if rows.Err() != nil {
-			return fmt.Errorf("rows errored with (%v)", rows.Err())
-		}
 
 		//Output some information.
 		if len(ctx.String(csvFlag.Name)) > 0 {
@@ -158,3 +122,12 @@ If you want to see all the transactions in the database, or export to CSV
 		return nil
 	},
 }
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
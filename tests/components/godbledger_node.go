@@ -32,6 +32,11 @@ func StartGoDBLedger(t *testing.T, config *cmd.LedgerConfig, logfilename string,
 		fmt.Sprintf("--database-location=%s-%d", config.DatabaseLocation, index),
 	}
 
+	// SQLite stores its database under the data directory rather than the database location
+	if config.DatabaseType == "sqlite3" {
+		args = append(args, fmt.Sprintf("--datadir=%s-%d", config.DataDirectory, index))
+	}
+
 	if config.Key != "" {
 		args = append(args,
 			fmt.Sprintf("--ca-cert=%s", config.CACert),
@@ -30,6 +30,20 @@ var evaluators = []types.Evaluator{
 	ev.DoubleTransaction,
 	ev.DoubleTransactionIgnoreOne,
 	ev.TradingSimulator,
+	ev.AllocatedTransaction,
+	ev.PostingRuleViolation,
+	ev.TrashRestore,
+	ev.BatchTransactions,
+	ev.ListingPagination,
+	ev.StreamListing,
+	ev.LargeAmounts,
+	ev.MultipleLedgers,
+	ev.SearchTransactions,
+	ev.IntegrityCheck,
+	ev.ReadAPI,
+	ev.AccountRegister,
+	ev.EventSubscription,
+	ev.WebhookDeadLetters,
 }
 
 func runEndToEndTest(t *testing.T, cfg *cmd.LedgerConfig) {
@@ -0,0 +1,96 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AccountRegister posts to a cash account across several months and expects
+// its register for March to open with the February balance, run through the
+// March splits and close at the March balance
+var AccountRegister = types.Evaluator{
+	Name:       "Account Register",
+	Evaluation: accountRegister,
+}
+
+func accountRegister(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+	ctx := context.Background()
+
+	ids := []string{}
+	for _, txn := range []struct {
+		date    string
+		account string
+		amount  int64
+	}{
+		{"2021-02-10", "Equity:Capital", 100000},
+		{"2021-03-01", "Expenses:Rent", -25000},
+		{"2021-03-15", "Income:Sales", 7000},
+		{"2021-03-31", "Expenses:Rent", -25000},
+		{"2021-04-01", "Income:Sales", 3000},
+	} {
+		req := &transaction.TransactionRequest{
+			Date:        txn.date,
+			Description: "Cash " + txn.account,
+			Lines: []*transaction.LineItem{
+				{Accountname: "Assets:Cash", Description: "Cash", Amount: txn.amount, Currency: "USD"},
+				{Accountname: txn.account, Description: "Cash", Amount: -txn.amount, Currency: "USD"},
+			},
+		}
+		res, err := client.AddTransaction(ctx, req)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, res.Message)
+	}
+	if _, err := client.VoidTransaction(ctx, &transaction.DeleteRequest{Identifier: ids[3]}); err != nil {
+		return err
+	}
+
+	res, err := client.GetAccountRegister(ctx, &transaction.RegisterRequest{Account: "Assets:Cash", Startdate: "2021-03-01", Date: "2021-03-31"})
+	if err != nil {
+		return err
+	}
+	if len(res.Registers) != 1 {
+		return fmt.Errorf("Expected a register in one currency, got %v", res.Registers)
+	}
+	register := res.Registers[0]
+	lines := []string{}
+	for _, line := range register.Lines {
+		lines = append(lines, fmt.Sprintf("%s %s %s %s", line.Identifier, line.Date, line.ExactAmount, line.ExactBalance))
+	}
+	expected := []string{
+		fmt.Sprintf("%s 2021-03-01 -25000 75000", ids[1]),
+		fmt.Sprintf("%s 2021-03-15 7000 82000", ids[2]),
+	}
+	if register.Currency != "USD" || register.ExactOpening != "100000" || register.ExactClosing != "82000" || fmt.Sprint(lines) != fmt.Sprint(expected) {
+		return fmt.Errorf("Unexpected register %s to %s with lines %v, expected 100000 to 82000 with %v", register.ExactOpening, register.ExactClosing, lines, expected)
+	}
+
+	// Without a range the register covers every split
+	res, err = client.GetAccountRegister(ctx, &transaction.RegisterRequest{Account: "Assets:Cash"})
+	if err != nil {
+		return err
+	}
+	if len(res.Registers) != 1 || len(res.Registers[0].Lines) != 4 || res.Registers[0].ExactOpening != "0" || res.Registers[0].ExactClosing != "85000" {
+		return fmt.Errorf("Unexpected register of every split %v", res.Registers)
+	}
+
+	_, err = client.GetAccountRegister(ctx, &transaction.RegisterRequest{Account: "Assets:Bank"})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Expected the register of a missing account to be NotFound, got %v", err)
+	}
+	_, err = client.GetAccountRegister(ctx, &transaction.RegisterRequest{Account: "Assets:Cash", Startdate: "March"})
+	if status.Code(err) != codes.InvalidArgument {
+		return fmt.Errorf("Expected a bad date to be InvalidArgument, got %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package evaluators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+)
+
+// AllocatedTransaction saves an allocation rule and submits a transaction with a line posted through it, expecting the line to be spread across the rule's accounts with the rounding remainder landing on the heaviest target
+var AllocatedTransaction = types.Evaluator{
+	Name:       "Allocated Transaction",
+	Evaluation: allocatedTransaction,
+}
+
+func allocatedTransaction(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+
+	rule := &transaction.AllocationRuleRequest{
+		Name: "rent",
+		Targets: []*transaction.AllocationTarget{
+			{Account: "Expenses:Rent:Admin", Weight: 60},
+			{Account: "Expenses:Rent:Sales", Weight: 30},
+			{Account: "Expenses:Rent:Warehouse", Weight: 10},
+		},
+	}
+	_, err := client.AddAllocationRule(context.Background(), rule)
+	if err != nil {
+		return err
+	}
+
+	date, _ := time.Parse("2006-01-02", "2011-03-15")
+
+	transactionLines := make([]*transaction.LineItem, 2)
+
+	transactionLines[0] = &transaction.LineItem{
+		Accountname: "Expenses:Rent",
+		Description: "Rent",
+		Amount:      100001,
+		Currency:    "USD",
+		Allocation:  "rent",
+	}
+
+	transactionLines[1] = &transaction.LineItem{
+		Accountname: "Assets:Checking",
+		Description: "Rent",
+		Amount:      -100001,
+		Currency:    "USD",
+	}
+
+	req := &transaction.TransactionRequest{
+		Date:        date.Format("2006-01-02"),
+		Description: "Monthly Rent",
+		Lines:       transactionLines,
+	}
+	_, err = client.AddTransaction(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.GetTB(context.Background(), &transaction.TBRequest{Date: time.Now().Format("2006-01-02")})
+	if err != nil {
+		return err
+	}
+
+	// Initialise a variable to check that the trial balance balances
+	balance := int64(0)
+	// Check to ensure the Trial Balance Matches.
+	for i := 0; i < len(res.Lines); i++ {
+		balance += res.Lines[i].Amount
+		switch res.Lines[i].Accountname {
+		case "Assets:Checking":
+			if res.Lines[i].Amount != int64(-100001) {
+				return errors.New("Trial Balance Checking Account Incorrect")
+			}
+		case "Expenses:Rent:Admin":
+			if res.Lines[i].Amount != int64(60001) {
+				return errors.New("Trial Balance Rent Admin Account Incorrect")
+			}
+		case "Expenses:Rent:Sales":
+			if res.Lines[i].Amount != int64(30000) {
+				return errors.New("Trial Balance Rent Sales Account Incorrect")
+			}
+		case "Expenses:Rent:Warehouse":
+			if res.Lines[i].Amount != int64(10000) {
+				return errors.New("Trial Balance Rent Warehouse Account Incorrect")
+			}
+		default:
+			return fmt.Errorf("Unknown Account %s", res.Lines[i].Accountname)
+		}
+	}
+
+	if balance != int64(0) {
+		return errors.New("Trial Balance does not balance")
+	}
+
+	return nil
+}
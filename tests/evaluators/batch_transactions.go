@@ -0,0 +1,107 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// BatchTransactions streams journals through AddTransactions and expects an unbalanced journal to be reported on its own, and to roll back the whole batch when it is atomic
+var BatchTransactions = types.Evaluator{
+	Name:       "Batch Transactions",
+	Evaluation: batchTransactions,
+}
+
+func batchJournal(debit, credit string, debitAmount, creditAmount int64) *transaction.TransactionRequest {
+	date, _ := time.Parse("2006-01-02", "2011-03-15")
+	return &transaction.TransactionRequest{
+		Date:        date.Format("2006-01-02"),
+		Description: "Batch journal",
+		Lines: []*transaction.LineItem{
+			{Accountname: debit, Description: "Debit", Amount: debitAmount, Currency: "USD"},
+			{Accountname: credit, Description: "Credit", Amount: creditAmount, Currency: "USD"},
+		},
+	}
+}
+
+func sendBatch(client transaction.TransactorClient, atomic bool, journals ...*transaction.TransactionRequest) ([]codes.Code, error) {
+	stream, err := client.AddTransactions(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, journal := range journals {
+		if err := stream.Send(&transaction.BatchTransactionRequest{Transaction: journal, Atomic: atomic}); err != nil {
+			return nil, err
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	results := []codes.Code{}
+	for _, result := range res.GetResults() {
+		results = append(results, codes.Code(result.GetCode()))
+	}
+	return results, nil
+}
+
+func expectCodes(received, expected []codes.Code) error {
+	if len(received) != len(expected) {
+		return fmt.Errorf("Expected %d results but received %d", len(expected), len(received))
+	}
+	for i := range expected {
+		if received[i] != expected[i] {
+			return fmt.Errorf("Expected journal %d to return %s but received %s", i, expected[i], received[i])
+		}
+	}
+	return nil
+}
+
+func batchTransactions(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+
+	results, err := sendBatch(client, false,
+		batchJournal("Expenses:Groceries", "Assets:Checking", 7500, -7500),
+		batchJournal("Expenses:Orphan", "Assets:Orphan", 100, -50),
+		batchJournal("Expenses:Groceries", "Assets:Checking", 2500, -2500),
+	)
+	if err != nil {
+		return err
+	}
+	if err := expectCodes(results, []codes.Code{codes.OK, codes.InvalidArgument, codes.OK}); err != nil {
+		return err
+	}
+
+	results, err = sendBatch(client, true,
+		batchJournal("Expenses:Groceries", "Assets:Checking", 1000, -1000),
+		batchJournal("Expenses:Orphan", "Assets:Orphan", 100, -50),
+	)
+	if err != nil {
+		return err
+	}
+	if err := expectCodes(results, []codes.Code{codes.Aborted, codes.InvalidArgument}); err != nil {
+		return err
+	}
+
+	res, err := client.GetTB(context.Background(), &transaction.TBRequest{Date: time.Now().Format("2006-01-02")})
+	if err != nil {
+		return err
+	}
+	if len(res.Lines) != 2 {
+		return fmt.Errorf("Expected 2 Trial Balance lines but received %d", len(res.Lines))
+	}
+	for _, line := range res.Lines {
+		if line.Accountname == "Expenses:Groceries" && line.Amount != 10000 {
+			return fmt.Errorf("Expected Expenses:Groceries to total 10000 but received %d", line.Amount)
+		}
+	}
+
+	return nil
+}
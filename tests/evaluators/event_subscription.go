@@ -0,0 +1,90 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EventSubscription subscribes to the events of the ledger and expects adding
+// a transaction to publish its new accounts and the transaction, then resumes
+// after the transaction to receive its void
+var EventSubscription = types.Evaluator{
+	Name:       "Event Subscription",
+	Evaluation: eventSubscription,
+}
+
+func eventSubscription(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Starting from sequence zero replays the events held since the node
+	// started, so nothing is missed while the stream is being set up
+	stream, err := client.Subscribe(ctx, &transaction.SubscribeRequest{Kinds: []string{"account_added", "transaction_added"}})
+	if err != nil {
+		return err
+	}
+
+	res, err := client.AddTransaction(ctx, &transaction.TransactionRequest{
+		Date:        "2021-05-01",
+		Description: "Capital",
+		Lines: []*transaction.LineItem{
+			{Accountname: "Assets:Cash", Description: "Capital", Amount: 5000, Currency: "USD"},
+			{Accountname: "Equity:Capital", Description: "Capital", Amount: -5000, Currency: "USD"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	accounts := map[string]bool{}
+	var added *transaction.Event
+	for added == nil {
+		e, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("Could not receive event (%v)", err)
+		}
+		switch e.Kind {
+		case "account_added":
+			accounts[e.Account] = true
+		case "transaction_added":
+			added = e
+		}
+	}
+	if !accounts["Assets:Cash"] || !accounts["Equity:Capital"] || added.Transaction != res.Message {
+		return fmt.Errorf("Unexpected events, accounts %v and transaction %v for %s", accounts, added, res.Message)
+	}
+
+	if _, err := client.VoidTransaction(ctx, &transaction.DeleteRequest{Identifier: res.Message}); err != nil {
+		return err
+	}
+	resumed, err := client.Subscribe(ctx, &transaction.SubscribeRequest{After: added.Sequence, Epoch: added.Epoch, Kinds: []string{"transaction_voided"}})
+	if err != nil {
+		return err
+	}
+	e, err := resumed.Recv()
+	if err != nil {
+		return fmt.Errorf("Could not receive resumed event (%v)", err)
+	}
+	if e.Transaction != res.Message || e.Sequence <= added.Sequence {
+		return fmt.Errorf("Expected the void of %s after %d, got %v", res.Message, added.Sequence, e)
+	}
+
+	expired, err := client.Subscribe(ctx, &transaction.SubscribeRequest{After: added.Sequence, Epoch: "before-restart"})
+	if err != nil {
+		return err
+	}
+	if _, err := expired.Recv(); status.Code(err) != codes.OutOfRange {
+		return fmt.Errorf("Expected resuming another epoch to be OutOfRange, got %v", err)
+	}
+	return nil
+}
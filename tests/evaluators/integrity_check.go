@@ -0,0 +1,67 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IntegrityCheck posts a transaction in a new currency and expects the
+// ledger to pass an integrity check, and the currency to be refused deletion
+// while the transaction uses it
+var IntegrityCheck = types.Evaluator{
+	Name:       "Integrity Check",
+	Evaluation: integrityCheck,
+}
+
+func integrityCheck(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+	ctx := context.Background()
+
+	if _, err := client.AddCurrency(ctx, &transaction.CurrencyRequest{Currency: "XTS", Decimals: 2}); err != nil {
+		return err
+	}
+	req := &transaction.TransactionRequest{
+		Date:        "2021-06-01",
+		Description: "Test currency",
+		Lines: []*transaction.LineItem{
+			{Accountname: "Expenses:General", Description: "Test currency", Amount: 1000, Currency: "XTS"},
+			{Accountname: "Assets:Cash", Description: "Test currency", Amount: -1000, Currency: "XTS"},
+		},
+	}
+	if _, err := client.AddTransaction(ctx, req); err != nil {
+		return err
+	}
+
+	res, err := client.CheckIntegrity(ctx, &transaction.IntegrityRequest{Repair: true})
+	if err != nil {
+		return err
+	}
+	if len(res.Violations) > 0 || res.Repaired != 0 {
+		return fmt.Errorf("Expected a clean ledger, found %v and repaired %d", res.Violations, res.Repaired)
+	}
+
+	_, err = client.DeleteCurrency(ctx, &transaction.DeleteCurrencyRequest{Currency: "XTS"})
+	if status.Code(err) != codes.FailedPrecondition {
+		return fmt.Errorf("Expected deleting a currency in use to fail with FailedPrecondition, got %v", err)
+	}
+	if _, err := client.DeleteCurrency(ctx, &transaction.DeleteCurrencyRequest{Currency: "GBP"}); err != nil {
+		return fmt.Errorf("Could not delete an unused currency (%v)", err)
+	}
+
+	res, err = client.CheckIntegrity(ctx, &transaction.IntegrityRequest{})
+	if err != nil {
+		return err
+	}
+	if len(res.Violations) > 0 {
+		return fmt.Errorf("Expected a clean ledger after deleting currencies, found %v", res.Violations)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+)
+
+// LargeAmounts submits a transaction in ETH, with 18 decimals, whose amounts
+// do not fit in an int64 and expects them back exactly from the trial balance
+// and the listing
+var LargeAmounts = types.Evaluator{
+	Name:       "Large Amounts",
+	Evaluation: largeAmounts,
+}
+
+func largeAmounts(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+
+	// 123,456,789,012.345678901234567890 ETH
+	debit := "123456789012345678901234567890"
+	credit := "-123456789012345678901234567890"
+	req := &transaction.TransactionRequest{
+		Date:        time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02"),
+		Description: "Token Deposit",
+		Lines: []*transaction.LineItem{
+			{Accountname: "Assets:Wallet", Description: "Deposit", ExactAmount: debit, Currency: "ETH"},
+			{Accountname: "Equity:Contributions", Description: "Deposit", ExactAmount: credit, Currency: "ETH"},
+		},
+	}
+	if _, err := client.AddTransaction(context.Background(), req); err != nil {
+		return err
+	}
+
+	res, err := client.GetTB(context.Background(), &transaction.TBRequest{Date: time.Now().Format("2006-01-02")})
+	if err != nil {
+		return err
+	}
+	if len(res.Lines) != 2 {
+		return fmt.Errorf("Expected 2 trial balance lines but received %d", len(res.Lines))
+	}
+	for _, line := range res.Lines {
+		expected, formatted := debit, "123456789012.345678901234567890"
+		if line.Accountname == "Equity:Contributions" {
+			expected, formatted = credit, "-123456789012.345678901234567890"
+		}
+		if line.ExactAmount != expected || line.AmountStr != formatted {
+			return fmt.Errorf("Trial Balance %s Account is %s (%s) not %s", line.Accountname, line.ExactAmount, line.AmountStr, expected)
+		}
+		if line.Amount != 0 {
+			return fmt.Errorf("Trial Balance %s Account has an int64 amount of %d for a value that does not fit", line.Accountname, line.Amount)
+		}
+	}
+
+	listing, err := client.GetListing(context.Background(), &transaction.ReportRequest{Startdate: "2021-01-01", Date: "2021-12-31"})
+	if err != nil {
+		return err
+	}
+	if len(listing.Transactions) != 1 {
+		return fmt.Errorf("Expected 1 transaction in the listing but received %d", len(listing.Transactions))
+	}
+	for _, line := range listing.Transactions[0].Lines {
+		if line.ExactAmount != debit && line.ExactAmount != credit {
+			return fmt.Errorf("Listing line %s has amount %s", line.Accountname, line.ExactAmount)
+		}
+	}
+
+	return nil
+}
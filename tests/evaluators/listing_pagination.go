@@ -0,0 +1,75 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+)
+
+// ListingPagination posts three journals and expects the listing to page through them with a cursor and to filter them by account and tag
+var ListingPagination = types.Evaluator{
+	Name:       "Listing Pagination",
+	Evaluation: listingPagination,
+}
+
+func listingPagination(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+
+	journals := []*transaction.TransactionRequest{
+		batchJournal("Expenses:Groceries", "Assets:Checking", 7500, -7500),
+		batchJournal("Expenses:Rent", "Assets:Checking", 2500, -2500),
+		batchJournal("Expenses:Fuel", "Assets:Savings", 500, -500),
+	}
+	journals[1].Tags = []string{"Home"}
+	ids := []string{}
+	for _, journal := range journals {
+		r, err := client.AddTransaction(context.Background(), journal)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, r.GetMessage())
+	}
+
+	req := &transaction.ReportRequest{Startdate: "2011-03-01", Date: "2011-03-31", Limit: 2}
+	res, err := client.GetListing(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if len(res.Transactions) != 2 || res.Cursor == "" {
+		return fmt.Errorf("Expected a page of 2 transactions and a cursor but received %d and %q", len(res.Transactions), res.Cursor)
+	}
+	if res.Cursor != res.Transactions[1].Identifier {
+		return fmt.Errorf("Expected the cursor %q to be the last identifier %q", res.Cursor, res.Transactions[1].Identifier)
+	}
+	req.Cursor = res.Cursor
+	res, err = client.GetListing(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if len(res.Transactions) != 1 || res.Cursor != "" {
+		return fmt.Errorf("Expected a last page of 1 transaction but received %d and cursor %q", len(res.Transactions), res.Cursor)
+	}
+
+	res, err = client.GetListing(context.Background(), &transaction.ReportRequest{Startdate: "2011-03-01", Date: "2011-03-31", Accounts: []string{"Assets:Checking"}})
+	if err != nil {
+		return err
+	}
+	if len(res.Transactions) != 2 {
+		return fmt.Errorf("Expected 2 transactions posting to Assets:Checking but received %d", len(res.Transactions))
+	}
+
+	res, err = client.GetListing(context.Background(), &transaction.ReportRequest{Startdate: "2011-03-01", Date: "2011-03-31", Tags: []string{"Home"}})
+	if err != nil {
+		return err
+	}
+	if len(res.Transactions) != 1 || res.Transactions[0].Identifier != ids[1] {
+		return fmt.Errorf("Expected the transaction tagged Home but received %d transactions", len(res.Transactions))
+	}
+
+	return nil
+}
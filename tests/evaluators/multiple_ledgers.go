@@ -0,0 +1,115 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultipleLedgers creates ledgers for two companies on the node and posts a
+// transaction to each, expecting their trial balances to hold only their own
+// accounts and currencies
+var MultipleLedgers = types.Evaluator{
+	Name:       "Multiple Ledgers",
+	Evaluation: multipleLedgers,
+}
+
+func multipleLedgers(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+	ctx := context.Background()
+
+	for _, id := range []string{"acme", "globex"} {
+		if _, err := client.CreateLedger(ctx, &transaction.CreateLedgerRequest{Identifier: id}); err != nil {
+			return err
+		}
+	}
+	_, err := client.CreateLedger(ctx, &transaction.CreateLedgerRequest{Identifier: "acme"})
+	if status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("Creating a ledger twice returned %v not AlreadyExists", err)
+	}
+	_, err = client.CreateLedger(ctx, &transaction.CreateLedgerRequest{Identifier: "../acme"})
+	if status.Code(err) != codes.InvalidArgument {
+		return fmt.Errorf("Creating a ledger with an invalid identifier returned %v not InvalidArgument", err)
+	}
+
+	listed, err := client.ListLedgers(ctx, &transaction.ListLedgersRequest{})
+	if err != nil {
+		return err
+	}
+	if len(listed.Ledgers) != 2 || listed.Ledgers[0].Identifier != "acme" || listed.Ledgers[1].Identifier != "globex" {
+		return fmt.Errorf("Expected the acme and globex ledgers but received %v", listed.Ledgers)
+	}
+
+	if _, err := client.AddCurrency(ctx, &transaction.CurrencyRequest{Currency: "NZD", Decimals: 2, Ledger: "globex"}); err != nil {
+		return err
+	}
+	date := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	for ledger, currency := range map[string]string{"acme": "USD", "globex": "NZD"} {
+		req := &transaction.TransactionRequest{
+			Date:        date,
+			Description: "Capital",
+			Ledger:      ledger,
+			Lines: []*transaction.LineItem{
+				{Accountname: "Assets:" + ledger, Description: "Capital", Amount: 1000, Currency: currency},
+				{Accountname: "Equity:Capital", Description: "Capital", Amount: -1000, Currency: currency},
+			},
+		}
+		if _, err := client.AddTransaction(ctx, req); err != nil {
+			return err
+		}
+	}
+
+	_, err = client.AddTransaction(ctx, &transaction.TransactionRequest{
+		Date:        date,
+		Description: "Capital",
+		Ledger:      "acme",
+		Lines: []*transaction.LineItem{
+			{Accountname: "Assets:acme", Description: "Capital", Amount: 1000, Currency: "NZD"},
+			{Accountname: "Equity:Capital", Description: "Capital", Amount: -1000, Currency: "NZD"},
+		},
+	})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Using a currency of another ledger returned %v not NotFound", err)
+	}
+
+	for ledger, currency := range map[string]string{"acme": "USD", "globex": "NZD"} {
+		res, err := client.GetTB(ctx, &transaction.TBRequest{Date: time.Now().Format("2006-01-02"), Ledger: ledger})
+		if err != nil {
+			return err
+		}
+		if len(res.Lines) != 2 {
+			return fmt.Errorf("Expected 2 trial balance lines in %s but received %d", ledger, len(res.Lines))
+		}
+		for _, line := range res.Lines {
+			if line.Accountname != "Assets:"+ledger && line.Accountname != "Equity:Capital" {
+				return fmt.Errorf("Trial Balance of %s holds the %s Account", ledger, line.Accountname)
+			}
+			if line.Currency != currency {
+				return fmt.Errorf("Trial Balance of %s is in %s not %s", ledger, line.Currency, currency)
+			}
+		}
+	}
+
+	res, err := client.GetTB(ctx, &transaction.TBRequest{Date: time.Now().Format("2006-01-02")})
+	if err != nil {
+		return err
+	}
+	if len(res.Lines) != 0 {
+		return fmt.Errorf("Expected the default ledger to be empty but received %d trial balance lines", len(res.Lines))
+	}
+
+	_, err = client.GetTB(ctx, &transaction.TBRequest{Date: time.Now().Format("2006-01-02"), Ledger: "initech"})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Using a ledger that does not exist returned %v not NotFound", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package evaluators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PostingRuleViolation saves a maximum amount posting rule and expects a transaction breaking it to be rejected with a structured error, while a transaction within the limit is accepted
+var PostingRuleViolation = types.Evaluator{
+	Name:       "Posting Rule Violation",
+	Evaluation: postingRuleViolation,
+}
+
+func postingRuleViolation(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+
+	rule := &transaction.PostingRuleRequest{
+		Name:     "line-limit",
+		Type:     "max_amount",
+		Currency: "USD",
+		Amount:   10000,
+	}
+	_, err := client.AddPostingRule(context.Background(), rule)
+	if err != nil {
+		return err
+	}
+
+	date, _ := time.Parse("2006-01-02", "2011-03-15")
+
+	transactionLines := make([]*transaction.LineItem, 2)
+
+	transactionLines[0] = &transaction.LineItem{
+		Accountname: "Expenses:Groceries",
+		Description: "Groceries",
+		Amount:      17500,
+		Currency:    "USD",
+	}
+
+	transactionLines[1] = &transaction.LineItem{
+		Accountname: "Assets:Checking",
+		Description: "Groceries",
+		Amount:      -17500,
+		Currency:    "USD",
+	}
+
+	req := &transaction.TransactionRequest{
+		Date:        date.Format("2006-01-02"),
+		Description: "Whole Food Market",
+		Lines:       transactionLines,
+	}
+	_, err = client.AddTransaction(context.Background(), req)
+	if err == nil {
+		return errors.New("Transaction exceeding the posting rule was accepted")
+	}
+
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		return fmt.Errorf("Expected InvalidArgument but received %s", st.Code())
+	}
+	violations := 0
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations += len(badRequest.GetFieldViolations())
+		}
+	}
+	if violations != 2 {
+		return fmt.Errorf("Expected 2 rule violations but received %d", violations)
+	}
+
+	transactionLines[0].Amount = 7500
+	transactionLines[1].Amount = -7500
+	_, err = client.AddTransaction(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.GetTB(context.Background(), &transaction.TBRequest{Date: time.Now().Format("2006-01-02")})
+	if err != nil {
+		return err
+	}
+
+	if len(res.Lines) != 2 {
+		return fmt.Errorf("Expected 2 Trial Balance lines but received %d", len(res.Lines))
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReadAPI posts a transaction and tags an account, then expects to read the
+// transaction, accounts, currencies, tags and account balances back
+var ReadAPI = types.Evaluator{
+	Name:       "Read API",
+	Evaluation: readAPI,
+}
+
+func readAPI(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+	ctx := context.Background()
+
+	for _, date := range []string{"2021-06-01", "2021-07-01"} {
+		req := &transaction.TransactionRequest{
+			Date:        date,
+			Description: "Office rent",
+			Lines: []*transaction.LineItem{
+				{Accountname: "Expenses:Rent", Description: "Office rent", Amount: 150000, Currency: "USD"},
+				{Accountname: "Assets:Cash", Description: "Office rent", Amount: -150000, Currency: "USD"},
+			},
+			Tags: []string{"Rent"},
+		}
+		if _, err := client.AddTransaction(ctx, req); err != nil {
+			return err
+		}
+	}
+	res, err := client.AddTransaction(ctx, &transaction.TransactionRequest{
+		Date:        "2021-06-15",
+		Description: "Petty cash",
+		Lines: []*transaction.LineItem{
+			{Accountname: "Assets:Cash", Description: "Petty cash", Amount: 2500, Currency: "AUD"},
+			{Accountname: "Equity:Capital", Description: "Petty cash", Amount: -2500, Currency: "AUD"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	txnID := res.Message
+	if _, err := client.AddTag(ctx, &transaction.AccountTagRequest{Account: "Assets:Cash", Tag: []string{"Balance Sheet"}}); err != nil {
+		return err
+	}
+
+	txn, err := client.GetTransaction(ctx, &transaction.GetTransactionRequest{Identifier: txnID})
+	if err != nil {
+		return err
+	}
+	if txn.Identifier != txnID || txn.Description != "Petty cash" || len(txn.Lines) != 2 {
+		return fmt.Errorf("Transaction %s read back as %v", txnID, txn)
+	}
+
+	accounts, err := client.ListAccounts(ctx, &transaction.ListAccountsRequest{})
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, account := range accounts.Accounts {
+		names = append(names, account.Name)
+		if account.Name != "Assets:Cash" {
+			continue
+		}
+		tagged := false
+		for _, tag := range account.Tags {
+			tagged = tagged || tag == "Balance Sheet"
+		}
+		if !tagged {
+			return fmt.Errorf("Expected Assets:Cash to be tagged Balance Sheet, got %v", account.Tags)
+		}
+	}
+	if fmt.Sprint(names) != "[Assets:Cash Equity:Capital Expenses:Rent]" {
+		return fmt.Errorf("Unexpected accounts %v", names)
+	}
+
+	currencies, err := client.ListCurrencies(ctx, &transaction.ListCurrenciesRequest{})
+	if err != nil {
+		return err
+	}
+	decimals := map[string]int64{}
+	for _, currency := range currencies.Currencies {
+		decimals[currency.Name] = currency.Decimals
+	}
+	if decimals["USD"] != 2 || decimals["BTC"] != 8 {
+		return fmt.Errorf("Unexpected currencies %v", decimals)
+	}
+
+	tags, err := client.ListTags(ctx, &transaction.ListTagsRequest{})
+	if err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, tag := range tags.Tags {
+		found[tag] = true
+	}
+	if !found["Rent"] || !found["Balance Sheet"] {
+		return fmt.Errorf("Expected the Rent and Balance Sheet tags, got %v", tags.Tags)
+	}
+
+	for _, test := range []struct {
+		date     string
+		expected string
+	}{
+		{"2021-06-30", "[-1500.00 USD 25.00 AUD]"},
+		{"2021-07-01", "[-3000.00 USD 25.00 AUD]"},
+		{"", "[-3000.00 USD 25.00 AUD]"},
+	} {
+		balance, err := client.GetAccountBalance(ctx, &transaction.AccountBalanceRequest{Account: "Assets:Cash", Date: test.date})
+		if err != nil {
+			return err
+		}
+		lines := map[string]string{}
+		for _, line := range balance.Lines {
+			lines[line.Currency] = line.AmountStr + " " + line.Currency
+		}
+		if got := fmt.Sprint([]string{lines["USD"], lines["AUD"]}); got != test.expected || len(balance.Lines) != 2 {
+			return fmt.Errorf("Balance of Assets:Cash at %q was %v, expected %s", test.date, balance.Lines, test.expected)
+		}
+	}
+
+	_, err = client.GetAccountBalance(ctx, &transaction.AccountBalanceRequest{Account: "Assets:Bank"})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Expected the balance of a missing account to be NotFound, got %v", err)
+	}
+	if _, err := client.DeleteTransaction(ctx, &transaction.DeleteRequest{Identifier: txnID}); err != nil {
+		return err
+	}
+	_, err = client.GetTransaction(ctx, &transaction.GetTransactionRequest{Identifier: txnID})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Expected a trashed transaction to be NotFound, got %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SearchTransactions posts transactions with different descriptions and
+// expects a search to find them by the words of their descriptions and long
+// descriptions, within the date and amount ranges
+var SearchTransactions = types.Evaluator{
+	Name:       "Search Transactions",
+	Evaluation: searchTransactions,
+}
+
+func searchTransactions(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+	ctx := context.Background()
+
+	date := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	posted := []struct {
+		description string
+		line        string
+		amount      int64
+		date        time.Time
+	}{
+		{"Office rent", "June payment", 250000, date},
+		{"Printer paper from Officeworks", "Stationery", 4500, date.AddDate(0, 0, 14)},
+		// Longer than the 255 characters held with the transaction
+		{"Consulting invoice " + strings.Repeat("for the quarterly review ", 12) + "reconciliation", "Fees", 1200000, date.AddDate(0, 1, 0)},
+	}
+	ids := []string{}
+	for _, txn := range posted {
+		req := &transaction.TransactionRequest{
+			Date:        txn.date.Format("2006-01-02"),
+			Description: txn.description,
+			Lines: []*transaction.LineItem{
+				{Accountname: "Expenses:General", Description: txn.line, Amount: txn.amount, Currency: "USD"},
+				{Accountname: "Assets:Cash", Description: txn.line, Amount: -txn.amount, Currency: "USD"},
+			},
+		}
+		res, err := client.AddTransaction(ctx, req)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, res.Message)
+	}
+
+	search := func(req *transaction.SearchRequest) ([]string, error) {
+		res, err := client.SearchTransactions(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		found := []string{}
+		for _, txn := range res.Transactions {
+			found = append(found, txn.Identifier)
+		}
+		return found, nil
+	}
+	for _, test := range []struct {
+		req      *transaction.SearchRequest
+		expected []string
+	}{
+		{&transaction.SearchRequest{Query: "office"}, []string{ids[0], ids[1]}},
+		{&transaction.SearchRequest{Query: "Office rent"}, []string{ids[0]}},
+		{&transaction.SearchRequest{Query: "printer pap"}, []string{ids[1]}},
+		{&transaction.SearchRequest{Query: "reconciliation"}, []string{ids[2]}},
+		{&transaction.SearchRequest{Query: "office", Startdate: "2021-06-02"}, []string{ids[1]}},
+		{&transaction.SearchRequest{Query: "office", Date: "2021-06-01"}, []string{ids[0]}},
+		{&transaction.SearchRequest{Query: "review consulting", MinAmount: "1000000"}, []string{ids[2]}},
+		{&transaction.SearchRequest{Query: "office", MaxAmount: "5000"}, []string{ids[1]}},
+		{&transaction.SearchRequest{Query: "groceries"}, []string{}},
+	} {
+		found, err := search(test.req)
+		if err != nil {
+			return err
+		}
+		if strings.Join(found, ",") != strings.Join(test.expected, ",") {
+			return fmt.Errorf("Searching for %q found %v not %v", test.req.Query, found, test.expected)
+		}
+	}
+
+	res, err := client.SearchTransactions(ctx, &transaction.SearchRequest{Query: "office", Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(res.Transactions) != 1 || res.Cursor != ids[0] {
+		return fmt.Errorf("Expected a page of one transaction with a cursor but received %d with cursor %q", len(res.Transactions), res.Cursor)
+	}
+	if len(res.Transactions[0].Lines) != 2 {
+		return fmt.Errorf("Expected the search to return both lines but received %d", len(res.Transactions[0].Lines))
+	}
+
+	_, err = client.SearchTransactions(ctx, &transaction.SearchRequest{Query: " -- "})
+	if status.Code(err) != codes.InvalidArgument {
+		return fmt.Errorf("Searching without any words returned %v not InvalidArgument", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+)
+
+// StreamListing posts three journals and expects StreamListing to send each of them once, stopping at the limit when one is given
+var StreamListing = types.Evaluator{
+	Name:       "Stream Listing",
+	Evaluation: streamListing,
+}
+
+func receiveListing(client transaction.TransactorClient, req *transaction.ReportRequest) ([]*transaction.Transaction, error) {
+	stream, err := client.StreamListing(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	txns := []*transaction.Transaction{}
+	for {
+		txn, err := stream.Recv()
+		if err == io.EOF {
+			return txns, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, txn)
+	}
+}
+
+func streamListing(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+
+	for _, journal := range []*transaction.TransactionRequest{
+		batchJournal("Expenses:Groceries", "Assets:Checking", 7500, -7500),
+		batchJournal("Expenses:Rent", "Assets:Checking", 2500, -2500),
+		batchJournal("Expenses:Fuel", "Assets:Savings", 500, -500),
+	} {
+		if _, err := client.AddTransaction(context.Background(), journal); err != nil {
+			return err
+		}
+	}
+
+	txns, err := receiveListing(client, &transaction.ReportRequest{Startdate: "2011-03-01", Date: "2011-03-31"})
+	if err != nil {
+		return err
+	}
+	if len(txns) != 3 {
+		return fmt.Errorf("Expected 3 streamed transactions but received %d", len(txns))
+	}
+	for _, txn := range txns {
+		if len(txn.Lines) != 2 || txn.Lines[0].Decimals != 2 {
+			return fmt.Errorf("Expected 2 lines in USD for transaction %s but received %v", txn.Identifier, txn.Lines)
+		}
+	}
+
+	limited, err := receiveListing(client, &transaction.ReportRequest{Startdate: "2011-03-01", Date: "2011-03-31", Cursor: txns[0].Identifier, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(limited) != 1 || limited[0].Identifier != txns[1].Identifier {
+		return fmt.Errorf("Expected only transaction %s after the cursor but received %d transactions", txns[1].Identifier, len(limited))
+	}
+
+	return nil
+}
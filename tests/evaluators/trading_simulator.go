@@ -144,7 +144,7 @@ func tradingSimulator(conns ...*grpc.ClientConn) error {
 
 				line2Account := "Assets:Crypto"
 				line2Desc := "Sell order on dd mmm yyyy\n\n"
-				line2Amount := int64(math.Round(pricepaid * 100))
+				line2Amount := int64(math.Round(pricepaid*100)) * -1
 
 				transactionLines[1] = &transaction.LineItem{
 					Accountname: line2Account,
@@ -154,7 +154,7 @@ func tradingSimulator(conns ...*grpc.ClientConn) error {
 
 				line3Account := "Revenue:Trading"
 				line3Desc := "Sell order on dd mmm yyyy\n\n"
-				line3Amount := int64(math.Round(price*100))*int64(amount) - int64(math.Round(pricepaid*100))
+				line3Amount := (int64(math.Round(price*100))*int64(amount) - int64(math.Round(pricepaid*100))) * -1
 
 				transactionLines[2] = &transaction.LineItem{
 					Accountname: line3Account,
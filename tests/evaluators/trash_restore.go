@@ -0,0 +1,100 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TrashRestore deletes a transaction, expecting it to drop out of the trial balance and appear in the trash, then restores it and expects the trial balance to include it again
+var TrashRestore = types.Evaluator{
+	Name:       "Trash and Restore",
+	Evaluation: trashRestore,
+}
+
+func trashRestore(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+
+	date, _ := time.Parse("2006-01-02", "2011-03-15")
+
+	transactionLines := make([]*transaction.LineItem, 2)
+
+	transactionLines[0] = &transaction.LineItem{
+		Accountname: "Expenses:Groceries",
+		Description: "Groceries",
+		Amount:      1500,
+		Currency:    "USD",
+	}
+
+	transactionLines[1] = &transaction.LineItem{
+		Accountname: "Assets:Checking",
+		Description: "Groceries",
+		Amount:      -1500,
+		Currency:    "USD",
+	}
+
+	req := &transaction.TransactionRequest{
+		Date:        date.Format("2006-01-02"),
+		Description: "Whole Food Market",
+		Lines:       transactionLines,
+	}
+	added, err := client.AddTransaction(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	id := added.GetMessage()
+
+	_, err = client.DeleteTransaction(context.Background(), &transaction.DeleteRequest{Identifier: id})
+	if err != nil {
+		return err
+	}
+
+	tb, err := client.GetTB(context.Background(), &transaction.TBRequest{Date: time.Now().Format("2006-01-02")})
+	if err != nil {
+		return err
+	}
+	if len(tb.Lines) != 0 {
+		return fmt.Errorf("Expected deleted transaction to be excluded from the Trial Balance but received %d lines", len(tb.Lines))
+	}
+
+	trash, err := client.ListTrash(context.Background(), &transaction.TrashRequest{})
+	if err != nil {
+		return err
+	}
+	if len(trash.Transactions) != 1 || trash.Transactions[0].GetIdentifier() != id {
+		return fmt.Errorf("Expected deleted transaction %s in the trash but received %v", id, trash.Transactions)
+	}
+
+	_, err = client.DeleteTransaction(context.Background(), &transaction.DeleteRequest{Identifier: id})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Expected NotFound deleting a trashed transaction but received %v", err)
+	}
+
+	_, err = client.RestoreTransaction(context.Background(), &transaction.DeleteRequest{Identifier: id})
+	if err != nil {
+		return err
+	}
+
+	tb, err = client.GetTB(context.Background(), &transaction.TBRequest{Date: time.Now().Format("2006-01-02")})
+	if err != nil {
+		return err
+	}
+	if len(tb.Lines) != 2 {
+		return fmt.Errorf("Expected 2 Trial Balance lines after restore but received %d", len(tb.Lines))
+	}
+
+	_, err = client.RestoreTransaction(context.Background(), &transaction.DeleteRequest{Identifier: id})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Expected NotFound restoring a transaction not in the trash but received %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darcys22/godbledger/proto/transaction"
+
+	"github.com/darcys22/godbledger/tests/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WebhookDeadLetters expects a node without webhooks to have no dead letters
+// and to refuse retrying or discarding a delivery it does not hold
+var WebhookDeadLetters = types.Evaluator{
+	Name:       "Webhook Dead Letters",
+	Evaluation: webhookDeadLetters,
+}
+
+func webhookDeadLetters(conns ...*grpc.ClientConn) error {
+	client := transaction.NewTransactorClient(conns[0])
+	ctx := context.Background()
+
+	res, err := client.ListWebhookDeadLetters(ctx, &transaction.WebhookDeadLettersRequest{})
+	if err != nil {
+		return err
+	}
+	if len(res.Deliveries) != 0 {
+		return fmt.Errorf("Expected no dead letters, got %v", res.Deliveries)
+	}
+
+	_, err = client.RetryWebhookDelivery(ctx, &transaction.WebhookDeliveryRequest{Identifier: "missing"})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Expected retrying a missing delivery to be NotFound, got %v", err)
+	}
+	_, err = client.DiscardWebhookDelivery(ctx, &transaction.WebhookDeliveryRequest{Identifier: "missing"})
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("Expected discarding a missing delivery to be NotFound, got %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package performs full a end-to-end test for GoDBLedger using the PostgreSQL database backend,
+// including spinning up a server and making sure its running, and sending test data to verify
+// Requires a running PostgreSQL server, the connection string for a user allowed to create
+// databases is read from GODBLEDGER_POSTGRES_DSN
+
+//go:build integration && postgres
+// +build integration,postgres
+
+package tests
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/darcys22/godbledger/godbledger/cmd"
+
+	_ "github.com/lib/pq"
+	"github.com/urfave/cli/v2"
+)
+
+const postgresTestDB = "godbledger_e2e"
+
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("GODBLEDGER_POSTGRES_DSN")
+	if dsn == "" {
+		dsn = "host=127.0.0.1 port=5432 user=postgres sslmode=disable"
+	}
+
+	// Each evaluator runs against its own node, and each node is given the connection string
+	// with its index appended, so create a fresh database for every one of them
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("Could not connect to PostgreSQL: %v", err)
+	}
+	defer admin.Close()
+	for i := range evaluators {
+		name := fmt.Sprintf("%s-%d", postgresTestDB, i)
+		if _, err := admin.Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS "%s"`, name)); err != nil {
+			t.Fatalf("Could not drop database %s: %v", name, err)
+		}
+		if _, err := admin.Exec(fmt.Sprintf(`CREATE DATABASE "%s"`, name)); err != nil {
+			t.Fatalf("Could not create database %s: %v", name, err)
+		}
+	}
+
+	// Create a config from the defaults which would usually be created by the CLI library
+	set := flag.NewFlagSet("test", 0)
+	set.String("config", "", "doc")
+	ctx := cli.NewContext(nil, set, nil)
+	err, cfg := cmd.MakeConfig(ctx)
+	if err != nil {
+		t.Fatalf("New Config Failed: %v", err)
+	}
+
+	// Set the Database type to PostgreSQL, dbname must come last so the node index is appended to it
+	cfg.DatabaseType = "postgres"
+	cfg.DatabaseLocation = fmt.Sprintf("%s dbname=%s", dsn, postgresTestDB)
+
+	runEndToEndTest(t, cfg)
+}
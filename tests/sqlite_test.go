@@ -22,8 +22,9 @@ func TestSQLite(t *testing.T) {
 		t.Fatalf("New Config Failed: %v", err)
 	}
 
-	// Set the Database type to a SQLite3 in memory database
-	cfg.DatabaseType = "memorydb"
+	// Set the Database type to SQLite3, each node keeps its database file under its own data directory
+	cfg.DatabaseType = "sqlite3"
+	cfg.DataDirectory = t.TempDir()
 
 	runEndToEndTest(t, cfg)
 }
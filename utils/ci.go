@@ -245,7 +245,12 @@ func doBuild(cmdline []string) {
 	}
 }
 
-func buildFlags(env build.Environment) (flags []string) {
+// buildTags are always built with, sqlite_fts5 includes the FTS5 extension
+// that indexes transaction descriptions for searching.
+var buildTags = []string{"sqlite_fts5"}
+
+func buildFlags(env build.Environment, tags ...string) (flags []string) {
+	flags = append(flags, "-tags", strings.Join(append(append([]string{}, buildTags...), tags...), ","))
 	var ld []string
 	if env.Commit != "" {
 		ld = append(ld, "-X", "github.com/darcys22/godbledger/godbledger/version.gitCommit="+env.Commit)
@@ -295,6 +300,7 @@ func doTest(cmdline []string) {
 	verbose := flag.Bool("v", false, "Whether to log verbosely")
 	integration := flag.Bool("integration", false, "Whether to run integration tests")
 	mysql := flag.Bool("mysql", false, "Whether to run mysql integration tests (not currently supported)")
+	postgres := flag.Bool("postgres", false, "Whether to run postgres integration tests, requires GODBLEDGER_POSTGRES_DSN")
 	secure := flag.Bool("secure", false, "Whether to run secure integration tests")
 	flag.CommandLine.Parse(cmdline)
 	env := build.Env()
@@ -307,19 +313,23 @@ func doTest(cmdline []string) {
 	// Run the actual tests.
 	// Test a single package at a time. CI builders are slow
 	// and some tests run into timeouts under load.
-	gotest := goTool("test", buildFlags(env)...)
-	gotest.Args = append(gotest.Args, "-p", "1")
-	if *coverage {
-		gotest.Args = append(gotest.Args, "-covermode=atomic", "-cover")
-	}
-	if *integration {
-		gotest.Args = append(gotest.Args, "-tags=integration")
+	tags := []string{}
+	if *integration || *mysql || *postgres || *secure {
+		tags = append(tags, "integration")
 	}
 	if *mysql {
-		gotest.Args = append(gotest.Args, "-tags=integration,mysql")
+		tags = append(tags, "mysql")
+	}
+	if *postgres {
+		tags = append(tags, "postgres")
 	}
 	if *secure {
-		gotest.Args = append(gotest.Args, "-tags=integration,secure")
+		tags = append(tags, "secure")
+	}
+	gotest := goTool("test", buildFlags(env, tags...)...)
+	gotest.Args = append(gotest.Args, "-p", "1")
+	if *coverage {
+		gotest.Args = append(gotest.Args, "-covermode=atomic", "-cover")
 	}
 	if *verbose {
 		gotest.Args = append(gotest.Args, "-v")